@@ -134,22 +134,50 @@ func (self DiameterAgent) processCCR(ccr *CCR, reqProcessor *config.DARequestPro
 		utils.Logger.Info(fmt.Sprintf("<DiameterAgent> SMGenericEvent: %+v", smgEv))
 		processorVars[CGRResultCode] = strconv.Itoa(diam.LimitedSuccess)
 	} else { // Find out maxUsage over APIs
+		_, reservation := reqProcessor.Flags[MetaReservation]
 		switch ccr.CCRequestType {
 		case 1:
-			err = self.smg.Call("SMGenericV1.InitiateSession", smgEv, &maxUsage)
+			if reservation { // event charging with unit reservation, reserve the requested units
+				err = self.smg.Call("SMGenericV1.ReserveEvent", smgEv.Clone(), &maxUsage)
+			} else {
+				err = self.smg.Call("SMGenericV1.InitiateSession", smgEv, &maxUsage)
+			}
 		case 2:
 			err = self.smg.Call("SMGenericV1.UpdateSession", smgEv, &maxUsage)
 		case 3, 4: // Handle them together since we generate CDR for them
 			var rpl string
-			if ccr.CCRequestType == 3 {
+			createCDR := self.cgrCfg.DiameterAgentCfg().CreateCDR
+			if ccr.CCRequestType == 3 && reservation { // debit the used units out of the reserved ones
+				if err = self.smg.Call("SMGenericV1.CommitEvent", smgEv.Clone(), &rpl); err == nil {
+					if usage, errUsage := smgEv.GetUsage(utils.META_DEFAULT); errUsage == nil {
+						maxUsage = usage.Seconds()
+					}
+				}
+			} else if ccr.CCRequestType == 3 {
 				err = self.smg.Call("SMGenericV1.TerminateSession", smgEv, &rpl)
-			} else if ccr.CCRequestType == 4 {
-				err = self.smg.Call("SMGenericV1.ChargeEvent", smgEv.Clone(), &maxUsage)
-				if maxUsage == 0 {
-					smgEv[utils.USAGE] = 0 // For CDR not to debit
+			} else {
+				switch ccr.RequestedAction { // immediate event charging
+				case DiameterDirectDebiting:
+					err = self.smg.Call("SMGenericV1.ChargeEvent", smgEv.Clone(), &maxUsage)
+					if maxUsage == 0 {
+						smgEv[utils.USAGE] = 0 // For CDR not to debit
+					}
+				case DiameterRefundAccount: // only the units reserved for the event can be given back
+					createCDR = false
+					if reservation {
+						err = self.smg.Call("SMGenericV1.CancelEvent", smgEv, &rpl)
+					} else {
+						err = fmt.Errorf("unsupported Requested-Action: %d", ccr.RequestedAction)
+					}
+				case DiameterCheckBalance: // report the usage available without debiting it
+					createCDR = false
+					err = self.smg.Call("SMGenericV1.GetMaxUsage", smgEv, &maxUsage)
+				default:
+					createCDR = false
+					err = fmt.Errorf("unsupported Requested-Action: %d", ccr.RequestedAction)
 				}
 			}
-			if self.cgrCfg.DiameterAgentCfg().CreateCDR &&
+			if createCDR &&
 				(!self.cgrCfg.DiameterAgentCfg().CDRRequiresSession || err == nil || !strings.HasSuffix(err.Error(), utils.ErrNoActiveSession.Error())) { // Check if CDR requires session
 				if errCdr := self.smg.Call("SMGenericV1.ProcessCDR", smgEv, &rpl); errCdr != nil {
 					err = errCdr
@@ -171,6 +199,10 @@ func (self DiameterAgent) processCCR(ccr *CCR, reqProcessor *config.DARequestPro
 				processorVars[CGRError] = utils.ErrRatingPlanNotFound.Error()
 			case strings.HasSuffix(err.Error(), utils.ErrUnauthorizedDestination.Error()):
 				processorVars[CGRError] = utils.ErrUnauthorizedDestination.Error()
+			case strings.HasSuffix(err.Error(), utils.ErrResourceUnavailable.Error()):
+				processorVars[CGRError] = utils.ErrResourceUnavailable.Error()
+			case strings.HasSuffix(err.Error(), utils.ErrSpendingCapReached.Error()):
+				processorVars[CGRError] = utils.ErrSpendingCapReached.Error()
 			default: // Unknown error
 				processorVars[CGRError] = err.Error()
 				processorVars[CGRResultCode] = strconv.Itoa(DiameterRatingFailed)
@@ -57,6 +57,12 @@ const (
 	CGRError             = "CGRError"
 	CGRMaxUsage          = "CGRMaxUsage"
 	CGRResultCode        = "CGRResultCode"
+	MetaReservation      = "*reservation" // request processor flag handling CCRs as event charging with unit reservation
+	// Requested-Action values, RFC 4006 8.41
+	DiameterDirectDebiting = 0
+	DiameterRefundAccount  = 1
+	DiameterCheckBalance   = 2
+	DiameterPriceEnquiry   = 3
 )
 
 var (
@@ -509,6 +515,7 @@ type CCR struct {
 	ServiceContextId  string    `avp:"Service-Context-Id"`
 	CCRequestType     int       `avp:"CC-Request-Type"`
 	CCRequestNumber   int       `avp:"CC-Request-Number"`
+	RequestedAction   int       `avp:"Requested-Action"`
 	EventTimestamp    time.Time `avp:"Event-Timestamp"`
 	SubscriptionId    []struct {
 		SubscriptionIdType int    `avp:"Subscription-Id-Type"`
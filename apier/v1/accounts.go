@@ -273,6 +273,18 @@ func (self *ApierV1) SetAccount(attr utils.AttrSetAccount, reply *string) (err e
 		if attr.Disabled != nil {
 			ub.Disabled = *attr.Disabled
 		}
+		if attr.MaxSessionCost != nil {
+			ub.MaxSessionCost = *attr.MaxSessionCost
+		}
+		if err := ub.SetSpendingLimit(attr.SpendingLimit, attr.SpendingLimitPeriod); err != nil {
+			return 0, err
+		}
+		if attr.CreditLimit != nil {
+			if *attr.CreditLimit < 0 {
+				return 0, fmt.Errorf("negative credit limit: %v", *attr.CreditLimit)
+			}
+			ub.CreditLimit = *attr.CreditLimit
+		}
 		// All prepared, save account
 		if err := self.DataDB.SetAccount(ub); err != nil {
 			return 0, err
@@ -409,6 +421,7 @@ type AttrAddBalance struct {
 	Value          float64
 	ExpiryTime     *string
 	RatingSubject  *string
+	Currency       *string
 	Categories     *string
 	DestinationIds *string
 	TimingIds      *string
@@ -420,13 +433,14 @@ type AttrAddBalance struct {
 }
 
 func (self *ApierV1) AddBalance(attr *AttrAddBalance, reply *string) error {
-	return self.modifyBalance(engine.TOPUP, attr, reply)
+	return self.modifyBalance(engine.TOPUP, "ApierV1.AddBalance", attr, reply)
 }
 func (self *ApierV1) DebitBalance(attr *AttrAddBalance, reply *string) error {
-	return self.modifyBalance(engine.DEBIT, attr, reply)
+	return self.modifyBalance(engine.DEBIT, "ApierV1.DebitBalance", attr, reply)
 }
 
-func (self *ApierV1) modifyBalance(aType string, attr *AttrAddBalance, reply *string) error {
+// modifyBalance executes the balance action on behalf of apiMethod, recorded as cause in the balance ledger
+func (self *ApierV1) modifyBalance(aType, apiMethod string, attr *AttrAddBalance, reply *string) error {
 	if missing := utils.MissingStructFields(attr, []string{"Tenant", "Account", "BalanceType", "Value"}); len(missing) != 0 {
 		return utils.NewErrMandatoryIeMissing(missing...)
 	}
@@ -457,6 +471,7 @@ func (self *ApierV1) modifyBalance(aType string, attr *AttrAddBalance, reply *st
 		aType += "_reset" // => *topup_reset/*debit_reset
 	}
 	a := &engine.Action{
+		Id:         apiMethod,
 		ActionType: aType,
 		Balance: &engine.BalanceFilter{
 			Uuid:           attr.BalanceUuid,
@@ -465,6 +480,7 @@ func (self *ApierV1) modifyBalance(aType string, attr *AttrAddBalance, reply *st
 			Value:          &utils.ValueFormula{Static: attr.Value},
 			ExpirationDate: expTime,
 			RatingSubject:  attr.RatingSubject,
+			Currency:       attr.Currency,
 			Weight:         attr.Weight,
 			Blocker:        attr.Blocker,
 			Disabled:       attr.Disabled,
@@ -525,6 +541,7 @@ func (self *ApierV1) SetBalance(attr *utils.AttrSetBalance, reply *string) error
 	at.SetAccountIDs(utils.StringMap{accID: true})
 
 	a := &engine.Action{
+		Id:         "ApierV1.SetBalance",
 		ActionType: engine.SET_BALANCE,
 		Balance: &engine.BalanceFilter{
 			Uuid:           attr.BalanceUUID,
@@ -532,6 +549,7 @@ func (self *ApierV1) SetBalance(attr *utils.AttrSetBalance, reply *string) error
 			Type:           utils.StringPointer(attr.BalanceType),
 			ExpirationDate: expTime,
 			RatingSubject:  attr.RatingSubject,
+			Currency:       attr.Currency,
 			Weight:         attr.Weight,
 			Blocker:        attr.Blocker,
 			Disabled:       attr.Disabled,
@@ -585,6 +603,7 @@ func (self *ApierV1) RemoveBalances(attr *utils.AttrSetBalance, reply *string) e
 	at := &engine.ActionTiming{}
 	at.SetAccountIDs(utils.StringMap{accID: true})
 	a := &engine.Action{
+		Id:         "ApierV1.RemoveBalances",
 		ActionType: engine.REMOVE_BALANCE,
 		Balance: &engine.BalanceFilter{
 			Uuid:           attr.BalanceUUID,
@@ -592,6 +611,7 @@ func (self *ApierV1) RemoveBalances(attr *utils.AttrSetBalance, reply *string) e
 			Type:           utils.StringPointer(attr.BalanceType),
 			ExpirationDate: expTime,
 			RatingSubject:  attr.RatingSubject,
+			Currency:       attr.Currency,
 			Weight:         attr.Weight,
 			Blocker:        attr.Blocker,
 			Disabled:       attr.Disabled,
@@ -623,3 +643,42 @@ func (self *ApierV1) RemoveBalances(attr *utils.AttrSetBalance, reply *string) e
 	*reply = OK
 	return nil
 }
+
+type AttrGetBalanceLedger struct {
+	Tenant    string
+	Account   string
+	TimeStart string // entries created starting with this time
+	TimeEnd   string // entries created before this time
+	utils.Paginator
+}
+
+// GetBalanceLedger returns the balance changes of an account, as recorded in StorDB
+func (self *ApierV1) GetBalanceLedger(attr AttrGetBalanceLedger, reply *[]*engine.BalanceLedgerEntry) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant", "Account"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	fltr := &engine.BalanceLedgerFilter{AccountID: utils.AccountKey(attr.Tenant, attr.Account), Paginator: attr.Paginator}
+	if attr.TimeStart != "" {
+		tStart, err := utils.ParseTimeDetectLayout(attr.TimeStart, self.Config.DefaultTimezone)
+		if err != nil {
+			return utils.NewErrServerError(err)
+		}
+		fltr.TimeStart = &tStart
+	}
+	if attr.TimeEnd != "" {
+		tEnd, err := utils.ParseTimeDetectLayout(attr.TimeEnd, self.Config.DefaultTimezone)
+		if err != nil {
+			return utils.NewErrServerError(err)
+		}
+		fltr.TimeEnd = &tEnd
+	}
+	entries, err := self.CdrDb.GetBalanceLedger(fltr)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = entries
+	return nil
+}
@@ -135,6 +135,38 @@ func (apier *ApierV1) GetSharedGroup(sgId string, reply *engine.SharedGroup) err
 	return nil
 }
 
+type AttrGetSharedGroupUsage struct {
+	SharedGroupID string
+	Time          string // defaults to now
+}
+
+// GetSharedGroupUsage returns the consumption of each shared group member and balance type within its current quota period
+func (apier *ApierV1) GetSharedGroupUsage(attr AttrGetSharedGroupUsage, reply *[]*engine.SharedGroupMemberUsage) error {
+	if missing := utils.MissingStructFields(&attr, []string{"SharedGroupID"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	t := time.Now()
+	if attr.Time != "" {
+		var err error
+		if t, err = utils.ParseTimeDetectLayout(attr.Time, apier.Config.DefaultTimezone); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	sg, err := apier.DataDB.GetSharedGroup(attr.SharedGroupID, false, utils.NonTransactional)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	mus, err := sg.GetMembersUsage(t)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = mus
+	return nil
+}
+
 func (self *ApierV1) SetDestination(attrs utils.AttrSetDestination, reply *string) (err error) {
 	if missing := utils.MissingStructFields(&attrs, []string{"Id", "Prefixes"}); len(missing) != 0 {
 		return utils.NewErrMandatoryIeMissing(missing...)
@@ -754,6 +786,7 @@ func (self *ApierV1) ReloadCache(attrs utils.AttrReloadCache, reply *string) (er
 		cache.Flush()
 		return
 	}
+	cache.RemPrefixKey(utils.ExchangeRatesPrefix, true, utils.NonTransactional) // cached on first use, queried again in DataDB
 	// Reload Destinations
 	dataIDs := make([]string, 0)
 	if attrs.DestinationIDs == nil {
@@ -1051,6 +1084,7 @@ func (self *ApierV1) FlushCache(args utils.AttrReloadCache, reply *string) (err
 		*reply = utils.OK
 		return
 	}
+	cache.RemPrefixKey(utils.ExchangeRatesPrefix, true, utils.NonTransactional)
 	if args.DestinationIDs == nil {
 		cache.RemPrefixKey(utils.DESTINATION_PREFIX, true, utils.NonTransactional)
 	} else if len(*args.DestinationIDs) != 0 {
@@ -1497,6 +1531,8 @@ func (self *ApierV1) LoadTariffPlanFromFolder(attrs utils.AttrLoadTpFromFolder,
 		path.Join(attrs.FolderPath, utils.ResourcesCsv),
 		path.Join(attrs.FolderPath, utils.StatsCsv),
 		path.Join(attrs.FolderPath, utils.ThresholdsCsv),
+		path.Join(attrs.FolderPath, utils.ExchangeRatesCsv),
+		path.Join(attrs.FolderPath, utils.TaxRulesCsv),
 	), "", self.Config.DefaultTimezone)
 	if err := loader.LoadAll(); err != nil {
 		return utils.NewErrServerError(err)
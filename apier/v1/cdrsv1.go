@@ -72,6 +72,43 @@ func (self *CdrsV1) RateCDRs(attrs utils.AttrRateCdrs, reply *string) error {
 	return nil
 }
 
+// Returns the CDRs discarded as duplicates within the deduplication window
+func (self *CdrsV1) GetDuplicateCDRs(ignr string, reply *[]*engine.DuplicateCDR) error {
+	return self.CdrSrv.V1GetDuplicateCDRs(ignr, reply)
+}
+
+// Re-rates CDRs matching the filter, returning the cost differences for approval
+func (self *CdrsV1) CreateRerateJob(args engine.ArgsRerateCDRs, reply *engine.RerateJob) error {
+	return self.CdrSrv.V1CreateRerateJob(args, reply)
+}
+
+func (self *CdrsV1) GetRerateJob(jobID string, reply *engine.RerateJob) error {
+	return self.CdrSrv.V1GetRerateJob(jobID, reply)
+}
+
+// Stores the re-rated CDRs of an approved job, a job failing to commit is kept to be committed again
+func (self *CdrsV1) CommitRerateJob(args engine.ArgsCommitRerateJob, reply *string) error {
+	return self.CdrSrv.V1CommitRerateJob(args, reply)
+}
+
+func (self *CdrsV1) DiscardRerateJob(jobID string, reply *string) error {
+	return self.CdrSrv.V1DiscardRerateJob(jobID, reply)
+}
+
+// Exports the re-rating report using a CDRE template
+func (self *CdrsV1) ExportRerateJob(args engine.ArgsExportRerateJob, reply *string) error {
+	return self.CdrSrv.V1ExportRerateJob(args, reply)
+}
+
+// Archives and removes the CDRs expired under the retention policies
+func (self *CdrsV1) EnforceRetentionPolicies(ignr string, reply *engine.CDRsRetentionReport) error {
+	return self.CdrSrv.V1EnforceRetentionPolicies(ignr, reply)
+}
+
+func (self *CdrsV1) GetRetentionReport(ignr string, reply *engine.CDRsRetentionReport) error {
+	return self.CdrSrv.V1GetRetentionReport(ignr, reply)
+}
+
 func (self *CdrsV1) StoreSMCost(attr engine.AttrCDRSStoreSMCost, reply *string) error {
 	return self.CdrSrv.V1StoreSMCost(attr, reply)
 }
@@ -0,0 +1,133 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+type AttrSetExchangeRate struct {
+	FromCurrency   string
+	ToCurrency     string
+	ActivationTime string // rate applies starting with this time, *now if empty
+	Rate           float64
+}
+
+// SetExchangeRate adds a rate to a currency pair, replacing the one with the same activation time
+func (apier *ApierV1) SetExchangeRate(attrs AttrSetExchangeRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency", "Rate"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if attrs.Rate <= 0 {
+		return fmt.Errorf("invalid exchange rate: %v", attrs.Rate)
+	}
+	if attrs.ActivationTime == "" {
+		attrs.ActivationTime = utils.META_NOW
+	}
+	at, err := utils.ParseTimeDetectLayout(attrs.ActivationTime, apier.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	ers, err := apier.DataDB.GetExchangeRates(attrs.FromCurrency, attrs.ToCurrency)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			return utils.NewErrServerError(err)
+		}
+		ers = &engine.ExchangeRates{FromCurrency: attrs.FromCurrency, ToCurrency: attrs.ToCurrency}
+	}
+	var replaced bool
+	for _, er := range ers.Rates {
+		if er.ActivationTime.Equal(at) {
+			er.Rate = attrs.Rate
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		ers.Rates = append(ers.Rates, &engine.ExchangeRate{ActivationTime: at, Rate: attrs.Rate})
+		ers.Sort()
+	}
+	if err := apier.DataDB.SetExchangeRates(ers); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrGetExchangeRates struct {
+	FromCurrency string
+	ToCurrency   string
+}
+
+// GetExchangeRates returns all the rates of a currency pair
+func (apier *ApierV1) GetExchangeRates(attrs AttrGetExchangeRates, reply *engine.ExchangeRates) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	ers, err := apier.DataDB.GetExchangeRates(attrs.FromCurrency, attrs.ToCurrency)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = *ers
+	return nil
+}
+
+// RemExchangeRates removes all the rates of a currency pair
+func (apier *ApierV1) RemExchangeRates(attrs AttrGetExchangeRates, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := apier.DataDB.RemExchangeRates(attrs.FromCurrency, attrs.ToCurrency); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrGetExchangeRate struct {
+	FromCurrency string
+	ToCurrency   string
+	Time         string // *now if empty
+}
+
+// GetExchangeRate returns the rate converting amounts between two currencies at the given time
+func (apier *ApierV1) GetExchangeRate(attrs AttrGetExchangeRate, reply *float64) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	t := time.Now()
+	if attrs.Time != "" {
+		var err error
+		if t, err = utils.ParseTimeDetectLayout(attrs.Time, apier.Config.DefaultTimezone); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	rate, err := engine.GetExchangeRate(attrs.FromCurrency, attrs.ToCurrency, t)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = rate
+	return nil
+}
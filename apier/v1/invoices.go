@@ -0,0 +1,103 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package v1
+
+import (
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+type AttrGenerateInvoice struct {
+	Tenant      string
+	Account     string
+	PeriodStart string   // billing period start, included
+	PeriodEnd   string   // billing period end, excluded
+	GroupBy     []string // CDR fields grouping the usage lines, empty for the configured ones
+}
+
+// GenerateInvoice invoices the CDRs of an account within the billing period, freezing the invoice in StorDB
+func (self *ApierV1) GenerateInvoice(attr AttrGenerateInvoice, reply *engine.Invoice) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant", "Account", "PeriodStart", "PeriodEnd"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	pStart, err := utils.ParseTimeDetectLayout(attr.PeriodStart, self.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	pEnd, err := utils.ParseTimeDetectLayout(attr.PeriodEnd, self.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	inv, err := engine.NewInvoiceService(self.CdrDb, self.Config).GenerateInvoice(&engine.ArgsGenerateInvoice{
+		Tenant: attr.Tenant, Account: attr.Account, PeriodStart: pStart, PeriodEnd: pEnd, GroupBy: attr.GroupBy})
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = *inv
+	return nil
+}
+
+type AttrGetInvoices struct {
+	Tenant  string
+	Account string // empty for all the accounts of the tenant
+	utils.Paginator
+}
+
+// GetInvoices returns the invoices generated for a tenant, in the order of their numbers
+func (self *ApierV1) GetInvoices(attr AttrGetInvoices, reply *[]*engine.Invoice) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	invs, err := engine.NewInvoiceService(self.CdrDb, self.Config).GetInvoices(
+		&engine.InvoiceFilter{Tenant: attr.Tenant, Account: attr.Account, Paginator: attr.Paginator})
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = invs
+	return nil
+}
+
+type AttrRenderInvoice struct {
+	Tenant   string
+	Number   int
+	Template string // <*json|*csv|*html|$file_in_templates_dir>, defaults to *json
+}
+
+// RenderInvoice renders a stored invoice through one of the invoice templates
+func (self *ApierV1) RenderInvoice(attr AttrRenderInvoice, reply *string) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant", "Number"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	invS := engine.NewInvoiceService(self.CdrDb, self.Config)
+	invs, err := invS.GetInvoices(&engine.InvoiceFilter{Tenant: attr.Tenant, Number: int64(attr.Number)})
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	rendered, err := invS.RenderInvoice(invs[0], attr.Template)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = rendered
+	return nil
+}
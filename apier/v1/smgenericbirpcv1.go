@@ -33,18 +33,24 @@ type SMGenericBiRpcV1 struct {
 // Publishes methods exported by SMGenericBiRpcV1 as SMGenericV1 (so we can handle standard RPC methods via birpc socket)
 func (self *SMGenericBiRpcV1) Handlers() map[string]interface{} {
 	return map[string]interface{}{
-		"SMGenericV1.GetMaxUsage":             self.GetMaxUsage,
-		"SMGenericV1.GetLCRSuppliers":         self.GetLCRSuppliers,
-		"SMGenericV1.InitiateSession":         self.InitiateSession,
-		"SMGenericV1.UpdateSession":           self.UpdateSession,
-		"SMGenericV1.TerminateSession":        self.TerminateSession,
-		"SMGenericV1.ChargeEvent":             self.ChargeEvent,
-		"SMGenericV1.ProcessCDR":              self.ProcessCDR,
-		"SMGenericV1.GetActiveSessions":       self.GetActiveSessions,
-		"SMGenericV1.GetActiveSessionsCount":  self.GetActiveSessionsCount,
-		"SMGenericV1.GetṔassiveSessions":      self.GetṔassiveSessions,
-		"SMGenericV1.GetPassiveSessionsCount": self.GetPassiveSessionsCount,
-		"SMGenericV1.ReplicateActiveSessions": self.ReplicateActiveSessions,
+		"SMGenericV1.GetMaxUsage":              self.GetMaxUsage,
+		"SMGenericV1.GetLCRSuppliers":          self.GetLCRSuppliers,
+		"SMGenericV1.InitiateSession":          self.InitiateSession,
+		"SMGenericV1.UpdateSession":            self.UpdateSession,
+		"SMGenericV1.InitiateSessionWithUnits": self.InitiateSessionWithUnits,
+		"SMGenericV1.UpdateSessionWithUnits":   self.UpdateSessionWithUnits,
+		"SMGenericV1.TerminateSession":         self.TerminateSession,
+		"SMGenericV1.ChargeEvent":              self.ChargeEvent,
+		"SMGenericV1.ReserveEvent":             self.ReserveEvent,
+		"SMGenericV1.CommitEvent":              self.CommitEvent,
+		"SMGenericV1.CancelEvent":              self.CancelEvent,
+		"SMGenericV1.ProcessCDR":               self.ProcessCDR,
+		"SMGenericV1.GetActiveSessions":        self.GetActiveSessions,
+		"SMGenericV1.GetActiveSessionsCount":   self.GetActiveSessionsCount,
+		"SMGenericV1.GetṔassiveSessions":       self.GetṔassiveSessions,
+		"SMGenericV1.GetPassiveSessionsCount":  self.GetPassiveSessionsCount,
+		"SMGenericV1.ReplicateActiveSessions":  self.ReplicateActiveSessions,
+		"SMGenericV1.SyncSessions":             self.SyncSessions,
 	}
 }
 
@@ -68,6 +74,16 @@ func (self *SMGenericBiRpcV1) UpdateSession(clnt *rpc2.Client, ev sessionmanager
 	return self.sm.BiRPCV1UpdateSession(clnt, ev, maxUsage)
 }
 
+// Called on start of sessions charging several units at once, returns the units granted for each TOR
+func (self *SMGenericBiRpcV1) InitiateSessionWithUnits(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, maxUnits *map[string]float64) error {
+	return self.sm.BiRPCV1InitiateSessionWithUnits(clnt, ev, maxUnits)
+}
+
+// Interim updates of sessions charging several units at once, returns the units granted for each TOR
+func (self *SMGenericBiRpcV1) UpdateSessionWithUnits(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, maxUnits *map[string]float64) error {
+	return self.sm.BiRPCV1UpdateSessionWithUnits(clnt, ev, maxUnits)
+}
+
 // Called on session end, should stop debit loop
 func (self *SMGenericBiRpcV1) TerminateSession(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, reply *string) error {
 	return self.sm.BiRPCV1TerminateSession(clnt, ev, reply)
@@ -78,6 +94,21 @@ func (self *SMGenericBiRpcV1) ChargeEvent(clnt *rpc2.Client, ev sessionmanager.S
 	return self.sm.BiRPCV1ChargeEvent(clnt, ev, maxUsage)
 }
 
+// ReserveEvent debits the event usage and keeps it on hold until CommitEvent or CancelEvent
+func (self *SMGenericBiRpcV1) ReserveEvent(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, maxUsage *float64) error {
+	return self.sm.BiRPCV1ReserveEvent(clnt, ev, maxUsage)
+}
+
+// CommitEvent finalises a reservation with the real event usage
+func (self *SMGenericBiRpcV1) CommitEvent(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, reply *string) error {
+	return self.sm.BiRPCV1CommitEvent(clnt, ev, reply)
+}
+
+// CancelEvent releases a reservation
+func (self *SMGenericBiRpcV1) CancelEvent(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, reply *string) error {
+	return self.sm.BiRPCV1CancelEvent(clnt, ev, reply)
+}
+
 // Called on session end, should send the CDR to CDRS
 func (self *SMGenericBiRpcV1) ProcessCDR(clnt *rpc2.Client, ev sessionmanager.SMGenericEvent, reply *string) error {
 	return self.sm.BiRPCV1ProcessCDR(clnt, ev, reply)
@@ -106,3 +137,7 @@ func (self *SMGenericBiRpcV1) ReplicateActiveSessions(clnt *rpc2.Client, args se
 func (self *SMGenericBiRpcV1) ReplicatePassiveSessions(clnt *rpc2.Client, args sessionmanager.ArgsReplicateSessions, reply *string) error {
 	return self.sm.BiRPCV1ReplicateActiveSessions(clnt, args, reply)
 }
+
+func (self *SMGenericBiRpcV1) SyncSessions(clnt *rpc2.Client, args sessionmanager.ArgsSyncSessions, reply *[]sessionmanager.SMGenericEvent) error {
+	return self.sm.BiRPCV1SyncSessions(clnt, args, reply)
+}
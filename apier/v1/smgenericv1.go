@@ -50,11 +50,21 @@ func (self *SMGenericV1) InitiateSession(ev sessionmanager.SMGenericEvent, maxUs
 	return self.SMG.BiRPCV1InitiateSession(nil, ev, maxUsage)
 }
 
+// Called on start of sessions charging several units at once, returns the units granted for each TOR
+func (self *SMGenericV1) InitiateSessionWithUnits(ev sessionmanager.SMGenericEvent, maxUnits *map[string]float64) error {
+	return self.SMG.BiRPCV1InitiateSessionWithUnits(nil, ev, maxUnits)
+}
+
 // Interim updates, returns remaining duration from the rater
 func (self *SMGenericV1) UpdateSession(ev sessionmanager.SMGenericEvent, maxUsage *float64) error {
 	return self.SMG.BiRPCV1UpdateSession(nil, ev, maxUsage)
 }
 
+// Interim updates of sessions charging several units at once, returns the units granted for each TOR
+func (self *SMGenericV1) UpdateSessionWithUnits(ev sessionmanager.SMGenericEvent, maxUnits *map[string]float64) error {
+	return self.SMG.BiRPCV1UpdateSessionWithUnits(nil, ev, maxUnits)
+}
+
 // Called on session end, should stop debit loop
 func (self *SMGenericV1) TerminateSession(ev sessionmanager.SMGenericEvent, reply *string) error {
 	return self.SMG.BiRPCV1TerminateSession(nil, ev, reply)
@@ -65,6 +75,21 @@ func (self *SMGenericV1) ChargeEvent(ev sessionmanager.SMGenericEvent, maxUsage
 	return self.SMG.BiRPCV1ChargeEvent(nil, ev, maxUsage)
 }
 
+// ReserveEvent debits the event usage and keeps it on hold until CommitEvent or CancelEvent
+func (self *SMGenericV1) ReserveEvent(ev sessionmanager.SMGenericEvent, maxUsage *float64) error {
+	return self.SMG.BiRPCV1ReserveEvent(nil, ev, maxUsage)
+}
+
+// CommitEvent finalises a reservation with the real event usage
+func (self *SMGenericV1) CommitEvent(ev sessionmanager.SMGenericEvent, reply *string) error {
+	return self.SMG.BiRPCV1CommitEvent(nil, ev, reply)
+}
+
+// CancelEvent releases a reservation
+func (self *SMGenericV1) CancelEvent(ev sessionmanager.SMGenericEvent, reply *string) error {
+	return self.SMG.BiRPCV1CancelEvent(nil, ev, reply)
+}
+
 // Called on session end, should send the CDR to CDRS
 func (self *SMGenericV1) ProcessCDR(ev sessionmanager.SMGenericEvent, reply *string) error {
 	return self.SMG.BiRPCV1ProcessCDR(nil, ev, reply)
@@ -90,6 +115,11 @@ func (self *SMGenericV1) SetPassiveSessions(args sessionmanager.ArgsSetPassiveSe
 	return self.SMG.BiRPCV1SetPassiveSessions(nil, args, reply)
 }
 
+// Received from the replication peer to signal it is alive
+func (self *SMGenericV1) ReplicationHeartbeat(ignr string, reply *string) error {
+	return self.SMG.BiRPCV1ReplicationHeartbeat(nil, ignr, reply)
+}
+
 func (self *SMGenericV1) ReplicateActiveSessions(args sessionmanager.ArgsReplicateSessions, reply *string) error {
 	return self.SMG.BiRPCV1ReplicateActiveSessions(nil, args, reply)
 }
@@ -98,6 +128,11 @@ func (self *SMGenericV1) ReplicatePassiveSessions(args sessionmanager.ArgsReplic
 	return self.SMG.BiRPCV1ReplicatePassiveSessions(nil, args, reply)
 }
 
+// Reconciles the sessions restored after restart with the ones still active on the switch, replying with the start events of the ones kept
+func (self *SMGenericV1) SyncSessions(args sessionmanager.ArgsSyncSessions, reply *[]sessionmanager.SMGenericEvent) error {
+	return self.SMG.BiRPCV1SyncSessions(nil, args, reply)
+}
+
 // rpcclient.RpcClientConnection interface
 func (self *SMGenericV1) Call(serviceMethod string, args interface{}, reply interface{}) error {
 	methodSplit := strings.Split(serviceMethod, ".")
@@ -0,0 +1,121 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package v1
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+type AttrSetSubscription struct {
+	Tenant         string
+	Account        string
+	ID             string
+	Fee            float64
+	Period         string // *monthly or *yearly, defaults to *monthly
+	Billing        string // *anniversary or *calendar, defaults to *anniversary
+	Proration      string // *daily or *none, defaults to *daily
+	ActivationTime string // defaults to now, ignored on plan changes
+}
+
+// SetSubscription subscribes the account, charging the fee prorated till the end of the current period.
+// Setting an existing subscription changes its plan, refunding what was paid in advance for the old one.
+// Following periods are charged by *charge_subscriptions actions, scheduled in the account action plans.
+func (self *ApierV1) SetSubscription(attr AttrSetSubscription, reply *string) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant", "Account", "ID"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	var actTime time.Time
+	if attr.ActivationTime != "" {
+		var err error
+		if actTime, err = utils.ParseTimeDetectLayout(attr.ActivationTime, self.Config.DefaultTimezone); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	sub, err := engine.NewSubscription(attr.ID, attr.Fee, attr.Period, attr.Billing, attr.Proration, actTime)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	accID := utils.AccountKey(attr.Tenant, attr.Account)
+	if _, err := self.DataDB.GetAccount(accID); err != nil {
+		return err
+	}
+	at := &engine.ActionTiming{}
+	at.SetAccountIDs(utils.StringMap{accID: true})
+	at.SetActions(engine.Actions{&engine.Action{Id: "ApierV1.SetSubscription",
+		ActionType: engine.SUBSCRIBE, ExtraParameters: utils.ToJSON(sub)}})
+	if err := at.Execute(nil, nil); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = OK
+	return nil
+}
+
+type AttrRemoveSubscription struct {
+	Tenant  string
+	Account string
+	ID      string
+}
+
+// RemoveSubscription terminates the subscription now, refunding the days paid in advance
+func (self *ApierV1) RemoveSubscription(attr AttrRemoveSubscription, reply *string) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant", "Account", "ID"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	accID := utils.AccountKey(attr.Tenant, attr.Account)
+	acc, err := self.DataDB.GetAccount(accID)
+	if err != nil {
+		return err
+	}
+	if _, has := acc.Subscriptions[attr.ID]; !has {
+		return utils.ErrNotFound
+	}
+	at := &engine.ActionTiming{}
+	at.SetAccountIDs(utils.StringMap{accID: true})
+	at.SetActions(engine.Actions{&engine.Action{Id: "ApierV1.RemoveSubscription",
+		ActionType: engine.UNSUBSCRIBE, ExtraParameters: attr.ID}})
+	if err := at.Execute(nil, nil); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = OK
+	return nil
+}
+
+// GetSubscriptions returns the subscriptions of an account, ordered by ID
+func (self *ApierV1) GetSubscriptions(attr utils.AttrGetAccount, reply *[]*engine.Subscription) error {
+	if missing := utils.MissingStructFields(&attr, []string{"Tenant", "Account"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	acc, err := self.DataDB.GetAccount(utils.AccountKey(attr.Tenant, attr.Account))
+	if err != nil {
+		return err
+	}
+	if len(acc.Subscriptions) == 0 {
+		return utils.ErrNotFound
+	}
+	subs := make([]*engine.Subscription, 0, len(acc.Subscriptions))
+	for _, sub := range acc.Subscriptions {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	*reply = subs
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// SetTaxRules replaces all the tax rules of a tenant
+func (apier *ApierV1) SetTaxRules(trs engine.TaxRules, reply *string) error {
+	if missing := utils.MissingStructFields(&trs, []string{"Tenant", "Rules"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	for _, tr := range trs.Rules {
+		if tr.ID == "" {
+			return utils.NewErrMandatoryIeMissing("ID")
+		}
+		if tr.Rate < 0 {
+			return fmt.Errorf("invalid rate %v for tax %s", tr.Rate, tr.ID)
+		}
+	}
+	trs.Sort()
+	if err := apier.DataDB.SetTaxRules(&trs); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrGetTaxRules struct {
+	Tenant string
+}
+
+// GetTaxRules returns the tax rules of a tenant
+func (apier *ApierV1) GetTaxRules(attrs AttrGetTaxRules, reply *engine.TaxRules) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	trs, err := apier.DataDB.GetTaxRules(attrs.Tenant)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = *trs
+	return nil
+}
+
+// RemTaxRules removes all the tax rules of a tenant
+func (apier *ApierV1) RemTaxRules(attrs AttrGetTaxRules, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := apier.DataDB.RemTaxRules(attrs.Tenant); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrComputeTaxes struct {
+	Tenant         string
+	TaxClass       string
+	Category       string
+	DestinationIDs []string
+	Time           string // *now if empty
+	Cost           float64
+}
+
+// ComputeTaxes returns the taxes the tax rules of a tenant apply on a cost
+func (apier *ApierV1) ComputeTaxes(attrs AttrComputeTaxes, reply *[]*engine.TaxCharge) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	t := time.Now()
+	if attrs.Time != "" {
+		var err error
+		if t, err = utils.ParseTimeDetectLayout(attrs.Time, apier.Config.DefaultTimezone); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	trs, err := apier.DataDB.GetTaxRules(attrs.Tenant)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = trs.ComputeTaxes(attrs.Cost, attrs.TaxClass, attrs.Category,
+		utils.StringMapFromSlice(attrs.DestinationIDs), t, apier.Config.RoundingDecimals)
+	return nil
+}
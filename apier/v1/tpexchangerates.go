@@ -0,0 +1,87 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package v1
+
+import (
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Creates a new ExchangeRates profile within a tariff plan
+func (self *ApierV1) SetTPExchangeRates(attrs utils.TPExchangeRates, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "ID", "ExchangeRates"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.StorDb.SetTPExchangeRates([]*utils.TPExchangeRates{&attrs}); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrGetTPExchangeRates struct {
+	TPid string // Tariff plan id
+	ID   string // ExchangeRates id
+}
+
+// Queries specific ExchangeRates on tariff plan
+func (self *ApierV1) GetTPExchangeRates(attrs AttrGetTPExchangeRates, reply *utils.TPExchangeRates) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "ID"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if ers, err := self.StorDb.GetTPExchangeRates(attrs.TPid, attrs.ID); err != nil {
+		return utils.NewErrServerError(err)
+	} else if len(ers) == 0 {
+		return utils.ErrNotFound
+	} else {
+		*reply = *ers[0]
+	}
+	return nil
+}
+
+type AttrGetTPExchangeRateIds struct {
+	TPid string // Tariff plan id
+	utils.Paginator
+}
+
+// Queries ExchangeRates identities on specific tariff plan.
+func (self *ApierV1) GetTPExchangeRateIds(attrs AttrGetTPExchangeRateIds, reply *[]string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if ids, err := self.StorDb.GetTpTableIds(attrs.TPid, utils.TBLTPExchangeRates, utils.TPDistinctIds{"tag"}, nil, &attrs.Paginator); err != nil {
+		return utils.NewErrServerError(err)
+	} else if ids == nil {
+		return utils.ErrNotFound
+	} else {
+		*reply = ids
+	}
+	return nil
+}
+
+// Removes specific ExchangeRates on Tariff plan
+func (self *ApierV1) RemTPExchangeRates(attrs AttrGetTPExchangeRates, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "ID"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.StorDb.RemTpData(utils.TBLTPExchangeRates, attrs.TPid, map[string]string{"tag": attrs.ID}); err != nil {
+		return utils.NewErrServerError(err)
+	} else {
+		*reply = utils.OK
+	}
+	return nil
+}
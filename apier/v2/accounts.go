@@ -94,6 +94,12 @@ type AttrSetAccount struct {
 	ActionTriggerOverwrite bool
 	AllowNegative          *bool
 	Disabled               *bool
+	MaxSessionCost         *float64
+	SpendingLimit          *float64 // 0 removes the limit
+	SpendingLimitPeriod    *string  // *daily, *monthly or empty for no reset
+	CreditLimit            *float64 // amount the account can go negative, ignored with AllowNegative
+	ParentID               *string  // reseller account charged on top of this one, empty to detach it
+	ParentSubject          *string  // rating subject of the wholesale rates charged to the parent
 	ReloadScheduler        bool
 }
 
@@ -218,6 +224,21 @@ func (self *ApierV2) SetAccount(attr AttrSetAccount, reply *string) error {
 		if attr.Disabled != nil {
 			ub.Disabled = *attr.Disabled
 		}
+		if attr.MaxSessionCost != nil {
+			ub.MaxSessionCost = *attr.MaxSessionCost
+		}
+		if err := ub.SetSpendingLimit(attr.SpendingLimit, attr.SpendingLimitPeriod); err != nil {
+			return 0, err
+		}
+		if attr.CreditLimit != nil {
+			if *attr.CreditLimit < 0 {
+				return 0, fmt.Errorf("negative credit limit: %v", *attr.CreditLimit)
+			}
+			ub.CreditLimit = *attr.CreditLimit
+		}
+		if err := ub.SetParent(attr.ParentID, attr.ParentSubject); err != nil {
+			return 0, err
+		}
 		// All prepared, save account
 		if err := self.DataDB.SetAccount(ub); err != nil {
 			return 0, err
@@ -45,6 +45,65 @@ func (apier *ApierV2) GetCdrs(attrs utils.RPCCDRsFilter, reply *[]*engine.Extern
 	return nil
 }
 
+// ArgsGetCDRsPage are the arguments for cursor based CDR queries
+type ArgsGetCDRsPage struct {
+	utils.RPCCDRsFilter       // Paginator.Limit is used as page size, Offset is ignored
+	Cursor              int64 // NextCursor returned by the previous page, 0 for the first one
+}
+
+// RplCDRsPage is one page of CDRs together with the cursor for the next one
+type RplCDRsPage struct {
+	CDRs       []*engine.ExternalCDR
+	NextCursor int64 // 0 if there are no more CDRs
+}
+
+// Retrieves CDRs in pages ordered on OrderID, consistent also when new CDRs are stored in between queries
+func (apier *ApierV2) GetCDRsPage(args ArgsGetCDRsPage, reply *RplCDRsPage) error {
+	cdrsFltr, err := args.RPCCDRsFilter.AsCDRsFilter(apier.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	cdrs, nextCursor, err := engine.GetCDRsPage(apier.CdrDb, cdrsFltr, args.Cursor)
+	if err != nil {
+		if err.Error() != utils.NotFoundCaps {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	reply.CDRs = make([]*engine.ExternalCDR, len(cdrs))
+	for i, cdr := range cdrs {
+		reply.CDRs[i] = cdr.AsExternalCDR()
+	}
+	reply.NextCursor = nextCursor
+	return nil
+}
+
+// ArgsGetCDRsAggregates selects the CDRs to aggregate and how to group them
+type ArgsGetCDRsAggregates struct {
+	utils.RPCCDRsFilter
+	engine.CDRsAggregation
+}
+
+// Retrieves count, usage and cost metrics of CDRs grouped on fields and time buckets
+func (apier *ApierV2) GetCDRsAggregates(args ArgsGetCDRsAggregates, reply *[]*engine.CDRsAggregate) error {
+	if err := args.CDRsAggregation.Validate(); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	cdrsFltr, err := args.RPCCDRsFilter.AsCDRsFilter(apier.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	aggrs, err := apier.CdrDb.GetCDRsAggregates(cdrsFltr, &args.CDRsAggregation)
+	if err != nil {
+		if err.Error() != utils.NotFoundCaps {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = aggrs
+	return nil
+}
+
 func (apier *ApierV2) CountCdrs(attrs utils.RPCCDRsFilter, reply *int64) error {
 	cdrsFltr, err := attrs.AsCDRsFilter(apier.Config.DefaultTimezone)
 	if err != nil {
@@ -131,9 +131,9 @@ func startCdrc(internalCdrSChan, internalRaterChan chan rpcclient.RpcClientConne
 	}
 }
 
-func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRaterChan, internalCDRSChan chan rpcclient.RpcClientConnection, server *utils.Server, exitChan chan bool) {
+func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRaterChan, internalCDRSChan, internalRsChan, internalStatSChan chan rpcclient.RpcClientConnection, dataDB engine.DataDB, server *utils.Server, exitChan chan bool) {
 	utils.Logger.Info("Starting CGRateS SMGeneric service.")
-	var ralsConns, cdrsConn *rpcclient.RpcClientPool
+	var ralsConns, cdrsConn, rlsConn, statSConn *rpcclient.RpcClientPool
 	if len(cfg.SmGenericConfig.RALsConns) != 0 {
 		ralsConns, err = engine.NewRPCPool(rpcclient.POOL_FIRST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
 			cfg.SmGenericConfig.RALsConns, internalRaterChan, cfg.InternalTtl)
@@ -152,13 +152,31 @@ func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRate
 			return
 		}
 	}
+	if len(cfg.SmGenericConfig.RLsConns) != 0 {
+		rlsConn, err = engine.NewRPCPool(rpcclient.POOL_FIRST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
+			cfg.SmGenericConfig.RLsConns, internalRsChan, cfg.InternalTtl)
+		if err != nil {
+			utils.Logger.Crit(fmt.Sprintf("<SMGeneric> Could not connect to RLs: %s", err.Error()))
+			exitChan <- true
+			return
+		}
+	}
+	if len(cfg.SmGenericConfig.StatSConns) != 0 {
+		statSConn, err = engine.NewRPCPool(rpcclient.POOL_FIRST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
+			cfg.SmGenericConfig.StatSConns, internalStatSChan, cfg.InternalTtl)
+		if err != nil {
+			utils.Logger.Crit(fmt.Sprintf("<SMGeneric> Could not connect to StatS: %s", err.Error()))
+			exitChan <- true
+			return
+		}
+	}
 	smgReplConns, err := sessionmanager.NewSMGReplicationConns(cfg.SmGenericConfig.SMGReplicationConns, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout)
 	if err != nil {
 		utils.Logger.Crit(fmt.Sprintf("<SMGeneric> Could not connect to SMGReplicationConnection error: <%s>", err.Error()))
 		exitChan <- true
 		return
 	}
-	sm := sessionmanager.NewSMGeneric(cfg, ralsConns, cdrsConn, smgReplConns, cfg.DefaultTimezone)
+	sm := sessionmanager.NewSMGeneric(cfg, ralsConns, cdrsConn, rlsConn, statSConn, dataDB, smgReplConns, cfg.DefaultTimezone)
 	if err = sm.Connect(); err != nil {
 		utils.Logger.Err(fmt.Sprintf("<SMGeneric> error: %s!", err))
 	}
@@ -350,7 +368,7 @@ func startSmKamailio(internalRaterChan, internalCDRSChan, internalRsChan chan rp
 			return
 		}
 	}
-	sm, _ := sessionmanager.NewKamailioSessionManager(cfg.SmKamConfig, ralsConn, cdrsConn, rlSConn, cfg.DefaultTimezone)
+	sm, _ := sessionmanager.NewKamailioSessionManager(cfg.SmKamConfig, ralsConn, cdrsConn, rlSConn, cfg.DefaultTimezone, cfg.DefaultReqType)
 	smRpc.SMs = append(smRpc.SMs, sm)
 	if err = sm.Connect(); err != nil {
 		utils.Logger.Err(fmt.Sprintf("<SMKamailio> error: %s!", err))
@@ -453,6 +471,10 @@ func startCDRS(internalCdrSChan chan rpcclient.RpcClientConnection,
 	cdrServer.SetTimeToLive(cfg.ResponseCacheTTL, nil)
 	utils.Logger.Info("Registering CDRS HTTP Handlers.")
 	cdrServer.RegisterHandlersToServer(server)
+	if cfg.CDRSRetentionInterval != 0 && len(cfg.CDRSRetentionPolicies) != 0 {
+		utils.Logger.Info("Starting CDRS retention policies enforcement.")
+		go cdrServer.RunRetentionPolicies(cfg.CDRSRetentionInterval)
+	}
 	utils.Logger.Info("Registering CDRS RPC service.")
 	cdrSrv := v1.CdrsV1{CdrSrv: cdrServer}
 	server.RpcRegister(&cdrSrv)
@@ -742,6 +764,7 @@ func main() {
 	engine.SetRoundingDecimals(cfg.RoundingDecimals)
 	engine.SetRpSubjectPrefixMatching(cfg.RpSubjectPrefixMatching)
 	engine.SetLcrSubjectPrefixMatching(cfg.LcrSubjectPrefixMatching)
+	engine.SetDefaultCurrency(cfg.DefaultCurrency)
 	stopHandled := false
 
 	// Rpc/http server
@@ -794,7 +817,7 @@ func main() {
 
 	// Start SM-Generic
 	if cfg.SmGenericConfig.Enabled {
-		go startSmGeneric(internalSMGChan, internalRaterChan, internalCdrSChan, server, exitChan)
+		go startSmGeneric(internalSMGChan, internalRaterChan, internalCdrSChan, internalRsChan, internalStatSChan, dataDB, server, exitChan)
 	}
 	// Start SM-FreeSWITCH
 	if cfg.SmFsConfig.Enabled {
@@ -151,6 +151,8 @@ func main() {
 			path.Join(*dataPath, utils.ResourcesCsv),
 			path.Join(*dataPath, utils.StatsCsv),
 			path.Join(*dataPath, utils.ThresholdsCsv),
+			path.Join(*dataPath, utils.ExchangeRatesCsv),
+			path.Join(*dataPath, utils.TaxRulesCsv),
 		)
 	}
 	tpReader := engine.NewTpReader(dataDB, loader, *tpid, *timezone)
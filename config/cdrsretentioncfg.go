@@ -0,0 +1,57 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package config
+
+import (
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// CdrRetentionPolicy defines how long the CDRs of some tenants/runs are kept in StorDB
+type CdrRetentionPolicy struct {
+	Tenants         []string      // tenants the policy applies to, empty for all
+	RunIDs          []string      // run ids the policy applies to, empty for all
+	MaxAge          time.Duration // CDRs with SetupTime older than this are removed
+	ArchiveTemplate string        // CDRE template used to archive the CDRs before removal, empty to not archive
+	ArchivePath     string        // overwrites the export_path of the archive template
+}
+
+func (crp *CdrRetentionPolicy) loadFromJsonCfg(jsnCfg *CdrRetentionPolicyJsonCfg) (err error) {
+	if jsnCfg == nil {
+		return nil
+	}
+	if jsnCfg.Tenants != nil {
+		crp.Tenants = *jsnCfg.Tenants
+	}
+	if jsnCfg.Run_ids != nil {
+		crp.RunIDs = *jsnCfg.Run_ids
+	}
+	if jsnCfg.Max_age != nil {
+		if crp.MaxAge, err = utils.ParseDurationWithSecs(*jsnCfg.Max_age); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.Archive_template != nil {
+		crp.ArchiveTemplate = *jsnCfg.Archive_template
+	}
+	if jsnCfg.Archive_path != nil {
+		crp.ArchivePath = *jsnCfg.Archive_path
+	}
+	return nil
+}
@@ -211,6 +211,7 @@ type CGRConfig struct {
 	DefaultCategory          string            // set default type of record
 	DefaultTenant            string            // set default tenant
 	DefaultTimezone          string            // default timezone for timestamps where not specified <""|UTC|Local|$IANA_TZ_DB>
+	DefaultCurrency          string            // currency of the rates and monetary balances not specifying one
 	Reconnects               int               // number of recconect attempts in case of connection lost <-1 for infinite | nb>
 	ConnectTimeout           time.Duration     // timeout for RPC connection attempts
 	ReplyTimeout             time.Duration     // timeout replies if not reaching back
@@ -246,9 +247,19 @@ type CGRConfig struct {
 	CDRSAliaseSConns         []*HaPoolConfig // address where to reach the aliases service: <""|internal|x.y.z.y:1234>
 	CDRSCDRStatSConns        []*HaPoolConfig // address where to reach the cdrstats service. Empty to disable cdrstats gathering  <""|internal|x.y.z.y:1234>
 	CDRSStatSConns           []*HaPoolConfig
-	CDRSOnlineCDRExports     []string      // list of CDRE templates to use for real-time CDR exports
-	CDRStatsEnabled          bool          // Enable CDR Stats service
-	CDRStatsSaveInterval     time.Duration // Save interval duration
+	CDRSOnlineCDRExports     []string              // list of CDRE templates to use for real-time CDR exports
+	CDRSDedupFields          []*utils.RSRField     // fields identifying the same CDR received from different sources
+	CDRSDedupTimeTolerance   time.Duration         // maximum difference between time fields of duplicated CDRs
+	CDRSDedupWindow          time.Duration         // how long processed CDRs are remembered for duplicates matching
+	CDRSDedupPolicy          string                // action taken on duplicates <*drop|*keep_newest|*merge_extra_fields>
+	CDRSRerateJobTTL         time.Duration         // re-rating jobs not approved within this interval are discarded
+	CDRSRetentionInterval    time.Duration         // interval to enforce the retention policies, 0 to disable
+	CDRSRetentionBatchSize   int                   // number of CDRs archived and removed at once
+	CDRSRetentionPolicies    []*CdrRetentionPolicy // CDRs retention policies, enforced on retention interval
+	CDRSLocalTaxRunIDs       []string              // runs of the CDRs taxed with the tax rules in DataDB, empty to disable
+	CDRSTaxClassField        *utils.RSRField       // CDR field holding the customer tax class
+	CDRStatsEnabled          bool                  // Enable CDR Stats service
+	CDRStatsSaveInterval     time.Duration         // Save interval duration
 	CdreProfiles             map[string]*CdreConfig
 	CdrcProfiles             map[string][]*CdrcConfig // Number of CDRC instances running imports, format map[dirPath][]{Configs}
 	SmGenericConfig          *SmGenericConfig
@@ -271,6 +282,9 @@ type CGRConfig struct {
 	MailerAuthUser           string                   // Authenticate to email server using this user
 	MailerAuthPass           string                   // Authenticate to email server with this password
 	MailerFromAddr           string                   // From address used when sending emails out
+	InvoiceSRunIDs           []string                 // CDR runs billed on invoices
+	InvoiceSGroupBy          []string                 // CDR fields the invoice usage lines are grouped on
+	InvoiceSTemplatesDir     string                   // folder with additional render templates, the file name being the template ID
 	DataFolderPath           string                   // Path towards data folder, for tests internal usage, not loading out of .json options
 	sureTaxCfg               *SureTaxCfg              // Load here SureTax configuration, as pointer so we can have runtime reloads in the future
 	ConfigReloads            map[string]chan struct{} // Signals to specific entities that a config reload should occur
@@ -350,6 +364,31 @@ func (self *CGRConfig) checkConfigSanity() error {
 				return fmt.Errorf("<CDRS> Cannot find CDR export template with ID: <%s>", cdrePrfl)
 			}
 		}
+		if len(self.CDRSDedupFields) != 0 {
+			if !utils.IsSliceMember([]string{utils.MetaDrop, utils.MetaKeepNewest, utils.MetaMergeExtraFields}, self.CDRSDedupPolicy) {
+				return fmt.Errorf("<CDRS> Unsupported dedup_policy: <%s>", self.CDRSDedupPolicy)
+			}
+			if self.CDRSDedupWindow == 0 {
+				return errors.New("<CDRS> dedup_window cannot be 0 when deduplication is enabled")
+			}
+			if self.CDRSDedupPolicy == utils.MetaKeepNewest && !self.CDRSStoreCdrs {
+				return errors.New("<CDRS> dedup_policy *keep_newest needs store_cdrs enabled")
+			}
+		}
+		if self.CDRSRetentionInterval != 0 && self.CDRSRetentionBatchSize <= 0 {
+			return errors.New("<CDRS> retention_batch_size needs to be greater than 0")
+		}
+		for _, plcy := range self.CDRSRetentionPolicies {
+			if plcy.MaxAge <= 0 {
+				return errors.New("<CDRS> retention policy max_age needs to be greater than 0")
+			}
+			if plcy.ArchiveTemplate == "" {
+				continue
+			}
+			if _, hasIt := self.CdreProfiles[plcy.ArchiveTemplate]; !hasIt {
+				return fmt.Errorf("<CDRS> Cannot find CDR export template with ID: <%s>", plcy.ArchiveTemplate)
+			}
+		}
 	}
 	// CDRC sanity checks
 	for _, cdrcCfgs := range self.CdrcProfiles {
@@ -397,6 +436,16 @@ func (self *CGRConfig) checkConfigSanity() error {
 				return errors.New("<SMGeneric> CDRS not enabled but referenced by SMGeneric component")
 			}
 		}
+		for _, smgRLsConn := range self.SmGenericConfig.RLsConns {
+			if smgRLsConn.Address == utils.MetaInternal && !self.resourceSCfg.Enabled {
+				return errors.New("<SMGeneric> RLs not enabled but referenced by SMGeneric component")
+			}
+		}
+		for _, smgStatSConn := range self.SmGenericConfig.StatSConns {
+			if smgStatSConn.Address == utils.MetaInternal && !self.statsCfg.Enabled {
+				return errors.New("<SMGeneric> StatS not enabled but referenced by SMGeneric component")
+			}
+		}
 	}
 	// SMFreeSWITCH checks
 	if self.SmFsConfig.Enabled {
@@ -465,6 +514,9 @@ func (self *CGRConfig) checkConfigSanity() error {
 				return errors.New("<SMOpenSIPS> CDRS not enabled.")
 			}
 		}
+		if self.SmOsipsConfig.ListenJsonRPC != "" && self.SmOsipsConfig.MiJsonURL == "" {
+			return errors.New("<SMOpenSIPS> MI JSON URL is mandatory for event_jsonrpc interface.")
+		}
 	}
 	// SMAsterisk checks
 	if self.smAsteriskCfg.Enabled {
@@ -647,6 +699,11 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		return err
 	}
 
+	jsnInvoiceSCfg, err := jsnCfg.InvoiceSJsonCfg()
+	if err != nil {
+		return err
+	}
+
 	jsnSureTaxCfg, err := jsnCfg.SureTaxJsonCfg()
 	if err != nil {
 		return err
@@ -725,6 +782,9 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		if jsnGeneralCfg.Default_tenant != nil {
 			self.DefaultTenant = *jsnGeneralCfg.Default_tenant
 		}
+		if jsnGeneralCfg.Default_currency != nil {
+			self.DefaultCurrency = *jsnGeneralCfg.Default_currency
+		}
 		if jsnGeneralCfg.Connect_attempts != nil {
 			self.ConnectAttempts = *jsnGeneralCfg.Connect_attempts
 		}
@@ -933,6 +993,54 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 				self.CDRSOnlineCDRExports = append(self.CDRSOnlineCDRExports, expProfile)
 			}
 		}
+		if jsnCdrsCfg.Dedup_fields != nil {
+			if self.CDRSDedupFields, err = utils.ParseRSRFieldsFromSlice(*jsnCdrsCfg.Dedup_fields); err != nil {
+				return err
+			}
+		}
+		if jsnCdrsCfg.Dedup_time_tolerance != nil {
+			if self.CDRSDedupTimeTolerance, err = utils.ParseDurationWithSecs(*jsnCdrsCfg.Dedup_time_tolerance); err != nil {
+				return err
+			}
+		}
+		if jsnCdrsCfg.Dedup_window != nil {
+			if self.CDRSDedupWindow, err = utils.ParseDurationWithSecs(*jsnCdrsCfg.Dedup_window); err != nil {
+				return err
+			}
+		}
+		if jsnCdrsCfg.Dedup_policy != nil {
+			self.CDRSDedupPolicy = *jsnCdrsCfg.Dedup_policy
+		}
+		if jsnCdrsCfg.Rerate_job_ttl != nil {
+			if self.CDRSRerateJobTTL, err = utils.ParseDurationWithSecs(*jsnCdrsCfg.Rerate_job_ttl); err != nil {
+				return err
+			}
+		}
+		if jsnCdrsCfg.Retention_interval != nil {
+			if self.CDRSRetentionInterval, err = utils.ParseDurationWithSecs(*jsnCdrsCfg.Retention_interval); err != nil {
+				return err
+			}
+		}
+		if jsnCdrsCfg.Retention_batch_size != nil {
+			self.CDRSRetentionBatchSize = *jsnCdrsCfg.Retention_batch_size
+		}
+		if jsnCdrsCfg.Retention_policies != nil {
+			self.CDRSRetentionPolicies = make([]*CdrRetentionPolicy, len(*jsnCdrsCfg.Retention_policies))
+			for idx, jsnPlcy := range *jsnCdrsCfg.Retention_policies {
+				self.CDRSRetentionPolicies[idx] = new(CdrRetentionPolicy)
+				if err = self.CDRSRetentionPolicies[idx].loadFromJsonCfg(jsnPlcy); err != nil {
+					return err
+				}
+			}
+		}
+		if jsnCdrsCfg.Local_tax_run_ids != nil {
+			self.CDRSLocalTaxRunIDs = *jsnCdrsCfg.Local_tax_run_ids
+		}
+		if jsnCdrsCfg.Tax_class_field != nil {
+			if self.CDRSTaxClassField, err = utils.NewRSRField(*jsnCdrsCfg.Tax_class_field); err != nil {
+				return err
+			}
+		}
 	}
 
 	if jsnCdrstatsCfg != nil {
@@ -1116,6 +1224,18 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		}
 	}
 
+	if jsnInvoiceSCfg != nil {
+		if jsnInvoiceSCfg.Run_ids != nil {
+			self.InvoiceSRunIDs = *jsnInvoiceSCfg.Run_ids
+		}
+		if jsnInvoiceSCfg.Group_by != nil {
+			self.InvoiceSGroupBy = *jsnInvoiceSCfg.Group_by
+		}
+		if jsnInvoiceSCfg.Templates_dir != nil {
+			self.InvoiceSTemplatesDir = *jsnInvoiceSCfg.Templates_dir
+		}
+	}
+
 	if jsnSureTaxCfg != nil { // New config for SureTax
 		if self.sureTaxCfg, err = NewSureTaxCfgWithDefaults(); err != nil {
 			return err
@@ -39,6 +39,7 @@ const CGRATES_CFG_JSON = `
 	"default_category": "call",								// default category to consider when missing from requests
 	"default_tenant": "cgrates.org",						// default tenant to consider when missing from requests
 	"default_timezone": "Local",							// default timezone for timestamps where not specified <""|UTC|Local|$IANA_TZ_DB>
+	"default_currency": "",									// currency of the rates and monetary balances not specifying one, empty to not convert them
 	"connect_attempts": 3,									// initial server connect attempts
 	"reconnects": -1,										// number of retries in case of connection lost
 	"connect_timeout": "1s",								// consider connection unsuccessful on timeout, 0 to disable the feature
@@ -67,6 +68,7 @@ const CGRATES_CFG_JSON = `
 	"resource_profiles": {"limit": -1, "ttl": "", "static_ttl": false, "precache": false},		// control resource profiles caching
 	"resources": {"limit": -1, "ttl": "", "static_ttl": false, "precache": false},		// control resources caching
 	"timings": {"limit": -1, "ttl": "", "static_ttl": false, "precache": false},				// timings caching
+	"exchange_rates": {"limit": -1, "ttl": "", "static_ttl": false, "precache": false},		// exchange rates caching, populated on first use
 	"stats_queues": {"limit": -1, "ttl": "5m", "static_ttl": false, "precache": false},			// queues with metrics
 	"stats_event_queues": {"limit": -1, "ttl": "5m", "static_ttl": false, "precache": false},	// matching queues to events
 },
@@ -145,6 +147,16 @@ const CGRATES_CFG_JSON = `
 	"cdrstats_conns": [],					// address where to reach the cdrstats service, empty to disable cdrstats functionality: <""|*internal|x.y.z.y:1234>
 	"stats_conns": [],						// address where to reach the stat service, empty to disable stats functionality: <""|*internal|x.y.z.y:1234>
 	"online_cdr_exports":[],				// list of CDRE profiles to use for real-time CDR exports
+	"dedup_fields": [],						// fields identifying the same CDR received from different sources, empty to disable deduplication, eg: ["OriginHost","Account","Destination","AnswerTime"]
+	"dedup_time_tolerance": "1s",			// maximum difference between SetupTime/AnswerTime values of duplicated CDRs
+	"dedup_window": "1h",					// time window to remember processed CDRs for duplicates matching
+	"dedup_policy": "*drop",				// action taken on duplicates: <*drop|*keep_newest|*merge_extra_fields>, *keep_newest requires store_cdrs
+	"rerate_job_ttl": "1h",					// re-rating jobs not approved within this interval are discarded, 0 to keep them until approval
+	"retention_interval": "0s",				// interval to enforce the retention policies, 0 to disable
+	"retention_batch_size": 1000,			// number of CDRs archived and removed at once
+	"retention_policies": [],				// CDRs retention policies, eg: [{"tenants": [], "run_ids": ["*raw"], "max_age": "720h", "archive_template": "*default", "archive_path": ""}]
+	"local_tax_run_ids": [],				// runs of the CDRs taxed with the local tax rules, empty to disable
+	"tax_class_field": "TaxClass",			// CDR field holding the customer tax class of the local tax rules
 },
 
 
@@ -258,21 +270,31 @@ const CGRATES_CFG_JSON = `
 	"cdrs_conns": [
 		{"address": "*internal"}			// address where to reach CDR Server, empty to disable CDR capturing <*internal|x.y.z.y:1234>
 	],
+	"resources_conns": [],					// address where to reach the ResourceS <""|*internal|127.0.0.1:2013>
+	"stats_conns": [],						// address where to reach the StatS <""|*internal|127.0.0.1:2013>
 	"smg_replication_conns": [],			// replicate sessions towards these SMGs
+	"replication_heartbeat": "0s",			// signal liveness towards smg_replication_conns on this interval, refreshing also the replicated sessions when debit_interval is used, 0 to disable
+	"passive_takeover": "0s",				// activate the passive sessions if no replication or heartbeat was received within this interval, 0 to disable
 	"debit_interval": "0s",					// interval to perform debits on.
 	"min_call_duration": "0s",				// only authorize calls with allowed duration higher than this
 	"max_call_duration": "3h",				// maximum call duration a prepaid call can last
+	"low_credit_usage": "0s",				// warn the client once when the usage left for a session drops below this, 0 to disable
+	"low_credit_balance": 0,				// warn the client once when the monetary balance drops below this, 0 to disable
 	"session_ttl": "0s",					// time after a session with no updates is terminated, not defined by default
 	//"session_ttl_max_delay": "",			// activates session_ttl randomization and limits the maximum possible delay
 	//"session_ttl_last_used": "",			// tweak LastUsed for sessions timing-out, not defined by default
 	//"session_ttl_usage": "",				// tweak Usage for sessions timing-out, not defined by default
 	"session_indexes": [],					// index sessions based on these fields for GetActiveSessions API
+	"sessions_checkpoint_interval": "0s",	// save active sessions into data_db on this interval so they survive restarts, 0 to disable
+	"reservation_ttl": "5m",				// time after an event reservation which is not committed is cancelled
 },
 
 
 "sm_asterisk": {
 	"enabled": false,						// starts Asterisk SessionManager service: <true|false>
 	"create_cdr": false,					// create CDR out of events and sends it to CDRS component
+	"low_balance_prompt": "",				// media played to the caller when low balance is reached, eg: sound:low-balance
+	"disconnect_prompt": "",				// media played to the caller before disconnecting from CGRateS side, eg: sound:no-credit
 	"asterisk_conns":[						// instantiate connections to multiple Asterisk servers
 		{"address": "127.0.0.1:8088", "user": "cgrates", "password": "CGRateS.org", "connect_attempts": 3,"reconnects": 5}
 	],
@@ -319,6 +341,8 @@ const CGRATES_CFG_JSON = `
 	"debit_interval": "10s",				// interval to perform debits on.
 	"min_call_duration": "0s",				// only authorize calls with allowed duration higher than this
 	"max_call_duration": "3h",				// maximum call duration a prepaid call can last
+	"channel_sync_interval": "0s",			// sync sessions with the dialogs of kamailio regularly, 0 to disable
+	"compute_lcr": false,					// return LCR suppliers in every auth reply, not only when requested by cgr_computelcr
 	"evapi_conns":[							// instantiate connections to multiple Kamailio servers
 		{"address": "127.0.0.1:8448", "reconnects": 5}
 	],
@@ -328,6 +352,7 @@ const CGRATES_CFG_JSON = `
 "sm_opensips": {
 	"enabled": false,						// starts SessionManager service: <true|false>
 	"listen_udp": "127.0.0.1:2020",			// address where to listen for datagram events coming from OpenSIPS
+	"listen_jsonrpc": "",					// address where to listen for event_jsonrpc events, replaces the datagram interface if defined <""|127.0.0.1:2021>
 	"rals_conns": [
 		{"address": "*internal"}			// address where to reach the Rater <""|*internal|127.0.0.1:2013>
 	],
@@ -340,6 +365,7 @@ const CGRATES_CFG_JSON = `
 	"max_call_duration": "3h",				// maximum call duration a prepaid call can last
 	"events_subscribe_interval": "60s",		// automatic events subscription to OpenSIPS, 0 to disable it
 	"mi_addr": "127.0.0.1:8020",			// address where to reach OpenSIPS MI to send session disconnects
+	"mi_json_url": "http://127.0.0.1:8888/json",	// URL of OpenSIPS mi_json, replacing mi_addr when listen_jsonrpc is defined
 },
 
 
@@ -429,6 +455,13 @@ const CGRATES_CFG_JSON = `
 },
 
 
+"invoices": {							// used by the ApierV1 invoice APIs, InvoiceS runs no service of its own
+	"run_ids": ["*default"],				// CDR runs billed on invoices
+	"group_by": ["Category", "Destination"],	// CDR fields the usage lines are grouped on, ExtraFields supported
+	"templates_dir": "",					// folder with additional render templates, the file name being the template ID
+},
+
+
 "suretax": {
 	"url": "",								// API url
 	"client_number": "",					// client number, provided by SureTax
@@ -57,6 +57,7 @@ const (
 	USERSERV_JSN    = "users"
 	RESOURCES_JSON  = "resources"
 	MAILER_JSN      = "mailer"
+	INVOICES_JSN    = "invoices"
 	SURETAX_JSON    = "suretax"
 )
 
@@ -383,6 +384,18 @@ func (self CgrJsonCfg) MailerJsonCfg() (*MailerJsonCfg, error) {
 	return cfg, nil
 }
 
+func (self CgrJsonCfg) InvoiceSJsonCfg() (*InvoiceSJsonCfg, error) {
+	rawCfg, hasKey := self[INVOICES_JSN]
+	if !hasKey {
+		return nil, nil
+	}
+	cfg := new(InvoiceSJsonCfg)
+	if err := json.Unmarshal(*rawCfg, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func (self CgrJsonCfg) SureTaxJsonCfg() (*SureTaxJsonCfg, error) {
 	rawCfg, hasKey := self[SURETAX_JSON]
 	if !hasKey {
@@ -50,6 +50,7 @@ func TestDfGeneralJsonCfg(t *testing.T) {
 		Default_category:     utils.StringPointer("call"),
 		Default_tenant:       utils.StringPointer("cgrates.org"),
 		Default_timezone:     utils.StringPointer("Local"),
+		Default_currency:     utils.StringPointer(""),
 		Connect_attempts:     utils.IntPointer(3),
 		Reconnects:           utils.IntPointer(-1),
 		Connect_timeout:      utils.StringPointer("1s"),
@@ -118,6 +119,9 @@ func TestCacheJsonCfg(t *testing.T) {
 		utils.CacheTimings: &CacheParamJsonCfg{Limit: utils.IntPointer(-1),
 			Ttl: utils.StringPointer(""), Static_ttl: utils.BoolPointer(false),
 			Precache: utils.BoolPointer(false)},
+		utils.CacheExchangeRates: &CacheParamJsonCfg{Limit: utils.IntPointer(-1),
+			Ttl: utils.StringPointer(""), Static_ttl: utils.BoolPointer(false),
+			Precache: utils.BoolPointer(false)},
 		utils.CacheStatSQueues: &CacheParamJsonCfg{Limit: utils.IntPointer(-1),
 			Ttl: utils.StringPointer("5m"), Static_ttl: utils.BoolPointer(false),
 			Precache: utils.BoolPointer(false)},
@@ -214,12 +218,22 @@ func TestDfCdrsJsonCfg(t *testing.T) {
 			&HaPoolJsonCfg{
 				Address: utils.StringPointer("*internal"),
 			}},
-		Pubsubs_conns:      &[]*HaPoolJsonCfg{},
-		Users_conns:        &[]*HaPoolJsonCfg{},
-		Aliases_conns:      &[]*HaPoolJsonCfg{},
-		Cdrstats_conns:     &[]*HaPoolJsonCfg{},
-		Stats_conns:        &[]*HaPoolJsonCfg{},
-		Online_cdr_exports: &[]string{},
+		Pubsubs_conns:        &[]*HaPoolJsonCfg{},
+		Users_conns:          &[]*HaPoolJsonCfg{},
+		Aliases_conns:        &[]*HaPoolJsonCfg{},
+		Cdrstats_conns:       &[]*HaPoolJsonCfg{},
+		Stats_conns:          &[]*HaPoolJsonCfg{},
+		Online_cdr_exports:   &[]string{},
+		Dedup_fields:         &[]string{},
+		Dedup_time_tolerance: utils.StringPointer("1s"),
+		Dedup_window:         utils.StringPointer("1h"),
+		Dedup_policy:         utils.StringPointer(utils.MetaDrop),
+		Rerate_job_ttl:       utils.StringPointer("1h"),
+		Retention_interval:   utils.StringPointer("0s"),
+		Retention_batch_size: utils.IntPointer(1000),
+		Retention_policies:   &[]*CdrRetentionPolicyJsonCfg{},
+		Local_tax_run_ids:    &[]string{},
+		Tax_class_field:      utils.StringPointer(utils.TaxClass),
 	}
 	if cfg, err := dfCgrJsonCfg.CdrsJsonCfg(); err != nil {
 		t.Error(err)
@@ -441,12 +455,20 @@ func TestSmGenericJsonCfg(t *testing.T) {
 			&HaPoolJsonCfg{
 				Address: utils.StringPointer(utils.MetaInternal),
 			}},
-		Smg_replication_conns: &[]*HaPoolJsonCfg{},
-		Debit_interval:        utils.StringPointer("0s"),
-		Min_call_duration:     utils.StringPointer("0s"),
-		Max_call_duration:     utils.StringPointer("3h"),
-		Session_ttl:           utils.StringPointer("0s"),
-		Session_indexes:       utils.StringSlicePointer([]string{}),
+		Resources_conns:              &[]*HaPoolJsonCfg{},
+		Stats_conns:                  &[]*HaPoolJsonCfg{},
+		Smg_replication_conns:        &[]*HaPoolJsonCfg{},
+		Replication_heartbeat:        utils.StringPointer("0s"),
+		Passive_takeover:             utils.StringPointer("0s"),
+		Debit_interval:               utils.StringPointer("0s"),
+		Min_call_duration:            utils.StringPointer("0s"),
+		Max_call_duration:            utils.StringPointer("3h"),
+		Low_credit_usage:             utils.StringPointer("0s"),
+		Low_credit_balance:           utils.Float64Pointer(0),
+		Session_ttl:                  utils.StringPointer("0s"),
+		Session_indexes:              utils.StringSlicePointer([]string{}),
+		Sessions_checkpoint_interval: utils.StringPointer("0s"),
+		Reservation_ttl:              utils.StringPointer("5m"),
 	}
 	if cfg, err := dfCgrJsonCfg.SmGenericJsonCfg(); err != nil {
 		t.Error(err)
@@ -504,11 +526,13 @@ func TestSmKamJsonCfg(t *testing.T) {
 			&HaPoolJsonCfg{
 				Address: utils.StringPointer(utils.MetaInternal),
 			}},
-		Resources_conns:   &[]*HaPoolJsonCfg{},
-		Create_cdr:        utils.BoolPointer(false),
-		Debit_interval:    utils.StringPointer("10s"),
-		Min_call_duration: utils.StringPointer("0s"),
-		Max_call_duration: utils.StringPointer("3h"),
+		Resources_conns:       &[]*HaPoolJsonCfg{},
+		Create_cdr:            utils.BoolPointer(false),
+		Debit_interval:        utils.StringPointer("10s"),
+		Min_call_duration:     utils.StringPointer("0s"),
+		Max_call_duration:     utils.StringPointer("3h"),
+		Channel_sync_interval: utils.StringPointer("0s"),
+		Compute_lcr:           utils.BoolPointer(false),
 		Evapi_conns: &[]*KamConnJsonCfg{
 			&KamConnJsonCfg{
 				Address:    utils.StringPointer("127.0.0.1:8448"),
@@ -525,8 +549,9 @@ func TestSmKamJsonCfg(t *testing.T) {
 
 func TestSmOsipsJsonCfg(t *testing.T) {
 	eCfg := &SmOsipsJsonCfg{
-		Enabled:    utils.BoolPointer(false),
-		Listen_udp: utils.StringPointer("127.0.0.1:2020"),
+		Enabled:        utils.BoolPointer(false),
+		Listen_udp:     utils.StringPointer("127.0.0.1:2020"),
+		Listen_jsonrpc: utils.StringPointer(""),
 		Rals_conns: &[]*HaPoolJsonCfg{
 			&HaPoolJsonCfg{
 				Address: utils.StringPointer(utils.MetaInternal),
@@ -541,6 +566,7 @@ func TestSmOsipsJsonCfg(t *testing.T) {
 		Max_call_duration:         utils.StringPointer("3h"),
 		Events_subscribe_interval: utils.StringPointer("60s"),
 		Mi_addr:                   utils.StringPointer("127.0.0.1:8020"),
+		Mi_json_url:               utils.StringPointer("http://127.0.0.1:8888/json"),
 	}
 	if cfg, err := dfCgrJsonCfg.SmOsipsJsonCfg(); err != nil {
 		t.Error(err)
@@ -551,8 +577,10 @@ func TestSmOsipsJsonCfg(t *testing.T) {
 
 func TestSmAsteriskJsonCfg(t *testing.T) {
 	eCfg := &SMAsteriskJsonCfg{
-		Enabled:    utils.BoolPointer(false),
-		Create_cdr: utils.BoolPointer(false),
+		Enabled:            utils.BoolPointer(false),
+		Create_cdr:         utils.BoolPointer(false),
+		Low_balance_prompt: utils.StringPointer(""),
+		Disconnect_prompt:  utils.StringPointer(""),
 		Asterisk_conns: &[]*AstConnJsonCfg{
 			&AstConnJsonCfg{
 				Address:          utils.StringPointer("127.0.0.1:8088"),
@@ -717,6 +745,19 @@ func TestDfMailerJsonCfg(t *testing.T) {
 	}
 }
 
+func TestDfInvoiceSJsonCfg(t *testing.T) {
+	eCfg := &InvoiceSJsonCfg{
+		Run_ids:       &[]string{utils.META_DEFAULT},
+		Group_by:      &[]string{utils.CATEGORY, utils.DESTINATION},
+		Templates_dir: utils.StringPointer(""),
+	}
+	if cfg, err := dfCgrJsonCfg.InvoiceSJsonCfg(); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eCfg, cfg) {
+		t.Error("Received: ", cfg)
+	}
+}
+
 func TestDfSureTaxJsonCfg(t *testing.T) {
 	eCfg := &SureTaxJsonCfg{
 		Url:                     utils.StringPointer(""),
@@ -172,6 +172,9 @@ func TestCgrCfgJSONDefaultsGeneral(t *testing.T) {
 	if cgrCfg.DefaultTimezone != "Local" {
 		t.Error(cgrCfg.DefaultTimezone)
 	}
+	if cgrCfg.DefaultCurrency != "" {
+		t.Error(cgrCfg.DefaultCurrency)
+	}
 	if cgrCfg.ConnectAttempts != 3 {
 		t.Error(cgrCfg.ConnectAttempts)
 	}
@@ -337,6 +340,36 @@ func TestCgrCfgJSONDefaultsCDRS(t *testing.T) {
 	if cgrCfg.CDRSOnlineCDRExports != nil {
 		t.Error(cgrCfg.CDRSOnlineCDRExports)
 	}
+	if cgrCfg.CDRSDedupFields != nil {
+		t.Error(cgrCfg.CDRSDedupFields)
+	}
+	if cgrCfg.CDRSDedupTimeTolerance != time.Duration(1*time.Second) {
+		t.Error(cgrCfg.CDRSDedupTimeTolerance)
+	}
+	if cgrCfg.CDRSDedupWindow != time.Duration(1*time.Hour) {
+		t.Error(cgrCfg.CDRSDedupWindow)
+	}
+	if cgrCfg.CDRSDedupPolicy != utils.MetaDrop {
+		t.Error(cgrCfg.CDRSDedupPolicy)
+	}
+	if cgrCfg.CDRSRerateJobTTL != time.Duration(1*time.Hour) {
+		t.Error(cgrCfg.CDRSRerateJobTTL)
+	}
+	if cgrCfg.CDRSRetentionInterval != 0 {
+		t.Error(cgrCfg.CDRSRetentionInterval)
+	}
+	if cgrCfg.CDRSRetentionBatchSize != 1000 {
+		t.Error(cgrCfg.CDRSRetentionBatchSize)
+	}
+	if len(cgrCfg.CDRSRetentionPolicies) != 0 {
+		t.Error(cgrCfg.CDRSRetentionPolicies)
+	}
+	if len(cgrCfg.CDRSLocalTaxRunIDs) != 0 {
+		t.Error(cgrCfg.CDRSLocalTaxRunIDs)
+	}
+	if cgrCfg.CDRSTaxClassField == nil || cgrCfg.CDRSTaxClassField.Id != utils.TaxClass {
+		t.Error(cgrCfg.CDRSTaxClassField)
+	}
 }
 
 func TestCgrCfgJSONDefaultsCDRStats(t *testing.T) {
@@ -388,16 +421,24 @@ func TestCgrCfgJSONDefaultsCdreProfiles(t *testing.T) {
 
 func TestCgrCfgJSONDefaultsSMGenericCfg(t *testing.T) {
 	eSmGeCfg := &SmGenericConfig{
-		Enabled:             false,
-		ListenBijson:        "127.0.0.1:2014",
-		RALsConns:           []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
-		CDRsConns:           []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
-		SMGReplicationConns: []*HaPoolConfig{},
-		DebitInterval:       0 * time.Second,
-		MinCallDuration:     0 * time.Second,
-		MaxCallDuration:     3 * time.Hour,
-		SessionTTL:          0 * time.Second,
-		SessionIndexes:      utils.StringMap{},
+		Enabled:                    false,
+		ListenBijson:               "127.0.0.1:2014",
+		RALsConns:                  []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
+		CDRsConns:                  []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
+		RLsConns:                   []*HaPoolConfig{},
+		StatSConns:                 []*HaPoolConfig{},
+		SMGReplicationConns:        []*HaPoolConfig{},
+		ReplicationHeartbeat:       0,
+		PassiveTakeover:            0,
+		DebitInterval:              0 * time.Second,
+		MinCallDuration:            0 * time.Second,
+		MaxCallDuration:            3 * time.Hour,
+		LowCreditUsage:             0 * time.Second,
+		LowCreditBalance:           0,
+		SessionTTL:                 0 * time.Second,
+		SessionIndexes:             utils.StringMap{},
+		SessionsCheckpointInterval: 0 * time.Second,
+		ReservationTTL:             5 * time.Minute,
 	}
 
 	if !reflect.DeepEqual(cgrCfg.SmGenericConfig, eSmGeCfg) {
@@ -441,6 +482,8 @@ func TestCgrCfgJSONDefaultsCacheCFG(t *testing.T) {
 			TTL: time.Duration(0), StaticTTL: false, Precache: false},
 		utils.CacheTimings: &CacheParamConfig{Limit: -1,
 			TTL: time.Duration(0), StaticTTL: false, Precache: false},
+		utils.CacheExchangeRates: &CacheParamConfig{Limit: -1,
+			TTL: time.Duration(0), StaticTTL: false, Precache: false},
 		utils.CacheStatSQueues: &CacheParamConfig{Limit: -1,
 			TTL: time.Duration(5 * time.Minute), StaticTTL: false, Precache: false},
 		utils.CacheStatSEventQueues: &CacheParamConfig{Limit: -1,
@@ -479,15 +522,17 @@ func TestCgrCfgJSONDefaultsSMFsConfig(t *testing.T) {
 
 func TestCgrCfgJSONDefaultsSMKamConfig(t *testing.T) {
 	eSmKaCfg := &SmKamConfig{
-		Enabled:         false,
-		RALsConns:       []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
-		CDRsConns:       []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
-		RLsConns:        []*HaPoolConfig{},
-		CreateCdr:       false,
-		DebitInterval:   10 * time.Second,
-		MinCallDuration: 0 * time.Second,
-		MaxCallDuration: 3 * time.Hour,
-		EvapiConns:      []*KamConnConfig{&KamConnConfig{Address: "127.0.0.1:8448", Reconnects: 5}},
+		Enabled:             false,
+		RALsConns:           []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
+		CDRsConns:           []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
+		RLsConns:            []*HaPoolConfig{},
+		CreateCdr:           false,
+		DebitInterval:       10 * time.Second,
+		MinCallDuration:     0 * time.Second,
+		MaxCallDuration:     3 * time.Hour,
+		ChannelSyncInterval: 0 * time.Second,
+		ComputeLcr:          false,
+		EvapiConns:          []*KamConnConfig{&KamConnConfig{Address: "127.0.0.1:8448", Reconnects: 5}},
 	}
 	if !reflect.DeepEqual(cgrCfg.SmKamConfig, eSmKaCfg) {
 		t.Errorf("received: %+v, expecting: %+v", cgrCfg.SmKamConfig, eSmKaCfg)
@@ -498,6 +543,7 @@ func TestCgrCfgJSONDefaultsSMOsipsConfig(t *testing.T) {
 	eSmOpCfg := &SmOsipsConfig{
 		Enabled:                 false,
 		ListenUdp:               "127.0.0.1:2020",
+		ListenJsonRPC:           "",
 		RALsConns:               []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
 		CDRsConns:               []*HaPoolConfig{&HaPoolConfig{Address: "*internal"}},
 		CreateCdr:               false,
@@ -506,6 +552,7 @@ func TestCgrCfgJSONDefaultsSMOsipsConfig(t *testing.T) {
 		MaxCallDuration:         3 * time.Hour,
 		EventsSubscribeInterval: 60 * time.Second,
 		MiAddr:                  "127.0.0.1:8020",
+		MiJsonURL:               "http://127.0.0.1:8888/json",
 	}
 
 	if !reflect.DeepEqual(cgrCfg.SmOsipsConfig, eSmOpCfg) {
@@ -658,6 +705,18 @@ func TestCgrCfgJSONDefaultsMailer(t *testing.T) {
 	}
 }
 
+func TestCgrCfgJSONDefaultsInvoiceS(t *testing.T) {
+	if !reflect.DeepEqual(cgrCfg.InvoiceSRunIDs, []string{utils.META_DEFAULT}) {
+		t.Error(cgrCfg.InvoiceSRunIDs)
+	}
+	if !reflect.DeepEqual(cgrCfg.InvoiceSGroupBy, []string{utils.CATEGORY, utils.DESTINATION}) {
+		t.Error(cgrCfg.InvoiceSGroupBy)
+	}
+	if cgrCfg.InvoiceSTemplatesDir != "" {
+		t.Error(cgrCfg.InvoiceSTemplatesDir)
+	}
+}
+
 func TestCgrCfgJSONDefaultsSureTax(t *testing.T) {
 	localt, err := time.LoadLocation("Local")
 	if err != nil {
@@ -759,3 +818,61 @@ func TestRadiusAgentCfg(t *testing.T) {
 		t.Errorf("received: %+v, expecting: %+v", cgrCfg.radiusAgentCfg.RequestProcessors, testRA.RequestProcessors)
 	}
 }
+
+func TestCgrCfgCdrsRetentionPolicies(t *testing.T) {
+	JSN_CFG := `
+{
+"cdrs": {
+	"retention_interval": "1h",
+	"retention_policies": [
+		{"run_ids": ["*raw"], "max_age": "720h"},
+		{"tenants": ["cgrates.org"], "max_age": "9504h", "archive_template": "*default", "archive_path": "/tmp/archive"},
+	],
+},
+}`
+	eCfgs := []*CdrRetentionPolicy{
+		&CdrRetentionPolicy{RunIDs: []string{utils.MetaRaw}, MaxAge: time.Duration(720 * time.Hour)},
+		&CdrRetentionPolicy{Tenants: []string{"cgrates.org"}, MaxAge: time.Duration(9504 * time.Hour),
+			ArchiveTemplate: utils.META_DEFAULT, ArchivePath: "/tmp/archive"},
+	}
+	if cgrCfg, err := NewCGRConfigFromJsonStringWithDefaults(JSN_CFG); err != nil {
+		t.Error(err)
+	} else if cgrCfg.CDRSRetentionInterval != time.Duration(time.Hour) {
+		t.Errorf("Unexpected retention interval: %v", cgrCfg.CDRSRetentionInterval)
+	} else if !reflect.DeepEqual(eCfgs, cgrCfg.CDRSRetentionPolicies) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eCfgs), utils.ToJSON(cgrCfg.CDRSRetentionPolicies))
+	}
+	JSN_CFG = `
+{
+"rals": {"enabled": true},
+"cdrs": {
+	"enabled": true,
+	"retention_policies": [
+		{"max_age": "720h", "archive_template": "not_existing"},
+	],
+},
+}`
+	if cgrCfg, err := NewCGRConfigFromJsonStringWithDefaults(JSN_CFG); err != nil {
+		t.Error(err)
+	} else if err := cgrCfg.checkConfigSanity(); err == nil {
+		t.Error("Expecting error for missing archive template")
+	}
+}
+
+func TestCgrCfgCdrsDedupKeepNewestStore(t *testing.T) {
+	JSN_CFG := `
+{
+"rals": {"enabled": true},
+"cdrs": {
+	"enabled": true,
+	"store_cdrs": false,
+	"dedup_fields": ["OriginHost", "Account", "Destination", "AnswerTime"],
+	"dedup_policy": "*keep_newest",
+},
+}`
+	if cgrCfg, err := NewCGRConfigFromJsonStringWithDefaults(JSN_CFG); err != nil {
+		t.Error(err)
+	} else if err := cgrCfg.checkConfigSanity(); err == nil {
+		t.Error("Expecting error for *keep_newest without stored CDRs")
+	}
+}
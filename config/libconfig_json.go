@@ -31,6 +31,7 @@ type GeneralJsonCfg struct {
 	Default_category     *string
 	Default_tenant       *string
 	Default_timezone     *string
+	Default_currency     *string
 	Connect_attempts     *int
 	Reconnects           *int
 	Connect_timeout      *string
@@ -90,18 +91,37 @@ type SchedulerJsonCfg struct {
 
 // Cdrs config section
 type CdrsJsonCfg struct {
-	Enabled             *bool
-	Extra_fields        *[]string
-	Store_cdrs          *bool
-	Cdr_account_summary *bool
-	Sm_cost_retries     *int
-	Rals_conns          *[]*HaPoolJsonCfg
-	Pubsubs_conns       *[]*HaPoolJsonCfg
-	Users_conns         *[]*HaPoolJsonCfg
-	Aliases_conns       *[]*HaPoolJsonCfg
-	Cdrstats_conns      *[]*HaPoolJsonCfg
-	Stats_conns         *[]*HaPoolJsonCfg
-	Online_cdr_exports  *[]string
+	Enabled              *bool
+	Extra_fields         *[]string
+	Store_cdrs           *bool
+	Cdr_account_summary  *bool
+	Sm_cost_retries      *int
+	Rals_conns           *[]*HaPoolJsonCfg
+	Pubsubs_conns        *[]*HaPoolJsonCfg
+	Users_conns          *[]*HaPoolJsonCfg
+	Aliases_conns        *[]*HaPoolJsonCfg
+	Cdrstats_conns       *[]*HaPoolJsonCfg
+	Stats_conns          *[]*HaPoolJsonCfg
+	Online_cdr_exports   *[]string
+	Dedup_fields         *[]string
+	Dedup_time_tolerance *string
+	Dedup_window         *string
+	Dedup_policy         *string
+	Rerate_job_ttl       *string
+	Retention_interval   *string
+	Retention_batch_size *int
+	Retention_policies   *[]*CdrRetentionPolicyJsonCfg
+	Local_tax_run_ids    *[]string
+	Tax_class_field      *string
+}
+
+// One CDR retention policy
+type CdrRetentionPolicyJsonCfg struct {
+	Tenants          *[]string
+	Run_ids          *[]string
+	Max_age          *string
+	Archive_template *string
+	Archive_path     *string
 }
 
 type CdrReplicationJsonCfg struct {
@@ -185,19 +205,27 @@ type CdrcJsonCfg struct {
 
 // SM-Generic config section
 type SmGenericJsonCfg struct {
-	Enabled               *bool
-	Listen_bijson         *string
-	Rals_conns            *[]*HaPoolJsonCfg
-	Cdrs_conns            *[]*HaPoolJsonCfg
-	Smg_replication_conns *[]*HaPoolJsonCfg
-	Debit_interval        *string
-	Min_call_duration     *string
-	Max_call_duration     *string
-	Session_ttl           *string
-	Session_ttl_max_delay *string
-	Session_ttl_last_used *string
-	Session_ttl_usage     *string
-	Session_indexes       *[]string
+	Enabled                      *bool
+	Listen_bijson                *string
+	Rals_conns                   *[]*HaPoolJsonCfg
+	Cdrs_conns                   *[]*HaPoolJsonCfg
+	Resources_conns              *[]*HaPoolJsonCfg
+	Stats_conns                  *[]*HaPoolJsonCfg
+	Smg_replication_conns        *[]*HaPoolJsonCfg
+	Replication_heartbeat        *string
+	Passive_takeover             *string
+	Debit_interval               *string
+	Min_call_duration            *string
+	Max_call_duration            *string
+	Low_credit_usage             *string
+	Low_credit_balance           *float64
+	Session_ttl                  *string
+	Session_ttl_max_delay        *string
+	Session_ttl_last_used        *string
+	Session_ttl_usage            *string
+	Session_indexes              *[]string
+	Sessions_checkpoint_interval *string
+	Reservation_ttl              *string
 }
 
 // SM-FreeSWITCH config section
@@ -237,10 +265,12 @@ type AstConnJsonCfg struct {
 }
 
 type SMAsteriskJsonCfg struct {
-	Enabled          *bool
-	Sm_generic_conns *[]*HaPoolJsonCfg // Connections towards generic SMf
-	Create_cdr       *bool
-	Asterisk_conns   *[]*AstConnJsonCfg
+	Enabled            *bool
+	Sm_generic_conns   *[]*HaPoolJsonCfg // Connections towards generic SMf
+	Create_cdr         *bool
+	Low_balance_prompt *string
+	Disconnect_prompt  *string
+	Asterisk_conns     *[]*AstConnJsonCfg
 }
 
 type CacheParamJsonCfg struct {
@@ -261,15 +291,17 @@ type FsConnJsonCfg struct {
 
 // SM-Kamailio config section
 type SmKamJsonCfg struct {
-	Enabled           *bool
-	Rals_conns        *[]*HaPoolJsonCfg
-	Cdrs_conns        *[]*HaPoolJsonCfg
-	Resources_conns   *[]*HaPoolJsonCfg
-	Create_cdr        *bool
-	Debit_interval    *string
-	Min_call_duration *string
-	Max_call_duration *string
-	Evapi_conns       *[]*KamConnJsonCfg
+	Enabled               *bool
+	Rals_conns            *[]*HaPoolJsonCfg
+	Cdrs_conns            *[]*HaPoolJsonCfg
+	Resources_conns       *[]*HaPoolJsonCfg
+	Create_cdr            *bool
+	Debit_interval        *string
+	Min_call_duration     *string
+	Max_call_duration     *string
+	Channel_sync_interval *string
+	Compute_lcr           *bool
+	Evapi_conns           *[]*KamConnJsonCfg
 }
 
 // Represents one connection instance towards Kamailio
@@ -282,6 +314,7 @@ type KamConnJsonCfg struct {
 type SmOsipsJsonCfg struct {
 	Enabled                   *bool
 	Listen_udp                *string
+	Listen_jsonrpc            *string
 	Rals_conns                *[]*HaPoolJsonCfg
 	Cdrs_conns                *[]*HaPoolJsonCfg
 	Create_cdr                *bool
@@ -290,6 +323,7 @@ type SmOsipsJsonCfg struct {
 	Max_call_duration         *string
 	Events_subscribe_interval *string
 	Mi_addr                   *string
+	Mi_json_url               *string
 }
 
 // Represents one connection instance towards OpenSIPS
@@ -400,6 +434,13 @@ type MailerJsonCfg struct {
 	From_address  *string
 }
 
+// InvoiceS config section
+type InvoiceSJsonCfg struct {
+	Run_ids       *[]string
+	Group_by      *[]string
+	Templates_dir *string
+}
+
 // SureTax config section
 type SureTaxJsonCfg struct {
 	Url                     *string
@@ -88,19 +88,27 @@ func (self *FsConnConfig) loadFromJsonCfg(jsnCfg *FsConnJsonCfg) error {
 }
 
 type SmGenericConfig struct {
-	Enabled             bool
-	ListenBijson        string
-	RALsConns           []*HaPoolConfig
-	CDRsConns           []*HaPoolConfig
-	SMGReplicationConns []*HaPoolConfig
-	DebitInterval       time.Duration
-	MinCallDuration     time.Duration
-	MaxCallDuration     time.Duration
-	SessionTTL          time.Duration
-	SessionTTLMaxDelay  *time.Duration
-	SessionTTLLastUsed  *time.Duration
-	SessionTTLUsage     *time.Duration
-	SessionIndexes      utils.StringMap
+	Enabled                    bool
+	ListenBijson               string
+	RALsConns                  []*HaPoolConfig
+	CDRsConns                  []*HaPoolConfig
+	RLsConns                   []*HaPoolConfig
+	StatSConns                 []*HaPoolConfig
+	SMGReplicationConns        []*HaPoolConfig
+	ReplicationHeartbeat       time.Duration
+	PassiveTakeover            time.Duration
+	DebitInterval              time.Duration
+	MinCallDuration            time.Duration
+	MaxCallDuration            time.Duration
+	LowCreditUsage             time.Duration
+	LowCreditBalance           float64
+	SessionTTL                 time.Duration
+	SessionTTLMaxDelay         *time.Duration
+	SessionTTLLastUsed         *time.Duration
+	SessionTTLUsage            *time.Duration
+	SessionIndexes             utils.StringMap
+	SessionsCheckpointInterval time.Duration
+	ReservationTTL             time.Duration
 }
 
 func (self *SmGenericConfig) loadFromJsonCfg(jsnCfg *SmGenericJsonCfg) error {
@@ -128,6 +136,20 @@ func (self *SmGenericConfig) loadFromJsonCfg(jsnCfg *SmGenericJsonCfg) error {
 			self.CDRsConns[idx].loadFromJsonCfg(jsnHaCfg)
 		}
 	}
+	if jsnCfg.Resources_conns != nil {
+		self.RLsConns = make([]*HaPoolConfig, len(*jsnCfg.Resources_conns))
+		for idx, jsnHaCfg := range *jsnCfg.Resources_conns {
+			self.RLsConns[idx] = NewDfltHaPoolConfig()
+			self.RLsConns[idx].loadFromJsonCfg(jsnHaCfg)
+		}
+	}
+	if jsnCfg.Stats_conns != nil {
+		self.StatSConns = make([]*HaPoolConfig, len(*jsnCfg.Stats_conns))
+		for idx, jsnHaCfg := range *jsnCfg.Stats_conns {
+			self.StatSConns[idx] = NewDfltHaPoolConfig()
+			self.StatSConns[idx].loadFromJsonCfg(jsnHaCfg)
+		}
+	}
 	if jsnCfg.Smg_replication_conns != nil {
 		self.SMGReplicationConns = make([]*HaPoolConfig, len(*jsnCfg.Smg_replication_conns))
 		for idx, jsnHaCfg := range *jsnCfg.Smg_replication_conns {
@@ -135,6 +157,16 @@ func (self *SmGenericConfig) loadFromJsonCfg(jsnCfg *SmGenericJsonCfg) error {
 			self.SMGReplicationConns[idx].loadFromJsonCfg(jsnHaCfg)
 		}
 	}
+	if jsnCfg.Replication_heartbeat != nil {
+		if self.ReplicationHeartbeat, err = utils.ParseDurationWithSecs(*jsnCfg.Replication_heartbeat); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.Passive_takeover != nil {
+		if self.PassiveTakeover, err = utils.ParseDurationWithSecs(*jsnCfg.Passive_takeover); err != nil {
+			return err
+		}
+	}
 	if jsnCfg.Debit_interval != nil {
 		if self.DebitInterval, err = utils.ParseDurationWithSecs(*jsnCfg.Debit_interval); err != nil {
 			return err
@@ -150,6 +182,14 @@ func (self *SmGenericConfig) loadFromJsonCfg(jsnCfg *SmGenericJsonCfg) error {
 			return err
 		}
 	}
+	if jsnCfg.Low_credit_usage != nil {
+		if self.LowCreditUsage, err = utils.ParseDurationWithSecs(*jsnCfg.Low_credit_usage); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.Low_credit_balance != nil {
+		self.LowCreditBalance = *jsnCfg.Low_credit_balance
+	}
 	if jsnCfg.Session_ttl != nil {
 		if self.SessionTTL, err = utils.ParseDurationWithSecs(*jsnCfg.Session_ttl); err != nil {
 			return err
@@ -172,6 +212,16 @@ func (self *SmGenericConfig) loadFromJsonCfg(jsnCfg *SmGenericJsonCfg) error {
 	if jsnCfg.Session_indexes != nil {
 		self.SessionIndexes = utils.StringMapFromSlice(*jsnCfg.Session_indexes)
 	}
+	if jsnCfg.Sessions_checkpoint_interval != nil {
+		if self.SessionsCheckpointInterval, err = utils.ParseDurationWithSecs(*jsnCfg.Sessions_checkpoint_interval); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.Reservation_ttl != nil {
+		if self.ReservationTTL, err = utils.ParseDurationWithSecs(*jsnCfg.Reservation_ttl); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -314,15 +364,17 @@ func (self *KamConnConfig) loadFromJsonCfg(jsnCfg *KamConnJsonCfg) error {
 
 // SM-Kamailio config section
 type SmKamConfig struct {
-	Enabled         bool
-	RALsConns       []*HaPoolConfig
-	CDRsConns       []*HaPoolConfig
-	RLsConns        []*HaPoolConfig
-	CreateCdr       bool
-	DebitInterval   time.Duration
-	MinCallDuration time.Duration
-	MaxCallDuration time.Duration
-	EvapiConns      []*KamConnConfig
+	Enabled             bool
+	RALsConns           []*HaPoolConfig
+	CDRsConns           []*HaPoolConfig
+	RLsConns            []*HaPoolConfig
+	CreateCdr           bool
+	DebitInterval       time.Duration
+	MinCallDuration     time.Duration
+	MaxCallDuration     time.Duration
+	ChannelSyncInterval time.Duration
+	ComputeLcr          bool
+	EvapiConns          []*KamConnConfig
 }
 
 func (self *SmKamConfig) loadFromJsonCfg(jsnCfg *SmKamJsonCfg) error {
@@ -372,6 +424,14 @@ func (self *SmKamConfig) loadFromJsonCfg(jsnCfg *SmKamJsonCfg) error {
 			return err
 		}
 	}
+	if jsnCfg.Channel_sync_interval != nil {
+		if self.ChannelSyncInterval, err = utils.ParseDurationWithSecs(*jsnCfg.Channel_sync_interval); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.Compute_lcr != nil {
+		self.ComputeLcr = *jsnCfg.Compute_lcr
+	}
 	if jsnCfg.Evapi_conns != nil {
 		self.EvapiConns = make([]*KamConnConfig, len(*jsnCfg.Evapi_conns))
 		for idx, jsnConnCfg := range *jsnCfg.Evapi_conns {
@@ -402,6 +462,7 @@ func (self *OsipsConnConfig) loadFromJsonCfg(jsnCfg *OsipsConnJsonCfg) error {
 type SmOsipsConfig struct {
 	Enabled                 bool
 	ListenUdp               string
+	ListenJsonRPC           string // event_jsonrpc interface, used instead of datagrams when defined
 	RALsConns               []*HaPoolConfig
 	CDRsConns               []*HaPoolConfig
 	CreateCdr               bool
@@ -410,6 +471,7 @@ type SmOsipsConfig struct {
 	MaxCallDuration         time.Duration
 	EventsSubscribeInterval time.Duration
 	MiAddr                  string
+	MiJsonURL               string
 }
 
 func (self *SmOsipsConfig) loadFromJsonCfg(jsnCfg *SmOsipsJsonCfg) error {
@@ -420,6 +482,9 @@ func (self *SmOsipsConfig) loadFromJsonCfg(jsnCfg *SmOsipsJsonCfg) error {
 	if jsnCfg.Listen_udp != nil {
 		self.ListenUdp = *jsnCfg.Listen_udp
 	}
+	if jsnCfg.Listen_jsonrpc != nil {
+		self.ListenJsonRPC = *jsnCfg.Listen_jsonrpc
+	}
 	if jsnCfg.Rals_conns != nil {
 		self.RALsConns = make([]*HaPoolConfig, len(*jsnCfg.Rals_conns))
 		for idx, jsnHaCfg := range *jsnCfg.Rals_conns {
@@ -460,6 +525,9 @@ func (self *SmOsipsConfig) loadFromJsonCfg(jsnCfg *SmOsipsJsonCfg) error {
 	if jsnCfg.Mi_addr != nil {
 		self.MiAddr = *jsnCfg.Mi_addr
 	}
+	if jsnCfg.Mi_json_url != nil {
+		self.MiJsonURL = *jsnCfg.Mi_json_url
+	}
 
 	return nil
 }
@@ -501,10 +569,12 @@ func (aConnCfg *AsteriskConnCfg) loadFromJsonCfg(jsnCfg *AstConnJsonCfg) error {
 }
 
 type SMAsteriskCfg struct {
-	Enabled       bool
-	SMGConns      []*HaPoolConfig
-	CreateCDR     bool
-	AsteriskConns []*AsteriskConnCfg
+	Enabled          bool
+	SMGConns         []*HaPoolConfig
+	CreateCDR        bool
+	LowBalancePrompt string // media played on low balance warnings
+	DisconnectPrompt string // media played before disconnecting the channel
+	AsteriskConns    []*AsteriskConnCfg
 }
 
 func (aCfg *SMAsteriskCfg) loadFromJsonCfg(jsnCfg *SMAsteriskJsonCfg) (err error) {
@@ -521,6 +591,12 @@ func (aCfg *SMAsteriskCfg) loadFromJsonCfg(jsnCfg *SMAsteriskJsonCfg) (err error
 	if jsnCfg.Create_cdr != nil {
 		aCfg.CreateCDR = *jsnCfg.Create_cdr
 	}
+	if jsnCfg.Low_balance_prompt != nil {
+		aCfg.LowBalancePrompt = *jsnCfg.Low_balance_prompt
+	}
+	if jsnCfg.Disconnect_prompt != nil {
+		aCfg.DisconnectPrompt = *jsnCfg.Disconnect_prompt
+	}
 	if jsnCfg.Asterisk_conns != nil {
 		aCfg.AsteriskConns = make([]*AsteriskConnCfg, len(*jsnCfg.Asterisk_conns))
 		for i, jsnAConn := range *jsnCfg.Asterisk_conns {
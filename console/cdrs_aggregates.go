@@ -0,0 +1,64 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package console
+
+import (
+	"github.com/cgrates/cgrates/apier/v2"
+	"github.com/cgrates/cgrates/engine"
+)
+
+func init() {
+	c := &CmdGetCDRsAggregates{
+		name:      "cdrs_aggregates",
+		rpcMethod: "ApierV2.GetCDRsAggregates",
+	}
+	commands[c.Name()] = c
+	c.CommandExecuter = &CommandExecuter{c}
+}
+
+// Commander implementation
+type CmdGetCDRsAggregates struct {
+	name      string
+	rpcMethod string
+	rpcParams *v2.ArgsGetCDRsAggregates
+	*CommandExecuter
+}
+
+func (self *CmdGetCDRsAggregates) Name() string {
+	return self.name
+}
+
+func (self *CmdGetCDRsAggregates) RpcMethod() string {
+	return self.rpcMethod
+}
+
+func (self *CmdGetCDRsAggregates) RpcParams(reset bool) interface{} {
+	if reset || self.rpcParams == nil {
+		self.rpcParams = new(v2.ArgsGetCDRsAggregates)
+	}
+	return self.rpcParams
+}
+
+func (self *CmdGetCDRsAggregates) PostprocessRpcParams() error {
+	return nil
+}
+
+func (self *CmdGetCDRsAggregates) RpcResult() interface{} {
+	var aggrs []*engine.CDRsAggregate
+	return &aggrs
+}
@@ -0,0 +1,23 @@
+USE `cgrates`;
+
+ALTER TABLE `tp_destination_rates`
+	ADD COLUMN `currency` varchar(8) NOT NULL DEFAULT '' after `max_cost_strategy`,
+	ADD COLUMN `tier_counter` varchar(64) NOT NULL DEFAULT '' after `currency`;
+
+ALTER TABLE `tp_shared_groups`
+	ADD COLUMN `quota` DECIMAL(20,4) NOT NULL DEFAULT 0 after `rating_subject`,
+	ADD COLUMN `quota_period` varchar(24) NOT NULL DEFAULT '' after `quota`,
+	ADD COLUMN `quota_balance_type` varchar(24) NOT NULL DEFAULT '' after `quota_period`;
+
+CREATE TABLE IF NOT EXISTS `tp_exchange_rates` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `from_currency` varchar(8) NOT NULL,
+  `to_currency` varchar(8) NOT NULL,
+  `activation_time` varchar(24) NOT NULL,
+  `rate` DECIMAL(20,10) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_exchange_rate` (`tpid`,`from_currency`,`to_currency`,`activation_time`)
+);
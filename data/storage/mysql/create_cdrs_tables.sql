@@ -55,3 +55,36 @@ CREATE TABLE sm_costs (
   KEY run_origin_idx (run_id, origin_id),
   KEY deleted_at_idx (deleted_at)
 );
+
+DROP TABLE IF EXISTS balance_ledger;
+CREATE TABLE balance_ledger (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  account_id varchar(128) NOT NULL,
+  balance_uuid varchar(64) NOT NULL,
+  balance_id varchar(64) NOT NULL,
+  balance_type varchar(24) NOT NULL,
+  value_before DECIMAL(30,9) NOT NULL,
+  value_after DECIMAL(30,9) NOT NULL,
+  operation varchar(64) NOT NULL,
+  cause varchar(64) NOT NULL,
+  created_at TIMESTAMP NULL,
+  PRIMARY KEY (`id`),
+  KEY account_time_idx (account_id, created_at)
+);
+
+DROP TABLE IF EXISTS invoices;
+CREATE TABLE invoices (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  tenant varchar(64) NOT NULL,
+  number int(11) NOT NULL,
+  account varchar(128) NOT NULL,
+  period_start TIMESTAMP NULL,
+  period_end TIMESTAMP NULL,
+  total DECIMAL(20,4) NOT NULL,
+  content MEDIUMTEXT NOT NULL,
+  created_at TIMESTAMP NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY tenant_number (tenant, number),
+  UNIQUE KEY account_period (tenant, account, period_start),
+  KEY account_idx (tenant, account)
+);
@@ -71,6 +71,8 @@ CREATE TABLE `tp_destination_rates` (
   `rounding_decimals` tinyint(4) NOT NULL,
   `max_cost` decimal(7,4) NOT NULL,
   `max_cost_strategy` varchar(16) NOT NULL,
+  `currency` varchar(8) NOT NULL,
+  `tier_counter` varchar(64) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`id`),
   KEY `tpid` (`tpid`),
@@ -133,12 +135,56 @@ CREATE TABLE `tp_shared_groups` (
   `account` varchar(64) NOT NULL,
   `strategy` varchar(24) NOT NULL,
   `rating_subject` varchar(24) NOT NULL,
+  `quota` DECIMAL(20,4) NOT NULL,
+  `quota_period` varchar(24) NOT NULL,
+  `quota_balance_type` varchar(24) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`id`),
   KEY `tpid` (`tpid`),
   UNIQUE KEY `unique_shared_group` (`tpid`,`tag`,`account`,`strategy`,`rating_subject`)
 );
 
+--
+-- Table structure for table `tp_exchange_rates`
+--
+
+DROP TABLE IF EXISTS `tp_exchange_rates`;
+CREATE TABLE `tp_exchange_rates` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `from_currency` varchar(8) NOT NULL,
+  `to_currency` varchar(8) NOT NULL,
+  `activation_time` varchar(24) NOT NULL,
+  `rate` DECIMAL(20,10) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_exchange_rate` (`tpid`,`from_currency`,`to_currency`,`activation_time`)
+);
+
+--
+-- Table structure for table `tp_tax_rules`
+--
+
+DROP TABLE IF EXISTS `tp_tax_rules`;
+CREATE TABLE `tp_tax_rules` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tenant` varchar(64) NOT NULL,
+  `tag` varchar(64) NOT NULL,
+  `tax_class` varchar(64) NOT NULL,
+  `categories` varchar(64) NOT NULL,
+  `destination_ids` varchar(64) NOT NULL,
+  `activation_time` varchar(24) NOT NULL,
+  `rate` DECIMAL(10,6) NOT NULL,
+  `compound` BOOLEAN NOT NULL,
+  `weight` DECIMAL(8,2) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_tax_rule` (`tpid`,`tenant`,`tag`,`tax_class`,`categories`,`destination_ids`,`activation_time`)
+);
+
 --
 -- Table structure for table `tp_actions`
 --
@@ -0,0 +1,20 @@
+ALTER TABLE tp_destination_rates
+	ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT '',
+	ADD COLUMN tier_counter VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE tp_shared_groups
+	ADD COLUMN quota NUMERIC(20,4) NOT NULL DEFAULT 0,
+	ADD COLUMN quota_period VARCHAR(24) NOT NULL DEFAULT '',
+	ADD COLUMN quota_balance_type VARCHAR(24) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS tp_exchange_rates (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  from_currency VARCHAR(8) NOT NULL,
+  to_currency VARCHAR(8) NOT NULL,
+  activation_time VARCHAR(24) NOT NULL,
+  rate NUMERIC(20,10) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (tpid, from_currency, to_currency, activation_time)
+);
+CREATE INDEX IF NOT EXISTS tpexchangerates_tpid_idx ON tp_exchange_rates (tpid);
@@ -63,3 +63,35 @@ CREATE INDEX run_origin_smcost_idx ON sm_costs (run_id, origin_id);
 DROP INDEX IF EXISTS deleted_at_smcost_idx;
 CREATE INDEX deleted_at_smcost_idx ON sm_costs (deleted_at);
 
+DROP TABLE IF EXISTS balance_ledger;
+CREATE TABLE balance_ledger (
+  id SERIAL PRIMARY KEY,
+  account_id VARCHAR(128) NOT NULL,
+  balance_uuid VARCHAR(64) NOT NULL,
+  balance_id VARCHAR(64) NOT NULL,
+  balance_type VARCHAR(24) NOT NULL,
+  value_before NUMERIC(30,9) NOT NULL,
+  value_after NUMERIC(30,9) NOT NULL,
+  operation VARCHAR(64) NOT NULL,
+  cause VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE
+);
+DROP INDEX IF EXISTS account_time_ledger_idx;
+CREATE INDEX account_time_ledger_idx ON balance_ledger (account_id, created_at);
+
+DROP TABLE IF EXISTS invoices;
+CREATE TABLE invoices (
+  id SERIAL PRIMARY KEY,
+  tenant VARCHAR(64) NOT NULL,
+  number INTEGER NOT NULL,
+  account VARCHAR(128) NOT NULL,
+  period_start TIMESTAMP WITH TIME ZONE,
+  period_end TIMESTAMP WITH TIME ZONE,
+  total NUMERIC(20,4) NOT NULL,
+  content TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (tenant, number),
+  UNIQUE (tenant, account, period_start)
+);
+DROP INDEX IF EXISTS tenant_account_invoice_idx;
+CREATE INDEX tenant_account_invoice_idx ON invoices (tenant, account);
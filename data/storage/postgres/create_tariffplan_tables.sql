@@ -68,6 +68,8 @@ CREATE TABLE tp_destination_rates (
   rounding_decimals SMALLINT NOT NULL,
   max_cost NUMERIC(7,4) NOT NULL,
   max_cost_strategy VARCHAR(16) NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  tier_counter VARCHAR(64) NOT NULL,
   created_at TIMESTAMP WITH TIME ZONE,
   UNIQUE (tpid, tag , destinations_tag)
 );
@@ -128,12 +130,54 @@ CREATE TABLE tp_shared_groups (
   account VARCHAR(64) NOT NULL,
   strategy VARCHAR(24) NOT NULL,
   rating_subject VARCHAR(24) NOT NULL,
+  quota NUMERIC(20,4) NOT NULL,
+  quota_period VARCHAR(24) NOT NULL,
+  quota_balance_type VARCHAR(24) NOT NULL,
   created_at TIMESTAMP WITH TIME ZONE,
   UNIQUE (tpid, tag, account , strategy , rating_subject)
 );
 CREATE INDEX tpsharedgroups_tpid_idx ON tp_shared_groups (tpid);
 CREATE INDEX tpsharedgroups_idx ON tp_shared_groups (tpid,tag);
 
+--
+-- Table structure for table `tp_exchange_rates`
+--
+
+DROP TABLE IF EXISTS tp_exchange_rates;
+CREATE TABLE tp_exchange_rates (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  from_currency VARCHAR(8) NOT NULL,
+  to_currency VARCHAR(8) NOT NULL,
+  activation_time VARCHAR(24) NOT NULL,
+  rate NUMERIC(20,10) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (tpid, from_currency, to_currency, activation_time)
+);
+CREATE INDEX tpexchangerates_tpid_idx ON tp_exchange_rates (tpid);
+
+--
+-- Table structure for table `tp_tax_rules`
+--
+
+DROP TABLE IF EXISTS tp_tax_rules;
+CREATE TABLE tp_tax_rules (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  tenant VARCHAR(64) NOT NULL,
+  tag VARCHAR(64) NOT NULL,
+  tax_class VARCHAR(64) NOT NULL,
+  categories VARCHAR(64) NOT NULL,
+  destination_ids VARCHAR(64) NOT NULL,
+  activation_time VARCHAR(24) NOT NULL,
+  rate NUMERIC(10,6) NOT NULL,
+  compound BOOLEAN NOT NULL,
+  weight NUMERIC(8,2) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (tpid, tenant, tag, tax_class, categories, destination_ids, activation_time)
+);
+CREATE INDEX tptaxrules_tpid_idx ON tp_tax_rules (tpid);
+
 --
 -- Table structure for table `tp_actions`
 --
@@ -40,7 +40,16 @@ type Account struct {
 	ActionTriggers    ActionTriggers
 	AllowNegative     bool
 	Disabled          bool
+	MaxSessionCost    float64                              // maximum cost of one session, 0 for no limit
+	SpendingLimit     *SpendingLimit                       // maximum amount spent within a period, across sessions
+	CreditLimit       float64                              // amount the default monetary balance can go below zero, unlimited with AllowNegative
+	Subscriptions     map[string]*Subscription             // recurring fees charged in advance, indexed on ID
+	ParentID          string                               // reseller account charged on top of this one, empty for top level accounts
+	ParentSubject     string                               // rating subject of the wholesale rates charged to the parent, defaults to the parent account
+	SharedGroupUsage  map[string]map[string]*SpendingLimit // consumption out of the shared groups within their quota periods, indexed on shared group ID and balance type
 	executingTriggers bool
+	ledger            *balanceLedger // balance changes waiting for the account to be saved
+	subscriptionCDRs  []*CDR         // subscription charges waiting for the account to be saved
 }
 
 // User's available minutes for the specified destination
@@ -282,13 +291,17 @@ func (ub *Account) getBalancesForPrefix(prefix, category, direction, tor string,
 	if tor != utils.MONETARY && tor != utils.GENERIC {
 		balances = append(balances, ub.BalanceMap[utils.GENERIC]...)
 	}
+	var limitAcc *Account // the account credit limit covers only its monetary balances
+	if tor == utils.MONETARY {
+		limitAcc = ub
+	}
 	var usefulBalances Balances
 	for _, b := range balances {
 
 		if b.Disabled {
 			continue
 		}
-		if b.IsExpired() || (len(b.SharedGroups) == 0 && b.GetValue() <= 0 && !b.Blocker) {
+		if b.IsExpired() || (len(b.SharedGroups) == 0 && b.availableValue(limitAcc) <= 0 && !b.Blocker) {
 			continue
 		}
 		if sharedGroup != "" && b.SharedGroups[sharedGroup] == false {
@@ -371,6 +384,9 @@ func (account *Account) getAlldBalancesForPrefix(destination, category, directio
 }
 
 func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun bool, goNegative bool) (cc *CallCost, err error) {
+	if !ub.unlimitedNegative() {
+		ub.GetDefaultMoneyBalance() // make sure the credit limit can be used
+	}
 	usefulUnitBalances := ub.getAlldBalancesForPrefix(cd.Destination, cd.Category, cd.Direction, cd.TOR)
 	usefulMoneyBalances := ub.getAlldBalancesForPrefix(cd.Destination, cd.Category, cd.Direction, utils.MONETARY)
 	//utils.Logger.Info(fmt.Sprintf("%+v, %+v", usefulMoneyBalances, usefulUnitBalances))
@@ -495,16 +511,18 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 		cc.Timespans = append(cc.Timespans, leftCC.Timespans...)
 	}
 
-	if leftCC.Cost > 0 && goNegative {
+	if leftCC.Cost > 0 && goNegative && ub.unlimitedNegative() {
 		initialLength := len(cc.Timespans)
 		cc.Timespans = append(cc.Timespans, leftCC.Timespans...)
 
-		var debitedConnectFeeBalance Balance
+		var connectFeeInfo *MonetaryInfo
 		var ok bool
 
 		if initialLength == 0 {
 			// this is the first add, debit the connect fee
-			ok, debitedConnectFeeBalance = ub.DebitConnectionFee(cc, usefulMoneyBalances, count, true)
+			if ok, connectFeeInfo, err = ub.DebitConnectionFee(cc, usefulMoneyBalances, count, true); err != nil {
+				return nil, err
+			}
 		}
 		//log.Printf("Left CC: %+v ", leftCC)
 		// get the default money balanance
@@ -524,11 +542,7 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 					Duration: 0,
 					Cost:     ts.RateInterval.Rating.ConnectFee,
 					BalanceInfo: &DebitInfo{
-						Monetary: &MonetaryInfo{
-							UUID:  debitedConnectFeeBalance.Uuid,
-							ID:    debitedConnectFeeBalance.ID,
-							Value: debitedConnectFeeBalance.Value,
-						},
+						Monetary:  connectFeeInfo,
 						AccountID: ub.ID,
 					},
 				}
@@ -544,13 +558,19 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 					continue
 				}
 
-				cost := increment.Cost
 				defaultBalance := ub.GetDefaultMoneyBalance()
+				exRate, err := defaultBalance.exchangeRate(ts.RateInterval.Currency(), ts.TimeStart)
+				if err != nil {
+					return nil, err
+				}
+				cost := convertCost(increment.Cost, exRate)
 				defaultBalance.SubstractValue(cost)
 				increment.BalanceInfo.Monetary = &MonetaryInfo{
-					UUID:  defaultBalance.Uuid,
-					ID:    defaultBalance.ID,
-					Value: defaultBalance.Value,
+					UUID:         defaultBalance.Uuid,
+					ID:           defaultBalance.ID,
+					Value:        defaultBalance.Value,
+					ExchangeRate: exRate,
+					TierCounter:  ts.RateInterval.TierCounter(),
 				}
 				increment.BalanceInfo.AccountID = ub.ID
 				increment.paid = true
@@ -564,6 +584,7 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 							Value:          cost,
 							DestinationIDs: utils.NewStringMap(leftCC.Destination),
 						})
+					ub.countTierUsage(increment, ts.RateInterval, leftCC.TOR)
 				}
 			}
 		}
@@ -833,33 +854,59 @@ func (acc *Account) Clone() *Account {
 		ActionTriggers: nil, // not used when cloned (dryRun)
 		AllowNegative:  acc.AllowNegative,
 		Disabled:       acc.Disabled,
+		MaxSessionCost: acc.MaxSessionCost,
+		SpendingLimit:  acc.SpendingLimit.Clone(),
+		CreditLimit:    acc.CreditLimit,
+		ParentID:       acc.ParentID,
+		ParentSubject:  acc.ParentSubject,
 	}
 	for key, balanceChain := range acc.BalanceMap {
 		newAcc.BalanceMap[key] = balanceChain.Clone()
 	}
+	if acc.SharedGroupUsage != nil {
+		newAcc.SharedGroupUsage = make(map[string]map[string]*SpendingLimit, len(acc.SharedGroupUsage))
+		for sgID, usages := range acc.SharedGroupUsage {
+			newAcc.SharedGroupUsage[sgID] = make(map[string]*SpendingLimit, len(usages))
+			for balanceType, usage := range usages {
+				newAcc.SharedGroupUsage[sgID][balanceType] = usage.Clone()
+			}
+		}
+	}
 	return newAcc
 }
 
-func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances Balances, count bool, block bool) (bool, Balance) {
-	var debitedBalance Balance
+// DebitConnectionFee debits the connect fee out of the first balance able to pay it,
+// returning false if a blocker balance was found and the monetary info of the debited balance
+func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances Balances, count bool, block bool) (bool, *MonetaryInfo, error) {
+	var debitedInfo *MonetaryInfo
 
 	if cc.deductConnectFee {
 		connectFee := cc.GetConnectFee()
+		var currency string
+		var feeTime time.Time
+		if len(cc.Timespans) != 0 {
+			currency, feeTime = cc.Timespans[0].RateInterval.Currency(), cc.Timespans[0].TimeStart
+		}
 		//log.Print("CONNECT FEE: %f", connectFee)
 		connectFeePaid := false
 		for _, b := range usefulMoneyBalances {
-			if b.GetValue() >= connectFee {
-				b.SubstractValue(connectFee)
+			exRate, err := b.exchangeRate(currency, feeTime)
+			if err != nil {
+				return false, nil, err
+			}
+			amount := convertCost(connectFee, exRate)
+			if b.availableValue(b.account) >= amount {
+				b.SubstractValue(amount)
 				// the conect fee is not refundable!
 				if count {
-					acc.countUnits(connectFee, utils.MONETARY, cc, b)
+					acc.countUnits(amount, utils.MONETARY, cc, b)
 				}
 				connectFeePaid = true
-				debitedBalance = *b
+				debitedInfo = &MonetaryInfo{UUID: b.Uuid, ID: b.ID, Value: b.Value, ExchangeRate: exRate}
 				break
 			}
 			if b.Blocker && block { // stop here
-				return false, debitedBalance
+				return false, debitedInfo, nil
 			}
 		}
 		// debit connect fee
@@ -867,15 +914,20 @@ func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances Balance
 			cc.negativeConnectFee = true
 			// there are no money for the connect fee; go negative
 			b := acc.GetDefaultMoneyBalance()
-			b.SubstractValue(connectFee)
-			debitedBalance = *b
+			exRate, err := b.exchangeRate(currency, feeTime)
+			if err != nil {
+				return false, nil, err
+			}
+			amount := convertCost(connectFee, exRate)
+			b.SubstractValue(amount)
+			debitedInfo = &MonetaryInfo{UUID: b.Uuid, ID: b.ID, Value: b.Value, ExchangeRate: exRate}
 			// the conect fee is not refundable!
 			if count {
-				acc.countUnits(connectFee, utils.MONETARY, cc, b)
+				acc.countUnits(amount, utils.MONETARY, cc, b)
 			}
 		}
 	}
-	return true, debitedBalance
+	return true, debitedInfo, nil
 }
 
 func (acc *Account) matchActionFilter(condition string) (bool, error) {
@@ -1059,7 +1111,8 @@ func (acc *Account) AsOldStructure() interface{} {
 
 func (acc *Account) AsAccountSummary() *AccountSummary {
 	idSplt := strings.Split(acc.ID, utils.CONCATENATED_KEY_SEP)
-	ad := &AccountSummary{AllowNegative: acc.AllowNegative, Disabled: acc.Disabled}
+	ad := &AccountSummary{AllowNegative: acc.AllowNegative, Disabled: acc.Disabled,
+		MaxSessionCost: acc.MaxSessionCost, SpendingLimit: acc.SpendingLimit.Clone(), CreditLimit: acc.CreditLimit}
 	if len(idSplt) == 1 {
 		ad.ID = idSplt[0]
 	} else if len(idSplt) == 2 {
@@ -1088,6 +1141,9 @@ type AccountSummary struct {
 	BalanceSummaries []*BalanceSummary
 	AllowNegative    bool
 	Disabled         bool
+	MaxSessionCost   float64
+	SpendingLimit    *SpendingLimit
+	CreditLimit      float64
 }
 
 func (as *AccountSummary) Clone() (cln *AccountSummary) {
@@ -1096,6 +1152,9 @@ func (as *AccountSummary) Clone() (cln *AccountSummary) {
 	cln.ID = as.ID
 	cln.AllowNegative = as.AllowNegative
 	cln.Disabled = as.Disabled
+	cln.MaxSessionCost = as.MaxSessionCost
+	cln.SpendingLimit = as.SpendingLimit.Clone()
+	cln.CreditLimit = as.CreditLimit
 	if as.BalanceSummaries != nil {
 		cln.BalanceSummaries = make([]*BalanceSummary, len(as.BalanceSummaries))
 		for i, bs := range as.BalanceSummaries {
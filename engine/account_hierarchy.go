@@ -0,0 +1,274 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// getParents returns the accounts above acc in the reseller hierarchy, starting with its direct parent
+func (acc *Account) getParents() (parents []*Account, err error) {
+	visited := utils.StringMap{acc.ID: true}
+	for parentID := acc.ParentID; parentID != ""; {
+		if visited[parentID] {
+			return nil, fmt.Errorf("loop in the hierarchy of account %s at %s", acc.ID, parentID)
+		}
+		visited[parentID] = true
+		parent, err := dataStorage.GetAccount(parentID)
+		if err != nil {
+			return nil, fmt.Errorf("parent account %s: %v", parentID, err)
+		}
+		parents = append(parents, parent)
+		parentID = parent.ParentID
+	}
+	return
+}
+
+// parentSubject returns the rating subject of the wholesale rates the parent is charged with for the traffic of acc
+func (acc *Account) parentSubject() string {
+	if acc.ParentSubject != "" {
+		return acc.ParentSubject
+	}
+	if ta, err := utils.NewTAFromAccountKey(acc.ParentID); err == nil {
+		return ta.Account
+	}
+	return ""
+}
+
+// SetParent attaches the account to a reseller account, an empty parentID detaching it
+func (acc *Account) SetParent(parentID, parentSubject *string) error {
+	if parentID != nil {
+		if *parentID != "" {
+			if _, err := utils.NewTAFromAccountKey(*parentID); err != nil {
+				return err
+			}
+		}
+		prevParentID := acc.ParentID
+		acc.ParentID = *parentID
+		if _, err := acc.getParents(); err != nil {
+			acc.ParentID = prevParentID
+			return err
+		}
+	}
+	if parentSubject != nil {
+		acc.ParentSubject = *parentSubject
+	}
+	return nil
+}
+
+// parentDescriptor returns the call descriptor rating the traffic of child on its parent account
+func (cd *CallDescriptor) parentDescriptor(child *Account) *CallDescriptor {
+	pCD := cd.Clone()
+	ta, _ := utils.NewTAFromAccountKey(child.ParentID) // validated on SetParent
+	pCD.Tenant = ta.Tenant
+	pCD.Account = ta.Account
+	pCD.Subject = child.parentSubject()
+	pCD.FallbackSubject = ""
+	return pCD
+}
+
+// getMaxSessionDurationWithParents returns the most restrictive of the max session durations
+// of the account and of its parents, -1 if none of them is limited
+func (cd *CallDescriptor) getMaxSessionDurationWithParents(account *Account, parents []*Account) (time.Duration, error) {
+	duration, err := cd.getMaxSessionDuration(account)
+	if err != nil {
+		return 0, err
+	}
+	child := account
+	for _, parent := range parents {
+		pDuration, err := cd.parentDescriptor(child).getMaxSessionDuration(parent)
+		if err != nil {
+			return 0, err
+		}
+		if duration == -1 || (pDuration != -1 && pDuration < duration) {
+			duration = pDuration
+		}
+		child = parent
+	}
+	return duration, nil
+}
+
+// debitParents charges the traffic debited on account to each of its parents, at the wholesale rates of the level below,
+// recording the parent debits on the increments of cc so they are refunded together with the ones of the account.
+// The call descriptor has to be cloned before debiting the account since debit modifies it.
+func (cd *CallDescriptor) debitParents(cc *CallCost, account *Account, parents []*Account, goNegative bool) error {
+	child := account
+	for _, parent := range parents {
+		pCC, err := cd.parentDescriptor(child).debit(parent, false, goNegative)
+		if err != nil {
+			return fmt.Errorf("debiting parent account %s: %v", parent.ID, err)
+		}
+		cc.addParentDebits(parent.ID, pCC)
+		child = parent
+	}
+	return nil
+}
+
+// debitWithParents debits the account followed by its parents, refunding the levels already debited if one of them fails
+func (cd *CallDescriptor) debitWithParents(account *Account, parents []*Account) (cc *CallCost, err error) {
+	parentsCD := cd.Clone()
+	if cc, err = cd.debit(account, cd.DryRun, !cd.DenyNegativeAccount); err != nil {
+		return
+	}
+	cc.AccountSummary = cd.AccountSummary()
+	if cd.DryRun || len(parents) == 0 {
+		return
+	}
+	if err = parentsCD.debitParents(cc, account, parents, !cd.DenyNegativeAccount); err != nil {
+		rcd := cc.CreateCallDescriptor()
+		rcd.CgrID = cd.CgrID
+		cc.Timespans.Decompress()
+		for _, ts := range cc.Timespans {
+			rcd.Increments = append(rcd.Increments, ts.Increments...)
+		}
+		rcd.Increments.Decompress()
+		if errRefund := rcd.refundIncrements(); errRefund != nil {
+			utils.Logger.Err(fmt.Sprintf("<Rater> Could not refund account <%s> after %s, error: %s",
+				account.ID, err.Error(), errRefund.Error()))
+		}
+		return nil, err
+	}
+	return
+}
+
+// addParentDebits records the increments debited on one parent onto the increments of cc,
+// each of them going with the increment of cc it started within
+func (cc *CallCost) addParentDebits(parentID string, pCC *CallCost) {
+	type startedIncrement struct {
+		start time.Time
+		incr  *Increment
+	}
+	cc.Timespans.Decompress()
+	var incrs []*startedIncrement
+	for _, ts := range cc.Timespans {
+		start := ts.TimeStart
+		for _, incr := range ts.Increments {
+			incrs = append(incrs, &startedIncrement{start: start, incr: incr})
+			start = start.Add(incr.Duration)
+		}
+	}
+	if len(incrs) == 0 {
+		return
+	}
+	pCC.Timespans.Decompress()
+	for _, pTS := range pCC.Timespans {
+		pStart := pTS.TimeStart
+		for _, pIncr := range pTS.Increments {
+			i := sort.Search(len(incrs), func(i int) bool { return incrs[i].start.After(pStart) })
+			if i != 0 {
+				i--
+			}
+			pStart = pStart.Add(pIncr.Duration)
+			if pIncr.BalanceInfo == nil {
+				continue
+			}
+			accountID := pIncr.BalanceInfo.AccountID
+			if accountID == "" {
+				accountID = parentID
+			}
+			incr := incrs[i].incr
+			if incr.BalanceInfo == nil {
+				incr.BalanceInfo = new(DebitInfo)
+			}
+			if pIncr.BalanceInfo.Unit != nil && pIncr.BalanceInfo.Unit.UUID != "" {
+				incr.BalanceInfo.Parents = incr.BalanceInfo.Parents.add(accountID, pIncr.BalanceInfo.Unit.UUID,
+					pCC.TOR, pIncr.BalanceInfo.Unit.Consumed)
+			}
+			if pIncr.BalanceInfo.Monetary != nil && pIncr.BalanceInfo.Monetary.UUID != "" {
+				incr.BalanceInfo.Parents = incr.BalanceInfo.Parents.add(accountID, pIncr.BalanceInfo.Monetary.UUID,
+					utils.MONETARY, pIncr.BalanceInfo.Monetary.ChargedAmount(pIncr.Cost))
+			}
+		}
+	}
+	cc.Timespans.Compress()
+}
+
+// parentLockIDs returns the guardian locks of the parent accounts not already locked as shared group members
+func parentLockIDs(parents []*Account, acntIDs utils.StringMap) (lkIDs []string) {
+	for _, parent := range parents {
+		if !acntIDs[parent.ID] {
+			lkIDs = append(lkIDs, utils.ACCOUNT_PREFIX+parent.ID)
+		}
+	}
+	return
+}
+
+// resellerRunID returns the RunID of the CDRs charging the parent at level of the hierarchy, 1 for the direct parent
+func resellerRunID(level int) string {
+	return utils.MetaReseller + "_" + strconv.Itoa(level)
+}
+
+// resellerCDRs builds out of a debited *default CDR one CDR per parent of its account, each level of the hierarchy
+// being costed with the parent debits recorded on the increments when the parents were debited together with the account
+func (self *CdrServer) resellerCDRs(cdr *CDR) (cdrs []*CDR, err error) {
+	if cdr.RunID != utils.META_DEFAULT || cdr.Cost == -1 {
+		return
+	}
+	incrs := cdr.debitedIncrements()
+	if len(incrs) == 0 {
+		return
+	}
+	acc, err := self.dataDB.GetAccount(utils.AccountKey(cdr.Tenant, cdr.Account))
+	if err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	parents, err := acc.getParents()
+	if err != nil {
+		return
+	}
+	costs := make(map[string]float64) // monetary debits per parent account
+	for _, incr := range append(incrs, cdr.CostDetails.GetRoundIncrements()...) {
+		if incr.BalanceInfo == nil {
+			continue
+		}
+		for _, pd := range incr.BalanceInfo.Parents {
+			var value float64
+			if pd.BalanceType == utils.MONETARY {
+				value = pd.Value * float64(incr.GetCompressFactor())
+			}
+			costs[pd.AccountID] += value
+		}
+	}
+	child := acc
+	for i, parent := range parents {
+		cost, debited := costs[parent.ID]
+		if !debited { // parent attached after the debit
+			break
+		}
+		ta, _ := utils.NewTAFromAccountKey(parent.ID)
+		pCDR := cdr.Clone()
+		pCDR.RunID = resellerRunID(i + 1)
+		pCDR.Tenant = ta.Tenant
+		pCDR.Account = ta.Account
+		pCDR.Subject = child.parentSubject()
+		pCDR.Cost = utils.Round(cost, self.cgrCfg.RoundingDecimals, utils.ROUNDING_MIDDLE)
+		pCDR.CostDetails = nil // refunded together with the debits of the account
+		pCDR.CostSource = utils.CDRS_SOURCE
+		cdrs = append(cdrs, pCDR)
+		child = parent
+	}
+	return
+}
@@ -0,0 +1,233 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestAccountSetParent(t *testing.T) {
+	top := &Account{ID: "vdf:rsl_top"}
+	mid := &Account{ID: "vdf:rsl_mid", ParentID: top.ID}
+	for _, acc := range []*Account{top, mid} {
+		if err := dataStorage.SetAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	child := &Account{ID: "vdf:rsl_child"}
+	if err := child.SetParent(utils.StringPointer(mid.ID), nil); err != nil {
+		t.Fatal(err)
+	}
+	if parents, err := child.getParents(); err != nil {
+		t.Error(err)
+	} else if len(parents) != 2 || parents[0].ID != mid.ID || parents[1].ID != top.ID {
+		t.Errorf("Unexpected parents: %s", utils.ToJSON(parents))
+	}
+	if child.parentSubject() != "rsl_mid" {
+		t.Errorf("Unexpected parent subject: %s", child.parentSubject())
+	}
+	if err := dataStorage.SetAccount(child); err != nil {
+		t.Fatal(err)
+	}
+	if err := top.SetParent(utils.StringPointer(child.ID), nil); err == nil {
+		t.Error("Expecting loop error")
+	} else if top.ParentID != "" {
+		t.Errorf("Parent changed on error: %s", top.ParentID)
+	}
+	if err := top.SetParent(utils.StringPointer("vdf:rsl_missing"), nil); err == nil {
+		t.Error("Expecting missing parent error")
+	}
+	if err := child.SetParent(utils.StringPointer(""), utils.StringPointer("")); err != nil || child.ParentID != "" {
+		t.Errorf("Detaching parent: %v, %s", err, child.ParentID)
+	}
+}
+
+func TestAccountHierarchyDebit(t *testing.T) {
+	parent := &Account{ID: "vdf:rsl_parent", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "rsl_parent_money", Value: 5}}}}
+	child := &Account{ID: "vdf:rsl_debit", ParentID: parent.ID, ParentSubject: "minu",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "rsl_child_money", Value: 20}}}}
+	for _, acc := range []*Account{parent, child} {
+		if err := dataStorage.SetAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 35, 0, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "minu",
+		Account:     "rsl_debit",
+		Destination: "0723",
+	}
+	// the parent balance is the most restrictive along the chain
+	if d, err := cd.Clone().GetMaxSessionDuration(); err != nil {
+		t.Error(err)
+	} else if d != 10*time.Second {
+		t.Errorf("Expecting: %v, received: %v", 10*time.Second, d)
+	}
+	cd.TimeEnd = cd.TimeStart.Add(4 * time.Second)
+	cc, err := cd.Debit()
+	if err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 2 {
+		t.Errorf("Unexpected cost: %v", cc.Cost)
+	}
+	if acc, err := dataStorage.GetAccount(child.ID); err != nil {
+		t.Error(err)
+	} else if acc.BalanceMap[utils.MONETARY][0].GetValue() != 18 {
+		t.Errorf("Unexpected child balance: %s", utils.ToJSON(acc.BalanceMap))
+	}
+	if acc, err := dataStorage.GetAccount(parent.ID); err != nil {
+		t.Error(err)
+	} else if acc.BalanceMap[utils.MONETARY][0].GetValue() != 3 {
+		t.Errorf("Unexpected parent balance: %s", utils.ToJSON(acc.BalanceMap))
+	}
+	if resellerRunID(1) != "*reseller_1" {
+		t.Errorf("Unexpected RunID: %s", resellerRunID(1))
+	}
+}
+
+func TestAccountHierarchyRefund(t *testing.T) {
+	top := &Account{ID: "vdf:rsl_refund_top", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "rsl_refund_top_money", Value: 10}}}}
+	mid := &Account{ID: "vdf:rsl_refund_mid", ParentID: top.ID, ParentSubject: "minu",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "rsl_refund_mid_money", Value: 10}}}}
+	child := &Account{ID: "vdf:rsl_refund_child", ParentID: mid.ID, ParentSubject: "minu",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "rsl_refund_child_money", Value: 20}}}}
+	for _, acc := range []*Account{top, mid, child} {
+		if err := dataStorage.SetAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkBalances := func(values ...float64) {
+		for i, accID := range []string{child.ID, mid.ID, top.ID} {
+			if acc, err := dataStorage.GetAccount(accID); err != nil {
+				t.Error(err)
+			} else if val := acc.BalanceMap[utils.MONETARY][0].GetValue(); val != values[i] {
+				t.Errorf("Account: %s, expecting: %v, received: %v", accID, values[i], val)
+			}
+		}
+	}
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 34, 4, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "minu",
+		Account:     "rsl_refund_child",
+		Destination: "0723",
+		CgrID:       "rsl_refund",
+	}
+	cc, err := cd.Debit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBalances(18, 8, 8)
+	// refund the way the sessions do, out of the stored EventCost
+	var incrmts Increments
+	for _, ts := range NewEventCostFromCallCost(cc, "rsl_refund", utils.META_DEFAULT).AsCallCost().Timespans {
+		incrmts = append(incrmts, ts.Increments...)
+	}
+	rcd := &CallDescriptor{Direction: "*out", Category: "0", Tenant: "vdf", Subject: "minu",
+		Account: "rsl_refund_child", Destination: "0723", CgrID: "rsl_refund", Increments: incrmts}
+	if err := rcd.RefundIncrements(); err != nil {
+		t.Fatal(err)
+	}
+	checkBalances(20, 10, 10)
+	// the top level of another tenant cannot be rated, the levels below are refunded
+	unrated := &Account{ID: "rsl_unrated:rsl_refund_top", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "rsl_unrated_money", Value: 10}}}}
+	mid.ParentID = unrated.ID
+	for _, acc := range []*Account{unrated, mid} {
+		if err := dataStorage.SetAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cd.TimeEnd = cd.TimeStart.Add(4 * time.Second)
+	if _, err := cd.Debit(); err == nil {
+		t.Error("Expecting error debiting the top account")
+	}
+	checkBalances(20, 10, 10)
+}
+
+func TestAccountHierarchyResellerCDRs(t *testing.T) {
+	top := &Account{ID: "vdf:rsl_cdrs_top", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "rsl_cdrs_top_money", Value: 10}}}}
+	mid := &Account{ID: "vdf:rsl_cdrs_mid", ParentID: top.ID, ParentSubject: "minu",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "rsl_cdrs_mid_money", Value: 10}}}}
+	child := &Account{ID: "vdf:rsl_cdrs_child", ParentID: mid.ID, ParentSubject: "minu",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "rsl_cdrs_child_money", Value: 20}}}}
+	for _, acc := range []*Account{top, mid, child} {
+		if err := dataStorage.SetAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 34, 4, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "minu",
+		Account:     "rsl_cdrs_child",
+		Destination: "0723",
+		CgrID:       "rsl_cdrs",
+	}
+	cc, err := cd.Debit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdr := &CDR{CGRID: "rsl_cdrs", RunID: utils.META_DEFAULT, RequestType: utils.META_PREPAID, Direction: "*out",
+		Tenant: "vdf", Category: "0", Account: "rsl_cdrs_child", Subject: "minu", Destination: "0723",
+		SetupTime: cd.TimeStart, AnswerTime: cd.TimeStart, Usage: 4 * time.Second, Cost: cc.Cost, CostDetails: cc}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cdrS := &CdrServer{cgrCfg: cfg, dataDB: dataStorage} // no RALs, the costs come out of the recorded debits
+	cdrs, err := cdrS.resellerCDRs(cdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cdrs) != 2 {
+		t.Fatalf("Unexpected reseller CDRs: %s", utils.ToJSON(cdrs))
+	}
+	for i, accID := range []string{"rsl_cdrs_mid", "rsl_cdrs_top"} {
+		if cdrs[i].RunID != resellerRunID(i+1) || cdrs[i].Account != accID || cdrs[i].Subject != "minu" ||
+			cdrs[i].Cost != 2 || cdrs[i].CostDetails != nil {
+			t.Errorf("Unexpected reseller CDR: %s", utils.ToJSON(cdrs[i]))
+		}
+	}
+	// rated only, none of the accounts debited
+	cdr.RequestType = utils.META_RATED
+	if cdrs, err := cdrS.resellerCDRs(cdr); err != nil {
+		t.Error(err)
+	} else if len(cdrs) != 0 {
+		t.Errorf("Unexpected reseller CDRs: %s", utils.ToJSON(cdrs))
+	}
+}
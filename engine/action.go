@@ -78,6 +78,11 @@ const (
 	SET_DDESTINATIONS         = "*set_ddestinations"
 	TRANSFER_MONETARY_DEFAULT = "*transfer_monetary_default"
 	CGR_RPC                   = "*cgr_rpc"
+	SET_CREDIT_LIMIT          = "*set_credit_limit"
+	SUBSCRIBE                 = "*subscribe"
+	UNSUBSCRIBE               = "*unsubscribe"
+	CHARGE_SUBSCRIPTIONS      = "*charge_subscriptions"
+	ROLLOVER                  = "*rollover"
 )
 
 func (a *Action) Clone() *Action {
@@ -114,6 +119,11 @@ func getActionFunc(typ string) (actionTypeFunc, bool) {
 		SET_BALANCE:               setBalanceAction,
 		TRANSFER_MONETARY_DEFAULT: transferMonetaryDefaultAction,
 		CGR_RPC:                   cgrRPCAction,
+		SET_CREDIT_LIMIT:          setCreditLimitAction,
+		SUBSCRIBE:                 subscribeAction,
+		UNSUBSCRIBE:               unsubscribeAction,
+		CHARGE_SUBSCRIPTIONS:      chargeSubscriptionsAction,
+		ROLLOVER:                  rolloverAction,
 	}
 	f, exists := actionFuncMap[typ]
 	return f, exists
@@ -158,7 +168,11 @@ func parseTemplateValue(rsrFlds utils.RSRFields, acnt *Account, action *Action)
 		case "ActionType":
 			parsedValue += rsrFld.ParseValue(action.ActionType)
 		case "ActionValue":
-			parsedValue += rsrFld.ParseValue(strconv.FormatFloat(b.GetValue(), 'f', -1, 64))
+			actionValue := b.GetValue()
+			if action.ActionType == ROLLOVER { // the units moved into the rollover balance
+				actionValue = action.balanceValue
+			}
+			parsedValue += rsrFld.ParseValue(strconv.FormatFloat(actionValue, 'f', -1, 64))
 		case "BalanceType":
 			parsedValue += rsrFld.ParseValue(action.Balance.GetType())
 		case "BalanceUUID":
@@ -213,9 +227,12 @@ func cdrLogAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actio
 	// set stored cdr values
 	var cdrs []*CDR
 	for _, action := range acs {
-		if !utils.IsSliceMember([]string{DEBIT, DEBIT_RESET, TOPUP, TOPUP_RESET}, action.ActionType) || action.Balance == nil {
+		if !utils.IsSliceMember([]string{DEBIT, DEBIT_RESET, TOPUP, TOPUP_RESET, ROLLOVER}, action.ActionType) || action.Balance == nil {
 			continue // Only log specific actions
 		}
+		if action.ActionType == ROLLOVER && action.balanceValue == 0 {
+			continue // nothing was rolled over
+		}
 		cdr := &CDR{RunID: action.ActionType, Source: CDRLOG, SetupTime: time.Now(), AnswerTime: time.Now(), OriginID: utils.GenUUID(), ExtraFields: make(map[string]string)}
 		cdr.CGRID = utils.Sha1(cdr.OriginID, cdr.SetupTime.String())
 		cdr.Usage = time.Duration(1) * time.Second
@@ -330,7 +330,10 @@ func (at *ActionTiming) Execute(successActions, failedActions chan *Action) (err
 					transactionFailed = true
 					break
 				}
-				if err := actionFunction(acc, nil, a, aac); err != nil {
+				endLedger := acc.ledgerScope(a.ActionType, a.Id)
+				err := actionFunction(acc, nil, a, aac)
+				endLedger()
+				if err != nil {
 					utils.Logger.Err(fmt.Sprintf("Error executing action %s: %v!", a.ActionType, err))
 					transactionFailed = true
 					if failedActions != nil {
@@ -346,7 +349,7 @@ func (at *ActionTiming) Execute(successActions, failedActions chan *Action) (err
 				}
 			}
 			if !transactionFailed && !removeAccountActionFound {
-				dataStorage.SetAccount(acc)
+				saveAccount(acc)
 			}
 			return 0, nil
 		}, 0, accID)
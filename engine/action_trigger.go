@@ -93,7 +93,15 @@ func (at *ActionTrigger) Execute(ub *Account, sq *CDRStatsQueueTriggered) (err e
 			break
 		}
 		//go utils.Logger.Info(fmt.Sprintf("Executing %v, %v: %v", ub, sq, a))
-		if err := actionFunction(ub, sq, a, aac); err != nil {
+		var endLedger func()
+		if ub != nil {
+			endLedger = ub.ledgerScope(a.ActionType, a.Id)
+		}
+		err := actionFunction(ub, sq, a, aac)
+		if endLedger != nil {
+			endLedger()
+		}
+		if err != nil {
 			utils.Logger.Err(fmt.Sprintf("Error executing action %s: %v!", a.ActionType, err))
 			transactionFailed = false
 			break
@@ -112,7 +120,7 @@ func (at *ActionTrigger) Execute(ub *Account, sq *CDRStatsQueueTriggered) (err e
 			"Id":        at.ID,
 			"ActionIds": at.ActionsID,
 		})
-		dataStorage.SetAccount(ub)
+		saveAccount(ub)
 	}
 	return
 }
@@ -34,6 +34,7 @@ type BalanceFilter struct {
 	Weight         *float64
 	DestinationIDs *utils.StringMap
 	RatingSubject  *string
+	Currency       *string
 	Categories     *utils.StringMap
 	SharedGroups   *utils.StringMap
 	TimingIDs      *utils.StringMap
@@ -53,6 +54,7 @@ func (bp *BalanceFilter) CreateBalance() *Balance {
 		Weight:         bp.GetWeight(),
 		DestinationIDs: bp.GetDestinationIDs(),
 		RatingSubject:  bp.GetRatingSubject(),
+		Currency:       bp.GetCurrency(),
 		Categories:     bp.GetCategories(),
 		SharedGroups:   bp.GetSharedGroups(),
 		Timings:        bp.Timings,
@@ -82,6 +84,10 @@ func (bf *BalanceFilter) Clone() *BalanceFilter {
 		result.RatingSubject = new(string)
 		*result.RatingSubject = *bf.RatingSubject
 	}
+	if bf.Currency != nil {
+		result.Currency = new(string)
+		*result.Currency = *bf.Currency
+	}
 	if bf.Type != nil {
 		result.Type = new(string)
 		*result.Type = *bf.Type
@@ -150,6 +156,9 @@ func (bf *BalanceFilter) LoadFromBalance(b *Balance) *BalanceFilter {
 	if b.RatingSubject != "" {
 		bf.RatingSubject = &b.RatingSubject
 	}
+	if b.Currency != "" {
+		bf.Currency = &b.Currency
+	}
 	if !b.Categories.IsEmpty() {
 		bf.Categories = &b.Categories
 	}
@@ -271,6 +280,13 @@ func (bp *BalanceFilter) GetRatingSubject() string {
 	return *bp.RatingSubject
 }
 
+func (bp *BalanceFilter) GetCurrency() string {
+	if bp == nil || bp.Currency == nil {
+		return ""
+	}
+	return *bp.Currency
+}
+
 func (bp *BalanceFilter) GetDisabled() bool {
 	if bp == nil || bp.Disabled == nil {
 		return false
@@ -325,6 +341,9 @@ func (bf *BalanceFilter) ModifyBalance(b *Balance) {
 	if bf.RatingSubject != nil {
 		b.RatingSubject = *bf.RatingSubject
 	}
+	if bf.Currency != nil {
+		b.Currency = *bf.Currency
+	}
 	if bf.Categories != nil {
 		b.Categories = *bf.Categories
 	}
@@ -0,0 +1,167 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// BalanceLedgerEntry records one change of a balance value, never modified once stored
+type BalanceLedgerEntry struct {
+	AccountID   string
+	BalanceUUID string
+	BalanceID   string
+	BalanceType string
+	ValueBefore float64
+	ValueAfter  float64
+	Operation   string // *debit, *refund, *expired or the type of the action changing the balance
+	Cause       string // CGRID of the charged event, ID of the action or API method
+	CreatedAt   time.Time
+}
+
+// BalanceLedgerFilter selects the ledger entries of one account, created within [TimeStart, TimeEnd)
+type BalanceLedgerFilter struct {
+	AccountID string
+	TimeStart *time.Time
+	TimeEnd   *time.Time
+	utils.Paginator
+}
+
+// ledgerBalance is the part of a balance the ledger needs to compare
+type ledgerBalance struct {
+	ID             string
+	Type           string
+	Value          float64
+	ExpirationDate time.Time
+}
+
+// balanceLedger collects the entries of an account until it is saved
+type balanceLedger struct {
+	balances  map[string]*ledgerBalance // balance values already accounted for, indexed on UUID
+	operation string
+	cause     string
+	entries   []*BalanceLedgerEntry
+}
+
+// ledgerBalances returns the ledger view of the account balances
+func (acc *Account) ledgerBalances() map[string]*ledgerBalance {
+	lbs := make(map[string]*ledgerBalance)
+	for bType, bc := range acc.BalanceMap {
+		for _, b := range bc {
+			lbs[b.Uuid] = &ledgerBalance{ID: b.ID, Type: bType, Value: b.GetValue(), ExpirationDate: b.ExpirationDate}
+		}
+	}
+	return lbs
+}
+
+// recordLedger appends an entry for each balance changed since the previous record, under the current operation
+func (acc *Account) recordLedger() {
+	if acc.ledger == nil {
+		return
+	}
+	now := time.Now()
+	crnt := acc.ledgerBalances()
+	uuids := make([]string, 0, len(crnt)+len(acc.ledger.balances))
+	for uuid := range crnt {
+		uuids = append(uuids, uuid)
+	}
+	for uuid := range acc.ledger.balances {
+		if _, has := crnt[uuid]; !has {
+			uuids = append(uuids, uuid)
+		}
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		entry := &BalanceLedgerEntry{AccountID: acc.ID, BalanceUUID: uuid,
+			Operation: acc.ledger.operation, Cause: acc.ledger.cause, CreatedAt: now}
+		before, hasBefore := acc.ledger.balances[uuid]
+		after, hasAfter := crnt[uuid]
+		switch {
+		case !hasBefore:
+			entry.BalanceID, entry.BalanceType, entry.ValueAfter = after.ID, after.Type, after.Value
+		case !hasAfter:
+			entry.BalanceID, entry.BalanceType, entry.ValueBefore = before.ID, before.Type, before.Value
+			if !before.ExpirationDate.IsZero() && before.ExpirationDate.Before(now) {
+				entry.Operation = utils.MetaExpired
+			}
+		default:
+			entry.BalanceID, entry.BalanceType = after.ID, after.Type
+			entry.ValueBefore, entry.ValueAfter = before.Value, after.Value
+		}
+		if entry.ValueBefore == entry.ValueAfter {
+			continue
+		}
+		acc.ledger.entries = append(acc.ledger.entries, entry)
+	}
+	acc.ledger.balances = crnt
+}
+
+// ledgerScope attributes the balance changes from now on to operation and cause,
+// returning the function which records them and gives back the previous scope
+func (acc *Account) ledgerScope(operation, cause string) (end func()) {
+	if acc.ledger == nil {
+		acc.ledger = &balanceLedger{balances: acc.ledgerBalances()}
+	} else {
+		acc.recordLedger()
+	}
+	prevOperation, prevCause := acc.ledger.operation, acc.ledger.cause
+	acc.ledger.operation, acc.ledger.cause = operation, cause
+	return func() {
+		acc.recordLedger()
+		acc.ledger.operation, acc.ledger.cause = prevOperation, prevCause
+	}
+}
+
+// inheritLedgerScope starts a ledger on acc under the current scope of the account owning the debit
+func (acc *Account) inheritLedgerScope(owner *Account) {
+	if owner.ledger == nil || acc.ledger != nil {
+		return
+	}
+	acc.ledger = &balanceLedger{balances: acc.ledgerBalances(),
+		operation: owner.ledger.operation, cause: owner.ledger.cause}
+}
+
+// storeLedger writes the entries collected so far into StorDB
+func (acc *Account) storeLedger() {
+	if acc.ledger == nil {
+		return
+	}
+	acc.recordLedger()
+	entries := acc.ledger.entries
+	acc.ledger.entries = nil
+	if len(entries) == 0 || cdrStorage == nil {
+		return
+	}
+	if err := cdrStorage.SetBalanceLedgerEntries(entries); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<BalanceLedger> Could not store ledger entries for account: %s, error: %s", acc.ID, err.Error()))
+	}
+}
+
+// saveAccount stores the account followed by its ledger entries and subscription charges
+func saveAccount(acc *Account) error {
+	if err := dataStorage.SetAccount(acc); err != nil {
+		return err
+	}
+	acc.storeLedger()
+	acc.storeSubscriptionCDRs()
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestBalanceLedgerScopes(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:ledger",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "m1", ID: "MONEY", Value: 10}},
+			utils.VOICE: Balances{&Balance{Uuid: "v1", ID: "MINUTES", Value: 60,
+				ExpirationDate: time.Now().Add(-time.Minute)}},
+		}}
+	endDebit := acc.ledgerScope(DEBIT, "cgrid1")
+	acc.BalanceMap[utils.MONETARY][0].SubstractValue(2)
+	endTopup := acc.ledgerScope(TOPUP, "TOPUP_5")
+	acc.BalanceMap[utils.MONETARY][0].AddValue(5)
+	acc.BalanceMap[utils.MONETARY] = append(acc.BalanceMap[utils.MONETARY], &Balance{Uuid: "m2", ID: "BONUS", Value: 3})
+	endTopup()
+	acc.BalanceMap[utils.MONETARY][0].SubstractValue(1)
+	acc.CleanExpiredStuff()
+	endDebit()
+	for _, entry := range acc.ledger.entries {
+		entry.CreatedAt = time.Time{}
+	}
+	eEntries := []*BalanceLedgerEntry{
+		&BalanceLedgerEntry{AccountID: acc.ID, BalanceUUID: "m1", BalanceID: "MONEY", BalanceType: utils.MONETARY,
+			ValueBefore: 10, ValueAfter: 8, Operation: DEBIT, Cause: "cgrid1"},
+		&BalanceLedgerEntry{AccountID: acc.ID, BalanceUUID: "m1", BalanceID: "MONEY", BalanceType: utils.MONETARY,
+			ValueBefore: 8, ValueAfter: 13, Operation: TOPUP, Cause: "TOPUP_5"},
+		&BalanceLedgerEntry{AccountID: acc.ID, BalanceUUID: "m2", BalanceID: "BONUS", BalanceType: utils.MONETARY,
+			ValueBefore: 0, ValueAfter: 3, Operation: TOPUP, Cause: "TOPUP_5"},
+		&BalanceLedgerEntry{AccountID: acc.ID, BalanceUUID: "m1", BalanceID: "MONEY", BalanceType: utils.MONETARY,
+			ValueBefore: 13, ValueAfter: 12, Operation: DEBIT, Cause: "cgrid1"},
+		&BalanceLedgerEntry{AccountID: acc.ID, BalanceUUID: "v1", BalanceID: "MINUTES", BalanceType: utils.VOICE,
+			ValueBefore: 60, ValueAfter: 0, Operation: utils.MetaExpired, Cause: "cgrid1"},
+	}
+	if !reflect.DeepEqual(eEntries, acc.ledger.entries) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eEntries), utils.ToJSON(acc.ledger.entries))
+	}
+	acc.storeLedger()
+	if len(acc.ledger.entries) != 0 {
+		t.Errorf("Entries not flushed: %s", utils.ToJSON(acc.ledger.entries))
+	}
+}
+
+func TestBalanceLedgerInheritScope(t *testing.T) {
+	owner := &Account{ID: "cgrates.org:owner"}
+	member := &Account{ID: "cgrates.org:member",
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "sg1", Value: 5, SharedGroups: utils.NewStringMap("SG1")}},
+		}}
+	member.inheritLedgerScope(owner)
+	if member.ledger != nil {
+		t.Error("Ledger started without owner scope")
+	}
+	owner.ledgerScope(DEBIT, "cgrid2")
+	member.inheritLedgerScope(owner)
+	member.BalanceMap[utils.MONETARY][0].SubstractValue(5)
+	member.recordLedger()
+	if len(member.ledger.entries) != 1 ||
+		member.ledger.entries[0].Operation != DEBIT || member.ledger.entries[0].Cause != "cgrid2" ||
+		member.ledger.entries[0].ValueBefore != 5 || member.ledger.entries[0].ValueAfter != 0 {
+		t.Errorf("Unexpected entries: %s", utils.ToJSON(member.ledger.entries))
+	}
+}
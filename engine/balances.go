@@ -45,8 +45,11 @@ type Balance struct {
 	Disabled       bool
 	Factor         ValueFactor
 	Blocker        bool
+	CreditLimit    float64 // amount the balance can be debited below zero
+	Currency       string  // currency of monetary balances, empty for the default one
 	precision      int
-	account        *Account // used to store ub reference for shared balances
+	account        *Account       // used to store ub reference for shared balances
+	sharedUsage    *SpendingLimit // consumption of the debited account out of the shared group of the balance
 	dirty          bool
 }
 
@@ -64,6 +67,7 @@ func (b *Balance) Equal(o *Balance) bool {
 		b.DestinationIDs.Equal(o.DestinationIDs) &&
 		b.Directions.Equal(o.Directions) &&
 		b.RatingSubject == o.RatingSubject &&
+		b.Currency == o.Currency &&
 		b.Categories.Equal(o.Categories) &&
 		b.SharedGroups.Equal(o.SharedGroups) &&
 		b.Disabled == o.Disabled &&
@@ -89,7 +93,8 @@ func (b *Balance) MatchFilter(o *BalanceFilter, skipIds bool) bool {
 		(o.Categories == nil || b.Categories.Includes(*o.Categories)) &&
 		(o.TimingIDs == nil || b.TimingIDs.Includes(*o.TimingIDs)) &&
 		(o.SharedGroups == nil || b.SharedGroups.Includes(*o.SharedGroups)) &&
-		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject)
+		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject) &&
+		(o.Currency == nil || b.Currency == *o.Currency)
 }
 
 func (b *Balance) HardMatchFilter(o *BalanceFilter, skipIds bool) bool {
@@ -111,7 +116,8 @@ func (b *Balance) HardMatchFilter(o *BalanceFilter, skipIds bool) bool {
 		(o.Categories == nil || b.Categories.Equal(*o.Categories)) &&
 		(o.TimingIDs == nil || b.TimingIDs.Equal(*o.TimingIDs)) &&
 		(o.SharedGroups == nil || b.SharedGroups.Equal(*o.SharedGroups)) &&
-		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject)
+		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject) &&
+		(o.Currency == nil || b.Currency == *o.Currency)
 }
 
 // the default balance has standard Id
@@ -180,6 +186,8 @@ func (b *Balance) Clone() *Balance {
 		Timings:        b.Timings, // should not be a problem with aliasing
 		Blocker:        b.Blocker,
 		Disabled:       b.Disabled,
+		CreditLimit:    b.CreditLimit,
+		Currency:       b.Currency,
 		dirty:          b.dirty,
 	}
 	if b.DestinationIDs != nil {
@@ -209,7 +217,7 @@ func (b *Balance) getMatchingPrefixAndDestID(dest string) (prefix, destId string
 // Returns the available number of seconds for a specified credit
 func (b *Balance) GetMinutesForCredit(origCD *CallDescriptor, initialCredit float64) (duration time.Duration, credit float64) {
 	cd := origCD.Clone()
-	availableDuration := time.Duration(b.GetValue()) * time.Second
+	availableDuration := time.Duration(b.availableValue(nil)) * time.Second // bounded by the shared group quota
 	duration = availableDuration
 	credit = initialCredit
 	cc, err := b.GetCost(cd, false)
@@ -290,6 +298,9 @@ func (b *Balance) AddValue(amount float64) {
 
 func (b *Balance) SubstractValue(amount float64) {
 	b.SetValue(b.GetValue() - amount)
+	if b.sharedUsage != nil {
+		b.sharedUsage.AddSpent(amount, time.Now())
+	}
 }
 
 func (b *Balance) SetValue(amount float64) {
@@ -305,7 +316,7 @@ func (b *Balance) SetDirty() {
 // debitUnits will debit units for call descriptor.
 // returns the amount debited within cc
 func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Balances, count bool, dryRun, debitConnectFee bool) (cc *CallCost, err error) {
-	if !b.IsActiveAt(cd.TimeStart) || b.GetValue() <= 0 {
+	if !b.IsActiveAt(cd.TimeStart) || b.availableValue(nil) <= 0 {
 		return
 	}
 	if duration, err := utils.ParseZeroRatingSubject(b.RatingSubject); err == nil {
@@ -352,7 +363,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 			if b.Factor != nil {
 				amount = utils.Round(amount/b.Factor.GetValue(cd.TOR), globalRoundingDecimals, utils.ROUNDING_UP)
 			}
-			if b.GetValue() >= amount {
+			if b.availableValue(nil) >= amount {
 				b.SubstractValue(amount)
 				inc.BalanceInfo.Unit = &UnitInfo{
 					UUID:          b.Uuid,
@@ -387,7 +398,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 	} else {
 		// get the cost from balance
 		//log.Printf("::::::: %+v", cd)
-		var debitedConnectFeeBalance Balance
+		var connectFeeInfo *MonetaryInfo
 		var ok bool
 		cc, err = b.GetCost(cd, true)
 		if err != nil {
@@ -395,7 +406,9 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 		}
 		if debitConnectFee {
 			// this is the first add, debit the connect fee
-			if ok, debitedConnectFeeBalance = ub.DebitConnectionFee(cc, moneyBalances, count, true); !ok {
+			if ok, connectFeeInfo, err = ub.DebitConnectionFee(cc, moneyBalances, count, true); err != nil {
+				return nil, err
+			} else if !ok {
 				// found blocker balance
 				return nil, nil
 			}
@@ -418,11 +431,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					Duration: 0,
 					Cost:     ts.RateInterval.Rating.ConnectFee,
 					BalanceInfo: &DebitInfo{
-						Monetary: &MonetaryInfo{
-							UUID:  debitedConnectFeeBalance.Uuid,
-							ID:    debitedConnectFeeBalance.ID,
-							Value: debitedConnectFeeBalance.Value,
-						},
+						Monetary:  connectFeeInfo,
 						AccountID: ub.ID,
 					},
 				}
@@ -476,17 +485,25 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					continue
 				}
 				var moneyBal *Balance
+				var moneyCost, exRate float64
 				for _, mb := range moneyBalances {
-					if mb.GetValue() >= cost {
+					if exRate, err = mb.exchangeRate(ts.RateInterval.Currency(), ts.TimeStart); err != nil {
+						return nil, err
+					}
+					if moneyCost = convertCost(cost, exRate); mb.availableValue(mb.account) >= moneyCost {
 						moneyBal = mb
 						break
 					}
 				}
-				if cost != 0 && moneyBal == nil && (!dryRun || ub.AllowNegative) { // Fix for issue #685
+				if cost != 0 && moneyBal == nil && (!dryRun || ub.AllowNegative) && ub.unlimitedNegative() { // Fix for issue #685
 					utils.Logger.Warning(fmt.Sprintf("<RALs> Going negative on account %s with AllowNegative: false", cd.GetAccountKey()))
 					moneyBal = ub.GetDefaultMoneyBalance()
+					if exRate, err = moneyBal.exchangeRate(ts.RateInterval.Currency(), ts.TimeStart); err != nil {
+						return nil, err
+					}
+					moneyCost = convertCost(cost, exRate)
 				}
-				if b.GetValue() >= amount && (moneyBal != nil || cost == 0) {
+				if b.availableValue(nil) >= amount && (moneyBal != nil || cost == 0) {
 					b.SubstractValue(amount)
 					inc.BalanceInfo.Unit = &UnitInfo{
 						UUID:          b.Uuid,
@@ -499,11 +516,12 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					}
 					inc.BalanceInfo.AccountID = ub.ID
 					if cost != 0 {
-						moneyBal.SubstractValue(cost)
+						moneyBal.SubstractValue(moneyCost)
 						inc.BalanceInfo.Monetary = &MonetaryInfo{
-							UUID:  moneyBal.Uuid,
-							ID:    moneyBal.ID,
-							Value: moneyBal.Value,
+							UUID:         moneyBal.Uuid,
+							ID:           moneyBal.ID,
+							Value:        moneyBal.Value,
+							ExchangeRate: exRate,
 						}
 						cd.MaxCostSoFar += cost
 					}
@@ -511,7 +529,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					if count {
 						ub.countUnits(amount, cc.TOR, cc, b)
 						if cost != 0 {
-							ub.countUnits(cost, utils.MONETARY, cc, moneyBal)
+							ub.countUnits(moneyCost, utils.MONETARY, cc, moneyBal)
 						}
 					}
 				} else {
@@ -536,7 +554,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 }
 
 func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Balances, count bool, dryRun, debitConnectFee bool) (cc *CallCost, err error) {
-	if !b.IsActiveAt(cd.TimeStart) || b.GetValue() <= 0 {
+	if !b.IsActiveAt(cd.TimeStart) || b.availableValue(ub) <= 0 {
 		return
 	}
 	//log.Print("B: ", utils.ToJSON(b))
@@ -546,13 +564,15 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 		return nil, err
 	}
 
-	var debitedConnectFeeBalance Balance
+	var connectFeeInfo *MonetaryInfo
 	var ok bool
 	//log.Print("cc: " + utils.ToJSON(cc))
 	if debitConnectFee {
 
 		// this is the first add, debit the connect fee
-		if ok, debitedConnectFeeBalance = ub.DebitConnectionFee(cc, moneyBalances, count, true); !ok {
+		if ok, connectFeeInfo, err = ub.DebitConnectionFee(cc, moneyBalances, count, true); err != nil {
+			return nil, err
+		} else if !ok {
 			// balance is blocker
 			return nil, nil
 		}
@@ -579,11 +599,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				Duration: 0,
 				Cost:     ts.RateInterval.Rating.ConnectFee,
 				BalanceInfo: &DebitInfo{
-					Monetary: &MonetaryInfo{
-						UUID:  debitedConnectFeeBalance.Uuid,
-						ID:    debitedConnectFeeBalance.ID,
-						Value: debitedConnectFeeBalance.Value,
-					},
+					Monetary:  connectFeeInfo,
 					AccountID: ub.ID,
 				},
 			}
@@ -593,6 +609,10 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 		}
 
 		maxCost, strategy := ts.RateInterval.GetMaxCost()
+		var exRate float64
+		if exRate, err = b.exchangeRate(ts.RateInterval.Currency(), ts.TimeStart); err != nil {
+			return nil, err
+		}
 		//log.Printf("Timing: %+v", ts.RateInterval.Timing)
 		//log.Printf("Rate: %+v", ts.RateInterval.Rating)
 		for incIndex, inc := range ts.Increments {
@@ -604,7 +624,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				continue
 			}
 
-			amount := inc.Cost
+			amount := convertCost(inc.Cost, exRate)
 			inc.paid = false
 			if strategy == utils.MAX_COST_DISCONNECT && cd.MaxCostSoFar >= maxCost {
 				// cut the entire current timespan
@@ -623,9 +643,10 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 			if strategy == utils.MAX_COST_FREE && cd.MaxCostSoFar >= maxCost {
 				amount, inc.Cost = 0.0, 0.0
 				inc.BalanceInfo.Monetary = &MonetaryInfo{
-					UUID:  b.Uuid,
-					ID:    b.ID,
-					Value: b.Value,
+					UUID:        b.Uuid,
+					ID:          b.ID,
+					Value:       b.Value,
+					TierCounter: ts.RateInterval.TierCounter(),
 				}
 				inc.BalanceInfo.AccountID = ub.ID
 				if b.RatingSubject != "" {
@@ -634,6 +655,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				inc.paid = true
 				if count {
 					ub.countUnits(amount, utils.MONETARY, cc, b)
+					ub.countTierUsage(inc, ts.RateInterval, cc.TOR)
 				}
 
 				//log.Printf("TS: %+v", cc.Cost)
@@ -641,13 +663,15 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				continue
 			}
 
-			if b.GetValue() >= amount {
+			if b.availableValue(ub) >= amount {
 				b.SubstractValue(amount)
-				cd.MaxCostSoFar += amount
+				cd.MaxCostSoFar += inc.Cost
 				inc.BalanceInfo.Monetary = &MonetaryInfo{
-					UUID:  b.Uuid,
-					ID:    b.ID,
-					Value: b.Value,
+					UUID:         b.Uuid,
+					ID:           b.ID,
+					Value:        b.Value,
+					ExchangeRate: exRate,
+					TierCounter:  ts.RateInterval.TierCounter(),
 				}
 				inc.BalanceInfo.AccountID = ub.ID
 				if b.RatingSubject != "" {
@@ -656,6 +680,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				inc.paid = true
 				if count {
 					ub.countUnits(amount, utils.MONETARY, cc, b)
+					ub.countTierUsage(inc, ts.RateInterval, cc.TOR)
 				}
 			} else {
 				inc.paid = false
@@ -683,7 +708,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 
 // Converts the balance towards compressed information to be displayed
 func (b *Balance) AsBalanceSummary(typ string) *BalanceSummary {
-	bd := &BalanceSummary{UUID: b.Uuid, ID: b.ID, Type: typ, Value: b.Value, Disabled: b.Disabled}
+	bd := &BalanceSummary{UUID: b.Uuid, ID: b.ID, Type: typ, Value: b.Value, Disabled: b.Disabled, Currency: b.Currency}
 	if bd.ID == "" {
 		bd.ID = b.Uuid
 	}
@@ -797,7 +822,7 @@ func (bc Balances) SaveDirtyBalances(acc *Account) {
 			}
 		}
 		if b.account != nil && b.account != acc && b.dirty && savedAccounts[b.account.ID] == false {
-			dataStorage.SetAccount(b.account)
+			saveAccount(b.account)
 			savedAccounts[b.account.ID] = true
 		}
 	}
@@ -819,4 +844,5 @@ type BalanceSummary struct {
 	Type     string // *voice, *data, etc
 	Value    float64
 	Disabled bool
+	Currency string // currency of monetary balances, empty for the default one
 }
@@ -201,11 +201,14 @@ func (cc *CallCost) Round() {
 			ts.RateInterval.Rating.RoundingDecimals,
 			ts.RateInterval.Rating.RoundingMethod)
 		correctionCost := roundedCost - cost
+		parentCorrections := ts.parentsRounding()
 		//log.Print(cost, roundedCost, correctionCost)
-		if correctionCost != 0 {
+		if correctionCost != 0 || len(parentCorrections) != 0 {
+			balanceInfo := inc.BalanceInfo.Clone()
+			balanceInfo.Parents = parentCorrections
 			ts.RoundIncrement = &Increment{
 				Cost:        correctionCost,
-				BalanceInfo: inc.BalanceInfo,
+				BalanceInfo: balanceInfo,
 			}
 			totalCorrectionCost += correctionCost
 			ts.Cost += correctionCost
@@ -216,7 +219,7 @@ func (cc *CallCost) Round() {
 
 func (cc *CallCost) GetRoundIncrements() (roundIncrements Increments) {
 	for _, ts := range cc.Timespans {
-		if ts.RoundIncrement != nil && ts.RoundIncrement.Cost != 0 {
+		if ts.RoundIncrement != nil && (ts.RoundIncrement.Cost != 0 || len(ts.RoundIncrement.BalanceInfo.Parents) != 0) {
 			roundIncrements = append(roundIncrements, ts.RoundIncrement)
 		}
 	}
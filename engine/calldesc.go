@@ -74,6 +74,7 @@ var (
 	aliasService             rpcclient.RpcClientConnection
 	rpSubjectPrefixMatching  bool
 	lcrSubjectPrefixMatching bool
+	defaultCurrency          string
 )
 
 // Exported method to set the storage getter.
@@ -94,6 +95,11 @@ func SetLcrSubjectPrefixMatching(flag bool) {
 	lcrSubjectPrefixMatching = flag
 }
 
+// SetDefaultCurrency sets the currency of the rates and monetary balances not specifying one
+func SetDefaultCurrency(currency string) {
+	defaultCurrency = currency
+}
+
 /*
 Sets the database for CDR storing, used by *cdrlog in first place
 */
@@ -148,10 +154,12 @@ type CallDescriptor struct {
 	RunID               string
 	ForceDuration       bool // for Max debit if less than duration return err
 	PerformRounding     bool // flag for rating info rounding
-	DryRun              bool
+	DryRun              bool // debit without saving the account, giving back first on it the Increments debited before
 	DenyNegativeAccount bool // prevent account going on negative during debit
 	account             *Account
-	testCallcost        *CallCost // testing purpose only!
+	tierCounters        UnitCounters // account counters positioning the usage on tiered rates
+	ratingDB            *tpRatingDB  // rating data out of a tariff plan other than the active one
+	testCallcost        *CallCost    // testing purpose only!
 }
 
 func (cd *CallDescriptor) ValidateCallData() error {
@@ -214,7 +222,7 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 	if recursionDepth > RECURSION_MAX_DEPTH {
 		return utils.ErrMaxRecursionDepth, recursionDepth
 	}
-	rpf, err := RatingProfileSubjectPrefixMatching(key)
+	rpf, err := cd.ratingProfileSubjectPrefixMatching(key)
 	if err != nil || rpf == nil {
 		return utils.ErrNotFound, recursionDepth
 	}
@@ -233,6 +241,7 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 					Direction:   cd.Direction,
 					Tenant:      cd.Tenant,
 					Destination: cd.Destination,
+					ratingDB:    cd.ratingDB,
 				}
 				if index == 0 {
 					tempCD.TimeStart = cd.TimeStart
@@ -395,6 +404,9 @@ func (cd *CallDescriptor) splitInTimeSpans() (timespans []*TimeSpan) {
 		//log.Print(timespans[i].RateInterval)
 		for _, interval := range rateIntervals {
 			//log.Printf("\tINTERVAL: %+v", interval.Timing)
+			if counterID := interval.TierCounter(); counterID != "" {
+				interval = interval.withTierOffset(cd.tierOffset(counterID))
+			}
 			newTs := timespans[i].SplitByRateInterval(interval, cd.TOR != utils.VOICE)
 			//utils.PrintFull(timespans[i])
 			//utils.PrintFull(newTs)
@@ -455,6 +467,7 @@ Creates a CallCost structure with the cost information calculated for the receiv
 */
 func (cd *CallDescriptor) GetCost() (*CallCost, error) {
 	cd.account = nil // make sure it's not cached
+	cd.tierCounters = nil
 	cc, err := cd.getCost()
 	if err != nil || cd.GetDuration() == 0 {
 		return cc, err
@@ -569,11 +582,12 @@ func (origCD *CallDescriptor) getMaxSessionDuration(origAcc *Account) (time.Dura
 		origCD.TOR = utils.VOICE
 	}
 	cd := origCD.Clone()
+	cd.tierCounters = origAcc.UnitCounters
 	initialDuration := cd.TimeEnd.Sub(cd.TimeStart)
 	defaultBalance := account.GetDefaultMoneyBalance()
 
 	//use this to check what increment was payed with debt
-	initialDefaultBalanceValue := defaultBalance.GetValue()
+	initialDefaultBalanceValue := defaultBalance.availableValue(account)
 
 	cc, err := cd.debit(account, true, false)
 	if err != nil {
@@ -601,7 +615,7 @@ func (origCD *CallDescriptor) getMaxSessionDuration(origAcc *Account) (time.Dura
 		for _, incr := range ts.Increments {
 			totalCost += incr.Cost
 			if incr.BalanceInfo.Monetary != nil && incr.BalanceInfo.Monetary.UUID == defaultBalance.Uuid {
-				initialDefaultBalanceValue -= incr.Cost
+				initialDefaultBalanceValue -= incr.BalanceInfo.Monetary.ChargedAmount(incr.Cost)
 				if initialDefaultBalanceValue < 0 {
 					// this increment was payed with debt
 					// TODO: improve this check
@@ -637,8 +651,16 @@ func (cd *CallDescriptor) GetMaxSessionDuration() (duration time.Duration, err e
 				lkIDs = append(lkIDs, utils.ACCOUNT_PREFIX+acntID)
 			}
 		}
+		parents, err := account.getParents()
+		if err != nil {
+			return nil, err
+		}
+		lkIDs = append(lkIDs, parentLockIDs(parents, acntIDs)...)
 		_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
-			duration, err = cd.getMaxSessionDuration(account)
+			if parents, err = account.getParents(); err != nil { // fresh balances once locked
+				return
+			}
+			duration, err = cd.getMaxSessionDurationWithParents(account, parents)
 			return
 		}, 0, lkIDs...)
 		return
@@ -666,6 +688,12 @@ func (cd *CallDescriptor) debit(account *Account, dryRun bool, goNegative bool)
 	if cd.TOR == "" {
 		cd.TOR = utils.VOICE
 	}
+	if !dryRun {
+		defer account.ledgerScope(DEBIT, cd.CgrID)()
+	}
+	if account.UnitCounters != nil { // dry run clones come without counters
+		cd.tierCounters = account.UnitCounters
+	}
 	//log.Printf("Debit CD: %+v", cd)
 	cc, err = account.debitCreditBalance(cd, !dryRun, dryRun, goNegative)
 	//log.Printf("HERE: %+v %v", cc, err)
@@ -677,12 +705,15 @@ func (cd *CallDescriptor) debit(account *Account, dryRun bool, goNegative bool)
 	cc.UpdateRatedUsage()
 	cc.Timespans.Compress()
 	if !dryRun {
-		dataStorage.SetAccount(account)
+		if account.SpendingLimit != nil {
+			account.SpendingLimit.AddSpent(cc.spentOnAccount(account.ID), time.Now())
+		}
+		saveAccount(account)
 	}
 	if cd.PerformRounding {
 		cc.Round()
 		roundIncrements := cc.GetRoundIncrements()
-		if len(roundIncrements) != 0 {
+		if len(roundIncrements) != 0 && !dryRun {
 			rcd := cc.CreateCallDescriptor()
 			rcd.Increments = roundIncrements
 			rcd.refundRounding()
@@ -700,6 +731,10 @@ func (cd *CallDescriptor) Debit() (cc *CallCost, err error) {
 		if err != nil {
 			return nil, err
 		}
+		if cd.DryRun && len(cd.Increments) != 0 { // rating again traffic debited before
+			cd.Increments.Decompress()
+			account.refundIncrementsDryRun(cd.Increments, cd.TOR)
+		}
 		acntIDs, sgerr := account.GetUniqueSharedGroupMembers(cd)
 		if sgerr != nil {
 			return nil, sgerr
@@ -710,11 +745,16 @@ func (cd *CallDescriptor) Debit() (cc *CallCost, err error) {
 				lkIDs = append(lkIDs, utils.ACCOUNT_PREFIX+acntID)
 			}
 		}
+		parents, err := account.getParents()
+		if err != nil {
+			return nil, err
+		}
+		lkIDs = append(lkIDs, parentLockIDs(parents, acntIDs)...)
 		_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
-			cc, err = cd.debit(account, cd.DryRun, !cd.DenyNegativeAccount)
-			if err == nil {
-				cc.AccountSummary = cd.AccountSummary()
+			if parents, err = account.getParents(); err != nil {
+				return
 			}
+			cc, err = cd.debitWithParents(account, parents)
 			return
 		}, 0, lkIDs...)
 		return
@@ -744,13 +784,21 @@ func (cd *CallDescriptor) MaxDebit() (cc *CallCost, err error) {
 				lkIDs = append(lkIDs, utils.ACCOUNT_PREFIX+acntID)
 			}
 		}
+		parents, err := account.getParents()
+		if err != nil {
+			return nil, err
+		}
+		lkIDs = append(lkIDs, parentLockIDs(parents, acntIDs)...)
 		_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
-			remainingDuration, err := cd.getMaxSessionDuration(account)
+			if parents, err = account.getParents(); err != nil {
+				return
+			}
+			remainingDuration, err := cd.getMaxSessionDurationWithParents(account, parents)
 			if err != nil && cd.GetDuration() > 0 {
 				return nil, err
 			}
 			// check ForceDuartion
-			if cd.ForceDuration && !account.AllowNegative && remainingDuration < cd.GetDuration() {
+			if cd.ForceDuration && remainingDuration != -1 && remainingDuration < cd.GetDuration() {
 				return nil, utils.ErrInsufficientCredit
 			}
 			//log.Print("AFTER MAX SESSION: ", cd)
@@ -779,10 +827,7 @@ func (cd *CallDescriptor) MaxDebit() (cc *CallCost, err error) {
 				cd.DurationIndex -= initialDuration - remainingDuration
 			}
 			//log.Print("Remaining duration: ", remainingDuration)
-			cc, err = cd.debit(account, cd.DryRun, !cd.DenyNegativeAccount)
-			if err == nil {
-				cc.AccountSummary = cd.AccountSummary()
-			}
+			cc, err = cd.debitWithParents(account, parents)
 			//log.Print(balanceMap[0].Value, balanceMap[1].Value)
 			return
 		}, 0, lkIDs...)
@@ -794,16 +839,11 @@ func (cd *CallDescriptor) MaxDebit() (cc *CallCost, err error) {
 // refundIncrements has no locks
 func (cd *CallDescriptor) refundIncrements() (err error) {
 	accountsCache := make(map[string]*Account)
+	// will save the accounts only once at the end of the function
+	defer saveRefundedAccounts(accountsCache)
 	for _, increment := range cd.Increments {
-		account, found := accountsCache[increment.BalanceInfo.AccountID]
-		if !found {
-			if acc, err := dataStorage.GetAccount(increment.BalanceInfo.AccountID); err == nil && acc != nil {
-				account = acc
-				accountsCache[increment.BalanceInfo.AccountID] = account
-				// will save the account only once at the end of the function
-				defer dataStorage.SetAccount(account)
-			}
-		}
+		cd.refundParents(increment.BalanceInfo.Parents, 1, accountsCache)
+		account := cd.getRefundedAccount(increment.BalanceInfo.AccountID, accountsCache)
 		if account == nil {
 			utils.Logger.Warning(fmt.Sprintf("Could not get the account to be refunded: %s", increment.BalanceInfo.AccountID))
 			continue
@@ -818,14 +858,23 @@ func (cd *CallDescriptor) refundIncrements() (err error) {
 			}
 			balance.AddValue(increment.Duration.Seconds())
 			account.countUnits(-increment.Duration.Seconds(), unitType, cc, balance)
+			cd.refundSharedGroupUsage(balance, unitType, increment.Duration.Seconds(), accountsCache)
 		}
 		// check money too
 		if increment.BalanceInfo.Monetary != nil && increment.BalanceInfo.Monetary.UUID != "" {
 			if balance = account.BalanceMap[utils.MONETARY].GetBalance(increment.BalanceInfo.Monetary.UUID); balance == nil {
 				return
 			}
-			balance.AddValue(increment.Cost)
-			account.countUnits(-increment.Cost, utils.MONETARY, cc, balance)
+			charged := increment.BalanceInfo.Monetary.ChargedAmount(increment.Cost)
+			balance.AddValue(charged)
+			account.countUnits(-charged, utils.MONETARY, cc, balance)
+			cd.refundSharedGroupUsage(balance, utils.MONETARY, charged, accountsCache)
+			if counterID := increment.BalanceInfo.Monetary.TierCounter; counterID != "" {
+				account.countTierUnits(-increment.Duration.Seconds(), unitType, counterID)
+			}
+			if account.SpendingLimit != nil {
+				account.SpendingLimit.AddSpent(-increment.Cost, time.Now())
+			}
 		}
 	}
 	return
@@ -837,6 +886,9 @@ func (cd *CallDescriptor) RefundIncrements() (err error) {
 	// all must be locked in order to use cache
 	cd.Increments.Decompress()
 	accMap := make(utils.StringMap)
+	if cd.Account != "" { // shared group usage is counted on the debited account
+		accMap[utils.ACCOUNT_PREFIX+cd.GetAccountKey()] = true
+	}
 	for _, increment := range cd.Increments {
 		if increment.BalanceInfo == nil {
 			continue
@@ -844,6 +896,9 @@ func (cd *CallDescriptor) RefundIncrements() (err error) {
 		if increment.BalanceInfo.Monetary != nil || increment.BalanceInfo.Unit != nil {
 			accMap[utils.ACCOUNT_PREFIX+increment.BalanceInfo.AccountID] = true
 		}
+		for _, pd := range increment.BalanceInfo.Parents {
+			accMap[utils.ACCOUNT_PREFIX+pd.AccountID] = true
+		}
 	}
 	_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
 		err = cd.refundIncrements()
@@ -856,16 +911,11 @@ func (cd *CallDescriptor) refundRounding() (err error) {
 	// get account list for locking
 	// all must be locked in order to use cache
 	accountsCache := make(map[string]*Account)
+	// will save the accounts only once at the end of the function
+	defer saveRefundedAccounts(accountsCache)
 	for _, increment := range cd.Increments {
-		account, found := accountsCache[increment.BalanceInfo.AccountID]
-		if !found {
-			if acc, err := dataStorage.GetAccount(increment.BalanceInfo.AccountID); err == nil && acc != nil {
-				account = acc
-				accountsCache[increment.BalanceInfo.AccountID] = account
-				// will save the account only once at the end of the function
-				defer dataStorage.SetAccount(account)
-			}
-		}
+		cd.refundParents(increment.BalanceInfo.Parents, -1, accountsCache)
+		account := cd.getRefundedAccount(increment.BalanceInfo.AccountID, accountsCache)
 		if account == nil {
 			utils.Logger.Warning(fmt.Sprintf("Could not get the account to be refunded: %s", increment.BalanceInfo.AccountID))
 			continue
@@ -876,8 +926,13 @@ func (cd *CallDescriptor) refundRounding() (err error) {
 			if balance = account.BalanceMap[utils.MONETARY].GetBalance(increment.BalanceInfo.Monetary.UUID); balance == nil {
 				return
 			}
-			balance.AddValue(-increment.Cost)
-			account.countUnits(increment.Cost, utils.MONETARY, cc, balance)
+			charged := increment.BalanceInfo.Monetary.ChargedAmount(increment.Cost)
+			balance.AddValue(-charged)
+			account.countUnits(charged, utils.MONETARY, cc, balance)
+			cd.refundSharedGroupUsage(balance, utils.MONETARY, -charged, accountsCache)
+			if account.SpendingLimit != nil {
+				account.SpendingLimit.AddSpent(increment.Cost, time.Now())
+			}
 		}
 	}
 	return
@@ -885,8 +940,14 @@ func (cd *CallDescriptor) refundRounding() (err error) {
 
 func (cd *CallDescriptor) RefundRounding() (err error) {
 	accMap := make(utils.StringMap)
+	if cd.Account != "" { // shared group usage is counted on the debited account
+		accMap[utils.ACCOUNT_PREFIX+cd.GetAccountKey()] = true
+	}
 	for _, inc := range cd.Increments {
 		accMap[utils.ACCOUNT_PREFIX+inc.BalanceInfo.AccountID] = true
+		for _, pd := range inc.BalanceInfo.Parents {
+			accMap[utils.ACCOUNT_PREFIX+pd.AccountID] = true
+		}
 	}
 	_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
 		err = cd.refundRounding()
@@ -895,6 +956,72 @@ func (cd *CallDescriptor) RefundRounding() (err error) {
 	return
 }
 
+// refundIncrementsDryRun gives back on the account the increments it was debited with, in memory only,
+// without counters or triggers since the account is not saved
+func (acc *Account) refundIncrementsDryRun(increments Increments, unitType string) {
+	if unitType == "" {
+		unitType = utils.VOICE
+	}
+	for _, increment := range increments {
+		if increment.BalanceInfo == nil || increment.BalanceInfo.AccountID != acc.ID {
+			continue
+		}
+		if unit := increment.BalanceInfo.Unit; unit != nil && unit.UUID != "" {
+			if balance := acc.BalanceMap[unitType].GetBalance(unit.UUID); balance != nil {
+				balance.AddValue(increment.Duration.Seconds())
+			}
+		}
+		if monetary := increment.BalanceInfo.Monetary; monetary != nil && monetary.UUID != "" {
+			if balance := acc.BalanceMap[utils.MONETARY].GetBalance(monetary.UUID); balance != nil {
+				balance.AddValue(monetary.ChargedAmount(increment.Cost))
+			}
+		}
+	}
+}
+
+// getRefundedAccount returns the account out of accountsCache, loading it on first use
+func (cd *CallDescriptor) getRefundedAccount(accountID string, accountsCache map[string]*Account) *Account {
+	if account, found := accountsCache[accountID]; found {
+		return account
+	}
+	account, err := dataStorage.GetAccount(accountID)
+	if err != nil || account == nil {
+		return nil
+	}
+	accountsCache[accountID] = account
+	account.ledgerScope(utils.MetaRefund, cd.CgrID)
+	return account
+}
+
+// saveRefundedAccounts stores the accounts changed by a refund
+func saveRefundedAccounts(accountsCache map[string]*Account) {
+	for _, account := range accountsCache {
+		saveAccount(account)
+	}
+}
+
+// refundParents gives back the parent debits recorded on an increment, a negative factor charging them instead
+func (cd *CallDescriptor) refundParents(parents ParentDebits, factor float64, accountsCache map[string]*Account) {
+	for _, pd := range parents {
+		account := cd.getRefundedAccount(pd.AccountID, accountsCache)
+		if account == nil {
+			utils.Logger.Warning(fmt.Sprintf("Could not get the parent account to be refunded: %s", pd.AccountID))
+			continue
+		}
+		balance := account.BalanceMap[pd.BalanceType].GetBalance(pd.BalanceUUID)
+		if balance == nil {
+			utils.Logger.Warning(fmt.Sprintf("Could not get the balance %s of parent account %s to be refunded", pd.BalanceUUID, pd.AccountID))
+			continue
+		}
+		value := factor * pd.Value
+		balance.AddValue(value)
+		account.countUnits(-value, pd.BalanceType, cd.CreateCallCost(), balance)
+		if pd.BalanceType == utils.MONETARY && account.SpendingLimit != nil {
+			account.SpendingLimit.AddSpent(-value, time.Now())
+		}
+	}
+}
+
 // Creates a CallCost structure copying related data from CallDescriptor
 func (cd *CallDescriptor) CreateCallCost() *CallCost {
 	return &CallCost{
@@ -933,6 +1060,7 @@ func (cd *CallDescriptor) Clone() *CallDescriptor {
 		DryRun:          cd.DryRun,
 		CgrID:           cd.CgrID,
 		RunID:           cd.RunID,
+		ratingDB:        cd.ratingDB,
 	}
 }
 
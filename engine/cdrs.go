@@ -91,7 +91,10 @@ func NewCdrServer(cgrCfg *config.CGRConfig, cdrDb CdrStorage, dataDB DataDB, rat
 	return &CdrServer{cgrCfg: cgrCfg, cdrDb: cdrDb, dataDB: dataDB,
 		rals: rater, pubsub: pubsub, users: users, aliases: aliases,
 		cdrstats: cdrstats, stats: stats, guard: guardian.Guardian,
-		httpPoster: utils.NewHTTPPoster(cgrCfg.HttpSkipTlsVerify, cgrCfg.ReplyTimeout)}, nil
+		httpPoster: utils.NewHTTPPoster(cgrCfg.HttpSkipTlsVerify, cgrCfg.ReplyTimeout),
+		dedup: NewCDRDeduplicator(cgrCfg.CDRSDedupFields, cgrCfg.CDRSDedupTimeTolerance,
+			cgrCfg.CDRSDedupWindow, cgrCfg.CDRSDedupPolicy),
+		rerateJobs: newRerateJobs(cgrCfg.CDRSRerateJobTTL), retention: new(cdrsRetention)}, nil
 }
 
 type CdrServer struct {
@@ -107,6 +110,9 @@ type CdrServer struct {
 	guard         *guardian.GuardianLock
 	responseCache *cache.ResponseCache
 	httpPoster    *utils.HTTPPoster // used for replication
+	dedup         *CDRDeduplicator  // nil when deduplication is disabled
+	rerateJobs    *rerateJobs       // re-rating jobs waiting for approval
+	retention     *cdrsRetention    // serializes the retention policies enforcement
 }
 
 func (self *CdrServer) Timezone() string {
@@ -128,6 +134,7 @@ func (self *CdrServer) RegisterHandlersToServer(server *utils.Server) {
 	cdrServer = self // Share the server object for handlers
 	server.RegisterHttpFunc("/cdr_http", cgrCdrHandler)
 	server.RegisterHttpFunc("/freeswitch_json", fsCdrHandler)
+	server.RegisterHttpFunc("/cdrs_stream", cdrsStreamHandler)
 }
 
 // Used to process external CDRs
@@ -182,6 +189,16 @@ func (self *CdrServer) processCdr(cdr *CDR) (err error) {
 	if cdr.RunID == utils.MetaRaw {
 		cdr.Cost = -1.0
 	}
+	if self.dedup != nil {
+		if len(cdr.CGRID) == 0 {
+			cdr.ComputeCGRID()
+		}
+		if dupCDR := self.dedup.Match(cdr); dupCDR != nil {
+			if processNew, err := self.handleDuplicateCDR(cdr, dupCDR); err != nil || !processNew {
+				return err
+			}
+		}
+	}
 	if self.cgrCfg.CDRSStoreCdrs { // Store RawCDRs, this we do sync so we can reply with the status
 		if cdr.CostDetails != nil {
 			cdr.CostDetails.UpdateCost()
@@ -216,6 +233,103 @@ func (self *CdrServer) processCdr(cdr *CDR) (err error) {
 	return nil
 }
 
+// handleDuplicateCDR applies the dedup policy on a CDR matching an already processed one
+// Returns true if the new CDR should continue being processed
+func (self *CdrServer) handleDuplicateCDR(cdr, dupCDR *CDR) (processNew bool, err error) {
+	switch self.cgrCfg.CDRSDedupPolicy {
+	case utils.MetaKeepNewest:
+		if self.cgrCfg.CDRSStoreCdrs {
+			var oldCDRs []*CDR
+			if oldCDRs, _, err = self.cdrDb.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{dupCDR.CGRID}}, false); err != nil &&
+				err != utils.ErrNotFound {
+				utils.Logger.Err(fmt.Sprintf("<CDRS> Querying duplicated CDR with CGRID: %s, got error: %s", dupCDR.CGRID, err.Error()))
+				return
+			}
+			for _, oldCDR := range oldCDRs { // give back what the replaced CDR has debited
+				if err = self.refundCDR(oldCDR); err != nil {
+					utils.Logger.Err(fmt.Sprintf("<CDRS> Refunding duplicated CDR with CGRID: %s, RunID: %s, got error: %s",
+						oldCDR.CGRID, oldCDR.RunID, err.Error()))
+					return
+				}
+			}
+			if _, _, err = self.cdrDb.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{dupCDR.CGRID}}, true); err != nil &&
+				err != utils.ErrNotFound {
+				utils.Logger.Err(fmt.Sprintf("<CDRS> Removing duplicated CDR with CGRID: %s, got error: %s", dupCDR.CGRID, err.Error()))
+				return
+			}
+			err = nil
+		}
+		self.dedup.RecordDiscarded(dupCDR, cdr)
+		return true, nil
+	case utils.MetaMergeExtraFields:
+		if self.cgrCfg.CDRSStoreCdrs { // the raw CDR together with its derived and rated runs
+			var storedCDRs []*CDR
+			if storedCDRs, _, err = self.cdrDb.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{dupCDR.CGRID}}, false); err != nil {
+				if err != utils.ErrNotFound {
+					utils.Logger.Err(fmt.Sprintf("<CDRS> Querying duplicated CDR with CGRID: %s, got error: %s", dupCDR.CGRID, err.Error()))
+					return
+				}
+				err = nil
+			}
+			for _, storedCDR := range storedCDRs {
+				mrgdCDR := storedCDR.Clone()
+				mergeExtraFields(mrgdCDR, cdr)
+				if err = self.cdrDb.SetCDR(mrgdCDR, true); err != nil {
+					utils.Logger.Err(fmt.Sprintf("<CDRS> Merging duplicated CDR %+v, got error: %s", mrgdCDR, err.Error()))
+					return
+				}
+			}
+		}
+	}
+	self.dedup.RecordDiscarded(cdr, dupCDR)
+	utils.Logger.Info(fmt.Sprintf("<CDRS> Discarding CDR with CGRID: %s, duplicate of CGRID: %s", cdr.CGRID, dupCDR.CGRID))
+	return
+}
+
+// mergeExtraFields adds to cdr the extra fields of dupCDR it does not have
+func mergeExtraFields(cdr, dupCDR *CDR) {
+	if cdr.ExtraFields == nil {
+		cdr.ExtraFields = make(map[string]string)
+	}
+	for fld, val := range dupCDR.ExtraFields {
+		if _, has := cdr.ExtraFields[fld]; !has {
+			cdr.ExtraFields[fld] = val
+		}
+	}
+}
+
+// refundCDR refunds on accounts the increments debited when rating the CDR
+func (self *CdrServer) refundCDR(cdr *CDR) error {
+	if self.rals == nil {
+		return nil
+	}
+	refundIncrements := cdr.debitedIncrements()
+	if len(refundIncrements) == 0 {
+		return nil
+	}
+	cd := cdr.CostDetails.CreateCallDescriptor()
+	cd.CgrID = cdr.CGRID
+	cd.RunID = cdr.RunID
+	cd.Increments = refundIncrements
+	cd.Increments.Compress()
+	var response float64
+	return self.rals.Call("Responder.RefundIncrements", cd, &response)
+}
+
+// debitedIncrements returns the increments debited on accounts when rating the CDR
+func (cdr *CDR) debitedIncrements() (incrs Increments) {
+	if cdr.RunID == utils.MetaRaw || cdr.CostDetails == nil ||
+		!utils.IsSliceMember([]string{utils.META_PREPAID, utils.PREPAID, utils.META_PSEUDOPREPAID, utils.PSEUDOPREPAID,
+			utils.META_POSTPAID, utils.POSTPAID}, cdr.RequestType) {
+		return nil
+	}
+	cdr.CostDetails.Timespans.Decompress()
+	for _, ts := range cdr.CostDetails.Timespans {
+		incrs = append(incrs, ts.Increments...)
+	}
+	return
+}
+
 // Returns error if not able to properly store the CDR, mediation is async since we can always recover offline
 func (self *CdrServer) deriveRateStoreStatsReplicate(cdr *CDR, store, cdrstats, replicate bool) error {
 	cdrRuns, err := self.deriveCdrs(cdr)
@@ -249,6 +363,15 @@ func (self *CdrServer) deriveRateStoreStatsReplicate(cdr *CDR, store, cdrstats,
 		}
 		ratedCDRs = append(ratedCDRs, rcvRatedCDRs...)
 	}
+	// Cost the traffic for the reseller accounts above the charged ones
+	for _, ratedCDR := range ratedCDRs {
+		rsCDRs, err := self.resellerCDRs(ratedCDR)
+		if err != nil {
+			utils.Logger.Err(fmt.Sprintf("<CDRS> Costing CDR %+v for resellers, got error: %s", ratedCDR, err.Error()))
+			continue
+		}
+		ratedCDRs = append(ratedCDRs, rsCDRs...)
+	}
 	// Request should be processed by SureTax
 	for _, ratedCDR := range ratedCDRs {
 		if ratedCDR.RunID == utils.META_SURETAX {
@@ -258,6 +381,17 @@ func (self *CdrServer) deriveRateStoreStatsReplicate(cdr *CDR, store, cdrstats,
 			}
 		}
 	}
+	// Tax the CDRs out of the local tax rules
+	if len(self.cgrCfg.CDRSLocalTaxRunIDs) != 0 {
+		for _, ratedCDR := range ratedCDRs {
+			if !utils.IsSliceMember(self.cgrCfg.CDRSLocalTaxRunIDs, ratedCDR.RunID) {
+				continue
+			}
+			if err := applyLocalTaxes(ratedCDR, self.cgrCfg.CDRSTaxClassField, self.cgrCfg.RoundingDecimals); err != nil {
+				utils.Logger.Err(fmt.Sprintf("<CDRS> Applying local taxes on CDR %+v, got error: %s", ratedCDR, err.Error()))
+			}
+		}
+	}
 	// Store AccountSummary if requested
 	if self.cgrCfg.CDRScdrAccountSummary {
 		for _, ratedCDR := range ratedCDRs {
@@ -426,6 +560,11 @@ func (self *CdrServer) rateCDR(cdr *CDR) ([]*CDR, error) {
 				cdrClone.CostSource = smCost.CostSource
 				cdrsRated = append(cdrsRated, cdrClone)
 			}
+			if unitCDRs, err := self.unitRunCDRs(cdr, cgrID); err != nil {
+				utils.Logger.Warning(fmt.Sprintf("<Cdrs> WARNING: Could not get the costs of additional units for cgrid: %s, runid: %s, error: %s", cdr.CGRID, cdr.RunID, err.Error()))
+			} else {
+				cdrsRated = append(cdrsRated, unitCDRs...)
+			}
 			return cdrsRated, nil
 		} else { //calculate CDR as for pseudoprepaid
 			utils.Logger.Warning(fmt.Sprintf("<Cdrs> WARNING: Could not find CallCostLog for cgrid: %s, source: %s, runid: %s, will recalculate", cdr.CGRID, utils.SESSION_MANAGER_SOURCE, cdr.RunID))
@@ -443,15 +582,38 @@ func (self *CdrServer) rateCDR(cdr *CDR) ([]*CDR, error) {
 	return []*CDR{cdr}, nil
 }
 
-// Retrive the cost from engine
-func (self *CdrServer) getCostFromRater(cdr *CDR) (*CallCost, error) {
-	cc := new(CallCost)
-	var err error
+// unitRunCDRs returns one CDR for each of the additional units charged by the session next to the usage of cdr,
+// out of the SMCosts stored by the session with RunID as <cdr.RunID>:<TOR of the units>
+func (self *CdrServer) unitRunCDRs(cdr *CDR, cgrID string) (unitCDRs []*CDR, err error) {
+	smCosts, err := self.cdrDb.GetSMCosts(cgrID, "", cdr.OriginHost, cdr.ExtraFields[utils.OriginIDPrefix])
+	if err != nil {
+		return nil, err
+	}
+	unitRunPrefix := cdr.RunID + utils.CONCATENATED_KEY_SEP
+	for _, smCost := range smCosts {
+		if !strings.HasPrefix(smCost.RunID, unitRunPrefix) || smCost.CostDetails == nil {
+			continue
+		}
+		unitCDR := cdr.Clone()
+		unitCDR.OriginID = smCost.OriginID
+		unitCDR.RunID = smCost.RunID
+		unitCDR.ToR = strings.TrimPrefix(smCost.RunID, unitRunPrefix)
+		unitCDR.Usage = time.Duration(smCost.Usage * utils.NANO_MULTIPLIER)
+		unitCDR.Cost = smCost.CostDetails.Cost
+		unitCDR.CostDetails = smCost.CostDetails
+		unitCDR.CostSource = smCost.CostSource
+		unitCDRs = append(unitCDRs, unitCDR)
+	}
+	return
+}
+
+// callDescriptorForCDR builds the CallDescriptor used to rate the CDR
+func (self *CdrServer) callDescriptorForCDR(cdr *CDR) *CallDescriptor {
 	timeStart := cdr.AnswerTime
 	if timeStart.IsZero() { // Fix for FreeSWITCH unanswered calls
 		timeStart = cdr.SetupTime
 	}
-	cd := &CallDescriptor{
+	return &CallDescriptor{
 		TOR:             cdr.ToR,
 		Direction:       cdr.Direction,
 		Tenant:          cdr.Tenant,
@@ -464,6 +626,13 @@ func (self *CdrServer) getCostFromRater(cdr *CDR) (*CallCost, error) {
 		DurationIndex:   cdr.Usage,
 		PerformRounding: true,
 	}
+}
+
+// Retrive the cost from engine
+func (self *CdrServer) getCostFromRater(cdr *CDR) (*CallCost, error) {
+	cc := new(CallCost)
+	var err error
+	cd := self.callDescriptorForCDR(cdr)
 	if utils.IsSliceMember([]string{utils.META_PSEUDOPREPAID, utils.META_POSTPAID, utils.META_PREPAID, utils.PSEUDOPREPAID, utils.POSTPAID, utils.PREPAID}, cdr.RequestType) { // Prepaid - Cost can be recalculated in case of missing records from SM
 		err = self.rals.Call("Responder.Debit", cd, cc)
 	} else {
@@ -627,6 +796,19 @@ func (self *CdrServer) V1RateCDRs(attrs utils.AttrRateCDRs, reply *string) error
 	return nil
 }
 
+// V1GetDuplicateCDRs returns the CDRs discarded by deduplication within the dedup window
+func (self *CdrServer) V1GetDuplicateCDRs(ignr string, reply *[]*DuplicateCDR) error {
+	if self.dedup == nil {
+		return utils.ErrNotFound
+	}
+	dups := self.dedup.Discarded()
+	if len(dups) == 0 {
+		return utils.ErrNotFound
+	}
+	*reply = dups
+	return nil
+}
+
 func (cdrsrv *CdrServer) Call(serviceMethod string, args interface{}, reply interface{}) error {
 	parts := strings.Split(serviceMethod, ".")
 	if len(parts) != 2 {
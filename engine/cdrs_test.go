@@ -0,0 +1,60 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestCDRSRateUnitRuns(t *testing.T) {
+	cdrDb := &testCdrStorage{smCosts: []*SMCost{
+		&SMCost{CGRID: "CGRID_UNITS", RunID: utils.META_DEFAULT, OriginHost: "127.0.0.1", OriginID: "units1",
+			CostSource: utils.SESSION_MANAGER_SOURCE, Usage: 60,
+			CostDetails: &CallCost{TOR: utils.VOICE, Cost: 1.5}},
+		&SMCost{CGRID: "CGRID_UNITS", RunID: utils.ConcatenatedKey(utils.META_DEFAULT, utils.DATA), OriginHost: "127.0.0.1", OriginID: "units1",
+			CostSource: utils.SESSION_MANAGER_SOURCE, Usage: 2048,
+			CostDetails: &CallCost{TOR: utils.DATA, Cost: 0.3}},
+		&SMCost{CGRID: "CGRID_UNITS", RunID: "reseller", OriginHost: "127.0.0.1", OriginID: "units1",
+			CostSource: utils.SESSION_MANAGER_SOURCE, Usage: 60,
+			CostDetails: &CallCost{TOR: utils.VOICE, Cost: 1.2}},
+	}}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cdrS := &CdrServer{cgrCfg: cfg, cdrDb: cdrDb, dataDB: dataStorage}
+	cdr := &CDR{CGRID: "CGRID_UNITS", RunID: utils.META_DEFAULT, OriginHost: "127.0.0.1", OriginID: "units1",
+		ToR: utils.VOICE, RequestType: utils.META_PREPAID, Direction: utils.OUT, Tenant: "cgrates.org", Category: "call",
+		Account: "units", Subject: "units", Destination: "1002",
+		AnswerTime: time.Date(2017, 10, 1, 10, 0, 0, 0, time.UTC), Usage: time.Duration(time.Minute)}
+	if err := cdrS.deriveRateStoreStatsReplicate(cdr, true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(cdrDb.cdrs) != 2 {
+		t.Fatalf("Unexpected stored CDRs: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+	if cdrDb.cdrs[0].RunID != utils.META_DEFAULT || cdrDb.cdrs[0].Cost != 1.5 {
+		t.Errorf("Unexpected CDR: %s", utils.ToJSON(cdrDb.cdrs[0]))
+	}
+	if unitCDR := cdrDb.cdrs[1]; unitCDR.RunID != "*default:*data" || unitCDR.ToR != utils.DATA ||
+		unitCDR.Usage != time.Duration(2048*time.Second) || unitCDR.Cost != 0.3 ||
+		unitCDR.CostSource != utils.SESSION_MANAGER_SOURCE {
+		t.Errorf("Unexpected units CDR: %s", utils.ToJSON(unitCDR))
+	}
+}
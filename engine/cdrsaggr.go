@@ -0,0 +1,142 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// cdrAggrTimeLayout is the layout used by StorDB when returning time buckets
+const cdrAggrTimeLayout = "2006-01-02 15:04:05"
+
+// sqlCDRColumns maps the CDR fields which can be grouped on to their column in SQL StorDB, other fields are considered ExtraFields
+var sqlCDRColumns = map[string]string{
+	utils.CGRID:            "cgrid",
+	utils.MEDI_RUNID:       "run_id",
+	utils.CDRHOST:          "origin_host",
+	utils.CDRSOURCE:        "source",
+	utils.ACCID:            "origin_id",
+	utils.TOR:              "tor",
+	utils.REQTYPE:          "request_type",
+	utils.DIRECTION:        "direction",
+	utils.TENANT:           "tenant",
+	utils.CATEGORY:         "category",
+	utils.ACCOUNT:          "account",
+	utils.SUBJECT:          "subject",
+	utils.DESTINATION:      "destination",
+	utils.SUPPLIER:         "supplier",
+	utils.DISCONNECT_CAUSE: "disconnect_cause",
+}
+
+// aggrFieldRegexp restricts the GroupBy field names since ExtraFields end up in the StorDB query
+var aggrFieldRegexp = regexp.MustCompile(`^[\w-]+$`)
+
+// CDRsAggregation defines how the CDRs matching a filter are aggregated
+type CDRsAggregation struct {
+	GroupBy    []string // CDR fields or ExtraFields to group on
+	TimeBucket string   // <""|*hourly|*daily|*monthly>, empty for no time grouping
+	TimeField  string   // <SetupTime|AnswerTime>, time field used for buckets, defaults to AnswerTime
+}
+
+// Validate checks the aggregation parameters and populates the defaults
+func (aggr *CDRsAggregation) Validate() error {
+	for _, fld := range aggr.GroupBy {
+		if fld == "" {
+			return utils.NewErrMandatoryIeMissing("GroupBy")
+		}
+		if !aggrFieldRegexp.MatchString(fld) {
+			return fmt.Errorf("unsupported GroupBy field: %s", fld)
+		}
+	}
+	if !utils.IsSliceMember([]string{"", utils.MetaHourly, utils.MetaDaily, utils.MetaMonthly}, aggr.TimeBucket) {
+		return fmt.Errorf("unsupported TimeBucket: %s", aggr.TimeBucket)
+	}
+	if aggr.TimeField == "" {
+		aggr.TimeField = utils.ANSWER_TIME
+	}
+	if !utils.IsSliceMember([]string{utils.SETUP_TIME, utils.ANSWER_TIME}, aggr.TimeField) {
+		return fmt.Errorf("unsupported TimeField: %s", aggr.TimeField)
+	}
+	return nil
+}
+
+// CDRsAggregate holds the metrics of one group of CDRs
+type CDRsAggregate struct {
+	GroupValues map[string]string // values of the GroupBy fields, indexed on field name
+	TimeBucket  time.Time         // start of the time bucket, zero when not grouping on time
+	Count       int64
+	TotalUsage  time.Duration
+	AvgUsage    time.Duration
+	MinUsage    time.Duration
+	MaxUsage    time.Duration
+	TotalCost   float64
+	AvgCost     float64
+	MinCost     float64
+	MaxCost     float64
+}
+
+// computeAverages populates the average metrics out of totals
+func (ca *CDRsAggregate) computeAverages() {
+	if ca.Count == 0 {
+		return
+	}
+	ca.AvgUsage = time.Duration(int64(ca.TotalUsage) / ca.Count)
+	ca.AvgCost = ca.TotalCost / float64(ca.Count)
+}
+
+// parseTimeBucket converts the bucket returned by StorDB into time
+func parseTimeBucket(bucket string) (time.Time, error) {
+	if bucket == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(cdrAggrTimeLayout, bucket, time.UTC)
+}
+
+// mongoTimeBucketFormat returns the $dateToString format for a time bucket
+func mongoTimeBucketFormat(bucket string) string {
+	switch bucket {
+	case utils.MetaHourly:
+		return "%Y-%m-%d %H:00:00"
+	case utils.MetaDaily:
+		return "%Y-%m-%d 00:00:00"
+	case utils.MetaMonthly:
+		return "%Y-%m-01 00:00:00"
+	}
+	return ""
+}
+
+// sqlTimeColumn returns the column storing the CDR time field
+func sqlTimeColumn(timeField string) string {
+	if timeField == utils.SETUP_TIME {
+		return "setup_time"
+	}
+	return "answer_time"
+}
+
+// mongoCDRField returns the document field for a CDR field, ExtraFields are nested
+func mongoCDRField(fld string) string {
+	if _, has := sqlCDRColumns[fld]; has {
+		return strings.ToLower(fld)
+	}
+	return "extrafields." + fld
+}
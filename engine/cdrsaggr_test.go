@@ -0,0 +1,98 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestCDRsAggregationValidate(t *testing.T) {
+	aggr := &CDRsAggregation{GroupBy: []string{utils.ACCOUNT, "Cli"}, TimeBucket: utils.MetaDaily}
+	if err := aggr.Validate(); err != nil {
+		t.Error(err)
+	} else if aggr.TimeField != utils.ANSWER_TIME {
+		t.Errorf("Expecting default TimeField: %s, received: %s", utils.ANSWER_TIME, aggr.TimeField)
+	}
+	aggr = &CDRsAggregation{TimeBucket: "*weekly"}
+	if err := aggr.Validate(); err == nil {
+		t.Error("Expecting error for unsupported TimeBucket")
+	}
+	aggr = &CDRsAggregation{TimeField: utils.USAGE}
+	if err := aggr.Validate(); err == nil {
+		t.Error("Expecting error for unsupported TimeField")
+	}
+	aggr = &CDRsAggregation{GroupBy: []string{""}}
+	if err := aggr.Validate(); err == nil {
+		t.Error("Expecting error for empty GroupBy field")
+	}
+	for _, fld := range []string{`Cli"')) FROM cdrs; DROP TABLE cdrs; --`, "Cli'", `Cli"`, "extra.field", "$where"} {
+		aggr = &CDRsAggregation{GroupBy: []string{utils.ACCOUNT, fld}}
+		if err := aggr.Validate(); err == nil {
+			t.Errorf("Expecting error for GroupBy field: %s", fld)
+		}
+	}
+}
+
+func TestCDRsAggrSQLRejectsFieldName(t *testing.T) {
+	sqlStor := &SQLStorage{}
+	aggr := &CDRsAggregation{GroupBy: []string{`Cli"'))--`}}
+	if _, err := sqlStor.GetCDRsAggregates(new(utils.CDRsFilter), aggr); err == nil ||
+		err.Error() != `unsupported GroupBy field: Cli"'))--` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCDRsAggregateComputeAverages(t *testing.T) {
+	ca := &CDRsAggregate{Count: 4, TotalUsage: time.Duration(10 * time.Second), TotalCost: 1.0}
+	ca.computeAverages()
+	if ca.AvgUsage != time.Duration(2500*time.Millisecond) {
+		t.Errorf("Unexpected AvgUsage: %v", ca.AvgUsage)
+	}
+	if ca.AvgCost != 0.25 {
+		t.Errorf("Unexpected AvgCost: %v", ca.AvgCost)
+	}
+}
+
+func TestCDRsAggrParseTimeBucket(t *testing.T) {
+	if tm, err := parseTimeBucket(""); err != nil {
+		t.Error(err)
+	} else if !tm.IsZero() {
+		t.Errorf("Expecting zero time, received: %v", tm)
+	}
+	eTime := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	if tm, err := parseTimeBucket("2017-06-01 10:00:00"); err != nil {
+		t.Error(err)
+	} else if !tm.Equal(eTime) {
+		t.Errorf("Expecting: %v, received: %v", eTime, tm)
+	}
+}
+
+func TestCDRsAggrMongoCDRField(t *testing.T) {
+	if fld := mongoCDRField(utils.ACCOUNT); fld != AccountLow {
+		t.Errorf("Expecting: %s, received: %s", AccountLow, fld)
+	}
+	if fld := mongoCDRField(utils.CDRHOST); fld != OriginHostLow {
+		t.Errorf("Expecting: %s, received: %s", OriginHostLow, fld)
+	}
+	if fld := mongoCDRField("Cli"); fld != "extrafields.Cli" {
+		t.Errorf("Unexpected field: %s", fld)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+const CDRsPageSize = 1000 // default number of CDRs returned in one page when no limit is specified
+
+// GetCDRsPage returns one page of CDRs using keyset pagination on OrderID
+// cursor is the OrderID to start from, nextCursor is 0 when there are no more CDRs to query
+func GetCDRsPage(cdrDb CdrStorage, qryFltr *utils.CDRsFilter, cursor int64) (cdrs []*CDR, nextCursor int64, err error) {
+	fltr := *qryFltr // do not modify the original filter
+	if cursor != 0 && (fltr.OrderIDStart == nil || *fltr.OrderIDStart < cursor) {
+		fltr.OrderIDStart = &cursor
+	}
+	if fltr.Paginator.Limit == nil || *fltr.Paginator.Limit <= 0 {
+		fltr.Paginator.Limit = utils.IntPointer(CDRsPageSize)
+	}
+	fltr.Paginator.Offset = nil // keyset replaces offset
+	fltr.OrderByOrderID = true
+	if cdrs, _, err = cdrDb.GetCDRs(&fltr, false); err != nil {
+		return
+	}
+	if len(cdrs) == *fltr.Paginator.Limit {
+		nextCursor = cdrs[len(cdrs)-1].OrderID + 1
+	}
+	return
+}
+
+// cdrsStreamHandler streams the CDRs matching the filter received as JSON body, one ExternalCDR JSON per line
+// The Limit in filter is used as size of the batches queried out of StorDB
+func cdrsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	var rpcFltr utils.RPCCDRsFilter
+	if err := json.NewDecoder(r.Body).Decode(&rpcFltr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cdrsFltr, err := rpcFltr.AsCDRsFilter(cdrServer.cgrCfg.DefaultTimezone)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, canFlush := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	var cursor int64
+	for {
+		cdrs, nextCursor, err := GetCDRsPage(cdrServer.cdrDb, cdrsFltr, cursor)
+		if err != nil {
+			if err != utils.ErrNotFound {
+				utils.Logger.Err(fmt.Sprintf("<CDRS> Streaming CDRs, got error: %s", err.Error()))
+			}
+			return
+		}
+		for _, cdr := range cdrs {
+			if err := enc.Encode(cdr.AsExternalCDR()); err != nil { // client went away
+				return
+			}
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+		if nextCursor == 0 {
+			return
+		}
+		cursor = nextCursor
+	}
+}
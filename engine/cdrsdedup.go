@@ -0,0 +1,163 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/ltcache"
+)
+
+// DuplicateCDR is the report entry for a CDR discarded by the deduplication stage
+type DuplicateCDR struct {
+	CGRID         string // CGRID of the discarded CDR
+	OriginHost    string
+	OriginID      string
+	Source        string
+	MatchedCGRID  string // CGRID of the CDR we have kept instead
+	Policy        string // policy applied when discarding
+	DiscardedTime time.Time
+}
+
+// NewCDRDeduplicator returns a deduplicator or nil if no match fields are configured
+func NewCDRDeduplicator(fields utils.RSRFields, timeTolerance, window time.Duration, policy string) *CDRDeduplicator {
+	if len(fields) == 0 {
+		return nil
+	}
+	return &CDRDeduplicator{fields: fields, timeTolerance: timeTolerance,
+		window: window, policy: policy,
+		recentCDRs: ltcache.New(ltcache.UnlimitedCaching, window, true, nil)}
+}
+
+// CDRDeduplicator detects the same CDR received over different sources within a time window
+type CDRDeduplicator struct {
+	sync.Mutex
+	fields        utils.RSRFields
+	timeTolerance time.Duration
+	window        time.Duration
+	policy        string
+	recentCDRs    *ltcache.Cache  // map[matchKey][]*dedupCDR
+	discarded     []*DuplicateCDR // report of discarded CDRs within window
+}
+
+// dedupCDR is one CDR remembered for matching
+type dedupCDR struct {
+	cdr        *CDR
+	receivedAt time.Time
+}
+
+// matchKey builds the index key out of the match fields, time fields are matched with tolerance
+func (cd *CDRDeduplicator) matchKey(cdr *CDR) string {
+	var vals []string
+	for _, fld := range cd.fields {
+		if fld.Id == utils.SETUP_TIME || fld.Id == utils.ANSWER_TIME {
+			continue
+		}
+		vals = append(vals, cdr.FieldAsString(fld))
+	}
+	return utils.ConcatenatedKey(vals...)
+}
+
+// timesMatch compares the time fields of two CDRs using the configured tolerance
+func (cd *CDRDeduplicator) timesMatch(cdr1, cdr2 *CDR) bool {
+	for _, fld := range cd.fields {
+		var t1, t2 time.Time
+		switch fld.Id {
+		case utils.SETUP_TIME:
+			t1, t2 = cdr1.SetupTime, cdr2.SetupTime
+		case utils.ANSWER_TIME:
+			t1, t2 = cdr1.AnswerTime, cdr2.AnswerTime
+		default:
+			continue
+		}
+		diff := t1.Sub(t2)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > cd.timeTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the previously processed CDR duplicated by cdr, remembering cdr for further matching if no duplicate was found.
+// With *keep_newest policy the new CDR replaces the matched one in the index
+func (cd *CDRDeduplicator) Match(cdr *CDR) (matched *CDR) {
+	cd.Lock()
+	defer cd.Unlock()
+	now := time.Now()
+	key := cd.matchKey(cdr)
+	var recent []*dedupCDR
+	if x, ok := cd.recentCDRs.Get(key); ok {
+		recent = x.([]*dedupCDR)
+	}
+	var updated []*dedupCDR
+	var known bool // the same CDR was received before
+	for _, dCdr := range recent {
+		if now.Sub(dCdr.receivedAt) > cd.window {
+			continue
+		}
+		if dCdr.cdr.CGRID == cdr.CGRID {
+			known = true
+		}
+		if matched == nil && dCdr.cdr.CGRID != cdr.CGRID && cd.timesMatch(dCdr.cdr, cdr) {
+			matched = dCdr.cdr
+			if cd.policy == utils.MetaKeepNewest {
+				continue // replaced by the new CDR below
+			}
+		}
+		updated = append(updated, dCdr)
+	}
+	if !known && (matched == nil || cd.policy == utils.MetaKeepNewest) {
+		updated = append(updated, &dedupCDR{cdr: cdr, receivedAt: now})
+	}
+	cd.recentCDRs.Set(key, updated)
+	return
+}
+
+// RecordDiscarded adds the discarded CDR to the report
+func (cd *CDRDeduplicator) RecordDiscarded(discarded, kept *CDR) {
+	cd.Lock()
+	defer cd.Unlock()
+	cd.pruneDiscarded()
+	cd.discarded = append(cd.discarded, &DuplicateCDR{CGRID: discarded.CGRID,
+		OriginHost: discarded.OriginHost, OriginID: discarded.OriginID, Source: discarded.Source,
+		MatchedCGRID: kept.CGRID, Policy: cd.policy, DiscardedTime: time.Now()})
+}
+
+// Discarded returns the CDRs discarded within the deduplication window
+func (cd *CDRDeduplicator) Discarded() (dups []*DuplicateCDR) {
+	cd.Lock()
+	defer cd.Unlock()
+	cd.pruneDiscarded()
+	dups = make([]*DuplicateCDR, len(cd.discarded))
+	copy(dups, cd.discarded)
+	return
+}
+
+// pruneDiscarded removes report entries older than window, not thread safe
+func (cd *CDRDeduplicator) pruneDiscarded() {
+	var i int
+	for i < len(cd.discarded) && time.Since(cd.discarded[i].DiscardedTime) > cd.window {
+		i++
+	}
+	cd.discarded = cd.discarded[i:]
+}
@@ -0,0 +1,230 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// testCdrStorage keeps the CDRs in memory, the rest of CdrStorage is not implemented
+type testCdrStorage struct {
+	CdrStorage
+	cdrs     []*CDR
+	smCosts  []*SMCost
+	invoices []*Invoice
+	setErr   error // returned by SetCDR when not nil
+}
+
+func (ts *testCdrStorage) SetCDR(cdr *CDR, allowUpdate bool) error {
+	if ts.setErr != nil {
+		return ts.setErr
+	}
+	for i, stored := range ts.cdrs {
+		if stored.CGRID == cdr.CGRID && stored.RunID == cdr.RunID {
+			if !allowUpdate {
+				return utils.ErrExists
+			}
+			ts.cdrs[i] = cdr
+			return nil
+		}
+	}
+	ts.cdrs = append(ts.cdrs, cdr)
+	return nil
+}
+
+// GetCDRs supports the filter fields used in tests, CDRs are returned in the order they were stored
+func (ts *testCdrStorage) GetCDRs(fltr *utils.CDRsFilter, remove bool) (cdrs []*CDR, count int64, err error) {
+	var kept []*CDR
+	for _, cdr := range ts.cdrs {
+		if (len(fltr.CGRIDs) != 0 && !utils.IsSliceMember(fltr.CGRIDs, cdr.CGRID)) ||
+			(len(fltr.Tenants) != 0 && !utils.IsSliceMember(fltr.Tenants, cdr.Tenant)) ||
+			(len(fltr.RunIDs) != 0 && !utils.IsSliceMember(fltr.RunIDs, cdr.RunID)) ||
+			(len(fltr.Accounts) != 0 && !utils.IsSliceMember(fltr.Accounts, cdr.Account)) ||
+			(len(fltr.Sources) != 0 && !utils.IsSliceMember(fltr.Sources, cdr.Source)) ||
+			(len(fltr.NotSources) != 0 && utils.IsSliceMember(fltr.NotSources, cdr.Source)) ||
+			(fltr.MinCost != nil && cdr.Cost < *fltr.MinCost) ||
+			(fltr.AnswerTimeStart != nil && cdr.AnswerTime.Before(*fltr.AnswerTimeStart)) ||
+			(fltr.AnswerTimeEnd != nil && !cdr.AnswerTime.Before(*fltr.AnswerTimeEnd)) ||
+			(fltr.SetupTimeEnd != nil && !cdr.SetupTime.Before(*fltr.SetupTimeEnd)) ||
+			(fltr.OrderIDStart != nil && cdr.OrderID < *fltr.OrderIDStart) ||
+			(fltr.OrderIDEnd != nil && cdr.OrderID >= *fltr.OrderIDEnd) ||
+			(fltr.Paginator.Limit != nil && len(cdrs) == *fltr.Paginator.Limit) {
+			kept = append(kept, cdr)
+			continue
+		}
+		cdrs = append(cdrs, cdr)
+	}
+	if remove {
+		ts.cdrs = kept
+	}
+	if len(cdrs) == 0 {
+		return nil, 0, utils.ErrNotFound
+	}
+	return cdrs, int64(len(cdrs)), nil
+}
+
+// GetSMCosts filters on CGRID, RunID and OriginHost, the OriginID prefix is not supported
+func (ts *testCdrStorage) GetSMCosts(cgrid, runid, originHost, originIDPrefix string) (smCosts []*SMCost, err error) {
+	for _, smc := range ts.smCosts {
+		if (cgrid == "" || smc.CGRID == cgrid) && (runid == "" || smc.RunID == runid) &&
+			(originHost == "" || smc.OriginHost == originHost) {
+			smCosts = append(smCosts, smc)
+		}
+	}
+	return
+}
+
+func (ts *testCdrStorage) RemoveSMCost(smc *SMCost) error {
+	for i, stored := range ts.smCosts {
+		if stored.CGRID == smc.CGRID && stored.RunID == smc.RunID {
+			ts.smCosts = append(ts.smCosts[:i], ts.smCosts[i+1:]...)
+			return nil
+		}
+	}
+	return utils.ErrNotFound
+}
+
+func TestCDRDeduplicatorDisabled(t *testing.T) {
+	if cd := NewCDRDeduplicator(nil, time.Second, time.Hour, utils.MetaDrop); cd != nil {
+		t.Errorf("Expecting nil deduplicator, received: %+v", cd)
+	}
+}
+
+func TestCDRDeduplicatorMatch(t *testing.T) {
+	flds, _ := utils.ParseRSRFieldsFromSlice([]string{utils.CDRHOST, utils.ACCOUNT, utils.DESTINATION, utils.ANSWER_TIME})
+	cd := NewCDRDeduplicator(flds, time.Duration(2*time.Second), time.Hour, utils.MetaDrop)
+	cdr1 := &CDR{CGRID: "CGRID1", OriginHost: "192.168.1.1", OriginID: "ORIGIN1", Account: "1001", Destination: "1002",
+		AnswerTime: time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)}
+	if matched := cd.Match(cdr1); matched != nil {
+		t.Errorf("Unexpected match: %+v", matched)
+	}
+	if matched := cd.Match(cdr1); matched != nil { // same CDR received twice is not a duplicate from another source
+		t.Errorf("Unexpected match: %+v", matched)
+	}
+	if x, ok := cd.recentCDRs.Get(cd.matchKey(cdr1)); !ok {
+		t.Error("CDR not indexed")
+	} else if recent := x.([]*dedupCDR); len(recent) != 1 {
+		t.Errorf("Expecting the CDR indexed once, have: %d", len(recent))
+	}
+	cdr2 := &CDR{CGRID: "CGRID2", OriginHost: "192.168.1.1", OriginID: "ORIGIN1-smg", Account: "1001", Destination: "1002",
+		AnswerTime: time.Date(2017, 6, 1, 10, 0, 1, 0, time.UTC)}
+	if matched := cd.Match(cdr2); matched != cdr1 {
+		t.Errorf("Expecting: %+v, received: %+v", cdr1, matched)
+	}
+	cdr3 := &CDR{CGRID: "CGRID3", OriginHost: "192.168.1.1", OriginID: "ORIGIN3", Account: "1001", Destination: "1002",
+		AnswerTime: time.Date(2017, 6, 1, 10, 0, 5, 0, time.UTC)}
+	if matched := cd.Match(cdr3); matched != nil { // outside tolerance
+		t.Errorf("Unexpected match: %+v", matched)
+	}
+	cd.RecordDiscarded(cdr2, cdr1)
+	if dups := cd.Discarded(); len(dups) != 1 {
+		t.Errorf("Unexpected report: %+v", dups)
+	} else if dups[0].CGRID != cdr2.CGRID || dups[0].MatchedCGRID != cdr1.CGRID || dups[0].Policy != utils.MetaDrop {
+		t.Errorf("Unexpected report entry: %+v", dups[0])
+	}
+}
+
+func TestCDRDeduplicatorKeepNewest(t *testing.T) {
+	flds, _ := utils.ParseRSRFieldsFromSlice([]string{utils.ACCOUNT, utils.DESTINATION, utils.ANSWER_TIME})
+	cd := NewCDRDeduplicator(flds, time.Second, time.Hour, utils.MetaKeepNewest)
+	aTime := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	cdr1 := &CDR{CGRID: "CGRID1", Account: "1001", Destination: "1002", AnswerTime: aTime}
+	cdr2 := &CDR{CGRID: "CGRID2", Account: "1001", Destination: "1002", AnswerTime: aTime}
+	cdr3 := &CDR{CGRID: "CGRID3", Account: "1001", Destination: "1002", AnswerTime: aTime}
+	cd.Match(cdr1)
+	if matched := cd.Match(cdr2); matched != cdr1 {
+		t.Errorf("Expecting: %+v, received: %+v", cdr1, matched)
+	}
+	if matched := cd.Match(cdr3); matched != cdr2 { // cdr2 replaced cdr1 in index
+		t.Errorf("Expecting: %+v, received: %+v", cdr2, matched)
+	}
+}
+
+func TestCDRSHandleDuplicateKeepNewestRefund(t *testing.T) {
+	acntID := "cgrates.org:dedup_refund"
+	if err := dataStorage.SetAccount(&Account{ID: acntID,
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Uuid: "DEDUP_MONETARY", Value: 10}}}}); err != nil {
+		t.Fatal(err)
+	}
+	aTime := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	oldCDR := &CDR{CGRID: "CGRID_DEDUP_OLD", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_PREPAID,
+		Direction: utils.OUT, Tenant: "cgrates.org", Category: "call", Account: "dedup_refund", Subject: "dedup_refund",
+		Destination: "1002", AnswerTime: aTime, Usage: time.Duration(2 * time.Second), Cost: 2,
+		CostDetails: &CallCost{Direction: utils.OUT, Category: "call", Tenant: "cgrates.org", Subject: "dedup_refund",
+			Account: "dedup_refund", Destination: "1002", TOR: utils.VOICE, Cost: 2,
+			Timespans: TimeSpans{&TimeSpan{TimeStart: aTime, TimeEnd: aTime.Add(2 * time.Second),
+				Increments: Increments{&Increment{Duration: time.Second, Cost: 1, CompressFactor: 2,
+					BalanceInfo: &DebitInfo{Monetary: &MonetaryInfo{UUID: "DEDUP_MONETARY"}, AccountID: acntID}}}}}}}
+	cdrDb := &testCdrStorage{cdrs: []*CDR{oldCDR}}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.CDRSStoreCdrs = true
+	cfg.CDRSDedupPolicy = utils.MetaKeepNewest
+	flds, _ := utils.ParseRSRFieldsFromSlice([]string{utils.ACCOUNT, utils.DESTINATION, utils.ANSWER_TIME})
+	cdrS := &CdrServer{cgrCfg: cfg, cdrDb: cdrDb, rals: new(Responder),
+		dedup: NewCDRDeduplicator(flds, time.Second, time.Hour, utils.MetaKeepNewest)}
+	newCDR := &CDR{CGRID: "CGRID_DEDUP_NEW", Account: "dedup_refund", Destination: "1002", AnswerTime: aTime}
+	if processNew, err := cdrS.handleDuplicateCDR(newCDR, oldCDR); err != nil {
+		t.Fatal(err)
+	} else if !processNew {
+		t.Error("Expecting the new CDR to be processed")
+	}
+	if len(cdrDb.cdrs) != 0 {
+		t.Errorf("Expecting the old CDR removed, have: %+v", cdrDb.cdrs)
+	}
+	if acnt, err := dataStorage.GetAccount(acntID); err != nil {
+		t.Error(err)
+	} else if val := acnt.BalanceMap[utils.MONETARY][0].GetValue(); val != 12 {
+		t.Errorf("Expecting the old CDR cost refunded, balance value: %f", val)
+	}
+}
+
+func TestCDRSHandleDuplicateMergeExtraFields(t *testing.T) {
+	aTime := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	rawCDR := &CDR{CGRID: "CGRID_MERGE_OLD", RunID: utils.MetaRaw, Account: "1001", Destination: "1002", AnswerTime: aTime,
+		ExtraFields: map[string]string{"Field1": "Val1"}}
+	ratedCDR := rawCDR.Clone()
+	ratedCDR.RunID = utils.META_DEFAULT
+	cdrDb := &testCdrStorage{cdrs: []*CDR{rawCDR, ratedCDR}}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.CDRSStoreCdrs = true
+	cfg.CDRSDedupPolicy = utils.MetaMergeExtraFields
+	flds, _ := utils.ParseRSRFieldsFromSlice([]string{utils.ACCOUNT, utils.DESTINATION, utils.ANSWER_TIME})
+	cdrS := &CdrServer{cgrCfg: cfg, cdrDb: cdrDb,
+		dedup: NewCDRDeduplicator(flds, time.Second, time.Hour, utils.MetaMergeExtraFields)}
+	newCDR := &CDR{CGRID: "CGRID_MERGE_NEW", Account: "1001", Destination: "1002", AnswerTime: aTime,
+		ExtraFields: map[string]string{"Field1": "Val2", "Field2": "Val2"}}
+	if processNew, err := cdrS.handleDuplicateCDR(newCDR, rawCDR); err != nil {
+		t.Fatal(err)
+	} else if processNew {
+		t.Error("Expecting the new CDR discarded")
+	}
+	eExtraFields := map[string]string{"Field1": "Val1", "Field2": "Val2"}
+	if len(cdrDb.cdrs) != 2 {
+		t.Fatalf("Unexpected CDRs: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+	for _, cdr := range cdrDb.cdrs {
+		if !reflect.DeepEqual(eExtraFields, cdr.ExtraFields) {
+			t.Errorf("RunID: %s, expecting: %+v, received: %+v", cdr.RunID, eExtraFields, cdr.ExtraFields)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// ArgsRerateCDRs selects the CDRs to be re-rated and the target tariff
+type ArgsRerateCDRs struct {
+	utils.RPCCDRsFilter
+	TPID          string // rate out of this tariff plan in StorDB instead of the active one, needs RALs within this engine
+	RatingSubject string // alternative rating subject, empty to keep the one in CDR
+	Category      string // alternative category, empty to keep the one in CDR
+}
+
+// ArgsCommitRerateJob is used to approve a re-rating job
+type ArgsCommitRerateJob struct {
+	JobID          string
+	AdjustAccounts bool // refund the accounts with what the CDRs have debited and debit them again with the new costs
+}
+
+// ArgsExportRerateJob is used to export the report of a re-rating job via CDRE
+type ArgsExportRerateJob struct {
+	JobID          string
+	ExportTemplate string // CDRE profile used for export, *default if empty
+	ExportPath     string // overwrites the export path in template
+}
+
+// CDRRerateDiff is the difference between the stored and newly calculated cost of one CDR
+type CDRRerateDiff struct {
+	CGRID          string
+	RunID          string
+	OrderID        int64
+	Tenant         string
+	Account        string
+	RequestType    string
+	OldCost        float64
+	NewCost        float64
+	CostDelta      float64
+	OldCostDetails *CallCost
+	NewCostDetails *CallCost
+	Error          string // populated when the new cost could not be calculated
+	refunded       bool   // accounts refunded with the old cost on commit
+	debited        bool   // accounts debited with the new cost on commit
+	committed      bool   // re-rated CDR stored, skipped when retrying a failed commit
+}
+
+// RerateJob holds the re-rating results until approved or discarded
+type RerateJob struct {
+	ID            string
+	CreatedTime   time.Time
+	TPID          string
+	RatingSubject string
+	Category      string
+	Diffs         []*CDRRerateDiff
+	TotalDelta    float64
+	cdrs          map[string]*CDR // re-rated CDRs indexed on CGRID+RunID, committed on approval
+}
+
+const rerateJobLockPrefix = "rerate_job"
+
+func newRerateJobs(ttl time.Duration) *rerateJobs {
+	return &rerateJobs{jobs: make(map[string]*RerateJob), ttl: ttl}
+}
+
+// rerateJobs keeps the re-rating jobs waiting for approval
+type rerateJobs struct {
+	sync.RWMutex
+	jobs map[string]*RerateJob
+	ttl  time.Duration // jobs not approved within ttl are dropped, 0 to keep them until approval
+}
+
+// expired returns true if the job outlived the ttl
+func (rj *rerateJobs) expired(job *RerateJob, now time.Time) bool {
+	return rj.ttl != 0 && now.Sub(job.CreatedTime) > rj.ttl
+}
+
+func (rj *rerateJobs) get(jobID string) (job *RerateJob, has bool) {
+	rj.RLock()
+	if job, has = rj.jobs[jobID]; has && rj.expired(job, time.Now()) {
+		job, has = nil, false
+	}
+	rj.RUnlock()
+	return
+}
+
+// set stores the job, dropping the expired ones
+func (rj *rerateJobs) set(job *RerateJob) {
+	rj.Lock()
+	now := time.Now()
+	for jobID, storedJob := range rj.jobs {
+		if rj.expired(storedJob, now) {
+			delete(rj.jobs, jobID)
+		}
+	}
+	rj.jobs[job.ID] = job
+	rj.Unlock()
+}
+
+// remove drops a committed job
+func (rj *rerateJobs) remove(jobID string) {
+	rj.Lock()
+	delete(rj.jobs, jobID)
+	rj.Unlock()
+}
+
+// pop returns and removes the job
+func (rj *rerateJobs) pop(jobID string) (job *RerateJob, has bool) {
+	rj.Lock()
+	if job, has = rj.jobs[jobID]; has {
+		delete(rj.jobs, jobID)
+		if rj.expired(job, time.Now()) {
+			job, has = nil, false
+		}
+	}
+	rj.Unlock()
+	return
+}
+
+// rerateCDR calculates the new cost of the CDR without touching accounts
+func (self *CdrServer) rerateCDR(cdr *CDR, ratingSubject, category string, tpDB *tpRatingDB) (diff *CDRRerateDiff, rrCDR *CDR) {
+	diff = &CDRRerateDiff{CGRID: cdr.CGRID, RunID: cdr.RunID, OrderID: cdr.OrderID,
+		Tenant: cdr.Tenant, Account: cdr.Account, RequestType: cdr.RequestType,
+		OldCost: cdr.Cost, OldCostDetails: cdr.CostDetails}
+	rrCDR = cdr.Clone()
+	if ratingSubject != "" {
+		rrCDR.Subject = ratingSubject
+	}
+	if category != "" {
+		rrCDR.Category = category
+	}
+	cc, err := self.rateRerated(rrCDR, cdr.debitedIncrements(), tpDB, true)
+	if err != nil {
+		diff.Error = err.Error()
+		return diff, nil
+	}
+	self.setRerateCost(diff, rrCDR, cc)
+	return
+}
+
+// rateRerated returns the new cost of the CDR, out of the active tariff plan or tpDB.
+// The request types charging accounts are debited, on dryRun without saving the account after it got back the increments debited before
+func (self *CdrServer) rateRerated(rrCDR *CDR, debited Increments, tpDB *tpRatingDB, dryRun bool) (cc *CallCost, err error) {
+	cd := self.callDescriptorForCDR(rrCDR)
+	cd.CgrID = rrCDR.CGRID
+	cd.RunID = rrCDR.RunID
+	method := "Responder.GetCost"
+	if utils.IsSliceMember([]string{utils.META_PREPAID, utils.PREPAID, utils.META_PSEUDOPREPAID, utils.PSEUDOPREPAID,
+		utils.META_POSTPAID, utils.POSTPAID}, rrCDR.RequestType) {
+		method = "Responder.Debit"
+		if cd.DryRun = dryRun; dryRun {
+			cd.Increments = debited
+		}
+	}
+	cc = new(CallCost)
+	if tpDB == nil {
+		err = self.rals.Call(method, cd, cc)
+	} else { // the tariff plan is loaded within this engine only
+		cd.ratingDB = tpDB
+		if method == "Responder.Debit" {
+			cc, err = cd.Debit()
+		} else {
+			cc, err = cd.GetCost()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	cc.UpdateCost()
+	cc.UpdateRatedUsage()
+	return
+}
+
+// setRerateCost updates the re-rated CDR and its diff with the new cost
+func (self *CdrServer) setRerateCost(diff *CDRRerateDiff, rrCDR *CDR, cc *CallCost) {
+	rrCDR.Cost = cc.Cost
+	rrCDR.CostDetails = cc
+	rrCDR.CostSource = utils.CDRS_SOURCE
+	rrCDR.ExtraInfo = ""
+	diff.NewCost = cc.Cost
+	diff.NewCostDetails = cc
+	diff.CostDelta = utils.Round(cc.Cost-diff.OldCost, self.cgrCfg.RoundingDecimals, utils.ROUNDING_MIDDLE)
+}
+
+// loadRerateTP loads the tariff plan a job rates on, nil for the active one
+func (self *CdrServer) loadRerateTP(tpid string) (*tpRatingDB, error) {
+	if tpid == "" {
+		return nil, nil
+	}
+	lr, canLoad := self.cdrDb.(LoadReader)
+	if !canLoad || !self.cgrCfg.RALsEnabled {
+		return nil, utils.NewErrServerError(fmt.Errorf("re-rating on TPID %s needs StorDB and RALs within this engine", tpid))
+	}
+	tpDB, err := newTPRatingDB(lr, tpid, self.cgrCfg.DefaultTimezone)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return nil, err
+	}
+	return tpDB, nil
+}
+
+// V1CreateRerateJob re-rates the CDRs matching filter and returns the differences without storing them
+func (self *CdrServer) V1CreateRerateJob(args ArgsRerateCDRs, reply *RerateJob) error {
+	if self.rals == nil {
+		return utils.NewErrNotConnected(utils.RALService)
+	}
+	cdrFltr, err := args.RPCCDRsFilter.AsCDRsFilter(self.cgrCfg.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	cdrFltr.NotRunIDs = append(cdrFltr.NotRunIDs, utils.MetaRaw) // only rated CDRs have costs to compare
+	cdrs, _, err := self.cdrDb.GetCDRs(cdrFltr, false)
+	if err != nil {
+		return err
+	}
+	tpDB, err := self.loadRerateTP(args.TPID)
+	if err != nil {
+		return err
+	}
+	if tpDB != nil {
+		defer tpDB.close()
+	}
+	job := &RerateJob{ID: utils.GenUUID(), CreatedTime: time.Now(), TPID: args.TPID,
+		RatingSubject: args.RatingSubject, Category: args.Category,
+		cdrs: make(map[string]*CDR)}
+	for _, cdr := range cdrs {
+		diff, rrCDR := self.rerateCDR(cdr, args.RatingSubject, args.Category, tpDB)
+		job.Diffs = append(job.Diffs, diff)
+		if rrCDR == nil {
+			continue
+		}
+		job.TotalDelta += diff.CostDelta
+		job.cdrs[utils.ConcatenatedKey(cdr.CGRID, cdr.RunID)] = rrCDR
+	}
+	job.TotalDelta = utils.Round(job.TotalDelta, self.cgrCfg.RoundingDecimals, utils.ROUNDING_MIDDLE)
+	self.rerateJobs.set(job)
+	*reply = *job
+	return nil
+}
+
+// V1GetRerateJob returns a re-rating job waiting for approval
+func (self *CdrServer) V1GetRerateJob(jobID string, reply *RerateJob) error {
+	return lockRerateJob(jobID, func() error {
+		job, has := self.rerateJobs.get(jobID)
+		if !has {
+			return utils.ErrNotFound
+		}
+		*reply = *job
+		return nil
+	})
+}
+
+// lockRerateJob runs f locked on the job so it does not overlap with a commit of it
+func lockRerateJob(jobID string, f func() error) (err error) {
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) {
+		return nil, f()
+	}, 0, utils.ConcatenatedKey(rerateJobLockPrefix, jobID))
+	return
+}
+
+// V1CommitRerateJob stores the re-rated CDRs, optionally refunding the accounts with the old costs and debiting them with the new ones.
+// The job is removed once all its CDRs were committed, a failed commit can be retried for the CDRs left
+func (self *CdrServer) V1CommitRerateJob(args ArgsCommitRerateJob, reply *string) (err error) {
+	if err = lockRerateJob(args.JobID, func() error {
+		return self.commitRerateJob(args)
+	}); err != nil {
+		return
+	}
+	*reply = utils.OK
+	return
+}
+
+// commitRerateJob commits the CDRs of the job not committed yet, locked on job
+func (self *CdrServer) commitRerateJob(args ArgsCommitRerateJob) (err error) {
+	job, has := self.rerateJobs.get(args.JobID)
+	if !has {
+		return utils.ErrNotFound
+	}
+	var tpDB *tpRatingDB
+	if args.AdjustAccounts {
+		if tpDB, err = self.loadRerateTP(job.TPID); err != nil {
+			return
+		}
+		if tpDB != nil {
+			defer tpDB.close()
+		}
+	}
+	var failed []string // CDRs which could not be committed, as CGRID:RunID:error
+	job.TotalDelta = 0
+	for _, diff := range job.Diffs {
+		rrCDR, has := job.cdrs[utils.ConcatenatedKey(diff.CGRID, diff.RunID)]
+		if has && !diff.committed {
+			if err := self.commitRerated(diff, rrCDR, args.AdjustAccounts, tpDB); err != nil {
+				utils.Logger.Err(fmt.Sprintf("<CDRS> Committing re-rated CDR %+v, got error: %s", rrCDR, err.Error()))
+				failed = append(failed, utils.ConcatenatedKey(diff.CGRID, diff.RunID, err.Error()))
+			}
+		}
+		job.TotalDelta += diff.CostDelta // the debits on commit can change it
+	}
+	job.TotalDelta = utils.Round(job.TotalDelta, self.cgrCfg.RoundingDecimals, utils.ROUNDING_MIDDLE)
+	if len(failed) != 0 {
+		return utils.NewErrServerError(fmt.Errorf("failed committing re-rated CDRs: %s", strings.Join(failed, ", ")))
+	}
+	self.rerateJobs.remove(job.ID)
+	return
+}
+
+// commitRerated stores one re-rated CDR, each step done once so a failed commit can be retried
+func (self *CdrServer) commitRerated(diff *CDRRerateDiff, rrCDR *CDR, adjustAccounts bool, tpDB *tpRatingDB) (err error) {
+	if adjustAccounts && !diff.debited {
+		if !diff.refunded {
+			oldCDR := rrCDR.Clone()
+			oldCDR.CostDetails = diff.OldCostDetails
+			if err = self.refundCDR(oldCDR); err != nil {
+				return
+			}
+			diff.refunded = true
+		}
+		var cc *CallCost
+		if cc, err = self.rateRerated(rrCDR, nil, tpDB, false); err != nil {
+			return
+		}
+		self.setRerateCost(diff, rrCDR, cc) // what was actually debited
+		diff.debited = true
+	}
+	if err = self.cdrDb.SetCDR(rrCDR, true); err != nil {
+		return
+	}
+	diff.committed = true
+	return
+}
+
+// V1DiscardRerateJob drops a re-rating job without storing anything
+func (self *CdrServer) V1DiscardRerateJob(jobID string, reply *string) error {
+	if err := lockRerateJob(jobID, func() error {
+		if _, has := self.rerateJobs.pop(jobID); !has {
+			return utils.ErrNotFound
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	*reply = utils.OK
+	return nil
+}
+
+// V1ExportRerateJob exports the re-rated CDRs via CDRE, old cost and delta are available as extra fields
+func (self *CdrServer) V1ExportRerateJob(args ArgsExportRerateJob, reply *string) (err error) {
+	job, has := self.rerateJobs.get(args.JobID)
+	if !has {
+		return utils.ErrNotFound
+	}
+	expTplID := args.ExportTemplate
+	if expTplID == "" {
+		expTplID = utils.META_DEFAULT
+	}
+	expTpl, has := self.cgrCfg.CdreProfiles[expTplID]
+	if !has {
+		return fmt.Errorf("%s:ExportTemplate", utils.ErrNotFound)
+	}
+	var cdrs []*CDR
+	for _, diff := range job.Diffs {
+		rrCDR, has := job.cdrs[utils.ConcatenatedKey(diff.CGRID, diff.RunID)]
+		if !has {
+			continue
+		}
+		expCDR := rrCDR.Clone()
+		if expCDR.ExtraFields == nil {
+			expCDR.ExtraFields = make(map[string]string)
+		}
+		expCDR.ExtraFields[utils.OldCost] = strconv.FormatFloat(diff.OldCost, 'f', -1, 64)
+		expCDR.ExtraFields[utils.CostDelta] = strconv.FormatFloat(diff.CostDelta, 'f', -1, 64)
+		cdrs = append(cdrs, expCDR)
+	}
+	if len(cdrs) == 0 {
+		return utils.ErrNotFound
+	}
+	eDir := expTpl.ExportPath
+	if args.ExportPath != "" {
+		eDir = args.ExportPath
+	}
+	filePath := path.Join(eDir, fmt.Sprintf("rerate_%s.%s", job.ID, expTpl.ExportFormat))
+	cdre, err := NewCDRExporter(cdrs, expTpl, expTpl.ExportFormat, filePath, utils.META_NONE, job.ID,
+		expTpl.Synchronous, expTpl.Attempts, expTpl.FieldSeparator, expTpl.UsageMultiplyFactor,
+		expTpl.CostMultiplyFactor, self.cgrCfg.RoundingDecimals, self.cgrCfg.HttpSkipTlsVerify, self.httpPoster)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	if err = cdre.ExportCDRs(); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = filePath
+	return nil
+}
@@ -0,0 +1,217 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func testRerateCdrServer(cdrDb CdrStorage) *CdrServer {
+	cfg, _ := config.NewDefaultCGRConfig()
+	return &CdrServer{cgrCfg: cfg, cdrDb: cdrDb, rals: new(Responder),
+		rerateJobs: newRerateJobs(cfg.CDRSRerateJobTTL)}
+}
+
+func testRerateCDR() *CDR {
+	return &CDR{CGRID: "CGRID_RERATE", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_RATED,
+		Direction: utils.OUT, Tenant: "vdf", Category: "0", Account: "rif", Subject: "rif", Destination: "0256",
+		AnswerTime: time.Date(2012, time.February, 2, 17, 30, 0, 0, time.UTC), Usage: time.Duration(time.Hour), Cost: 2000}
+}
+
+func TestCDRSRerateJobPreview(t *testing.T) {
+	cdrDb := &testCdrStorage{cdrs: []*CDR{testRerateCDR()}}
+	cdrS := testRerateCdrServer(cdrDb)
+	var job RerateJob
+	if err := cdrS.V1CreateRerateJob(ArgsRerateCDRs{RPCCDRsFilter: utils.RPCCDRsFilter{CGRIDs: []string{"CGRID_RERATE"}}}, &job); err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Diffs) != 1 {
+		t.Fatalf("Unexpected diffs: %s", utils.ToJSON(job.Diffs))
+	}
+	if diff := job.Diffs[0]; diff.Error != "" || diff.OldCost != 2000 || diff.NewCost != 2700 || diff.CostDelta != 700 {
+		t.Errorf("Unexpected diff: %s", utils.ToJSON(diff))
+	}
+	if job.TotalDelta != 700 {
+		t.Errorf("Unexpected total delta: %f", job.TotalDelta)
+	}
+	if cdrDb.cdrs[0].Cost != 2000 {
+		t.Errorf("Stored CDR changed before approval: %+v", cdrDb.cdrs[0])
+	}
+	var rcvJob RerateJob
+	if err := cdrS.V1GetRerateJob(job.ID, &rcvJob); err != nil {
+		t.Error(err)
+	} else if rcvJob.ID != job.ID || rcvJob.TotalDelta != job.TotalDelta {
+		t.Errorf("Expecting: %+v, received: %+v", job, rcvJob)
+	}
+}
+
+func TestCDRSRerateJobCommit(t *testing.T) {
+	cdrDb := &testCdrStorage{cdrs: []*CDR{testRerateCDR()}}
+	cdrS := testRerateCdrServer(cdrDb)
+	var job RerateJob
+	if err := cdrS.V1CreateRerateJob(ArgsRerateCDRs{RPCCDRsFilter: utils.RPCCDRsFilter{CGRIDs: []string{"CGRID_RERATE"}}}, &job); err != nil {
+		t.Fatal(err)
+	}
+	var reply string
+	if err := cdrS.V1CommitRerateJob(ArgsCommitRerateJob{JobID: job.ID}, &reply); err != nil {
+		t.Fatal(err)
+	} else if reply != utils.OK {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+	if len(cdrDb.cdrs) != 1 || cdrDb.cdrs[0].Cost != 2700 || cdrDb.cdrs[0].CostSource != utils.CDRS_SOURCE {
+		t.Errorf("Unexpected stored CDRs: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+	if err := cdrS.V1CommitRerateJob(ArgsCommitRerateJob{JobID: job.ID}, &reply); err != utils.ErrNotFound {
+		t.Errorf("Expecting job committed once, received error: %v", err)
+	}
+}
+
+func TestCDRSRerateJobCommitErrors(t *testing.T) {
+	cdrDb := &testCdrStorage{cdrs: []*CDR{testRerateCDR()}}
+	cdrS := testRerateCdrServer(cdrDb)
+	var job RerateJob
+	if err := cdrS.V1CreateRerateJob(ArgsRerateCDRs{RPCCDRsFilter: utils.RPCCDRsFilter{CGRIDs: []string{"CGRID_RERATE"}}}, &job); err != nil {
+		t.Fatal(err)
+	}
+	cdrDb.setErr = errors.New("STORAGE_DOWN")
+	var reply string
+	if err := cdrS.V1CommitRerateJob(ArgsCommitRerateJob{JobID: job.ID}, &reply); err == nil {
+		t.Error("Expecting commit error")
+	} else if eErr := "SERVER_ERROR: failed committing re-rated CDRs: CGRID_RERATE:*default:STORAGE_DOWN"; err.Error() != eErr {
+		t.Errorf("Expecting: %s, received: %s", eErr, err.Error())
+	}
+	if reply == utils.OK {
+		t.Error("Unexpected OK reply")
+	}
+	cdrDb.setErr = nil
+	if err := cdrS.V1CommitRerateJob(ArgsCommitRerateJob{JobID: job.ID}, &reply); err != nil {
+		t.Errorf("Expecting failed commit retried, received error: %v", err)
+	} else if cdrDb.cdrs[0].Cost != 2700 {
+		t.Errorf("Unexpected stored CDRs: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+}
+
+// testRerateUnitsCDR returns a prepaid CDR which was paid out of a voice balance of the account
+func testRerateUnitsCDR(t *testing.T) *CDR {
+	acc := &Account{ID: "vdf:rerate_units", BalanceMap: map[string]Balances{
+		utils.VOICE: Balances{&Balance{Uuid: "rerate_voice", Value: 3600, RatingSubject: "*zero1s"}}}}
+	if err := dataStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	cdr := testRerateCDR()
+	cdr.CGRID = "CGRID_RERATE_UNITS"
+	cdr.RequestType = utils.META_PREPAID
+	cdr.Account = "rerate_units"
+	cdr.Usage = time.Duration(30 * time.Minute)
+	cd := (&CdrServer{}).callDescriptorForCDR(cdr)
+	cd.CgrID, cd.RunID = cdr.CGRID, cdr.RunID
+	cc, err := cd.Debit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc.UpdateCost()
+	cdr.Cost = cc.Cost
+	cdr.CostDetails = cc
+	return cdr
+}
+
+func testRerateUnitsLeft(t *testing.T) float64 {
+	acc, err := dataStorage.GetAccount("vdf:rerate_units")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc.BalanceMap[utils.VOICE].GetTotalValue()
+}
+
+func TestCDRSRerateJobDebitedUnits(t *testing.T) {
+	cdrDb := &testCdrStorage{cdrs: []*CDR{testRerateUnitsCDR(t)}}
+	if left := testRerateUnitsLeft(t); left != 1800 {
+		t.Fatalf("Unexpected units left: %f", left)
+	}
+	cdrS := testRerateCdrServer(cdrDb)
+	var job RerateJob
+	if err := cdrS.V1CreateRerateJob(ArgsRerateCDRs{RPCCDRsFilter: utils.RPCCDRsFilter{CGRIDs: []string{"CGRID_RERATE_UNITS"}}}, &job); err != nil {
+		t.Fatal(err)
+	}
+	if diff := job.Diffs[0]; diff.Error != "" || diff.NewCost != 0 || diff.CostDelta != 0 {
+		t.Errorf("Unexpected diff: %s", utils.ToJSON(diff))
+	}
+	if left := testRerateUnitsLeft(t); left != 1800 {
+		t.Errorf("Account changed before approval, units left: %f", left)
+	}
+	var reply string
+	if err := cdrS.V1CommitRerateJob(ArgsCommitRerateJob{JobID: job.ID, AdjustAccounts: true}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if left := testRerateUnitsLeft(t); left != 1800 {
+		t.Errorf("Expecting the units refunded and debited again, units left: %f", left)
+	}
+	if cdrDb.cdrs[0].Cost != 0 || cdrDb.cdrs[0].CostSource != utils.CDRS_SOURCE {
+		t.Errorf("Unexpected stored CDRs: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+}
+
+func TestCDRSRerateJobTPIDNoRALs(t *testing.T) {
+	cdrS := testRerateCdrServer(&testCdrStorage{cdrs: []*CDR{testRerateCDR()}})
+	var job RerateJob
+	if err := cdrS.V1CreateRerateJob(ArgsRerateCDRs{TPID: "TP_RERATE",
+		RPCCDRsFilter: utils.RPCCDRsFilter{CGRIDs: []string{"CGRID_RERATE"}}}, &job); err == nil {
+		t.Error("Expecting error re-rating on TPID without StorDB and RALs")
+	}
+}
+
+func TestCDRSRerateJobDiscard(t *testing.T) {
+	cdrDb := &testCdrStorage{cdrs: []*CDR{testRerateCDR()}}
+	cdrS := testRerateCdrServer(cdrDb)
+	var job RerateJob
+	if err := cdrS.V1CreateRerateJob(ArgsRerateCDRs{RPCCDRsFilter: utils.RPCCDRsFilter{CGRIDs: []string{"CGRID_RERATE"}}}, &job); err != nil {
+		t.Fatal(err)
+	}
+	var reply string
+	if err := cdrS.V1DiscardRerateJob(job.ID, &reply); err != nil {
+		t.Fatal(err)
+	} else if reply != utils.OK {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+	if err := cdrS.V1CommitRerateJob(ArgsCommitRerateJob{JobID: job.ID}, &reply); err != utils.ErrNotFound {
+		t.Errorf("Expecting discarded job, received error: %v", err)
+	}
+	if cdrDb.cdrs[0].Cost != 2000 {
+		t.Errorf("Stored CDR changed after discard: %+v", cdrDb.cdrs[0])
+	}
+}
+
+func TestCDRSRerateJobsTTL(t *testing.T) {
+	rj := newRerateJobs(time.Hour)
+	rj.set(&RerateJob{ID: "EXPIRED", CreatedTime: time.Now().Add(-2 * time.Hour)})
+	if _, has := rj.get("EXPIRED"); has {
+		t.Error("Expecting expired job not returned")
+	}
+	rj.set(&RerateJob{ID: "ACTIVE", CreatedTime: time.Now()})
+	if _, has := rj.jobs["EXPIRED"]; has {
+		t.Error("Expecting expired job removed")
+	}
+	if _, has := rj.pop("ACTIVE"); !has {
+		t.Error("Expecting active job")
+	}
+}
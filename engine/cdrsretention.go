@@ -0,0 +1,174 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// CDRsRetentionReport summarizes one enforcement of the retention policies
+type CDRsRetentionReport struct {
+	StartTime      time.Time
+	Duration       time.Duration
+	ArchivedCDRs   int
+	RemovedCDRs    int
+	RemovedSMCosts int
+	ArchiveFiles   []string
+}
+
+// retentionFilter returns the filter selecting the CDRs which have expired under policy
+func retentionFilter(plcy *config.CdrRetentionPolicy, now time.Time, batchSize int) *utils.CDRsFilter {
+	cutoff := now.Add(-plcy.MaxAge)
+	return &utils.CDRsFilter{Tenants: plcy.Tenants, RunIDs: plcy.RunIDs, SetupTimeEnd: &cutoff,
+		Paginator: utils.Paginator{Limit: utils.IntPointer(batchSize)}}
+}
+
+// archiveCDRs exports the CDRs using the archive template of the policy, returning the path of the archive
+func (self *CdrServer) archiveCDRs(plcy *config.CdrRetentionPolicy, cdrs []*CDR) (string, error) {
+	expTpl, has := self.cgrCfg.CdreProfiles[plcy.ArchiveTemplate]
+	if !has {
+		return "", fmt.Errorf("%s:ArchiveTemplate", utils.ErrNotFound)
+	}
+	eDir := expTpl.ExportPath
+	if plcy.ArchivePath != "" {
+		eDir = plcy.ArchivePath
+	}
+	exportID := fmt.Sprintf("%d_%d", cdrs[0].OrderID, cdrs[len(cdrs)-1].OrderID)
+	filePath := path.Join(eDir, fmt.Sprintf("archive_%s.%s", exportID, expTpl.ExportFormat))
+	cdre, err := NewCDRExporter(cdrs, expTpl, expTpl.ExportFormat, filePath, utils.META_NONE, exportID,
+		true, expTpl.Attempts, expTpl.FieldSeparator, expTpl.UsageMultiplyFactor,
+		expTpl.CostMultiplyFactor, self.cgrCfg.RoundingDecimals, self.cgrCfg.HttpSkipTlsVerify, self.httpPoster)
+	if err != nil {
+		return "", err
+	}
+	if err = cdre.ExportCDRs(); err != nil {
+		return "", err
+	}
+	if len(cdre.NegativeExports()) != 0 {
+		return "", fmt.Errorf("failed exporting %d CDRs", len(cdre.NegativeExports()))
+	}
+	return filePath, nil
+}
+
+// enforceRetentionPolicy archives and removes in batches the CDRs expired under policy, together with their sm_costs
+func (self *CdrServer) enforceRetentionPolicy(plcy *config.CdrRetentionPolicy, now time.Time, rpt *CDRsRetentionReport) error {
+	fltr := retentionFilter(plcy, now, self.cgrCfg.CDRSRetentionBatchSize)
+	var cursor int64
+	for {
+		cdrs, nextCursor, err := GetCDRsPage(self.cdrDb, fltr, cursor)
+		if err != nil {
+			if err == utils.ErrNotFound {
+				return nil
+			}
+			return err
+		}
+		if plcy.ArchiveTemplate != "" {
+			archPath, err := self.archiveCDRs(plcy, cdrs)
+			if err != nil {
+				return err // do not remove what we could not archive
+			}
+			rpt.ArchivedCDRs += len(cdrs)
+			rpt.ArchiveFiles = append(rpt.ArchiveFiles, archPath)
+		}
+		rmFltr := *fltr // remove exactly the CDRs in this batch
+		rmFltr.OrderIDStart = utils.Int64Pointer(cdrs[0].OrderID)
+		rmFltr.OrderIDEnd = utils.Int64Pointer(cdrs[len(cdrs)-1].OrderID + 1)
+		rmFltr.Paginator = utils.Paginator{}
+		rmFltr.Unscoped = true // free the space instead of soft deleting
+		if _, _, err := self.cdrDb.GetCDRs(&rmFltr, true); err != nil {
+			return err
+		}
+		rpt.RemovedCDRs += len(cdrs)
+		for _, cdr := range cdrs {
+			if err := self.cdrDb.RemoveSMCost(&SMCost{CGRID: cdr.CGRID, RunID: cdr.RunID}); err != nil {
+				utils.Logger.Warning(fmt.Sprintf("<CDRS> Removing SMCost for CGRID: %s, RunID: %s, got error: %s",
+					cdr.CGRID, cdr.RunID, err.Error()))
+				continue
+			}
+			rpt.RemovedSMCosts++
+		}
+		if nextCursor == 0 {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// cdrsRetention makes sure the retention policies are not enforced concurrently
+type cdrsRetention struct {
+	sync.Mutex
+	lastReport *CDRsRetentionReport
+}
+
+// enforceRetentionPolicies applies all the configured retention policies
+func (self *CdrServer) enforceRetentionPolicies() (*CDRsRetentionReport, error) {
+	self.retention.Lock()
+	defer self.retention.Unlock()
+	rpt := &CDRsRetentionReport{StartTime: time.Now()}
+	for _, plcy := range self.cgrCfg.CDRSRetentionPolicies {
+		if err := self.enforceRetentionPolicy(plcy, rpt.StartTime, rpt); err != nil {
+			return nil, err
+		}
+	}
+	rpt.Duration = time.Now().Sub(rpt.StartTime)
+	self.retention.lastReport = rpt
+	return rpt, nil
+}
+
+// RunRetentionPolicies enforces the retention policies on each interval, blocking
+func (self *CdrServer) RunRetentionPolicies(interval time.Duration) {
+	for {
+		if rpt, err := self.enforceRetentionPolicies(); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<CDRS> Enforcing retention policies, got error: %s", err.Error()))
+		} else if rpt.RemovedCDRs != 0 {
+			utils.Logger.Info(fmt.Sprintf("<CDRS> Retention policies archived %d and removed %d CDRs in %v",
+				rpt.ArchivedCDRs, rpt.RemovedCDRs, rpt.Duration))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// V1EnforceRetentionPolicies applies the retention policies on demand
+func (self *CdrServer) V1EnforceRetentionPolicies(ignr string, reply *CDRsRetentionReport) error {
+	if len(self.cgrCfg.CDRSRetentionPolicies) == 0 {
+		return utils.ErrNotFound
+	}
+	rpt, err := self.enforceRetentionPolicies()
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = *rpt
+	return nil
+}
+
+// V1GetRetentionReport returns the report of the last retention policies enforcement
+func (self *CdrServer) V1GetRetentionReport(ignr string, reply *CDRsRetentionReport) error {
+	self.retention.Lock()
+	defer self.retention.Unlock()
+	if self.retention.lastReport == nil {
+		return utils.ErrNotFound
+	}
+	*reply = *self.retention.lastReport
+	return nil
+}
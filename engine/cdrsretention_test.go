@@ -0,0 +1,129 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestCDRsRetentionFilter(t *testing.T) {
+	plcy := &config.CdrRetentionPolicy{Tenants: []string{"cgrates.org"}, RunIDs: []string{utils.MetaRaw},
+		MaxAge: time.Duration(720 * time.Hour)}
+	now := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	eCutoff := time.Date(2017, 5, 2, 10, 0, 0, 0, time.UTC)
+	eFltr := &utils.CDRsFilter{Tenants: []string{"cgrates.org"}, RunIDs: []string{utils.MetaRaw},
+		SetupTimeEnd: &eCutoff, Paginator: utils.Paginator{Limit: utils.IntPointer(100)}}
+	if fltr := retentionFilter(plcy, now, 100); !reflect.DeepEqual(eFltr, fltr) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eFltr), utils.ToJSON(fltr))
+	}
+}
+
+// testRetentionCDRs returns the CDRs used to check the retention run, in OrderID order
+func testRetentionCDRs(now time.Time) []*CDR {
+	old := now.Add(-time.Duration(1000 * time.Hour))
+	return []*CDR{
+		&CDR{CGRID: "RETENTION_1", RunID: utils.MetaRaw, OrderID: 1, Tenant: "cgrates.org", Account: "1001", SetupTime: old},
+		&CDR{CGRID: "RETENTION_1", RunID: utils.META_DEFAULT, OrderID: 2, Tenant: "cgrates.org", Account: "1001", SetupTime: old},
+		&CDR{CGRID: "RETENTION_2", RunID: utils.MetaRaw, OrderID: 3, Tenant: "itsyscom.com", Account: "1001", SetupTime: old},
+		&CDR{CGRID: "RETENTION_3", RunID: utils.MetaRaw, OrderID: 4, Tenant: "cgrates.org", Account: "1002", SetupTime: old},
+		&CDR{CGRID: "RETENTION_4", RunID: utils.MetaRaw, OrderID: 5, Tenant: "cgrates.org", Account: "1003", SetupTime: now},
+	}
+}
+
+func TestCDRsRetentionEnforce(t *testing.T) {
+	now := time.Now()
+	cdrDb := &testCdrStorage{cdrs: testRetentionCDRs(now),
+		smCosts: []*SMCost{&SMCost{CGRID: "RETENTION_1", RunID: utils.MetaRaw}, &SMCost{CGRID: "RETENTION_4", RunID: utils.MetaRaw}}}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.CDRSRetentionBatchSize = 1 // one CDR per batch so paging is covered
+	cfg.CDRSRetentionPolicies = []*config.CdrRetentionPolicy{
+		&config.CdrRetentionPolicy{Tenants: []string{"cgrates.org"}, RunIDs: []string{utils.MetaRaw},
+			MaxAge: time.Duration(720 * time.Hour)}}
+	cdrS := &CdrServer{cgrCfg: cfg, cdrDb: cdrDb, retention: new(cdrsRetention)}
+	var rpt CDRsRetentionReport
+	if err := cdrS.V1EnforceRetentionPolicies("", &rpt); err != nil {
+		t.Fatal(err)
+	}
+	if rpt.RemovedCDRs != 2 || rpt.RemovedSMCosts != 1 || rpt.ArchivedCDRs != 0 {
+		t.Errorf("Unexpected report: %+v", rpt)
+	}
+	var remaining []string
+	for _, cdr := range cdrDb.cdrs {
+		remaining = append(remaining, utils.ConcatenatedKey(cdr.CGRID, cdr.RunID))
+	}
+	eRemaining := []string{"RETENTION_1:*default", "RETENTION_2:*raw", "RETENTION_4:*raw"}
+	if !reflect.DeepEqual(eRemaining, remaining) {
+		t.Errorf("Expecting: %+v, received: %+v", eRemaining, remaining)
+	}
+	if len(cdrDb.smCosts) != 1 || cdrDb.smCosts[0].CGRID != "RETENTION_4" {
+		t.Errorf("Unexpected SMCosts: %s", utils.ToJSON(cdrDb.smCosts))
+	}
+	var lastRpt CDRsRetentionReport
+	if err := cdrS.V1GetRetentionReport("", &lastRpt); err != nil {
+		t.Error(err)
+	} else if lastRpt.RemovedCDRs != rpt.RemovedCDRs {
+		t.Errorf("Expecting: %+v, received: %+v", rpt, lastRpt)
+	}
+	if err := cdrS.V1EnforceRetentionPolicies("", &rpt); err != nil { // nothing left to remove
+		t.Error(err)
+	} else if rpt.RemovedCDRs != 0 {
+		t.Errorf("Unexpected report: %+v", rpt)
+	}
+}
+
+func TestCDRsRetentionEnforceArchive(t *testing.T) {
+	archDir, err := ioutil.TempDir("", "cdrs_retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(archDir)
+	now := time.Now()
+	cdrDb := &testCdrStorage{cdrs: testRetentionCDRs(now)}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.CDRSRetentionPolicies = []*config.CdrRetentionPolicy{
+		&config.CdrRetentionPolicy{MaxAge: time.Duration(720 * time.Hour),
+			ArchiveTemplate: utils.META_DEFAULT, ArchivePath: archDir}}
+	cdrS := &CdrServer{cgrCfg: cfg, cdrDb: cdrDb, retention: new(cdrsRetention)}
+	var rpt CDRsRetentionReport
+	if err := cdrS.V1EnforceRetentionPolicies("", &rpt); err != nil {
+		t.Fatal(err)
+	}
+	if rpt.RemovedCDRs != 4 || rpt.ArchivedCDRs != 4 || len(rpt.ArchiveFiles) != 1 {
+		t.Errorf("Unexpected report: %+v", rpt)
+	} else if _, err := os.Stat(rpt.ArchiveFiles[0]); err != nil {
+		t.Error(err)
+	}
+	if len(cdrDb.cdrs) != 1 || cdrDb.cdrs[0].CGRID != "RETENTION_4" {
+		t.Errorf("Unexpected CDRs: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+	cdrDb.cdrs = testRetentionCDRs(now)
+	cfg.CDRSRetentionPolicies[0].ArchiveTemplate = "UNKNOWN_TEMPLATE"
+	if err := cdrS.V1EnforceRetentionPolicies("", &rpt); err == nil {
+		t.Error("Expecting error on missing archive template")
+	}
+	if len(cdrDb.cdrs) != 5 {
+		t.Errorf("CDRs removed without being archived: %s", utils.ToJSON(cdrDb.cdrs))
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// availableValue returns the value which can be debited out of the balance, going below zero down to its credit limit.
+// The credit limit of acc applies to its default monetary balance, pass nil acc for unit balances.
+func (b *Balance) availableValue(acc *Account) float64 {
+	limit := b.CreditLimit
+	if acc != nil && b.IsDefault() && acc.CreditLimit > limit {
+		limit = acc.CreditLimit
+	}
+	value := b.GetValue() + limit
+	if b.sharedUsage != nil && b.sharedUsage.Limit > 0 { // bounded by the shared group quota of the debited account
+		if left := b.sharedUsage.Limit - b.sharedUsage.SpentAt(time.Now()); left < value {
+			value = left
+		}
+	}
+	return value
+}
+
+// unlimitedNegative returns true if the account can go negative without bounds on its default monetary balance
+func (acc *Account) unlimitedNegative() bool {
+	return acc.AllowNegative || acc.CreditLimit == 0
+}
+
+// setCreditLimitAction sets the action value as credit limit on the balances matched by ID or UUID,
+// or on the account when the action does not point to a balance
+func setCreditLimitAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+	if acc == nil {
+		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
+	}
+	limit := a.Balance.GetValue()
+	if limit < 0 {
+		return fmt.Errorf("negative credit limit: %v", limit)
+	}
+	if a.Balance.GetUuid() == "" && a.Balance.GetID() == "" {
+		acc.CreditLimit = limit
+		return nil
+	}
+	found := false
+	for balanceType, bChain := range acc.BalanceMap {
+		if a.Balance.GetType() != "" && a.Balance.GetType() != balanceType {
+			continue
+		}
+		for _, b := range bChain {
+			if b.MatchFilter(a.Balance, false) {
+				b.CreditLimit = limit
+				found = true
+			}
+		}
+	}
+	if !found {
+		return utils.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestCreditLimitAvailableValue(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:credit", CreditLimit: 20}
+	dflt := &Balance{ID: utils.META_DEFAULT, Value: -5}
+	if val := dflt.availableValue(acc); val != 15 {
+		t.Errorf("Unexpected available value: %v", val)
+	}
+	if val := dflt.availableValue(nil); val != -5 {
+		t.Errorf("Unexpected available value: %v", val)
+	}
+	bonus := &Balance{ID: "BONUS", Value: 1, CreditLimit: 3}
+	if val := bonus.availableValue(acc); val != 4 {
+		t.Errorf("Unexpected available value: %v", val)
+	}
+	if acc.unlimitedNegative() {
+		t.Error("Account with credit limit considered unlimited")
+	}
+	acc.AllowNegative = true
+	if !acc.unlimitedNegative() {
+		t.Error("AllowNegative account considered limited")
+	}
+}
+
+func TestCreditLimitDebit(t *testing.T) {
+	cc := &CallCost{
+		Direction:   utils.OUT,
+		Destination: "0723045326",
+		Timespans: []*TimeSpan{
+			&TimeSpan{
+				TimeStart:    time.Date(2013, 9, 24, 10, 48, 0, 0, time.UTC),
+				TimeEnd:      time.Date(2013, 9, 24, 10, 49, 20, 0, time.UTC),
+				ratingInfo:   &RatingInfo{},
+				RateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 1, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
+			},
+		},
+		TOR: utils.VOICE,
+	}
+	cd := &CallDescriptor{
+		TimeStart:     cc.Timespans[0].TimeStart,
+		TimeEnd:       cc.Timespans[0].TimeEnd,
+		Direction:     cc.Direction,
+		Destination:   cc.Destination,
+		TOR:           cc.TOR,
+		DurationIndex: cc.GetDuration(),
+		testCallcost:  cc,
+	}
+	acc := &Account{ID: "cgrates.org:credit", CreditLimit: 20, BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "money", Value: 50, Weight: 10}},
+	}}
+	acc.debitCreditBalance(cd, false, false, true)
+	if val := acc.BalanceMap[utils.MONETARY][0].GetValue(); val != 0 {
+		t.Errorf("Unexpected money balance: %v", val)
+	}
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != -20 {
+		t.Errorf("Default balance should stop at the credit limit, have: %v", val)
+	}
+}
+
+func TestCreditLimitAction(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:credit", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "m1", ID: "MONEY", Value: 10}},
+	}}
+	a := &Action{ActionType: SET_CREDIT_LIMIT, Balance: &BalanceFilter{Value: &utils.ValueFormula{Static: 15}}}
+	if err := setCreditLimitAction(acc, nil, a, nil); err != nil {
+		t.Error(err)
+	} else if acc.CreditLimit != 15 {
+		t.Errorf("Unexpected account credit limit: %v", acc.CreditLimit)
+	}
+	a.Balance.ID = utils.StringPointer("MONEY")
+	if err := setCreditLimitAction(acc, nil, a, nil); err != nil {
+		t.Error(err)
+	} else if acc.BalanceMap[utils.MONETARY][0].CreditLimit != 15 {
+		t.Errorf("Unexpected balance credit limit: %v", acc.BalanceMap[utils.MONETARY][0].CreditLimit)
+	}
+	a.Balance.ID = utils.StringPointer("OTHER")
+	if err := setCreditLimitAction(acc, nil, a, nil); err != utils.ErrNotFound {
+		t.Errorf("Expecting not found, received: %v", err)
+	}
+	a.Balance.Value = &utils.ValueFormula{Static: -1}
+	if err := setCreditLimitAction(acc, nil, a, nil); err == nil {
+		t.Error("Expecting error for negative credit limit")
+	}
+}
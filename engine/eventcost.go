@@ -66,10 +66,12 @@ func NewEventCostFromCallCost(cc *CallCost, cgrID, runID string) (ec *EventCost)
 				if incr.BalanceInfo.Monetary != nil {
 					if uuid := ec.Accounting.GetIDWithSet(
 						&BalanceCharge{
-							AccountID:   incr.BalanceInfo.AccountID,
-							BalanceUUID: incr.BalanceInfo.Monetary.UUID,
-							Units:       incr.Cost,
-							RatingID:    ec.ratingIDForRateInterval(incr.BalanceInfo.Monetary.RateInterval, rf),
+							AccountID:    incr.BalanceInfo.AccountID,
+							BalanceUUID:  incr.BalanceInfo.Monetary.UUID,
+							Units:        incr.Cost,
+							ExchangeRate: incr.BalanceInfo.Monetary.ExchangeRate,
+							TierCounter:  incr.BalanceInfo.Monetary.TierCounter,
+							RatingID:     ec.ratingIDForRateInterval(incr.BalanceInfo.Monetary.RateInterval, rf),
 						}); uuid != "" {
 						ecUUID = uuid
 					}
@@ -80,14 +82,23 @@ func NewEventCostFromCallCost(cc *CallCost, cgrID, runID string) (ec *EventCost)
 						BalanceUUID:   incr.BalanceInfo.Unit.UUID,
 						Units:         incr.BalanceInfo.Unit.Consumed,
 						RatingID:      ec.ratingIDForRateInterval(incr.BalanceInfo.Unit.RateInterval, rf),
-						ExtraChargeID: ecUUID})
+						ExtraChargeID: ecUUID,
+						Parents:       incr.BalanceInfo.Parents.Clone()})
 			} else if incr.BalanceInfo.Monetary != nil { // Only monetary
 				cIt.AccountingID = ec.Accounting.GetIDWithSet(
 					&BalanceCharge{
-						AccountID:   incr.BalanceInfo.AccountID,
-						BalanceUUID: incr.BalanceInfo.Monetary.UUID,
-						Units:       incr.Cost,
-						RatingID:    ec.ratingIDForRateInterval(incr.BalanceInfo.Monetary.RateInterval, rf)})
+						AccountID:    incr.BalanceInfo.AccountID,
+						BalanceUUID:  incr.BalanceInfo.Monetary.UUID,
+						Units:        incr.Cost,
+						ExchangeRate: incr.BalanceInfo.Monetary.ExchangeRate,
+						TierCounter:  incr.BalanceInfo.Monetary.TierCounter,
+						RatingID:     ec.ratingIDForRateInterval(incr.BalanceInfo.Monetary.RateInterval, rf),
+						Parents:      incr.BalanceInfo.Parents.Clone()})
+			} else if len(incr.BalanceInfo.Parents) != 0 { // free for the account, charged to its parents
+				cIt.AccountingID = ec.Accounting.GetIDWithSet(
+					&BalanceCharge{
+						AccountID: incr.BalanceInfo.AccountID,
+						Parents:   incr.BalanceInfo.Parents.Clone()})
 			}
 			cIl.Increments[j] = cIt
 		}
@@ -141,6 +152,8 @@ func (ec *EventCost) ratingIDForRateInterval(ri *RateInterval, rf RatingMatchedF
 			RoundingDecimals: ri.Rating.RoundingDecimals,
 			MaxCost:          ri.Rating.MaxCost,
 			MaxCostStrategy:  ri.Rating.MaxCostStrategy,
+			Currency:         ri.Rating.Currency,
+			TierCounter:      ri.Rating.TierCounter,
 			TimingID:         tmID,
 			RatesID:          rtUUID,
 			RatingFiltersID:  rfUUID})
@@ -155,7 +168,8 @@ func (ec *EventCost) rateIntervalForRatingID(ratingID string) (ri *RateInterval)
 	ri.Rating = &RIRate{ConnectFee: cIlRU.ConnectFee,
 		RoundingMethod:   cIlRU.RoundingMethod,
 		RoundingDecimals: cIlRU.RoundingDecimals,
-		MaxCost:          cIlRU.MaxCost, MaxCostStrategy: cIlRU.MaxCostStrategy}
+		MaxCost:          cIlRU.MaxCost, MaxCostStrategy: cIlRU.MaxCostStrategy,
+		Currency: cIlRU.Currency, TierCounter: cIlRU.TierCounter}
 	if cIlRU.RatesID != "" {
 		ri.Rating.Rates = ec.Rates[cIlRU.RatesID]
 	}
@@ -282,6 +296,7 @@ func (ec *EventCost) AsCallCost() *CallCost {
 			if cInc.AccountingID != "" {
 				cBC := ec.Accounting[cInc.AccountingID]
 				incr.BalanceInfo.AccountID = cBC.AccountID
+				incr.BalanceInfo.Parents = cBC.Parents.Clone()
 				var balanceType string
 				if cBC.BalanceUUID != "" {
 					if ec.AccountSummary != nil {
@@ -306,7 +321,8 @@ func (ec *EventCost) AsCallCost() *CallCost {
 					}
 				}
 				if cBC.ExtraChargeID != utils.META_NONE {
-					incr.BalanceInfo.Monetary = &MonetaryInfo{UUID: cBC.BalanceUUID}
+					incr.BalanceInfo.Monetary = &MonetaryInfo{UUID: cBC.BalanceUUID, ExchangeRate: cBC.ExchangeRate,
+						TierCounter: cBC.TierCounter}
 					incr.BalanceInfo.Monetary.RateInterval = ec.rateIntervalForRatingID(cBC.RatingID)
 				}
 			}
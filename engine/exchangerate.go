@@ -0,0 +1,140 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// ExchangeRate converts amounts between two currencies, starting with ActivationTime
+type ExchangeRate struct {
+	ActivationTime time.Time
+	Rate           float64 // units of the destination currency for one unit of the source currency
+}
+
+// ExchangeRates holds the rates of one currency pair
+type ExchangeRates struct {
+	FromCurrency string
+	ToCurrency   string
+	Rates        []*ExchangeRate // sorted on ActivationTime
+}
+
+func (ers *ExchangeRates) ID() string {
+	return utils.ConcatenatedKey(ers.FromCurrency, ers.ToCurrency)
+}
+
+// Sort orders the rates on their activation time
+func (ers *ExchangeRates) Sort() {
+	sort.Slice(ers.Rates, func(i, j int) bool { return ers.Rates[i].ActivationTime.Before(ers.Rates[j].ActivationTime) })
+}
+
+// RateAt returns the rate active at t
+func (ers *ExchangeRates) RateAt(t time.Time) (rate float64, has bool) {
+	for _, er := range ers.Rates {
+		if er.ActivationTime.After(t) {
+			break
+		}
+		rate, has = er.Rate, true
+	}
+	return
+}
+
+// getExchangeRates returns the exchange rates of one currency pair, cached after being queried in DataDB
+func getExchangeRates(fromCurrency, toCurrency string) (ers *ExchangeRates, err error) {
+	key := utils.ExchangeRatesPrefix + utils.ConcatenatedKey(fromCurrency, toCurrency)
+	if x, ok := cache.Get(key); ok {
+		if x == nil {
+			return nil, utils.ErrNotFound
+		}
+		return x.(*ExchangeRates), nil
+	}
+	if ers, err = dataStorage.GetExchangeRates(fromCurrency, toCurrency); err != nil {
+		if err == utils.ErrNotFound {
+			cache.Set(key, nil, true, utils.NonTransactional)
+		}
+		return
+	}
+	cache.Set(key, ers, true, utils.NonTransactional)
+	return
+}
+
+// GetExchangeRate returns the rate converting amounts from one currency into another at t,
+// using the inverse of the opposite pair if only that one is defined.
+// An empty currency stands for the default one, not converted if that is not configured either.
+func GetExchangeRate(fromCurrency, toCurrency string, t time.Time) (float64, error) {
+	fromCurrency = utils.FirstNonEmpty(fromCurrency, defaultCurrency)
+	toCurrency = utils.FirstNonEmpty(toCurrency, defaultCurrency)
+	if fromCurrency == "" || toCurrency == "" || fromCurrency == toCurrency {
+		return 1, nil
+	}
+	if ers, err := getExchangeRates(fromCurrency, toCurrency); err == nil {
+		if rate, has := ers.RateAt(t); has && rate != 0 {
+			return rate, nil
+		}
+	} else if err != utils.ErrNotFound {
+		return 0, err
+	}
+	if ers, err := getExchangeRates(toCurrency, fromCurrency); err == nil {
+		if rate, has := ers.RateAt(t); has && rate != 0 {
+			return 1 / rate, nil
+		}
+	} else if err != utils.ErrNotFound {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no exchange rate from %s to %s at %s", fromCurrency, toCurrency, t.String())
+}
+
+// exchangeRate returns the rate converting costs priced in currency into the currency of balance b,
+// 0 if no conversion is needed
+func (b *Balance) exchangeRate(currency string, t time.Time) (float64, error) {
+	balCurrency := utils.FirstNonEmpty(b.Currency, defaultCurrency)
+	currency = utils.FirstNonEmpty(currency, defaultCurrency)
+	if balCurrency == "" || currency == "" || balCurrency == currency {
+		return 0, nil
+	}
+	return GetExchangeRate(currency, balCurrency, t)
+}
+
+// convertCost returns the cost converted with exRate, unchanged if exRate is 0
+func convertCost(cost, exRate float64) float64 {
+	if exRate == 0 {
+		return cost
+	}
+	return utils.Round(cost*exRate, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+}
+
+// ChargedAmount returns the cost as it was debited from the monetary balance
+func (mi *MonetaryInfo) ChargedAmount(cost float64) float64 {
+	if mi == nil {
+		return cost
+	}
+	return convertCost(cost, mi.ExchangeRate)
+}
+
+// Currency returns the currency the prices are expressed in
+func (ri *RateInterval) Currency() string {
+	if ri == nil || ri.Rating == nil {
+		return ""
+	}
+	return ri.Rating.Currency
+}
@@ -0,0 +1,167 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestExchangeRatesRateAt(t *testing.T) {
+	ers := &ExchangeRates{FromCurrency: "GBP", ToCurrency: "EUR",
+		Rates: []*ExchangeRate{
+			&ExchangeRate{ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.2},
+			&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.1},
+		}}
+	ers.Sort()
+	if _, has := ers.RateAt(time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)); has {
+		t.Error("Expecting no rate before the first activation")
+	}
+	if rate, has := ers.RateAt(time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC)); !has || rate != 1.1 {
+		t.Errorf("Received rate: %v", rate)
+	}
+	if rate, has := ers.RateAt(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)); !has || rate != 1.2 {
+		t.Errorf("Received rate: %v", rate)
+	}
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	ers := &ExchangeRates{FromCurrency: "CHF", ToCurrency: "RON",
+		Rates: []*ExchangeRate{&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 4}}}
+	if err := dataStorage.SetExchangeRates(ers); err != nil {
+		t.Fatal(err)
+	}
+	tm := time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC)
+	if rate, err := GetExchangeRate("CHF", "RON", tm); err != nil || rate != 4 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+	if rate, err := GetExchangeRate("RON", "CHF", tm); err != nil || rate != 0.25 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+	if rate, err := GetExchangeRate("", "CHF", tm); err != nil || rate != 1 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+	if _, err := GetExchangeRate("CHF", "RON", time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expecting error for missing exchange rate")
+	}
+	if _, err := GetExchangeRate("CHF", "JPY", tm); err == nil {
+		t.Error("Expecting error for missing exchange rate")
+	}
+}
+
+func TestDebitMoneyExchangeRate(t *testing.T) {
+	if err := dataStorage.SetExchangeRates(&ExchangeRates{FromCurrency: "CHF", ToCurrency: "RON",
+		Rates: []*ExchangeRate{&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 4}}}); err != nil {
+		t.Fatal(err)
+	}
+	cc := &CallCost{
+		Direction:   utils.OUT,
+		Destination: "0723045326",
+		Timespans: []*TimeSpan{
+			&TimeSpan{
+				TimeStart:     time.Date(2014, 9, 24, 10, 48, 0, 0, time.UTC),
+				TimeEnd:       time.Date(2014, 9, 24, 10, 49, 0, 0, time.UTC),
+				DurationIndex: 0,
+				RateInterval: &RateInterval{Rating: &RIRate{Currency: "CHF",
+					Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 0.25, RateIncrement: 10 * time.Second, RateUnit: 10 * time.Second}}}},
+			},
+		},
+		TOR: utils.VOICE,
+	}
+	cd := &CallDescriptor{
+		TimeStart:     cc.Timespans[0].TimeStart,
+		TimeEnd:       cc.Timespans[0].TimeEnd,
+		Direction:     cc.Direction,
+		Destination:   cc.Destination,
+		TOR:           cc.TOR,
+		DurationIndex: cc.GetDuration(),
+		testCallcost:  cc,
+	}
+	acc := &Account{ID: "cgrates.org:exr", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "money", Value: 10, Currency: "RON"}},
+	}}
+	cc, err := acc.debitCreditBalance(cd, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := acc.BalanceMap[utils.MONETARY][0].GetValue(); val != 4 {
+		t.Errorf("Expecting 4 left in balance, received: %v", val)
+	}
+	if cost := cc.Timespans[0].CalculateCost(); cost != 1.5 {
+		t.Errorf("Expecting cost of 1.5 in rating currency, received: %v", cost)
+	}
+	inc := cc.Timespans[0].Increments[0]
+	if inc.BalanceInfo.Monetary.ExchangeRate != 4 {
+		t.Errorf("Unexpected monetary info: %+v", inc.BalanceInfo.Monetary)
+	}
+	if charged := inc.BalanceInfo.Monetary.ChargedAmount(inc.Cost); charged != 1 {
+		t.Errorf("Unexpected charged amount: %v", charged)
+	}
+}
+
+func TestGetExchangeRateCached(t *testing.T) {
+	tm := time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := dataStorage.SetExchangeRates(&ExchangeRates{FromCurrency: "USD", ToCurrency: "GBP",
+		Rates: []*ExchangeRate{&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.8}}}); err != nil {
+		t.Fatal(err)
+	}
+	if rate, err := GetExchangeRate("USD", "GBP", tm); err != nil || rate != 0.8 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+	ms := dataStorage.(*MapStorage)
+	ms.mu.Lock()
+	delete(ms.dict, utils.ExchangeRatesPrefix+"USD:GBP") // bypass cache invalidation
+	ms.mu.Unlock()
+	if rate, err := GetExchangeRate("USD", "GBP", tm); err != nil || rate != 0.8 {
+		t.Errorf("Expecting cached rate, received: %v, error: %v", rate, err)
+	}
+	if err := dataStorage.SetExchangeRates(&ExchangeRates{FromCurrency: "USD", ToCurrency: "GBP",
+		Rates: []*ExchangeRate{&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetExchangeRate("USD", "GBP", tm); err == nil {
+		t.Error("Expecting error for zero exchange rate")
+	}
+	if err := dataStorage.RemExchangeRates("USD", "GBP"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBalanceExchangeRateDefaultCurrency(t *testing.T) {
+	if err := dataStorage.SetExchangeRates(&ExchangeRates{FromCurrency: "CHF", ToCurrency: "RON",
+		Rates: []*ExchangeRate{&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 4}}}); err != nil {
+		t.Fatal(err)
+	}
+	tm := time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC)
+	if rate, err := (&Balance{}).exchangeRate("RON", tm); err != nil || rate != 0 {
+		t.Errorf("Expecting no conversion without default currency, received: %v, error: %v", rate, err)
+	}
+	SetDefaultCurrency("CHF")
+	defer SetDefaultCurrency("")
+	if rate, err := (&Balance{}).exchangeRate("RON", tm); err != nil || rate != 0.25 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+	if rate, err := (&Balance{Currency: "RON"}).exchangeRate("", tm); err != nil || rate != 4 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+	if rate, err := (&Balance{}).exchangeRate("", tm); err != nil || rate != 0 {
+		t.Errorf("Received rate: %v, error: %v", rate, err)
+	}
+}
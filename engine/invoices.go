@@ -0,0 +1,381 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// invoiceChargesGroupBy groups the subscription CDRs into recurring charges
+var invoiceChargesGroupBy = []string{"SubscriptionID", "ChargeType"}
+
+// invoiceTemplateFuncs are available in all the invoice templates
+var invoiceTemplateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"seconds": func(d time.Duration) float64 {
+		return d.Seconds()
+	},
+}
+
+// htmlInvoiceTemplate renders the *html invoices
+var htmlInvoiceTemplate = htmltemplate.Must(htmltemplate.New(utils.MetaHTML).Funcs(invoiceTemplateFuncs).Parse(`<html>
+<head><title>Invoice {{.Number}}</title></head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>Tenant: {{.Tenant}}<br>Account: {{.Account}}<br>Period: {{date .PeriodStart}} - {{date .PeriodEnd}}<br>Issued: {{date .CreatedAt}}</p>
+{{define "lines"}}<table>
+<tr><th>Description</th><th>Count</th><th>Usage</th><th>Cost</th></tr>
+{{range .}}<tr><td>{{.GroupDescription}}</td><td>{{.Count}}</td><td>{{.Usage}}</td><td>{{.Cost}}</td></tr>
+{{end}}</table>{{end}}
+{{if .UsageLines}}<h2>Usage</h2>
+{{template "lines" .UsageLines}}{{end}}
+{{if .Charges}}<h2>Recurring charges</h2>
+{{template "lines" .Charges}}{{end}}
+{{if .Taxes}}<h2>Taxes</h2>
+{{template "lines" .Taxes}}{{end}}
+<p>Subtotal: {{.Subtotal}}<br>Taxes: {{.TaxTotal}}<br>Total: {{.Total}}</p>
+</body>
+</html>
+`))
+
+// Invoice is the billing document of one account over one period, frozen in StorDB once generated
+type Invoice struct {
+	Tenant      string
+	Number      int64 // sequential per tenant, assigned when stored
+	Account     string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	UsageLines  []*InvoiceLine // rated CDRs, grouped on the configured fields
+	Charges     []*InvoiceLine // recurring charges of the account subscriptions
+	Taxes       []*InvoiceLine // taxes applied on the rated CDRs, one line per tax
+	Subtotal    float64        // usage and recurring charges, taxes excluded
+	TaxTotal    float64
+	Total       float64
+	CreatedAt   time.Time
+}
+
+// InvoiceLine sums up the CDRs sharing the same group values
+type InvoiceLine struct {
+	GroupValues map[string]string
+	Count       int64
+	Usage       time.Duration
+	Cost        float64
+}
+
+// GroupDescription returns the group values sorted on field name, eg: "Category: call, Destination: 49"
+func (il *InvoiceLine) GroupDescription() string {
+	flds := make([]string, 0, len(il.GroupValues))
+	for fld := range il.GroupValues {
+		flds = append(flds, fld)
+	}
+	sort.Strings(flds)
+	descr := make([]string, len(flds))
+	for i, fld := range flds {
+		descr[i] = fld + ": " + il.GroupValues[fld]
+	}
+	return strings.Join(descr, ", ")
+}
+
+// InvoiceFilter selects stored invoices of one tenant
+type InvoiceFilter struct {
+	Tenant  string
+	Account string // empty for all the accounts of the tenant
+	Number  int64  // 0 for any number
+	utils.Paginator
+}
+
+// ArgsGenerateInvoice selects the account and the billing period [PeriodStart, PeriodEnd) to invoice
+type ArgsGenerateInvoice struct {
+	Tenant      string
+	Account     string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	GroupBy     []string // overwrites the configured group_by
+}
+
+// NewInvoiceService constructs the InvoiceS working on the CDRs in cdrDB.
+// InvoiceS is API only, constructed by ApierV1 on each request, hence not started by cgr-engine
+func NewInvoiceService(cdrDB CdrStorage, cfg *config.CGRConfig) *InvoiceService {
+	return &InvoiceService{cdrDB: cdrDB, cfg: cfg}
+}
+
+// InvoiceService generates, stores and renders invoices out of rated CDRs
+type InvoiceService struct {
+	cdrDB CdrStorage
+	cfg   *config.CGRConfig
+}
+
+// GenerateInvoice aggregates the CDRs of the account within the period and stores the invoice under the next number of the tenant,
+// periods already invoiced for the account are rejected with ErrExists
+func (invS *InvoiceService) GenerateInvoice(args *ArgsGenerateInvoice) (*Invoice, error) {
+	if !args.PeriodEnd.After(args.PeriodStart) {
+		return nil, errors.New("PeriodEnd needs to be after PeriodStart")
+	}
+	groupBy := args.GroupBy
+	if len(groupBy) == 0 {
+		groupBy = invS.cfg.InvoiceSGroupBy
+	}
+	usageFltr := invS.cdrsFilter(args)
+	usageFltr.RunIDs = invS.cfg.InvoiceSRunIDs
+	usageFltr.NotSources = []string{utils.MetaSubscription}
+	usageFltr.MinCost = utils.Float64Pointer(0) // unrated CDRs have negative cost
+	usageLines, err := invS.invoiceLines(usageFltr, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	chrgsFltr := invS.cdrsFilter(args)
+	chrgsFltr.Sources = []string{utils.MetaSubscription}
+	charges, err := invS.invoiceLines(chrgsFltr, invoiceChargesGroupBy)
+	if err != nil {
+		return nil, err
+	}
+	taxes, err := invS.taxLines(usageFltr)
+	if err != nil {
+		return nil, err
+	}
+	inv := newInvoice(args, usageLines, charges, taxes, invS.cfg.RoundingDecimals)
+	if _, err := guardian.Guardian.Guard(func() (interface{}, error) {
+		if invoiced, err := invS.periodInvoiced(args); err != nil {
+			return nil, err
+		} else if invoiced {
+			return nil, utils.ErrExists
+		}
+		return nil, invS.cdrDB.SetInvoice(inv)
+	}, 0, utils.ConcatenatedKey(utils.TBLInvoices, inv.Tenant)); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// GetInvoices returns the stored invoices matching the filter
+func (invS *InvoiceService) GetInvoices(fltr *InvoiceFilter) ([]*Invoice, error) {
+	return invS.cdrDB.GetInvoices(fltr)
+}
+
+// RenderInvoice renders the invoice with one of the *json, *csv or *html built-in templates
+// or with a template out of templates_dir, the ID being the file name
+func (invS *InvoiceService) RenderInvoice(inv *Invoice, tmplID string) (string, error) {
+	switch tmplID {
+	case "", utils.MetaJSON:
+		return utils.ToIJSON(inv), nil
+	case utils.MetaCSV:
+		return renderInvoiceCSV(inv)
+	case utils.MetaHTML:
+		var buf bytes.Buffer
+		if err := htmlInvoiceTemplate.Execute(&buf, inv); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	if invS.cfg.InvoiceSTemplatesDir == "" || filepath.Base(tmplID) != tmplID {
+		return "", fmt.Errorf("unsupported invoice template: %s", tmplID)
+	}
+	tmplPath := path.Join(invS.cfg.InvoiceSTemplatesDir, tmplID)
+	var buf bytes.Buffer
+	if filepath.Ext(tmplID) == ".html" { // escape the values
+		tmpl, err := htmltemplate.New(tmplID).Funcs(invoiceTemplateFuncs).ParseFiles(tmplPath)
+		if err != nil {
+			return "", err
+		}
+		if err := tmpl.Execute(&buf, inv); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	tmpl, err := template.New(tmplID).Funcs(invoiceTemplateFuncs).ParseFiles(tmplPath)
+	if err != nil {
+		return "", err
+	}
+	if err := tmpl.Execute(&buf, inv); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// cdrsFilter selects the CDRs of the account answered within the billing period
+func (invS *InvoiceService) cdrsFilter(args *ArgsGenerateInvoice) *utils.CDRsFilter {
+	return &utils.CDRsFilter{Tenants: []string{args.Tenant}, Accounts: []string{args.Account},
+		AnswerTimeStart: &args.PeriodStart, AnswerTimeEnd: &args.PeriodEnd}
+}
+
+// invoiceLines aggregates the CDRs matching the filter, one line per group, sorted on group description
+func (invS *InvoiceService) invoiceLines(fltr *utils.CDRsFilter, groupBy []string) ([]*InvoiceLine, error) {
+	aggrs, err := invS.cdrDB.GetCDRsAggregates(fltr, &CDRsAggregation{GroupBy: groupBy})
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lines := make([]*InvoiceLine, len(aggrs))
+	for i, aggr := range aggrs {
+		lines[i] = &InvoiceLine{GroupValues: aggr.GroupValues, Count: aggr.Count,
+			Usage: aggr.TotalUsage, Cost: utils.Round(aggr.TotalCost, invS.cfg.RoundingDecimals, utils.ROUNDING_MIDDLE)}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].GroupDescription() < lines[j].GroupDescription()
+	})
+	return lines, nil
+}
+
+// taxLines sums up the taxes stored by CDRS into the ExtraFields of the CDRs matching the filter, one line per tax,
+// CDRs carrying only the TaxTotal are summed up on a line of their own. The CDRs are read one page at a time
+func (invS *InvoiceService) taxLines(fltr *utils.CDRsFilter) ([]*InvoiceLine, error) {
+	linesIdx := make(map[string]*InvoiceLine)
+	addTax := func(cdr *CDR, taxID, amount string) error {
+		cost, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s for CDR with CGRID: %s", taxID, amount, cdr.CGRID)
+		}
+		line, has := linesIdx[taxID]
+		if !has {
+			line = &InvoiceLine{GroupValues: map[string]string{utils.Tax: taxID}}
+			linesIdx[taxID] = line
+		}
+		line.Count++
+		line.Usage += cdr.Usage
+		line.Cost += cost
+		return nil
+	}
+	var cursor int64
+	for {
+		cdrs, nextCursor, err := GetCDRsPage(invS.cdrDB, fltr, cursor)
+		if err != nil {
+			if err == utils.ErrNotFound {
+				break
+			}
+			return nil, err
+		}
+		for _, cdr := range cdrs {
+			var detailed bool
+			for fld, val := range cdr.ExtraFields {
+				if !strings.HasPrefix(fld, utils.TaxFieldPrefix) {
+					continue
+				}
+				if err := addTax(cdr, strings.TrimPrefix(fld, utils.TaxFieldPrefix), val); err != nil {
+					return nil, err
+				}
+				detailed = true
+			}
+			if taxTotal, has := cdr.ExtraFields[utils.TaxTotal]; has && !detailed {
+				if err := addTax(cdr, utils.TaxTotal, taxTotal); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if nextCursor == 0 {
+			break
+		}
+		cursor = nextCursor
+	}
+	if len(linesIdx) == 0 {
+		return nil, nil
+	}
+	lines := make([]*InvoiceLine, 0, len(linesIdx))
+	for _, line := range linesIdx {
+		line.Cost = utils.Round(line.Cost, invS.cfg.RoundingDecimals, utils.ROUNDING_MIDDLE)
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].GroupDescription() < lines[j].GroupDescription()
+	})
+	return lines, nil
+}
+
+// periodInvoiced checks whether a stored invoice of the account overlaps the requested period
+func (invS *InvoiceService) periodInvoiced(args *ArgsGenerateInvoice) (bool, error) {
+	invs, err := invS.cdrDB.GetInvoices(&InvoiceFilter{Tenant: args.Tenant, Account: args.Account})
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, inv := range invs {
+		if inv.PeriodStart.Before(args.PeriodEnd) && args.PeriodStart.Before(inv.PeriodEnd) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newInvoice builds the invoice out of its lines, computing the totals
+func newInvoice(args *ArgsGenerateInvoice, usageLines, charges, taxes []*InvoiceLine, roundingDecimals int) *Invoice {
+	inv := &Invoice{Tenant: args.Tenant, Account: args.Account,
+		PeriodStart: args.PeriodStart, PeriodEnd: args.PeriodEnd,
+		UsageLines: usageLines, Charges: charges, Taxes: taxes,
+		CreatedAt: time.Now()}
+	for _, lines := range [][]*InvoiceLine{usageLines, charges} {
+		for _, line := range lines {
+			inv.Subtotal += line.Cost
+		}
+	}
+	for _, line := range taxes {
+		inv.TaxTotal += line.Cost
+	}
+	inv.Subtotal = utils.Round(inv.Subtotal, roundingDecimals, utils.ROUNDING_MIDDLE)
+	inv.TaxTotal = utils.Round(inv.TaxTotal, roundingDecimals, utils.ROUNDING_MIDDLE)
+	inv.Total = utils.Round(inv.Subtotal+inv.TaxTotal, roundingDecimals, utils.ROUNDING_MIDDLE)
+	return inv
+}
+
+// renderInvoiceCSV renders one record per invoice line followed by the totals
+func renderInvoiceCSV(inv *Invoice) (string, error) {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+	records := [][]string{{"Number", "Account", "PeriodStart", "PeriodEnd", "Section", "Description", "Count", "Usage", "Cost"}}
+	invFlds := []string{strconv.FormatInt(inv.Number, 10), inv.Account,
+		inv.PeriodStart.Format(time.RFC3339), inv.PeriodEnd.Format(time.RFC3339)}
+	for _, section := range []struct {
+		name  string
+		lines []*InvoiceLine
+	}{{"Usage", inv.UsageLines}, {"Charges", inv.Charges}, {"Taxes", inv.Taxes}} {
+		for _, line := range section.lines {
+			records = append(records, append(append([]string{}, invFlds...), section.name, line.GroupDescription(),
+				strconv.FormatInt(line.Count, 10), line.Usage.String(), strconv.FormatFloat(line.Cost, 'f', -1, 64)))
+		}
+	}
+	for _, total := range []struct {
+		name  string
+		value float64
+	}{{"Subtotal", inv.Subtotal}, {"TaxTotal", inv.TaxTotal}, {"Total", inv.Total}} {
+		records = append(records, append(append([]string{}, invFlds...), total.name, "", "", "",
+			strconv.FormatFloat(total.value, 'f', -1, 64)))
+	}
+	if err := csvWriter.WriteAll(records); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,231 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+var testInvoice = newInvoice(&ArgsGenerateInvoice{Tenant: "cgrates.org", Account: "1001",
+	PeriodStart: time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)},
+	[]*InvoiceLine{
+		&InvoiceLine{GroupValues: map[string]string{utils.DESTINATION: "49", utils.CATEGORY: "call"},
+			Count: 3, Usage: time.Duration(5 * time.Minute), Cost: 1.25},
+		&InvoiceLine{GroupValues: map[string]string{utils.DESTINATION: "40", utils.CATEGORY: "call"},
+			Count: 1, Usage: time.Duration(time.Minute), Cost: 0.3333},
+	},
+	[]*InvoiceLine{
+		&InvoiceLine{GroupValues: map[string]string{"SubscriptionID": "PREMIUM", "ChargeType": utils.MetaRenewal},
+			Count: 1, Usage: time.Duration(31 * 24 * time.Hour), Cost: 10},
+	},
+	[]*InvoiceLine{
+		&InvoiceLine{GroupValues: map[string]string{utils.Tax: "VAT"}, Count: 4, Cost: 2.3},
+	}, 4)
+
+func TestInvoiceTotals(t *testing.T) {
+	if testInvoice.Subtotal != 11.5833 {
+		t.Errorf("Unexpected subtotal: %v", testInvoice.Subtotal)
+	}
+	if testInvoice.TaxTotal != 2.3 {
+		t.Errorf("Unexpected tax total: %v", testInvoice.TaxTotal)
+	}
+	if testInvoice.Total != 13.8833 {
+		t.Errorf("Unexpected total: %v", testInvoice.Total)
+	}
+	if descr := testInvoice.UsageLines[0].GroupDescription(); descr != "Category: call, Destination: 49" {
+		t.Errorf("Unexpected description: %s", descr)
+	}
+}
+
+func TestInvoiceRender(t *testing.T) {
+	tmplDir, err := ioutil.TempDir("", "invoice_templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmplDir)
+	if err := ioutil.WriteFile(path.Join(tmplDir, "short.txt"),
+		[]byte(`{{.Account}} {{date .PeriodStart}}: {{.Total}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.InvoiceSTemplatesDir = tmplDir
+	invS := NewInvoiceService(nil, cfg)
+	if out, err := invS.RenderInvoice(testInvoice, "short.txt"); err != nil {
+		t.Error(err)
+	} else if out != "1001 2017-05-01: 13.8833" {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	if out, err := invS.RenderInvoice(testInvoice, utils.MetaCSV); err != nil {
+		t.Error(err)
+	} else if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 8 {
+		t.Errorf("Unexpected output: %s", out)
+	} else if lines[4] != `0,1001,2017-05-01T00:00:00Z,2017-06-01T00:00:00Z,Taxes,Tax: VAT,4,0s,2.3` {
+		t.Errorf("Unexpected tax record: %s", lines[4])
+	}
+	if out, err := invS.RenderInvoice(testInvoice, utils.MetaHTML); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(out, "<td>ChargeType: *renewal, SubscriptionID: PREMIUM</td>") ||
+		!strings.Contains(out, "Total: 13.8833") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	if _, err := invS.RenderInvoice(testInvoice, "../short.txt"); err == nil {
+		t.Error("Expecting error for template outside templates_dir")
+	}
+}
+
+// GetCDRsAggregates groups the CDRs in memory, computing only the metrics used on invoices
+func (ts *testCdrStorage) GetCDRsAggregates(fltr *utils.CDRsFilter, aggr *CDRsAggregation) ([]*CDRsAggregate, error) {
+	cdrs, _, err := ts.GetCDRs(fltr, false)
+	if err != nil {
+		return nil, err
+	}
+	var aggrs []*CDRsAggregate
+	aggrsIdx := make(map[string]*CDRsAggregate)
+	for _, cdr := range cdrs {
+		grpVals := make(map[string]string)
+		var grpKey []string
+		for _, fld := range aggr.GroupBy {
+			rsrFld, _ := utils.NewRSRField(fld)
+			grpVals[fld] = cdr.FieldAsString(rsrFld)
+			grpKey = append(grpKey, grpVals[fld])
+		}
+		ca, has := aggrsIdx[utils.ConcatenatedKey(grpKey...)]
+		if !has {
+			ca = &CDRsAggregate{GroupValues: grpVals}
+			aggrsIdx[utils.ConcatenatedKey(grpKey...)] = ca
+			aggrs = append(aggrs, ca)
+		}
+		ca.Count++
+		ca.TotalUsage += cdr.Usage
+		ca.TotalCost += cdr.Cost
+	}
+	return aggrs, nil
+}
+
+func (ts *testCdrStorage) SetInvoice(inv *Invoice) error {
+	inv.Number = int64(len(ts.invoices) + 1)
+	ts.invoices = append(ts.invoices, inv)
+	return nil
+}
+
+func (ts *testCdrStorage) GetInvoices(fltr *InvoiceFilter) (invs []*Invoice, err error) {
+	for _, inv := range ts.invoices {
+		if inv.Tenant == fltr.Tenant && (fltr.Account == "" || inv.Account == fltr.Account) &&
+			(fltr.Number == 0 || inv.Number == fltr.Number) {
+			invs = append(invs, inv)
+		}
+	}
+	if len(invs) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
+func TestInvoiceGenerateLocalTaxes(t *testing.T) {
+	ts := new(testCdrStorage)
+	for i, cdr := range []*CDR{
+		&CDR{CGRID: "TAXED1", RunID: utils.META_DEFAULT, Tenant: "cgrates.org", Account: "1001", Category: "call",
+			Destination: "4986517174963", AnswerTime: time.Date(2015, 3, 1, 10, 0, 0, 0, time.UTC),
+			Usage: time.Duration(time.Minute), Cost: 10},
+		&CDR{CGRID: "TAXED2", RunID: utils.META_DEFAULT, Tenant: "cgrates.org", Account: "1001", Category: "call",
+			Destination: "4986517174963", AnswerTime: time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC),
+			Usage: time.Duration(2 * time.Minute), Cost: 10},
+		&CDR{CGRID: "SUBSCRIPTION1", RunID: utils.META_DEFAULT, Source: utils.MetaSubscription, Tenant: "cgrates.org",
+			Account: "1001", AnswerTime: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), Cost: 3,
+			ExtraFields: map[string]string{"SubscriptionID": "BASIC", "ChargeType": utils.MetaRenewal}},
+	} {
+		if i < 2 { // taxed by CDRS before storing
+			if err := applyLocalTaxes(cdr, nil, 4); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ts.SetCDR(cdr, false)
+	}
+	cfg, _ := config.NewDefaultCGRConfig()
+	invS := NewInvoiceService(ts, cfg)
+	args := &ArgsGenerateInvoice{Tenant: "cgrates.org", Account: "1001",
+		PeriodStart: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)}
+	inv, err := invS.GenerateInvoice(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Number != 1 || len(inv.UsageLines) != 1 || len(inv.Charges) != 1 || len(inv.Taxes) != 2 {
+		t.Fatalf("Unexpected invoice: %s", utils.ToJSON(inv))
+	}
+	if descr := inv.Taxes[0].GroupDescription(); descr != "Tax: LEVY" || inv.Taxes[0].Count != 2 || inv.Taxes[0].Cost != 0.238 {
+		t.Errorf("Unexpected tax line: %s, %+v", descr, inv.Taxes[0])
+	}
+	if descr := inv.Taxes[1].GroupDescription(); descr != "Tax: VAT" || inv.Taxes[1].Cost != 3.8 {
+		t.Errorf("Unexpected tax line: %s, %+v", descr, inv.Taxes[1])
+	}
+	if inv.Subtotal != 23 || inv.TaxTotal != 4.038 || inv.Total != 27.038 {
+		t.Errorf("Unexpected totals: %v, %v, %v", inv.Subtotal, inv.TaxTotal, inv.Total)
+	}
+	// overlapping the period already invoiced
+	if _, err := invS.GenerateInvoice(&ArgsGenerateInvoice{Tenant: "cgrates.org", Account: "1001",
+		PeriodStart: time.Date(2015, 3, 15, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2015, 4, 15, 0, 0, 0, 0, time.UTC)}); err != utils.ErrExists {
+		t.Errorf("Expecting ErrExists, received: %v", err)
+	}
+	args.PeriodStart, args.PeriodEnd = args.PeriodEnd, time.Date(2015, 5, 1, 0, 0, 0, 0, time.UTC)
+	if inv, err := invS.GenerateInvoice(args); err != nil {
+		t.Error(err)
+	} else if inv.Number != 2 || len(inv.Taxes) != 0 || inv.Total != 0 {
+		t.Errorf("Unexpected invoice: %s", utils.ToJSON(inv))
+	}
+}
+
+func TestInvoiceTaxLinesPaged(t *testing.T) {
+	ts := new(testCdrStorage)
+	for i, taxes := range []map[string]string{
+		map[string]string{utils.TaxFieldPrefix + "VAT": "1.9"},
+		map[string]string{utils.TaxFieldPrefix + "VAT": "0.95", utils.TaxFieldPrefix + "LEVY": "0.1"},
+		map[string]string{utils.TaxTotal: "0.5"},
+	} {
+		ts.SetCDR(&CDR{CGRID: fmt.Sprintf("PAGED%d", i), OrderID: int64(i + 1), RunID: utils.META_DEFAULT,
+			Tenant: "cgrates.org", Account: "1001", ExtraFields: taxes}, false)
+	}
+	cfg, _ := config.NewDefaultCGRConfig()
+	invS := NewInvoiceService(ts, cfg)
+	lines, err := invS.taxLines(&utils.CDRsFilter{Accounts: []string{"1001"}, Paginator: utils.Paginator{Limit: utils.IntPointer(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taxes := make(map[string]*InvoiceLine)
+	for _, line := range lines {
+		taxes[line.GroupDescription()] = line
+	}
+	if len(taxes) != 3 {
+		t.Fatalf("Unexpected tax lines: %s", utils.ToJSON(lines))
+	}
+	if vat := taxes["Tax: VAT"]; vat == nil || vat.Count != 2 || vat.Cost != 2.85 {
+		t.Errorf("Unexpected VAT line: %+v", vat)
+	}
+	if total := taxes["Tax: "+utils.TaxTotal]; total == nil || total.Count != 1 || total.Cost != 0.5 {
+		t.Errorf("Unexpected TaxTotal line: %+v", total)
+	}
+}
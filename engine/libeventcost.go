@@ -153,11 +153,14 @@ func (cIt *ChargingIncrement) TotalCost() float64 {
 
 // BalanceCharge represents one unit charged to a balance
 type BalanceCharge struct {
-	AccountID     string  // keep reference for shared balances
-	BalanceUUID   string  // balance charged
-	RatingID      string  // special price applied on this balance
-	Units         float64 // number of units charged
-	ExtraChargeID string  // used in cases when paying *voice with *monetary
+	AccountID     string       // keep reference for shared balances
+	BalanceUUID   string       // balance charged
+	RatingID      string       // special price applied on this balance
+	Units         float64      // number of units charged, monetary ones in the rating currency
+	ExchangeRate  float64      // converting monetary Units into the balance currency, 0 if they are the same
+	TierCounter   string       // account counter the usage was added to, when rated on tiers
+	ExtraChargeID string       // used in cases when paying *voice with *monetary
+	Parents       ParentDebits // debits of the reseller accounts above, refunded together with this charge
 }
 
 func (bc *BalanceCharge) Equals(oBC *BalanceCharge) bool {
@@ -165,12 +168,16 @@ func (bc *BalanceCharge) Equals(oBC *BalanceCharge) bool {
 		bc.BalanceUUID == oBC.BalanceUUID &&
 		bc.RatingID == oBC.RatingID &&
 		bc.Units == oBC.Units &&
-		bc.ExtraChargeID == oBC.ExtraChargeID
+		bc.ExchangeRate == oBC.ExchangeRate &&
+		bc.TierCounter == oBC.TierCounter &&
+		bc.ExtraChargeID == oBC.ExtraChargeID &&
+		bc.Parents.Equal(oBC.Parents)
 }
 
 func (bc *BalanceCharge) Clone() *BalanceCharge {
 	clnBC := new(BalanceCharge)
 	*clnBC = *bc
+	clnBC.Parents = bc.Parents.Clone()
 	return clnBC
 }
 
@@ -223,6 +230,8 @@ type RatingUnit struct {
 	RoundingDecimals int
 	MaxCost          float64
 	MaxCostStrategy  string
+	Currency         string // currency of the rates, empty for the default one
+	TierCounter      string // account counter selecting the rate groups, empty for call duration
 	TimingID         string // This RatingUnit is bounded to specific timing profile
 	RatesID          string
 	RatingFiltersID  string
@@ -234,6 +243,8 @@ func (ru *RatingUnit) Equals(oRU *RatingUnit) bool {
 		ru.RoundingDecimals == oRU.RoundingDecimals &&
 		ru.MaxCost == oRU.MaxCost &&
 		ru.MaxCostStrategy == oRU.MaxCostStrategy &&
+		ru.Currency == oRU.Currency &&
+		ru.TierCounter == oRU.TierCounter &&
 		ru.TimingID == oRU.TimingID &&
 		ru.RatesID == oRU.RatesID &&
 		ru.RatingFiltersID == oRU.RatingFiltersID
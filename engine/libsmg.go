@@ -0,0 +1,43 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"time"
+)
+
+// SMGSessionCheckpoint is the state of one active SMGeneric session saved in DataDB so it survives restarts
+type SMGSessionCheckpoint struct {
+	CGRID          string
+	CheckpointTime time.Time
+	Runs           []*SMGRunCheckpoint // one for each derived charging run of the session
+}
+
+// SMGRunCheckpoint is the state of one session run at checkpoint time
+type SMGRunCheckpoint struct {
+	RunID         string
+	UnitTOR       string
+	Timezone      string
+	EventStart    map[string]interface{} // Event which started the session
+	CD            *CallDescriptor        // CD used for debits, as updated by the last one
+	EventCost     *EventCost
+	ExtraDuration time.Duration
+	LastUsage     time.Duration
+	LastDebit     time.Duration
+	TotalUsage    time.Duration
+}
@@ -121,6 +121,8 @@ func LoadTariffPlanFromFolder(tpPath, timezone string, dataDB DataDB, disable_re
 		path.Join(tpPath, utils.ResourcesCsv),
 		path.Join(tpPath, utils.StatsCsv),
 		path.Join(tpPath, utils.ThresholdsCsv),
+		path.Join(tpPath, utils.ExchangeRatesCsv),
+		path.Join(tpPath, utils.TaxRulesCsv),
 	), "", timezone)
 	if err := loader.LoadAll(); err != nil {
 		return utils.NewErrServerError(err)
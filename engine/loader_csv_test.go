@@ -157,6 +157,7 @@ DY_PLAN,RT_DY,*any,10
 SG1,*any,*lowest,
 SG2,*any,*lowest,one
 SG3,*any,*lowest,
+SG3,cgrates.org:quota,*lowest,,200,*monthly,*monetary
 `
 	lcrs = `
 *in,cgrates.org,call,*any,*any,EU_LANDLINE,LCR_STANDARD,*static,ivo;dan;rif,2012-01-01T00:00:00Z,10
@@ -279,6 +280,18 @@ Stats1,*string,Account,1001;1002,2014-07-29T15:00:00Z,100,1s,*asr;*acd;*acc,true
 	thresholds = `
 #Id[0],FilterType[1],FilterFieldName[2],FilterFieldValues[3],ActivationInterval[4],ThresholdType[5],ThresholdValue[6],MinItems[7],Recurrent[8],MinSleep[9],Blocker[10],Stored[11],Weight[12],ActionIDs[13]
 Threshold1,*string,Account,1001;1002,2014-07-29T15:00:00Z,,1.2,10,true,1s,true,true,10,
+`
+	exchangeRates = `
+#Id[0],FromCurrency[1],ToCurrency[2],ActivationTime[3],Rate[4]
+EXR_EUR_USD,EUR,USD,2014-01-01T00:00:00Z,1.35
+EXR_EUR_USD,EUR,USD,2012-01-01T00:00:00Z,1.3
+`
+	taxRules = `
+#Tenant[0],Id[1],TaxClass[2],Categories[3],DestinationIds[4],ActivationTime[5],Rate[6],Compound[7],Weight[8]
+cgrates.org,VAT,,,,2014-01-01T00:00:00Z,0.19,false,20
+cgrates.org,VAT,,,,2012-01-01T00:00:00Z,0.18,false,20
+cgrates.org,VAT,*exempt,,,2014-01-01T00:00:00Z,0,false,20
+cgrates.org,LEVY,,call,GERMANY,2014-01-01T00:00:00Z,0.01,true,10
 `
 )
 
@@ -286,7 +299,7 @@ var csvr *TpReader
 
 func init() {
 	csvr = NewTpReader(dataStorage, NewStringCSVStorage(',', destinations, timings, rates, destinationRates, ratingPlans, ratingProfiles,
-		sharedGroups, lcrs, actions, actionPlans, actionTriggers, accountActions, derivedCharges, cdrStats, users, aliases, resProfiles, stats, thresholds), testTPID, "")
+		sharedGroups, lcrs, actions, actionPlans, actionTriggers, accountActions, derivedCharges, cdrStats, users, aliases, resProfiles, stats, thresholds, exchangeRates, taxRules), testTPID, "")
 
 	if err := csvr.LoadDestinations(); err != nil {
 		log.Print("error in LoadDestinations:", err)
@@ -346,6 +359,12 @@ func init() {
 	if err := csvr.LoadThresholds(); err != nil {
 		log.Print("error in LoadThresholds:", err)
 	}
+	if err := csvr.LoadExchangeRates(); err != nil {
+		log.Print("error in LoadExchangeRates:", err)
+	}
+	if err := csvr.LoadTaxRules(); err != nil {
+		log.Print("error in LoadTaxRules:", err)
+	}
 	csvr.WriteToDatabase(false, false, false)
 	cache.Flush()
 	dataStorage.LoadRatingCache(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
@@ -1042,6 +1061,24 @@ func TestLoadSharedGroups(t *testing.T) {
 	if !reflect.DeepEqual(sg2, expected) {
 		t.Error("Error loading shared group: ", sg2.AccountParameters)
 	}
+	sg3 := csvr.sharedGroups["SG3"]
+	expected = &SharedGroup{
+		Id: "SG3",
+		AccountParameters: map[string]*SharingParameters{
+			"*any": &SharingParameters{
+				Strategy: "*lowest",
+			},
+			"cgrates.org:quota": &SharingParameters{
+				Strategy:         "*lowest",
+				Quota:            200,
+				QuotaPeriod:      utils.MetaMonthly,
+				QuotaBalanceType: utils.MONETARY,
+			},
+		},
+	}
+	if !reflect.DeepEqual(sg3, expected) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(expected), utils.ToJSON(sg3))
+	}
 	/*sg, _ := dataStorage.GetSharedGroup("SG1", false)
 	  if len(sg.Members) != 0 {
 	      t.Errorf("Memebers should be empty: %+v", sg)
@@ -1488,3 +1525,40 @@ func TestLoadThresholds(t *testing.T) {
 		t.Errorf("Expecting: %+v, received: %+v", eThresholds["Threshold1"], csvr.thresholds["Threshold1"])
 	}
 }
+
+func TestLoadExchangeRates(t *testing.T) {
+	eERs := &ExchangeRates{
+		FromCurrency: "EUR",
+		ToCurrency:   "USD",
+		Rates: []*ExchangeRate{
+			&ExchangeRate{ActivationTime: time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.3},
+			&ExchangeRate{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.35},
+		},
+	}
+	if len(csvr.exchangeRates) != 1 {
+		t.Error("Failed to load exchange rates: ", len(csvr.exchangeRates))
+	} else if ers := csvr.exchangeRates["EUR:USD"]; !reflect.DeepEqual(eERs, ers) {
+		t.Errorf("Expecting: %+v, received: %+v", eERs, ers)
+	}
+}
+
+func TestLoadTaxRules(t *testing.T) {
+	eTRs := &TaxRules{
+		Tenant: "cgrates.org",
+		Rules: []*TaxRule{
+			&TaxRule{ID: "LEVY", Categories: utils.StringMap{"call": true}, DestinationIDs: utils.StringMap{"GERMANY": true},
+				ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.01, Compound: true, Weight: 10},
+			&TaxRule{ID: "VAT", Categories: utils.StringMap{}, DestinationIDs: utils.StringMap{},
+				ActivationTime: time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.18, Weight: 20},
+			&TaxRule{ID: "VAT", Categories: utils.StringMap{}, DestinationIDs: utils.StringMap{},
+				ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.19, Weight: 20},
+			&TaxRule{ID: "VAT", TaxClass: "*exempt", Categories: utils.StringMap{}, DestinationIDs: utils.StringMap{},
+				ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Weight: 20},
+		},
+	}
+	if len(csvr.taxRules) != 1 {
+		t.Error("Failed to load tax rules: ", len(csvr.taxRules))
+	} else if trs := csvr.taxRules["cgrates.org"]; !reflect.DeepEqual(eTRs, trs) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eTRs), utils.ToJSON(trs))
+	}
+}
@@ -107,6 +107,8 @@ func TestLoaderITLoadFromCSV(t *testing.T) {
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.ResourcesCsv),
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.StatsCsv),
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.ThresholdsCsv),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.ExchangeRatesCsv),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.TaxRulesCsv),
 	), "", "")
 
 	if err = loader.LoadDestinations(); err != nil {
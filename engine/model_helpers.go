@@ -39,6 +39,9 @@ func csvLoad(s interface{}, values []string) (interface{}, error) {
 		index := field.Tag.Get("index")
 		if index != "" {
 			idx, err := strconv.Atoi(index)
+			if err == nil && len(values) <= idx && field.Tag.Get("optional") == "true" {
+				continue // trailing column missing from older files, left to its default
+			}
 			if err != nil || len(values) <= idx {
 				return nil, fmt.Errorf("invalid %v.%v index %v", st.Name(), field.Name, index)
 			}
@@ -167,6 +170,8 @@ func modelEqual(this interface{}, other interface{}) bool {
 	return true
 }
 
+// getColumnCount returns the number of CSV columns of the model,
+// -1 if it has optional trailing columns and the records can have a variable number of fields
 func getColumnCount(s interface{}) int {
 	st := reflect.TypeOf(s)
 	numFields := st.NumField()
@@ -175,6 +180,9 @@ func getColumnCount(s interface{}) int {
 		field := st.Field(i)
 		index := field.Tag.Get("index")
 		if index != "" {
+			if field.Tag.Get("optional") == "true" {
+				return -1
+			}
 			count++
 		}
 	}
@@ -406,6 +414,8 @@ func (tps TpDestinationRates) AsMapDestinationRates() (map[string]*utils.TPDesti
 					RoundingDecimals: tp.RoundingDecimals,
 					MaxCost:          tp.MaxCost,
 					MaxCostStrategy:  tp.MaxCostStrategy,
+					Currency:         tp.Currency,
+					TierCounter:      tp.TierCounter,
 				},
 			},
 		}
@@ -455,6 +465,8 @@ func APItoModelDestinationRate(d *utils.TPDestinationRate) (result TpDestination
 				RoundingDecimals: dr.RoundingDecimals,
 				MaxCost:          dr.MaxCost,
 				MaxCostStrategy:  dr.MaxCostStrategy,
+				Currency:         dr.Currency,
+				TierCounter:      dr.TierCounter,
 			})
 		}
 		if len(d.DestinationRates) == 0 {
@@ -530,6 +542,8 @@ func GetRateInterval(rpl *utils.TPRatingPlanBinding, dr *utils.DestinationRate)
 			RoundingDecimals: dr.RoundingDecimals,
 			MaxCost:          dr.MaxCost,
 			MaxCostStrategy:  dr.MaxCostStrategy,
+			Currency:         dr.Currency,
+			TierCounter:      dr.TierCounter,
 			tag:              dr.Rate.ID,
 		},
 	}
@@ -689,9 +703,12 @@ func (tps TpSharedGroups) AsMapTPSharedGroups() (map[string]*utils.TPSharedGroup
 			ID:   tp.Tag,
 		}
 		sg := &utils.TPSharedGroup{
-			Account:       tp.Account,
-			Strategy:      tp.Strategy,
-			RatingSubject: tp.RatingSubject,
+			Account:          tp.Account,
+			Strategy:         tp.Strategy,
+			RatingSubject:    tp.RatingSubject,
+			Quota:            tp.Quota,
+			QuotaPeriod:      tp.QuotaPeriod,
+			QuotaBalanceType: tp.QuotaBalanceType,
 		}
 		if existing, exists := result[sgs.ID]; !exists {
 			sgs.SharedGroups = []*utils.TPSharedGroup{sg}
@@ -732,11 +749,14 @@ func APItoModelSharedGroup(sgs *utils.TPSharedGroups) (result TpSharedGroups) {
 	if sgs != nil {
 		for _, sg := range sgs.SharedGroups {
 			result = append(result, TpSharedGroup{
-				Tpid:          sgs.TPid,
-				Tag:           sgs.ID,
-				Account:       sg.Account,
-				Strategy:      sg.Strategy,
-				RatingSubject: sg.RatingSubject,
+				Tpid:             sgs.TPid,
+				Tag:              sgs.ID,
+				Account:          sg.Account,
+				Strategy:         sg.Strategy,
+				RatingSubject:    sg.RatingSubject,
+				Quota:            sg.Quota,
+				QuotaPeriod:      sg.QuotaPeriod,
+				QuotaBalanceType: sg.QuotaBalanceType,
 			})
 		}
 		if len(sgs.SharedGroups) == 0 {
@@ -758,6 +778,122 @@ func APItoModelSharedGroups(sgs []*utils.TPSharedGroups) (result TpSharedGroups)
 	return result
 }
 
+type TpExchangeRates []TpExchangeRate
+
+func (tps TpExchangeRates) AsMapTPExchangeRates() map[string]*utils.TPExchangeRates {
+	result := make(map[string]*utils.TPExchangeRates)
+	for _, tp := range tps {
+		er := &utils.TPExchangeRate{
+			FromCurrency:   tp.FromCurrency,
+			ToCurrency:     tp.ToCurrency,
+			ActivationTime: tp.ActivationTime,
+			Rate:           tp.Rate,
+		}
+		if existing, exists := result[tp.Tag]; !exists {
+			result[tp.Tag] = &utils.TPExchangeRates{
+				TPid:          tp.Tpid,
+				ID:            tp.Tag,
+				ExchangeRates: []*utils.TPExchangeRate{er},
+			}
+		} else {
+			existing.ExchangeRates = append(existing.ExchangeRates, er)
+		}
+	}
+	return result
+}
+
+func (tps TpExchangeRates) AsTPExchangeRates() (result []*utils.TPExchangeRates) {
+	for _, tp := range tps.AsMapTPExchangeRates() {
+		result = append(result, tp)
+	}
+	return
+}
+
+func APItoModelExchangeRates(ers *utils.TPExchangeRates) (result TpExchangeRates) {
+	if ers != nil {
+		for _, er := range ers.ExchangeRates {
+			result = append(result, TpExchangeRate{
+				Tpid:           ers.TPid,
+				Tag:            ers.ID,
+				FromCurrency:   er.FromCurrency,
+				ToCurrency:     er.ToCurrency,
+				ActivationTime: er.ActivationTime,
+				Rate:           er.Rate,
+			})
+		}
+		if len(ers.ExchangeRates) == 0 {
+			result = append(result, TpExchangeRate{
+				Tpid: ers.TPid,
+				Tag:  ers.ID,
+			})
+		}
+	}
+	return
+}
+
+type TpTaxRules []TpTaxRule
+
+func (tps TpTaxRules) AsMapTPTaxRules() map[string]*utils.TPTaxRules {
+	result := make(map[string]*utils.TPTaxRules)
+	for _, tp := range tps {
+		tr := &utils.TPTaxRule{
+			TaxClass:       tp.TaxClass,
+			Categories:     tp.Categories,
+			DestinationIDs: tp.DestinationIds,
+			ActivationTime: tp.ActivationTime,
+			Rate:           tp.Rate,
+			Compound:       tp.Compound,
+			Weight:         tp.Weight,
+		}
+		key := utils.ConcatenatedKey(tp.Tenant, tp.Tag)
+		if existing, exists := result[key]; !exists {
+			result[key] = &utils.TPTaxRules{
+				TPid:     tp.Tpid,
+				Tenant:   tp.Tenant,
+				ID:       tp.Tag,
+				TaxRules: []*utils.TPTaxRule{tr},
+			}
+		} else {
+			existing.TaxRules = append(existing.TaxRules, tr)
+		}
+	}
+	return result
+}
+
+func (tps TpTaxRules) AsTPTaxRules() (result []*utils.TPTaxRules) {
+	for _, tp := range tps.AsMapTPTaxRules() {
+		result = append(result, tp)
+	}
+	return
+}
+
+func APItoModelTaxRules(trs *utils.TPTaxRules) (result TpTaxRules) {
+	if trs != nil {
+		for _, tr := range trs.TaxRules {
+			result = append(result, TpTaxRule{
+				Tpid:           trs.TPid,
+				Tenant:         trs.Tenant,
+				Tag:            trs.ID,
+				TaxClass:       tr.TaxClass,
+				Categories:     tr.Categories,
+				DestinationIds: tr.DestinationIDs,
+				ActivationTime: tr.ActivationTime,
+				Rate:           tr.Rate,
+				Compound:       tr.Compound,
+				Weight:         tr.Weight,
+			})
+		}
+		if len(trs.TaxRules) == 0 {
+			result = append(result, TpTaxRule{
+				Tpid:   trs.TPid,
+				Tenant: trs.Tenant,
+				Tag:    trs.ID,
+			})
+		}
+	}
+	return
+}
+
 type TpActions []TpAction
 
 func (tps TpActions) AsMapTPActions() (map[string]*utils.TPActions, error) {
@@ -41,6 +41,45 @@ func TestModelHelperCsvLoadInt(t *testing.T) {
 	}
 }
 
+func TestModelHelperCsvLoadOptional(t *testing.T) {
+	type tpOptional struct {
+		Tag   string  `index:"0" re:"\w+"`
+		Value float64 `index:"1" re:"" optional:"true"`
+	}
+	if cnt := getColumnCount(tpOptional{}); cnt != -1 {
+		t.Errorf("Expecting variable column count, received: %d", cnt)
+	}
+	if cnt := getColumnCount(TpDestination{}); cnt != 2 {
+		t.Errorf("Expecting 2 columns, received: %d", cnt)
+	}
+	if l, err := csvLoad(tpOptional{}, []string{"TEST_OPT"}); err != nil {
+		t.Error(err)
+	} else if tp := l.(tpOptional); tp.Tag != "TEST_OPT" || tp.Value != 0 {
+		t.Errorf("model load failed: %+v", tp)
+	}
+	if l, err := csvLoad(tpOptional{}, []string{"TEST_OPT", "1.5"}); err != nil {
+		t.Error(err)
+	} else if tp := l.(tpOptional); tp.Value != 1.5 {
+		t.Errorf("model load failed: %+v", tp)
+	}
+	if _, err := csvLoad(tpOptional{}, []string{}); err == nil {
+		t.Error("Expecting error for missing mandatory column")
+	}
+}
+
+func TestModelHelperCsvLoadDestinationRate(t *testing.T) {
+	if l, err := csvLoad(TpDestinationRate{}, []string{"DR_1", "DST_1", "RT_1", "*up", "4", "0", ""}); err != nil {
+		t.Error(err)
+	} else if tpdr := l.(TpDestinationRate); tpdr.RatesTag != "RT_1" || tpdr.Currency != "" || tpdr.TierCounter != "" {
+		t.Errorf("model load failed: %+v", tpdr)
+	}
+	if l, err := csvLoad(TpDestinationRate{}, []string{"DR_1", "DST_1", "RT_1", "*up", "4", "0", "", "EUR", "CNTR_1"}); err != nil {
+		t.Error(err)
+	} else if tpdr := l.(TpDestinationRate); tpdr.Currency != "EUR" || tpdr.TierCounter != "CNTR_1" {
+		t.Errorf("model load failed: %+v", tpdr)
+	}
+}
+
 func TestModelHelperCsvDump(t *testing.T) {
 	tpd := TpDestination{
 		Tag:    "TEST_DEST",
@@ -135,17 +174,19 @@ func TestTPDestinationRateAsExportSlice(t *testing.T) {
 				DestinationId:    "TEST_DEST1",
 				RateId:           "TEST_RATE1",
 				RoundingMethod:   "*up",
-				RoundingDecimals: 4},
+				RoundingDecimals: 4,
+				Currency:         "USD"},
 			&utils.DestinationRate{
 				DestinationId:    "TEST_DEST2",
 				RateId:           "TEST_RATE2",
 				RoundingMethod:   "*up",
-				RoundingDecimals: 4},
+				RoundingDecimals: 4,
+				TierCounter:      "MONTHLY_MINUTES"},
 		},
 	}
 	expectedSlc := [][]string{
-		[]string{"TEST_DSTRATE", "TEST_DEST1", "TEST_RATE1", "*up", "4", "0", ""},
-		[]string{"TEST_DSTRATE", "TEST_DEST2", "TEST_RATE2", "*up", "4", "0", ""},
+		[]string{"TEST_DSTRATE", "TEST_DEST1", "TEST_RATE1", "*up", "4", "0", "", "USD", ""},
+		[]string{"TEST_DSTRATE", "TEST_DEST2", "TEST_RATE2", "*up", "4", "0", "", "", "MONTHLY_MINUTES"},
 	}
 	ms := APItoModelDestinationRate(tpDstRate)
 	var slc [][]string
@@ -372,8 +413,8 @@ func TestTPSharedGroupsAsExportSlice(t *testing.T) {
 		},
 	}
 	expectedSlc := [][]string{
-		[]string{"SHARED_GROUP_TEST", "*any", "*highest", "special1"},
-		[]string{"SHARED_GROUP_TEST", "second", "*highest", "special2"},
+		[]string{"SHARED_GROUP_TEST", "*any", "*highest", "special1", "0", "", ""},
+		[]string{"SHARED_GROUP_TEST", "second", "*highest", "special2", "0", "", ""},
 	}
 
 	ms := APItoModelSharedGroup(tpSGs)
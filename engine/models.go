@@ -70,6 +70,8 @@ type TpDestinationRate struct {
 	RoundingDecimals int     `index:"4" re:"\d+"`
 	MaxCost          float64 `index:"5" re:"\d+\.*\d*s*"`
 	MaxCostStrategy  string  `index:"6" re:"\*free|\*disconnect"`
+	Currency         string  `index:"7" re:"\w*" optional:"true"`
+	TierCounter      string  `index:"8" re:"" optional:"true"`
 	CreatedAt        time.Time
 }
 
@@ -241,13 +243,42 @@ func (aa *TpAccountAction) GetAccountActionId() string {
 }
 
 type TpSharedGroup struct {
-	Id            int64
-	Tpid          string
-	Tag           string `index:"0" re:"\w+\s*"`
-	Account       string `index:"1" re:"\*?\w+\s*"`
-	Strategy      string `index:"2" re:"\*\w+\s*"`
-	RatingSubject string `index:"3" re:"\*?\w]+\s*"`
-	CreatedAt     time.Time
+	Id               int64
+	Tpid             string
+	Tag              string  `index:"0" re:"\w+\s*"`
+	Account          string  `index:"1" re:"\*?\w+\s*"`
+	Strategy         string  `index:"2" re:"\*\w+\s*"`
+	RatingSubject    string  `index:"3" re:"\*?\w]+\s*"`
+	Quota            float64 `index:"4" re:"" optional:"true"`
+	QuotaPeriod      string  `index:"5" re:"" optional:"true"`
+	QuotaBalanceType string  `index:"6" re:"" optional:"true"`
+	CreatedAt        time.Time
+}
+
+type TpExchangeRate struct {
+	Id             int64
+	Tpid           string
+	Tag            string  `index:"0" re:"\w+\s*"`
+	FromCurrency   string  `index:"1" re:"\w+\s*"`
+	ToCurrency     string  `index:"2" re:"\w+\s*"`
+	ActivationTime string  `index:"3" re:""`
+	Rate           float64 `index:"4" re:"\d+\.?\d*"`
+	CreatedAt      time.Time
+}
+
+type TpTaxRule struct {
+	Id             int64
+	Tpid           string
+	Tenant         string  `index:"0" re:""`
+	Tag            string  `index:"1" re:"\w+\s*"`
+	TaxClass       string  `index:"2" re:""`
+	Categories     string  `index:"3" re:""`
+	DestinationIds string  `index:"4" re:""`
+	ActivationTime string  `index:"5" re:""`
+	Rate           float64 `index:"6" re:"\d+\.?\d*"`
+	Compound       bool    `index:"7" re:""`
+	Weight         float64 `index:"8" re:"\d+\.?\d*"`
+	CreatedAt      time.Time
 }
 
 type TpDerivedCharger struct {
@@ -450,6 +481,39 @@ func (t TBLSMCosts) TableName() string {
 	return utils.TBLSMCosts
 }
 
+type TBLBalanceLedger struct {
+	ID          int64
+	AccountID   string
+	BalanceUUID string
+	BalanceID   string
+	BalanceType string
+	ValueBefore float64
+	ValueAfter  float64
+	Operation   string
+	Cause       string
+	CreatedAt   time.Time
+}
+
+func (t TBLBalanceLedger) TableName() string {
+	return utils.TBLBalanceLedger
+}
+
+type TBLInvoice struct {
+	ID          int64
+	Tenant      string
+	Number      int64
+	Account     string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Total       float64
+	Content     string
+	CreatedAt   time.Time
+}
+
+func (t TBLInvoice) TableName() string {
+	return utils.TBLInvoices
+}
+
 type TpResource struct {
 	ID                 int64
 	Tpid               string
@@ -32,9 +32,10 @@ import (
 Defines a time interval for which a certain set of prices will apply
 */
 type RateInterval struct {
-	Timing *RITiming
-	Rating *RIRate
-	Weight float64
+	Timing     *RITiming
+	Rating     *RIRate
+	Weight     float64
+	tierOffset time.Duration // moves the usage on tiered rate groups, set at rating out of the account counter
 }
 
 // Separate structure used for rating plan size optimization
@@ -213,12 +214,20 @@ type RIRate struct {
 	RoundingDecimals int
 	MaxCost          float64
 	MaxCostStrategy  string
+	Currency         string     // currency of the prices, empty for the default one
+	TierCounter      string     // ID of the account counter positioning the usage on the rate groups, empty to use the call duration
 	Rates            RateGroups // GroupRateInterval (start time): Rate
 	tag              string     // loading validation only
 }
 
 func (rir *RIRate) Stringify() string {
 	str := fmt.Sprintf("%v %v %v %v %v", rir.ConnectFee, rir.RoundingMethod, rir.RoundingDecimals, rir.MaxCost, rir.MaxCostStrategy)
+	if rir.Currency != "" {
+		str += " " + rir.Currency
+	}
+	if rir.TierCounter != "" {
+		str += " " + rir.TierCounter
+	}
 	for _, r := range rir.Rates {
 		str += r.Stringify()
 	}
@@ -0,0 +1,99 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import "time"
+
+// TierCounter returns the ID of the account counter selecting the rate groups, empty if the interval is not tiered
+func (i *RateInterval) TierCounter() string {
+	if i == nil || i.Rating == nil {
+		return ""
+	}
+	return i.Rating.TierCounter
+}
+
+func (i *RateInterval) getTierOffset() time.Duration {
+	if i == nil {
+		return 0
+	}
+	return i.tierOffset
+}
+
+// withTierOffset returns a copy of the interval with its rate groups moved by offset
+func (i *RateInterval) withTierOffset(offset time.Duration) *RateInterval {
+	ri := *i
+	ri.tierOffset = offset
+	return &ri
+}
+
+// tierOffset returns how far the counter with counterID moves the usage on the rate groups.
+// The counter already contains the part of the call debited before, so only the difference is added.
+func (cd *CallDescriptor) tierOffset(counterID string) time.Duration {
+	if cd.tierCounters == nil {
+		if acc, err := dataStorage.GetAccount(cd.GetAccountKey()); err == nil && acc.UnitCounters != nil {
+			cd.tierCounters = acc.UnitCounters
+		} else {
+			cd.tierCounters = make(UnitCounters) // do not look for the account again
+		}
+	}
+	value, _ := cd.tierCounters.counterValue(cd.TOR, counterID)
+	return time.Duration(value*float64(time.Second)) - (cd.DurationIndex - cd.GetDuration())
+}
+
+// counterValue returns the value of the kind counter with the ID
+func (ucs UnitCounters) counterValue(kind, id string) (float64, bool) {
+	for _, uc := range ucs[kind] {
+		if uc == nil { // safeguard
+			continue
+		}
+		for _, c := range uc.Counters {
+			if c.Filter != nil && c.Filter.GetID() == id {
+				return c.Value, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// addTierUnits adds the usage rated on tiered rates to the kind counters with the ID
+func (ucs UnitCounters) addTierUnits(amount float64, kind, id string) {
+	for _, uc := range ucs[kind] {
+		if uc == nil { // safeguard
+			continue
+		}
+		for _, c := range uc.Counters {
+			if c.Filter != nil && c.Filter.GetID() == id {
+				c.Value += amount
+			}
+		}
+	}
+}
+
+// countTierUnits records the usage paid out of monetary balances on a tiered rate,
+// the one paid with unit balances being already counted by the event counters
+func (acc *Account) countTierUnits(amount float64, kind, counterID string) {
+	acc.UnitCounters.addTierUnits(amount, kind, counterID)
+	acc.ExecuteActionTriggers(nil)
+}
+
+// countTierUsage counts the usage of an increment rated on a tiered interval
+func (acc *Account) countTierUsage(inc *Increment, ri *RateInterval, kind string) {
+	if counterID := ri.TierCounter(); counterID != "" && inc.Duration != 0 {
+		acc.countTierUnits(inc.Duration.Seconds(), kind, counterID)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func testTieredRateInterval() *RateInterval {
+	return &RateInterval{
+		Timing: &RITiming{StartTime: "00:00:00"},
+		Rating: &RIRate{
+			RoundingMethod:   utils.ROUNDING_MIDDLE,
+			RoundingDecimals: 4,
+			TierCounter:      "MONTHLY_MINUTES",
+			Rates: RateGroups{
+				&Rate{GroupIntervalStart: 0, Value: 0.02, RateIncrement: time.Minute, RateUnit: time.Minute},
+				&Rate{GroupIntervalStart: 10 * time.Minute, Value: 0.01, RateIncrement: time.Minute, RateUnit: time.Minute},
+			},
+		},
+	}
+}
+
+func testTieredCounters(value float64) UnitCounters {
+	return UnitCounters{utils.VOICE: []*UnitCounter{
+		&UnitCounter{CounterType: utils.COUNTER_EVENT,
+			Counters: CounterFilters{&CounterFilter{Value: value,
+				Filter: &BalanceFilter{ID: utils.StringPointer("MONTHLY_MINUTES")}}}},
+	}}
+}
+
+func TestTieredSplitSpans(t *testing.T) {
+	cd := &CallDescriptor{Direction: utils.OUT, Category: "call", TOR: utils.VOICE,
+		Tenant: "cgrates.org", Subject: "tiered", Account: "tiered", Destination: "49",
+		TimeStart:     time.Date(2015, 4, 24, 8, 0, 0, 0, time.UTC),
+		TimeEnd:       time.Date(2015, 4, 24, 8, 3, 0, 0, time.UTC),
+		DurationIndex: 3 * time.Minute,
+		RatingInfos: RatingInfos{&RatingInfo{ActivationTime: time.Date(2015, 4, 23, 0, 0, 0, 0, time.UTC),
+			RateIntervals: RateIntervalList{testTieredRateInterval()}}},
+		tierCounters: testTieredCounters(540), // 9 minutes used this month
+	}
+	timespans := cd.splitInTimeSpans()
+	if len(timespans) != 2 {
+		t.Fatalf("Wrong number of timespans: %s", utils.ToJSON(timespans))
+	}
+	if timespans[0].GetDuration() != time.Minute || timespans[0].CalculateCost() != 0.02 {
+		t.Errorf("Wrong first tier timespan: %s", utils.ToJSON(timespans[0]))
+	}
+	if timespans[1].GetDuration() != 2*time.Minute || timespans[1].CalculateCost() != 0.02 {
+		t.Errorf("Wrong second tier timespan: %s", utils.ToJSON(timespans[1]))
+	}
+	// second debit of the same call, with the first 3 minutes already counted
+	cd.TimeStart, cd.TimeEnd = cd.TimeEnd, cd.TimeEnd.Add(3*time.Minute)
+	cd.DurationIndex = 6 * time.Minute
+	cd.tierCounters = testTieredCounters(720)
+	timespans = cd.splitInTimeSpans()
+	if len(timespans) != 1 || timespans[0].CalculateCost() != 0.03 {
+		t.Errorf("Wrong timespans: %s", utils.ToJSON(timespans))
+	}
+}
+
+func TestTieredDebitCounting(t *testing.T) {
+	ri := testTieredRateInterval()
+	cc := &CallCost{
+		Direction:   utils.OUT,
+		Destination: "49",
+		Timespans: []*TimeSpan{
+			&TimeSpan{
+				TimeStart:     time.Date(2015, 4, 24, 8, 0, 0, 0, time.UTC),
+				TimeEnd:       time.Date(2015, 4, 24, 8, 2, 0, 0, time.UTC),
+				DurationIndex: 2 * time.Minute,
+				RateInterval:  ri,
+			},
+		},
+		TOR: utils.VOICE,
+	}
+	cd := &CallDescriptor{
+		TimeStart:     cc.Timespans[0].TimeStart,
+		TimeEnd:       cc.Timespans[0].TimeEnd,
+		Direction:     cc.Direction,
+		Destination:   cc.Destination,
+		TOR:           cc.TOR,
+		DurationIndex: cc.GetDuration(),
+		testCallcost:  cc,
+	}
+	acc := &Account{ID: "cgrates.org:tiered", UnitCounters: testTieredCounters(60),
+		BalanceMap: map[string]Balances{
+			utils.MONETARY: Balances{&Balance{Uuid: "tiered_money", Value: 10}},
+		}}
+	cc, err := acc.debitCreditBalance(cd, true, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := acc.UnitCounters.counterValue(utils.VOICE, "MONTHLY_MINUTES"); val != 180 {
+		t.Errorf("Expecting counter at 180, received: %v", val)
+	}
+	if mi := cc.Timespans[0].Increments[0].BalanceInfo.Monetary; mi.TierCounter != "MONTHLY_MINUTES" {
+		t.Errorf("Unexpected monetary info: %+v", mi)
+	}
+	if err := dataStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	refundCD := &CallDescriptor{TOR: utils.VOICE, Increments: cc.Timespans[0].Increments[1:]}
+	if err := refundCD.RefundIncrements(); err != nil {
+		t.Fatal(err)
+	}
+	if acc, err = dataStorage.GetAccount(acc.ID); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := acc.UnitCounters.counterValue(utils.VOICE, "MONTHLY_MINUTES"); val != 120 {
+		t.Errorf("Expecting counter at 120 after refund, received: %v", val)
+	}
+}
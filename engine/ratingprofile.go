@@ -154,7 +154,7 @@ func (ris RatingInfos) String() string {
 func (rpf *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error) {
 	var ris RatingInfos
 	for index, rpa := range rpf.RatingPlanActivations.GetActiveForCall(cd) {
-		rpl, err := dataStorage.GetRatingPlan(rpa.RatingPlanId, false, utils.NonTransactional)
+		rpl, err := cd.getRatingPlan(rpa.RatingPlanId)
 		if err != nil || rpl == nil {
 			utils.Logger.Err(fmt.Sprintf("Error checking destination: %v", err))
 			continue
@@ -171,7 +171,7 @@ func (rpf *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error
 			}
 		} else {
 			for _, p := range utils.SplitPrefix(cd.Destination, MIN_PREFIX_MATCH) {
-				if destIDs, err := dataStorage.GetReverseDestination(p, false, utils.NonTransactional); err == nil {
+				if destIDs, err := cd.getReverseDestination(p); err == nil {
 					var bestWeight float64
 					for _, dID := range destIDs {
 						if _, ok := rpl.DestinationRates[dID]; ok {
@@ -255,10 +255,15 @@ type TenantRatingSubject struct {
 }
 
 func RatingProfileSubjectPrefixMatching(key string) (rp *RatingProfile, err error) {
+	return new(CallDescriptor).ratingProfileSubjectPrefixMatching(key)
+}
+
+// ratingProfileSubjectPrefixMatching matches the rating profile out of the tariff plan the CallDescriptor is rated on
+func (cd *CallDescriptor) ratingProfileSubjectPrefixMatching(key string) (rp *RatingProfile, err error) {
 	if !rpSubjectPrefixMatching || strings.HasSuffix(key, utils.ANY) {
-		return dataStorage.GetRatingProfile(key, false, utils.NonTransactional)
+		return cd.getRatingProfile(key)
 	}
-	if rp, err = dataStorage.GetRatingProfile(key, false, utils.NonTransactional); err == nil && rp != nil { // rp nil represents cached no-result
+	if rp, err = cd.getRatingProfile(key); err == nil && rp != nil { // rp nil represents cached no-result
 		return
 	}
 	lastIndex := strings.LastIndex(key, utils.CONCATENATED_KEY_SEP)
@@ -266,7 +271,7 @@ func RatingProfileSubjectPrefixMatching(key string) (rp *RatingProfile, err erro
 	subject := key[lastIndex:]
 	lenSubject := len(subject)
 	for i := 1; i < lenSubject-1; i++ {
-		if rp, err = dataStorage.GetRatingProfile(baseKey+subject[:lenSubject-i], false, utils.NonTransactional); err == nil && rp != nil {
+		if rp, err = cd.getRatingProfile(baseKey + subject[:lenSubject-i]); err == nil && rp != nil {
 			return
 		}
 	}
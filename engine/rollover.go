@@ -0,0 +1,120 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// RolloverParams are the extra parameters of the *rollover action, defining the balance receiving the unused units
+type RolloverParams struct {
+	ID         string   // ID of the rollover balance, mandatory since it replaces the one of the previous period
+	MaxValue   float64  // maximum units rolled over, 0 for no limit
+	ExpiryTime string   // expiration of the rollover balance, eg: *monthly or +720h, empty for unlimited
+	Weight     *float64 // weight of the rollover balance, defaults to one over the one of the rolled balances
+}
+
+// rolloverBalances moves the unused value of the balances matching the filter, expired ones included,
+// into a new balance cloned out of the first of them, returning it
+func (acc *Account) rolloverBalances(fltr *BalanceFilter, params *RolloverParams) (*Balance, error) {
+	if fltr == nil || fltr.Type == nil {
+		return nil, errors.New("missing balance type")
+	}
+	if params.ID == "" {
+		return nil, utils.NewErrMandatoryIeMissing("ID")
+	}
+	expDate, err := utils.ParseDate(params.ExpiryTime)
+	if err != nil {
+		return nil, err
+	}
+	var rolled *Balance
+	var value float64
+	for _, b := range acc.BalanceMap[*fltr.Type] {
+		if b.Disabled || b.ID == params.ID || !b.MatchFilter(fltr, false) {
+			continue
+		}
+		if rolled == nil {
+			rolled = b.Clone()
+		} else if b.Weight > rolled.Weight {
+			rolled.Weight = b.Weight
+		}
+		if b.GetValue() > 0 {
+			value += b.GetValue()
+			b.SetValue(0)
+		}
+	}
+	if rolled == nil {
+		return nil, utils.ErrNotFound
+	}
+	if params.MaxValue > 0 && value > params.MaxValue {
+		value = params.MaxValue
+	}
+	if value == 0 {
+		return nil, nil
+	}
+	// the rollover balance replaces the one of the previous period
+	bChain := acc.BalanceMap[*fltr.Type]
+	for i := 0; i < len(bChain); i++ {
+		if bChain[i].ID == params.ID {
+			bChain = append(bChain[:i], bChain[i+1:]...)
+			i--
+		}
+	}
+	rolled.Uuid = utils.GenUUID()
+	rolled.ID = params.ID
+	rolled.Value = value
+	rolled.ExpirationDate = expDate
+	if params.Weight != nil {
+		rolled.Weight = *params.Weight
+	} else {
+		rolled.Weight++ // consumed before the balances it comes from
+	}
+	rolled.CreditLimit = 0
+	rolled.dirty = true
+	acc.BalanceMap[*fltr.Type] = append(bChain, rolled)
+	return rolled, nil
+}
+
+// rolloverAction moves the unused units of the balances matched by the action into a rollover balance,
+// meant to be executed before the *topup_reset renewing them or at their expiration
+func rolloverAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+	if acc == nil {
+		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
+	}
+	var params RolloverParams
+	if a.ExtraParameters != "" {
+		if err := json.Unmarshal([]byte(a.ExtraParameters), &params); err != nil {
+			return err
+		}
+	}
+	rolled, err := acc.rolloverBalances(a.Balance, &params)
+	if err != nil {
+		return err
+	}
+	a.balanceValue = 0
+	if rolled != nil {
+		a.balanceValue = rolled.GetValue() // logged by *cdrlog as the action value
+		acc.InitCounters()
+		acc.ExecuteActionTriggers(nil)
+	}
+	return nil
+}
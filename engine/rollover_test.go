@@ -0,0 +1,120 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestRolloverBalances(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:rollover", BalanceMap: map[string]Balances{
+		utils.VOICE: Balances{
+			&Balance{Uuid: "uuid1", ID: "MONTHLY_VOICE", Value: 600 * float64(time.Second), Weight: 10,
+				DestinationIDs: utils.StringMap{"NAT": true}},
+			&Balance{Uuid: "uuid2", ID: "ROLLOVER_VOICE", Value: 20 * float64(time.Second), Weight: 30},
+			&Balance{Uuid: "uuid3", ID: "OTHER", Value: 100 * float64(time.Second), Weight: 10},
+		}}}
+	fltr := &BalanceFilter{Type: utils.StringPointer(utils.VOICE), ID: utils.StringPointer("MONTHLY_VOICE")}
+	rolled, err := acc.rolloverBalances(fltr, &RolloverParams{ID: "ROLLOVER_VOICE",
+		MaxValue: 500 * float64(time.Second), ExpiryTime: "*monthly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolled.ID != "ROLLOVER_VOICE" || rolled.Uuid == "uuid1" ||
+		rolled.GetValue() != 500*float64(time.Second) || rolled.Weight != 11 ||
+		!rolled.DestinationIDs.Equal(utils.StringMap{"NAT": true}) ||
+		rolled.ExpirationDate.Before(time.Now().AddDate(0, 1, -1)) {
+		t.Errorf("Unexpected rollover balance: %s", utils.ToJSON(rolled))
+	}
+	if acc.BalanceMap[utils.VOICE].GetBalance("uuid1").GetValue() != 0 {
+		t.Errorf("Unused units left in the rolled balance: %s", utils.ToJSON(acc.BalanceMap[utils.VOICE]))
+	}
+	// the previous rollover balance is replaced
+	if len(acc.BalanceMap[utils.VOICE]) != 3 || acc.BalanceMap[utils.VOICE].GetBalance("uuid2") != nil ||
+		acc.BalanceMap[utils.VOICE].GetBalance("uuid3").GetValue() != 100*float64(time.Second) {
+		t.Errorf("Unexpected balances: %s", utils.ToJSON(acc.BalanceMap[utils.VOICE]))
+	}
+	// nothing left to roll over
+	if rolled, err := acc.rolloverBalances(fltr, &RolloverParams{ID: "ROLLOVER_VOICE"}); err != nil || rolled != nil {
+		t.Errorf("Expecting no rollover, received: %s, %v", utils.ToJSON(rolled), err)
+	}
+	if _, err := acc.rolloverBalances(&BalanceFilter{Type: utils.StringPointer(utils.VOICE),
+		ID: utils.StringPointer("MISSING")}, &RolloverParams{ID: "ROLLOVER_VOICE"}); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	if _, err := acc.rolloverBalances(fltr, &RolloverParams{}); err == nil || err.Error() != utils.NewErrMandatoryIeMissing("ID").Error() {
+		t.Errorf("Expecting missing ID, received: %v", err)
+	}
+}
+
+func TestRolloverActionCdrlog(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:rollover", BalanceMap: map[string]Balances{
+		utils.SMS: Balances{&Balance{Uuid: "uuid1", ID: "MONTHLY_SMS", Value: 40, Weight: 10}}}}
+	rollover := &Action{ActionType: ROLLOVER, ExtraParameters: `{"ID":"ROLLOVER_SMS","MaxValue":25,"Weight":50}`,
+		Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS), ID: utils.StringPointer("MONTHLY_SMS")}}
+	topupReset := &Action{ActionType: TOPUP_RESET,
+		Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS), ID: utils.StringPointer("MONTHLY_SMS"),
+			Value: &utils.ValueFormula{Static: 100}}}
+	if err := rolloverAction(acc, nil, rollover, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := topupResetAction(acc, nil, topupReset, nil); err != nil {
+		t.Fatal(err)
+	}
+	var rolled *Balance
+	for _, b := range acc.BalanceMap[utils.SMS] {
+		if b.ID == "ROLLOVER_SMS" {
+			rolled = b
+		}
+	}
+	if rolled == nil || rolled.GetValue() != 25 || rolled.Weight != 50 || !rolled.ExpirationDate.IsZero() {
+		t.Errorf("Unexpected balances: %s", utils.ToJSON(acc.BalanceMap[utils.SMS]))
+	} else if acc.BalanceMap[utils.SMS].GetTotalValue() != 125 {
+		t.Errorf("Unexpected total value: %v", acc.BalanceMap[utils.SMS].GetTotalValue())
+	}
+	cdrlog := &Action{ActionType: CDRLOG}
+	if err := cdrLogAction(acc, nil, cdrlog, Actions{rollover}); err != nil {
+		t.Fatal(err)
+	}
+	var cdrs []*CDR
+	json.Unmarshal([]byte(cdrlog.ExpirationString), &cdrs)
+	if len(cdrs) != 1 || cdrs[0].RunID != ROLLOVER || cdrs[0].Cost != 25 {
+		t.Errorf("Wrong cdrlogs: %s", utils.ToJSON(cdrs))
+	}
+	// nothing left to roll over, not logged
+	for _, b := range acc.BalanceMap[utils.SMS] {
+		if b.ID == "MONTHLY_SMS" {
+			b.SetValue(0)
+		}
+	}
+	if err := rolloverAction(acc, nil, rollover, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cdrLogAction(acc, nil, cdrlog, Actions{rollover}); err != nil {
+		t.Fatal(err)
+	}
+	cdrs = nil
+	json.Unmarshal([]byte(cdrlog.ExpirationString), &cdrs)
+	if len(cdrs) != 0 {
+		t.Errorf("Wrong cdrlogs: %s", utils.ToJSON(cdrs))
+	}
+}
@@ -44,8 +44,11 @@ type SharedGroup struct {
 }
 
 type SharingParameters struct {
-	Strategy      string
-	RatingSubject string
+	Strategy         string
+	RatingSubject    string
+	Quota            float64 // maximum value the member can consume out of the shared balances within QuotaPeriod, 0 for no quota
+	QuotaPeriod      string  // *daily, *monthly or empty for a quota which is never reset
+	QuotaBalanceType string  // type of the shared balances the quota applies to, the other types being unlimited
 }
 
 func (sg *SharedGroup) SortBalancesByStrategy(myBalance *Balance, bc Balances) Balances {
@@ -100,11 +103,17 @@ func (sg *SharedGroup) GetBalances(destination, category, direction, balanceType
 			if nUb == nil || nUb.Disabled {
 				continue
 			}
+			nUb.inheritLedgerScope(ub)
 		}
 		//sg.members = append(sg.members, nUb)
 		sb := nUb.getBalancesForPrefix(destination, category, direction, balanceType, sg.Id)
 		bc = append(bc, sb...)
 	}
+	// count the consumption of the initiating user, bounded by its quota
+	usage := ub.sharedGroupUsage(sg, balanceType)
+	for _, b := range bc {
+		b.sharedUsage = usage
+	}
 	/*	} else {
 		for _, m := range sg.members {
 			sb := m.getBalancesForPrefix(destination, m.BalanceMap[balanceType], sg.Id)
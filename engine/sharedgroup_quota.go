@@ -0,0 +1,139 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// memberParameters returns the sharing parameters of an account, defaulting to the ones defined for *any
+func (sg *SharedGroup) memberParameters(acntID string) *SharingParameters {
+	if sp, has := sg.AccountParameters[acntID]; has {
+		return sp
+	}
+	return sg.AccountParameters[utils.ANY]
+}
+
+// memberQuota returns the quota of an account on the shared balances of balanceType, 0 if these are not limited,
+// and the period its consumption is counted on
+func (sg *SharedGroup) memberQuota(acntID, balanceType string) (quota float64, period string) {
+	sp := sg.memberParameters(acntID)
+	if sp == nil {
+		return
+	}
+	if sp.QuotaBalanceType == balanceType {
+		quota = sp.Quota
+	}
+	return quota, sp.QuotaPeriod
+}
+
+// sharedGroupUsage returns the consumption of the account out of the shared group balances of balanceType,
+// limited by the quota of the account within the group
+func (acc *Account) sharedGroupUsage(sg *SharedGroup, balanceType string) *SpendingLimit {
+	quota, period := sg.memberQuota(acc.ID, balanceType)
+	if acc.SharedGroupUsage == nil {
+		acc.SharedGroupUsage = make(map[string]map[string]*SpendingLimit)
+	}
+	if acc.SharedGroupUsage[sg.Id] == nil {
+		acc.SharedGroupUsage[sg.Id] = make(map[string]*SpendingLimit)
+	}
+	usage, has := acc.SharedGroupUsage[sg.Id][balanceType]
+	if !has || usage.Period != period { // counting starts over with a new quota period
+		var err error
+		if usage, err = NewSpendingLimit(quota, period); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<SharedGroups> shared group: %s, account: %s, %s", sg.Id, acc.ID, err.Error()))
+			usage = &SpendingLimit{}
+		}
+		acc.SharedGroupUsage[sg.Id][balanceType] = usage
+	}
+	usage.Limit = quota
+	return usage
+}
+
+// refundSharedGroupUsage gives back value to the usage of the debited account out of the shared groups of balance,
+// a negative value charging it instead
+func (cd *CallDescriptor) refundSharedGroupUsage(balance *Balance, balanceType string, value float64, accountsCache map[string]*Account) {
+	if len(balance.SharedGroups) == 0 || cd.Account == "" {
+		return
+	}
+	acc := cd.getRefundedAccount(cd.GetAccountKey(), accountsCache)
+	if acc == nil {
+		return
+	}
+	sgIDs := balance.SharedGroups.Slice()
+	sort.Strings(sgIDs)
+	for _, sgID := range sgIDs { // the debit counted the usage in one of the groups only
+		if usage, has := acc.SharedGroupUsage[sgID][balanceType]; has {
+			usage.AddSpent(-value, time.Now())
+			return
+		}
+	}
+}
+
+// SharedGroupMemberUsage is the consumption of one member out of the shared group balances of one type within the current quota period
+type SharedGroupMemberUsage struct {
+	AccountID   string
+	BalanceType string  // the quota balance type if the member did not consume yet
+	Quota       float64 // 0 for no quota on the balance type
+	QuotaPeriod string
+	PeriodStart time.Time
+	Used        float64
+}
+
+// GetMembersUsage returns the consumption of each member and balance type within the quota period containing t,
+// sorted on account and balance type
+func (sg *SharedGroup) GetMembersUsage(t time.Time) (mus []*SharedGroupMemberUsage, err error) {
+	for acntID := range sg.MemberIds {
+		acc, err := dataStorage.GetAccount(acntID)
+		if err != nil {
+			if err == utils.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		var used bool
+		for balanceType, usage := range acc.SharedGroupUsage[sg.Id] {
+			quota, period := sg.memberQuota(acntID, balanceType)
+			if usage.Period != period {
+				continue
+			}
+			mus = append(mus, &SharedGroupMemberUsage{AccountID: acntID, BalanceType: balanceType,
+				Quota: quota, QuotaPeriod: period, PeriodStart: usage.periodStart(t), Used: usage.SpentAt(t)})
+			used = true
+		}
+		if !used {
+			mu := &SharedGroupMemberUsage{AccountID: acntID}
+			if sp := sg.memberParameters(acntID); sp != nil {
+				mu.BalanceType, mu.QuotaPeriod = sp.QuotaBalanceType, sp.QuotaPeriod
+				mu.Quota, _ = sg.memberQuota(acntID, sp.QuotaBalanceType)
+			}
+			mus = append(mus, mu)
+		}
+	}
+	sort.Slice(mus, func(i, j int) bool {
+		if mus[i].AccountID != mus[j].AccountID {
+			return mus[i].AccountID < mus[j].AccountID
+		}
+		return mus[i].BalanceType < mus[j].BalanceType
+	})
+	return
+}
@@ -0,0 +1,147 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSharedGroupQuota(t *testing.T) {
+	sg := &SharedGroup{Id: "SG_QUOTA",
+		AccountParameters: map[string]*SharingParameters{
+			utils.ANY: &SharingParameters{Strategy: STRATEGY_MINE_LOWEST},
+			"vdf:sgq_kid": &SharingParameters{Strategy: STRATEGY_MINE_LOWEST,
+				Quota: 2, QuotaPeriod: utils.MetaMonthly, QuotaBalanceType: utils.MONETARY},
+		},
+		MemberIds: utils.NewStringMap("vdf:sgq_owner", "vdf:sgq_kid"),
+	}
+	if err := dataStorage.SetSharedGroup(sg, utils.NonTransactional); err != nil {
+		t.Fatal(err)
+	}
+	owner := &Account{ID: "vdf:sgq_owner", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "sgq_owner_money", Value: 10, SharedGroups: utils.NewStringMap("SG_QUOTA")}}}}
+	kid := &Account{ID: "vdf:sgq_kid", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "sgq_kid_money", SharedGroups: utils.NewStringMap("SG_QUOTA")}}}}
+	for _, acc := range []*Account{owner, kid} {
+		if err := dataStorage.SetAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 35, 0, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "minu",
+		Account:     "sgq_kid",
+		Destination: "0723",
+	}
+	// the kid can consume only its quota out of the shared balances
+	if d, err := cd.Clone().GetMaxSessionDuration(); err != nil {
+		t.Error(err)
+	} else if d != 4*time.Second {
+		t.Errorf("Expecting: %v, received: %v", 4*time.Second, d)
+	}
+	cd.TimeEnd = cd.TimeStart.Add(2 * time.Second)
+	cc, err := cd.Debit()
+	if err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 1 {
+		t.Errorf("Unexpected cost: %v", cc.Cost)
+	}
+	if acc, err := dataStorage.GetAccount(owner.ID); err != nil {
+		t.Error(err)
+	} else if acc.BalanceMap[utils.MONETARY][0].GetValue() != 9 {
+		t.Errorf("Unexpected owner balances: %s", utils.ToJSON(acc.BalanceMap))
+	}
+	cd.TimeEnd = cd.TimeStart.Add(time.Minute)
+	if d, err := cd.Clone().GetMaxSessionDuration(); err != nil {
+		t.Error(err)
+	} else if d != 2*time.Second {
+		t.Errorf("Expecting: %v, received: %v", 2*time.Second, d)
+	}
+	now := time.Now()
+	eUsage := []*SharedGroupMemberUsage{
+		&SharedGroupMemberUsage{AccountID: "vdf:sgq_kid", BalanceType: utils.MONETARY, Quota: 2, QuotaPeriod: utils.MetaMonthly,
+			PeriodStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), Used: 1},
+		&SharedGroupMemberUsage{AccountID: "vdf:sgq_owner"},
+	}
+	if mus, err := sg.GetMembersUsage(now); err != nil {
+		t.Error(err)
+	} else if utils.ToJSON(eUsage) != utils.ToJSON(mus) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eUsage), utils.ToJSON(mus))
+	}
+	// the refund gives the consumption back to the quota
+	refundCD := cd.Clone()
+	for _, ts := range cc.Timespans {
+		refundCD.Increments = append(refundCD.Increments, ts.Increments...)
+	}
+	if err := refundCD.RefundIncrements(); err != nil {
+		t.Fatal(err)
+	}
+	acc, err := dataStorage.GetAccount(kid.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if used := acc.SharedGroupUsage["SG_QUOTA"][utils.MONETARY].SpentAt(now); used != 0 {
+		t.Errorf("Unexpected usage after refund: %v", used)
+	}
+	// consumption out of other balance types does not count against the monetary one
+	acc.SharedGroupUsage["SG_QUOTA"][utils.DATA] = &SpendingLimit{Limit: 2, Period: utils.MetaMonthly,
+		Spent: 1024, PeriodStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())}
+	if err := dataStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := cd.Clone().GetMaxSessionDuration(); err != nil {
+		t.Error(err)
+	} else if d != 4*time.Second {
+		t.Errorf("Expecting: %v, received: %v", 4*time.Second, d)
+	}
+	// the quota limits its balance type only
+	if usage := acc.sharedGroupUsage(sg, utils.DATA); usage.Limit != 0 {
+		t.Errorf("Unexpected data limit: %+v", usage)
+	}
+	if usage := acc.sharedGroupUsage(sg, utils.MONETARY); usage.Limit != 2 {
+		t.Errorf("Unexpected monetary limit: %+v", usage)
+	}
+}
+
+func TestSharedGroupQuotaMinutesForCredit(t *testing.T) {
+	usage, err := NewSpendingLimit(30, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Balance{Uuid: "sgq_minutes", Value: 100, sharedUsage: usage}
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 36, 0, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "minu",
+		Account:     "sgq_kid",
+		Destination: "0723",
+		TOR:         utils.VOICE,
+	}
+	if d, _ := b.GetMinutesForCredit(cd, 0); d != 30*time.Second {
+		t.Errorf("Expecting: %v, received: %v", 30*time.Second, d)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// NewSpendingLimit validates the period and returns a SpendingLimit starting with nothing spent
+func NewSpendingLimit(limit float64, period string) (*SpendingLimit, error) {
+	if !utils.IsSliceMember([]string{"", utils.MetaDaily, utils.MetaMonthly}, period) {
+		return nil, fmt.Errorf("unsupported spending limit period: %s", period)
+	}
+	return &SpendingLimit{Limit: limit, Period: period}, nil
+}
+
+// SpendingLimit caps the monetary amount an account can spend within one period, across sessions
+type SpendingLimit struct {
+	Limit       float64
+	Period      string    // *daily, *monthly or empty for a limit which is never reset
+	Spent       float64   // amount spent within the current period
+	PeriodStart time.Time // start of the period Spent belongs to
+}
+
+// periodStart returns the start of the period containing t
+func (sl *SpendingLimit) periodStart(t time.Time) time.Time {
+	switch sl.Period {
+	case utils.MetaDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case utils.MetaMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// AddSpent records the amount spent at t, negative for refunds, starting over when a new period begins
+func (sl *SpendingLimit) AddSpent(amount float64, t time.Time) {
+	if ps := sl.periodStart(t); ps.After(sl.PeriodStart) {
+		sl.Spent = 0
+		sl.PeriodStart = ps
+	}
+	sl.Spent = utils.Round(sl.Spent+amount, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	if sl.Spent < 0 { // refund of something spent in a previous period
+		sl.Spent = 0
+	}
+}
+
+// SpentAt returns the amount spent within the period containing t
+func (sl *SpendingLimit) SpentAt(t time.Time) float64 {
+	if sl.periodStart(t).After(sl.PeriodStart) {
+		return 0
+	}
+	return sl.Spent
+}
+
+// Exceeded returns true if more than the limit was spent within the period containing t
+func (sl *SpendingLimit) Exceeded(t time.Time) bool {
+	return sl.SpentAt(t) > sl.Limit
+}
+
+// Clone returns a copy of the SpendingLimit
+func (sl *SpendingLimit) Clone() *SpendingLimit {
+	if sl == nil {
+		return nil
+	}
+	cln := *sl
+	return &cln
+}
+
+// spentOnAccount returns the rating cost of the increments paid out of the monetary balances of account,
+// the same amount refunds give back for each increment
+func (cc *CallCost) spentOnAccount(acntID string) (spent float64) {
+	for _, ts := range cc.Timespans {
+		var tsSpent float64
+		for _, incr := range ts.Increments {
+			if incr.BalanceInfo == nil || incr.BalanceInfo.Monetary == nil || incr.BalanceInfo.Monetary.UUID == "" ||
+				(incr.BalanceInfo.AccountID != "" && incr.BalanceInfo.AccountID != acntID) {
+				continue
+			}
+			tsSpent += incr.GetCost()
+		}
+		spent += tsSpent * float64(ts.GetCompressFactor())
+	}
+	return
+}
+
+// SetSpendingLimit updates the account spending limit, keeping what was already spent if the period does not change.
+// A limit of 0 removes it.
+func (acc *Account) SetSpendingLimit(limit *float64, period *string) error {
+	if limit == nil && period == nil {
+		return nil
+	}
+	if limit != nil && *limit == 0 {
+		acc.SpendingLimit = nil
+		return nil
+	}
+	if acc.SpendingLimit == nil && limit == nil {
+		return utils.NewErrMandatoryIeMissing("SpendingLimit")
+	}
+	var sl *SpendingLimit
+	if acc.SpendingLimit != nil {
+		sl = acc.SpendingLimit.Clone()
+	} else {
+		sl = new(SpendingLimit)
+	}
+	if limit != nil {
+		sl.Limit = *limit
+	}
+	if period != nil && *period != sl.Period {
+		newSl, err := NewSpendingLimit(sl.Limit, *period)
+		if err != nil {
+			return err
+		}
+		sl = newSl
+	}
+	acc.SpendingLimit = sl
+	return nil
+}
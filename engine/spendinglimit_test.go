@@ -0,0 +1,150 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSpendingLimitPeriods(t *testing.T) {
+	if _, err := NewSpendingLimit(10, "*weekly"); err == nil {
+		t.Error("Expecting error for unsupported period")
+	}
+	sl, err := NewSpendingLimit(10, utils.MetaDaily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := time.Date(2017, 10, 1, 10, 0, 0, 0, time.UTC)
+	sl.AddSpent(6, t1)
+	sl.AddSpent(5, t1.Add(time.Hour))
+	if !sl.Exceeded(t1.Add(time.Hour)) {
+		t.Errorf("Limit should be exceeded: %+v", sl)
+	}
+	sl.AddSpent(-2, t1.Add(2*time.Hour)) // refund
+	if sl.Exceeded(t1.Add(2*time.Hour)) || sl.SpentAt(t1.Add(2*time.Hour)) != 9 {
+		t.Errorf("Unexpected limit: %+v", sl)
+	}
+	t2 := time.Date(2017, 10, 2, 0, 30, 0, 0, time.UTC)
+	if spent := sl.SpentAt(t2); spent != 0 {
+		t.Errorf("New period should start from 0, have: %v", spent)
+	}
+	sl.AddSpent(3, t2)
+	if sl.Spent != 3 || !sl.PeriodStart.Equal(time.Date(2017, 10, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected limit: %+v", sl)
+	}
+	sl.AddSpent(-5, t2) // refund of a debit from previous period
+	if sl.Spent != 0 {
+		t.Errorf("Unexpected spent: %v", sl.Spent)
+	}
+	slMonthly, _ := NewSpendingLimit(10, utils.MetaMonthly)
+	slMonthly.AddSpent(8, t1)
+	if spent := slMonthly.SpentAt(t2); spent != 8 {
+		t.Errorf("Unexpected spent: %v", spent)
+	}
+}
+
+func TestAccountSetSpendingLimit(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:1001"}
+	period := utils.MetaDaily
+	if err := acc.SetSpendingLimit(nil, &period); err == nil {
+		t.Error("Expecting error when setting period without limit")
+	}
+	limit := 10.0
+	if err := acc.SetSpendingLimit(&limit, &period); err != nil {
+		t.Fatal(err)
+	}
+	acc.SpendingLimit.AddSpent(5, time.Now())
+	limit = 20
+	if err := acc.SetSpendingLimit(&limit, nil); err != nil {
+		t.Fatal(err)
+	} else if acc.SpendingLimit.Limit != 20 || acc.SpendingLimit.Spent != 5 {
+		t.Errorf("Unexpected limit: %+v", acc.SpendingLimit)
+	}
+	if cln := acc.Clone(); !reflect.DeepEqual(acc.SpendingLimit, cln.SpendingLimit) {
+		t.Errorf("Expecting: %+v, received: %+v", acc.SpendingLimit, cln.SpendingLimit)
+	}
+	limit = 0
+	if err := acc.SetSpendingLimit(&limit, nil); err != nil {
+		t.Fatal(err)
+	} else if acc.SpendingLimit != nil {
+		t.Errorf("Limit should be removed: %+v", acc.SpendingLimit)
+	}
+}
+
+func TestCallCostSpentOnAccount(t *testing.T) {
+	cc := &CallCost{Timespans: TimeSpans{
+		&TimeSpan{CompressFactor: 2, Increments: Increments{
+			&Increment{Duration: 0, Cost: 1, // connect fee
+				BalanceInfo: &DebitInfo{Monetary: &MonetaryInfo{UUID: "MONETARY1"}, AccountID: "cgrates.org:1001"}},
+			&Increment{Duration: time.Second, Cost: 0.5, CompressFactor: 10,
+				BalanceInfo: &DebitInfo{Monetary: &MonetaryInfo{UUID: "MONETARY1", ExchangeRate: 2}, AccountID: "cgrates.org:1001"}},
+			&Increment{Duration: time.Second, Cost: 0.5, CompressFactor: 5, // paid by a shared group member
+				BalanceInfo: &DebitInfo{Monetary: &MonetaryInfo{UUID: "MONETARY2"}, AccountID: "cgrates.org:1002"}},
+			&Increment{Duration: time.Second, Cost: 0.5, CompressFactor: 5, // paid by units
+				BalanceInfo: &DebitInfo{Unit: &UnitInfo{UUID: "VOICE1"}, AccountID: "cgrates.org:1001"}},
+		}}}}
+	if spent := cc.spentOnAccount("cgrates.org:1001"); spent != 12 {
+		t.Errorf("Expecting: 12, received: %f", spent)
+	}
+}
+
+func TestSpendingLimitDebitRefund(t *testing.T) {
+	acc := &Account{ID: "vdf:spending_limit",
+		BalanceMap:    map[string]Balances{utils.MONETARY: Balances{&Balance{Uuid: "SL_MONETARY", Value: 100}}},
+		SpendingLimit: &SpendingLimit{Limit: 1000}}
+	if err := dataStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	cd := &CallDescriptor{Direction: utils.OUT, Category: "0", Tenant: "vdf", Subject: "rif", Account: "spending_limit",
+		Destination: "0256", TOR: utils.VOICE, CgrID: "SPENDING_LIMIT_DEBIT_REFUND",
+		TimeStart: time.Date(2012, time.February, 2, 17, 30, 0, 0, time.UTC),
+		TimeEnd:   time.Date(2012, time.February, 2, 17, 30, 10, 0, time.UTC)}
+	cc, err := cd.Debit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, _ = dataStorage.GetAccount(acc.ID)
+	charged := 100 - acc.BalanceMap[utils.MONETARY][0].GetValue()
+	if charged == 0 || acc.SpendingLimit.Spent != charged {
+		t.Errorf("Charged: %f, spent: %+v", charged, acc.SpendingLimit)
+	}
+	var refundIncrements Increments
+	cc.Timespans.Decompress()
+	for _, ts := range cc.Timespans {
+		for _, incr := range ts.Increments {
+			if incr.Duration != 0 { // keep the connect fee
+				refundIncrements = append(refundIncrements, incr)
+			}
+		}
+	}
+	rcd := cc.CreateCallDescriptor()
+	rcd.CgrID = cd.CgrID
+	rcd.Increments = refundIncrements
+	if err := rcd.RefundIncrements(); err != nil {
+		t.Fatal(err)
+	}
+	acc, _ = dataStorage.GetAccount(acc.ID)
+	charged = 100 - acc.BalanceMap[utils.MONETARY][0].GetValue()
+	if charged != 1 || acc.SpendingLimit.Spent != charged {
+		t.Errorf("Charged after refund: %f, spent: %+v", charged, acc.SpendingLimit)
+	}
+}
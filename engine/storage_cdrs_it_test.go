@@ -45,6 +45,9 @@ func TestITCDRsMySQL(t *testing.T) {
 	if err := testSMCosts(cfg); err != nil {
 		t.Error(err)
 	}
+	if err := testRetentionPolicies(cfg); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestITCDRsPSQL(t *testing.T) {
@@ -61,6 +64,9 @@ func TestITCDRsPSQL(t *testing.T) {
 	if err := testSMCosts(cfg); err != nil {
 		t.Error(err)
 	}
+	if err := testRetentionPolicies(cfg); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestITCDRsMongo(t *testing.T) {
@@ -77,6 +83,9 @@ func TestITCDRsMongo(t *testing.T) {
 	if err := testSMCosts(cfg); err != nil {
 		t.Error(err)
 	}
+	if err := testRetentionPolicies(cfg); err != nil {
+		t.Error(err)
+	}
 }
 
 // helper function to populate CDRs and check if they were stored in storDb
@@ -818,6 +827,98 @@ func testGetCDRs(cfg *config.CGRConfig) error {
 	} else if len(CDRs) != 7 {
 		return fmt.Errorf("testGetCDRs #94, unexpected number of CDRs returned:  %+v", len(CDRs))
 	}
+	// Cursor based pagination over the remaining 9 CDRs
+	var cursor, lastOrderID int64
+	var pagedCDRs int
+	for i := 0; i < 3; i++ {
+		CDRs, nextCursor, err := GetCDRsPage(cdrStorage, &utils.CDRsFilter{Paginator: utils.Paginator{Limit: utils.IntPointer(4)}}, cursor)
+		if err != nil {
+			return fmt.Errorf("testGetCDRs #95, err: %v", err)
+		}
+		for _, cdr := range CDRs {
+			if cdr.OrderID <= lastOrderID {
+				return fmt.Errorf("testGetCDRs #96, unordered CDRs, OrderID: %d after: %d", cdr.OrderID, lastOrderID)
+			}
+			lastOrderID = cdr.OrderID
+		}
+		pagedCDRs += len(CDRs)
+		if cursor = nextCursor; cursor == 0 {
+			break
+		}
+	}
+	if pagedCDRs != 9 || cursor != 0 {
+		return fmt.Errorf("testGetCDRs #97, unexpected number of paged CDRs: %d, cursor: %d", pagedCDRs, cursor)
+	}
+	// Aggregates grouped on tenant and daily buckets
+	aggr := &CDRsAggregation{GroupBy: []string{utils.TENANT}, TimeBucket: utils.MetaDaily}
+	if err := aggr.Validate(); err != nil {
+		return fmt.Errorf("testGetCDRs #98, err: %v", err)
+	}
+	if aggrs, err := cdrStorage.GetCDRsAggregates(new(utils.CDRsFilter), aggr); err != nil {
+		return fmt.Errorf("testGetCDRs #99, err: %v", err)
+	} else {
+		var cnt int64
+		for _, ca := range aggrs {
+			cnt += ca.Count
+		}
+		if cnt != 9 {
+			return fmt.Errorf("testGetCDRs #100, unexpected number of aggregated CDRs: %d", cnt)
+		}
+	}
+	return nil
+}
 
+// testRetentionPolicies enforces a retention policy on StorDB and checks what was removed
+func testRetentionPolicies(cfg *config.CGRConfig) error {
+	if err := InitStorDb(cfg); err != nil {
+		return fmt.Errorf("testRetentionPolicies #1 err: %v", err)
+	}
+	cdrStorage, err := ConfigureCdrStorage(cfg.StorDBType, cfg.StorDBHost, cfg.StorDBPort, cfg.StorDBName, cfg.StorDBUser, cfg.StorDBPass,
+		cfg.StorDBMaxOpenConns, cfg.StorDBMaxIdleConns, cfg.StorDBConnMaxLifetime, cfg.StorDBCDRSIndexes)
+	if err != nil {
+		return fmt.Errorf("testRetentionPolicies #2 err: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, cdr := range testRetentionCDRs(now) {
+		cdr.OrderID = now.UnixNano() + int64(i)
+		cdr.Cost = -1
+		if err := cdrStorage.SetCDR(cdr, false); err != nil {
+			return fmt.Errorf("testRetentionPolicies #3 CDR: %+v, err: %v", cdr, err)
+		}
+	}
+	cc := &CallCost{Direction: utils.OUT, Destination: "1002", TOR: utils.VOICE}
+	for _, cgrID := range []string{"RETENTION_1", "RETENTION_4"} {
+		if err := cdrStorage.SetSMCost(&SMCost{CGRID: cgrID, RunID: utils.MetaRaw, OriginHost: "localhost", OriginID: cgrID,
+			CostSource: utils.UNIT_TEST, CostDetails: cc}); err != nil {
+			return fmt.Errorf("testRetentionPolicies #4 err: %v", err)
+		}
+	}
+	cdrsCfg := *cfg // do not alter the shared config
+	cdrsCfg.CDRSRetentionBatchSize = 1
+	cdrsCfg.CDRSRetentionPolicies = []*config.CdrRetentionPolicy{
+		&config.CdrRetentionPolicy{Tenants: []string{"cgrates.org"}, RunIDs: []string{utils.MetaRaw},
+			MaxAge: time.Duration(720 * time.Hour)}}
+	cdrS := &CdrServer{cgrCfg: &cdrsCfg, cdrDb: cdrStorage, retention: new(cdrsRetention)}
+	var rpt CDRsRetentionReport
+	if err := cdrS.V1EnforceRetentionPolicies("", &rpt); err != nil {
+		return fmt.Errorf("testRetentionPolicies #5 err: %v", err)
+	} else if rpt.RemovedCDRs != 2 || rpt.RemovedSMCosts != 1 {
+		return fmt.Errorf("testRetentionPolicies #6 unexpected report: %+v", rpt)
+	}
+	if cdrs, _, err := cdrStorage.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{"RETENTION_1", "RETENTION_2", "RETENTION_3", "RETENTION_4"}}, false); err != nil {
+		return fmt.Errorf("testRetentionPolicies #7 err: %v", err)
+	} else if len(cdrs) != 3 {
+		return fmt.Errorf("testRetentionPolicies #8 expecting 3 CDRs, received: %s", utils.ToJSON(cdrs))
+	}
+	if smCosts, err := cdrStorage.GetSMCosts("RETENTION_1", utils.MetaRaw, "", ""); err != nil {
+		return fmt.Errorf("testRetentionPolicies #9 err: %v", err)
+	} else if len(smCosts) != 0 {
+		return fmt.Errorf("testRetentionPolicies #10 expecting SMCost removed, received: %s", utils.ToJSON(smCosts))
+	}
+	if smCosts, err := cdrStorage.GetSMCosts("RETENTION_4", utils.MetaRaw, "", ""); err != nil {
+		return fmt.Errorf("testRetentionPolicies #11 err: %v", err)
+	} else if len(smCosts) != 1 {
+		return fmt.Errorf("testRetentionPolicies #12 expecting SMCost kept, received: %s", utils.ToJSON(smCosts))
+	}
 	return nil
 }
@@ -120,9 +120,18 @@ type DataDB interface {
 	GetSQStoredMetrics(sqID string) (sqSM *SQStoredMetrics, err error)
 	SetSQStoredMetrics(sqSM *SQStoredMetrics) (err error)
 	RemSQStoredMetrics(sqID string) (err error)
+	GetSMGSessionCheckpoints() (sCps []*SMGSessionCheckpoint, err error)
+	SetSMGSessionCheckpoint(sCp *SMGSessionCheckpoint) (err error)
+	RemSMGSessionCheckpoint(cgrID string) (err error)
 	GetThresholdCfg(ID string, skipCache bool, transactionID string) (th *ThresholdCfg, err error)
 	SetThresholdCfg(th *ThresholdCfg) (err error)
 	RemThresholdCfg(ID string, transactionID string) (err error)
+	GetExchangeRates(fromCurrency, toCurrency string) (ers *ExchangeRates, err error)
+	SetExchangeRates(ers *ExchangeRates) (err error)
+	RemExchangeRates(fromCurrency, toCurrency string) (err error)
+	GetTaxRules(tenant string) (trs *TaxRules, err error)
+	SetTaxRules(trs *TaxRules) (err error)
+	RemTaxRules(tenant string) (err error)
 	// CacheDataFromDB loads data to cache, prefix represents the cache prefix, IDs should be nil if all available data should be loaded
 	CacheDataFromDB(prefix string, IDs []string, mustBeCached bool) error // ToDo: Move this to dataManager
 }
@@ -139,7 +148,12 @@ type CdrStorage interface {
 	SetSMCost(smc *SMCost) error
 	GetSMCosts(cgrid, runid, originHost, originIDPrfx string) ([]*SMCost, error)
 	RemoveSMCost(*SMCost) error
+	SetBalanceLedgerEntries([]*BalanceLedgerEntry) error
+	GetBalanceLedger(*BalanceLedgerFilter) ([]*BalanceLedgerEntry, error)
+	SetInvoice(*Invoice) error
+	GetInvoices(*InvoiceFilter) ([]*Invoice, error)
 	GetCDRs(*utils.CDRsFilter, bool) ([]*CDR, int64, error)
+	GetCDRsAggregates(*utils.CDRsFilter, *CDRsAggregation) ([]*CDRsAggregate, error)
 }
 
 type LoadStorage interface {
@@ -171,6 +185,8 @@ type LoadReader interface {
 	GetTPResources(string, string) ([]*utils.TPResource, error)
 	GetTPStats(string, string) ([]*utils.TPStats, error)
 	GetTPThreshold(string, string) ([]*utils.TPThreshold, error)
+	GetTPExchangeRates(string, string) ([]*utils.TPExchangeRates, error)
+	GetTPTaxRules(string, string) ([]*utils.TPTaxRules, error)
 }
 
 type LoadWriter interface {
@@ -194,6 +210,8 @@ type LoadWriter interface {
 	SetTPResources([]*utils.TPResource) error
 	SetTPStats([]*utils.TPStats) error
 	SetTPThreshold([]*utils.TPThreshold) error
+	SetTPExchangeRates([]*utils.TPExchangeRates) error
+	SetTPTaxRules([]*utils.TPTaxRules) error
 }
 
 // NewMarshaler returns the marshaler type selected by mrshlerStr
@@ -741,6 +741,13 @@ func (ms *MapStorage) SetAccount(ub *Account) (err error) {
 			ac.UnitCounters = ub.UnitCounters
 			ac.AllowNegative = ub.AllowNegative
 			ac.Disabled = ub.Disabled
+			ac.MaxSessionCost = ub.MaxSessionCost
+			ac.SpendingLimit = ub.SpendingLimit
+			ac.CreditLimit = ub.CreditLimit
+			ac.Subscriptions = ub.Subscriptions
+			ac.ParentID = ub.ParentID
+			ac.ParentSubject = ub.ParentSubject
+			ac.SharedGroupUsage = ub.SharedGroupUsage
 			ub = ac
 		}
 	}
@@ -1597,6 +1604,43 @@ func (ms *MapStorage) RemSQStoredMetrics(sqID string) (err error) {
 	return
 }
 
+// GetSMGSessionCheckpoints retrieves all the SMG session checkpoints
+func (ms *MapStorage) GetSMGSessionCheckpoints() (sCps []*SMGSessionCheckpoint, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	for key, values := range ms.dict {
+		if !strings.HasPrefix(key, utils.SMGSessionCheckpointPrefix) {
+			continue
+		}
+		var sCp *SMGSessionCheckpoint
+		if err = ms.ms.Unmarshal(values, &sCp); err != nil {
+			return nil, err
+		}
+		sCps = append(sCps, sCp)
+	}
+	return
+}
+
+// SetSMGSessionCheckpoint stores the checkpoint of a SMG session
+func (ms *MapStorage) SetSMGSessionCheckpoint(sCp *SMGSessionCheckpoint) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var result []byte
+	if result, err = ms.ms.Marshal(sCp); err != nil {
+		return
+	}
+	ms.dict[utils.SMGSessionCheckpointPrefix+sCp.CGRID] = result
+	return
+}
+
+// RemSMGSessionCheckpoint removes the checkpoint of a SMG session
+func (ms *MapStorage) RemSMGSessionCheckpoint(cgrID string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.dict, utils.SMGSessionCheckpointPrefix+cgrID)
+	return
+}
+
 // GetThresholdCfg retrieves a ThresholdCfg from dataDB/cache
 func (ms *MapStorage) GetThresholdCfg(ID string, skipCache bool, transactionID string) (th *ThresholdCfg, err error) {
 	ms.mu.RLock()
@@ -1649,3 +1693,69 @@ func (ms *MapStorage) RemThresholdCfg(sqID string, transactionID string) (err er
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
+
+// GetExchangeRates retrieves the exchange rates of one currency pair
+func (ms *MapStorage) GetExchangeRates(fromCurrency, toCurrency string) (ers *ExchangeRates, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values, ok := ms.dict[utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency)]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	err = ms.ms.Unmarshal(values, &ers)
+	return
+}
+
+// SetExchangeRates stores the exchange rates of one currency pair
+func (ms *MapStorage) SetExchangeRates(ers *ExchangeRates) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var result []byte
+	if result, err = ms.ms.Marshal(ers); err != nil {
+		return
+	}
+	ms.dict[utils.ExchangeRatesPrefix+ers.ID()] = result
+	cache.RemKey(utils.ExchangeRatesPrefix+ers.ID(), true, utils.NonTransactional)
+	return
+}
+
+// RemExchangeRates removes the exchange rates of one currency pair
+func (ms *MapStorage) RemExchangeRates(fromCurrency, toCurrency string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.dict, utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency))
+	cache.RemKey(utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency), true, utils.NonTransactional)
+	return
+}
+
+// GetTaxRules retrieves the tax rules of one tenant
+func (ms *MapStorage) GetTaxRules(tenant string) (trs *TaxRules, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values, ok := ms.dict[utils.TaxRulesPrefix+tenant]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	err = ms.ms.Unmarshal(values, &trs)
+	return
+}
+
+// SetTaxRules stores the tax rules of one tenant
+func (ms *MapStorage) SetTaxRules(trs *TaxRules) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var result []byte
+	if result, err = ms.ms.Marshal(trs); err != nil {
+		return
+	}
+	ms.dict[utils.TaxRulesPrefix+trs.Tenant] = result
+	return
+}
+
+// RemTaxRules removes the tax rules of one tenant
+func (ms *MapStorage) RemTaxRules(tenant string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.dict, utils.TaxRulesPrefix+tenant)
+	return
+}
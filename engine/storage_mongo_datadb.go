@@ -61,6 +61,7 @@ const (
 	colRFI   = "request_filter_indexes"
 	colTmg   = "timings"
 	colRes   = "resources"
+	colExr   = "exchange_rates"
 )
 
 var (
@@ -115,6 +116,13 @@ func NewMongoStorage(host, port, db, user, pass, storageType string, cdrsIndexes
 		if err = ms.EnsureIndexes(); err != nil {
 			return nil, err
 		}
+	} else if storageType == utils.StorDB { // invoices added to existing databases still need unique numbers
+		dbSession := ms.session.Copy()
+		err = ensureInvoicesIndexes(dbSession.DB(ms.db))
+		dbSession.Close()
+		if err != nil {
+			return nil, err
+		}
 	}
 	ms.cnter = utils.NewCounter(time.Now().UnixNano(), 0)
 	return
@@ -181,7 +189,7 @@ func (ms *MongoStorage) EnsureIndexes() (err error) {
 			Sparse:     false,
 		}
 		for _, col := range []string{utils.TBLTPTimings, utils.TBLTPDestinations, utils.TBLTPDestinationRates, utils.TBLTPRatingPlans,
-			utils.TBLTPSharedGroups, utils.TBLTPCdrStats, utils.TBLTPActions, utils.TBLTPActionPlans, utils.TBLTPActionTriggers, utils.TBLTPStats, utils.TBLTPResources} {
+			utils.TBLTPSharedGroups, utils.TBLTPCdrStats, utils.TBLTPActions, utils.TBLTPActionPlans, utils.TBLTPActionTriggers, utils.TBLTPStats, utils.TBLTPResources, utils.TBLTPExchangeRates, utils.TBLTPTaxRules} {
 			if err = db.C(col).EnsureIndex(idx); err != nil {
 				return
 			}
@@ -298,6 +306,36 @@ func (ms *MongoStorage) EnsureIndexes() (err error) {
 		if err = db.C(utils.TBLSMCosts).EnsureIndex(idx); err != nil {
 			return
 		}
+		idx = mgo.Index{
+			Key:        []string{"accountid", "createdat"},
+			Unique:     false,
+			DropDups:   false,
+			Background: false,
+			Sparse:     false,
+		}
+		if err = db.C(utils.TBLBalanceLedger).EnsureIndex(idx); err != nil {
+			return
+		}
+		if err = ensureInvoicesIndexes(db); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ensureInvoicesIndexes keeps the invoice numbers unique per tenant and one invoice per account and period start
+func ensureInvoicesIndexes(db *mgo.Database) (err error) {
+	for _, key := range [][]string{{"tenant", "number"}, {"tenant", "account", "periodstart"}} {
+		idx := mgo.Index{
+			Key:        key,
+			Unique:     true,
+			DropDups:   false,
+			Background: false,
+			Sparse:     false,
+		}
+		if err = db.C(utils.TBLInvoices).EnsureIndex(idx); err != nil {
+			return
+		}
 	}
 	return
 }
@@ -328,6 +366,7 @@ func (ms *MongoStorage) getColNameForPrefix(prefix string) (name string, ok bool
 		utils.StatsPrefix:                colSts,
 		utils.TimingsPrefix:              colTmg,
 		utils.ResourcesPrefix:            colRes,
+		utils.ExchangeRatesPrefix:        colExr,
 	}
 	name, ok = colMap[prefix]
 	return
@@ -1152,6 +1191,13 @@ func (ms *MongoStorage) SetAccount(acc *Account) error {
 			ac.UnitCounters = acc.UnitCounters
 			ac.AllowNegative = acc.AllowNegative
 			ac.Disabled = acc.Disabled
+			ac.MaxSessionCost = acc.MaxSessionCost
+			ac.SpendingLimit = acc.SpendingLimit
+			ac.CreditLimit = acc.CreditLimit
+			ac.Subscriptions = acc.Subscriptions
+			ac.ParentID = acc.ParentID
+			ac.ParentSubject = acc.ParentSubject
+			ac.SharedGroupUsage = acc.SharedGroupUsage
 			acc = ac
 		}
 	}
@@ -2111,6 +2157,32 @@ func (ms *MongoStorage) RemSQStoredMetrics(sqmID string) (err error) {
 	return err
 }
 
+// GetSMGSessionCheckpoints retrieves all the SMG session checkpoints
+func (ms *MongoStorage) GetSMGSessionCheckpoints() (sCps []*SMGSessionCheckpoint, err error) {
+	session, col := ms.conn(utils.SMGSessionCheckpointPrefix)
+	defer session.Close()
+	err = col.Find(nil).All(&sCps)
+	return
+}
+
+// SetSMGSessionCheckpoint stores the checkpoint of a SMG session
+func (ms *MongoStorage) SetSMGSessionCheckpoint(sCp *SMGSessionCheckpoint) (err error) {
+	session, col := ms.conn(utils.SMGSessionCheckpointPrefix)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"cgrid": sCp.CGRID}, sCp)
+	return
+}
+
+// RemSMGSessionCheckpoint removes the checkpoint of a SMG session
+func (ms *MongoStorage) RemSMGSessionCheckpoint(cgrID string) (err error) {
+	session, col := ms.conn(utils.SMGSessionCheckpointPrefix)
+	defer session.Close()
+	if err = col.Remove(bson.M{"cgrid": cgrID}); err == mgo.ErrNotFound {
+		err = nil
+	}
+	return
+}
+
 // GetThresholdCfg retrieves a ThresholdCfg from dataDB/cache
 func (ms *MongoStorage) GetThresholdCfg(ID string, skipCache bool, transactionID string) (th *ThresholdCfg, err error) {
 	cacheKey := utils.ThresholdCfgPrefix + ID
@@ -2162,3 +2234,71 @@ func (ms *MongoStorage) RemThresholdCfg(ID string, transactionID string) (err er
 	session.Close()
 	return
 }
+
+// GetExchangeRates retrieves the exchange rates of one currency pair
+func (ms *MongoStorage) GetExchangeRates(fromCurrency, toCurrency string) (ers *ExchangeRates, err error) {
+	session, col := ms.conn(colExr)
+	defer session.Close()
+	ers = new(ExchangeRates)
+	if err = col.Find(bson.M{"fromcurrency": fromCurrency, "tocurrency": toCurrency}).One(ers); err != nil {
+		if err == mgo.ErrNotFound {
+			err = utils.ErrNotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+// SetExchangeRates stores the exchange rates of one currency pair
+func (ms *MongoStorage) SetExchangeRates(ers *ExchangeRates) (err error) {
+	session, col := ms.conn(colExr)
+	defer session.Close()
+	if _, err = col.Upsert(bson.M{"fromcurrency": ers.FromCurrency, "tocurrency": ers.ToCurrency}, ers); err != nil {
+		return
+	}
+	cache.RemKey(utils.ExchangeRatesPrefix+ers.ID(), true, utils.NonTransactional)
+	return
+}
+
+// RemExchangeRates removes the exchange rates of one currency pair
+func (ms *MongoStorage) RemExchangeRates(fromCurrency, toCurrency string) (err error) {
+	session, col := ms.conn(colExr)
+	defer session.Close()
+	if err = col.Remove(bson.M{"fromcurrency": fromCurrency, "tocurrency": toCurrency}); err == mgo.ErrNotFound {
+		err = nil
+	}
+	cache.RemKey(utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency), true, utils.NonTransactional)
+	return
+}
+
+// GetTaxRules retrieves the tax rules of one tenant
+func (ms *MongoStorage) GetTaxRules(tenant string) (trs *TaxRules, err error) {
+	session, col := ms.conn(utils.TaxRulesPrefix)
+	defer session.Close()
+	trs = new(TaxRules)
+	if err = col.Find(bson.M{"tenant": tenant}).One(trs); err != nil {
+		if err == mgo.ErrNotFound {
+			err = utils.ErrNotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+// SetTaxRules stores the tax rules of one tenant
+func (ms *MongoStorage) SetTaxRules(trs *TaxRules) (err error) {
+	session, col := ms.conn(utils.TaxRulesPrefix)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"tenant": trs.Tenant}, trs)
+	return
+}
+
+// RemTaxRules removes the tax rules of one tenant
+func (ms *MongoStorage) RemTaxRules(tenant string) (err error) {
+	session, col := ms.conn(utils.TaxRulesPrefix)
+	defer session.Close()
+	if err = col.Remove(bson.M{"tenant": tenant}); err == mgo.ErrNotFound {
+		err = nil
+	}
+	return
+}
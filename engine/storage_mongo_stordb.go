@@ -922,6 +922,93 @@ func (ms *MongoStorage) GetSMCosts(cgrid, runid, originHost, originIDPrefix stri
 	return smcs, nil
 }
 
+func (ms *MongoStorage) SetBalanceLedgerEntries(entries []*BalanceLedgerEntry) error {
+	docs := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		docs[i] = entry
+	}
+	session, col := ms.conn(utils.TBLBalanceLedger)
+	defer session.Close()
+	return col.Insert(docs...)
+}
+
+// GetBalanceLedger returns the ledger entries of an account, in the order they were created
+func (ms *MongoStorage) GetBalanceLedger(fltr *BalanceLedgerFilter) (entries []*BalanceLedgerEntry, err error) {
+	filter := bson.M{"accountid": fltr.AccountID}
+	if fltr.TimeStart != nil || fltr.TimeEnd != nil {
+		createdAt := bson.M{}
+		if fltr.TimeStart != nil {
+			createdAt["$gte"] = *fltr.TimeStart
+		}
+		if fltr.TimeEnd != nil {
+			createdAt["$lt"] = *fltr.TimeEnd
+		}
+		filter["createdat"] = createdAt
+	}
+	session, col := ms.conn(utils.TBLBalanceLedger)
+	defer session.Close()
+	q := col.Find(filter).Sort("createdat")
+	if fltr.Paginator.Limit != nil {
+		q = q.Limit(*fltr.Paginator.Limit)
+	}
+	if fltr.Paginator.Offset != nil {
+		q = q.Skip(*fltr.Paginator.Offset)
+	}
+	if err = q.All(&entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
+// SetInvoice stores the invoice under the next number of its tenant, stored invoices are never updated
+func (ms *MongoStorage) SetInvoice(inv *Invoice) error {
+	session, col := ms.conn(utils.TBLInvoices)
+	defer session.Close()
+	var lastInvs []*Invoice
+	if err := col.Find(bson.M{"tenant": inv.Tenant}).Sort("-number").Limit(1).All(&lastInvs); err != nil {
+		return err
+	}
+	inv.Number = 1
+	if len(lastInvs) != 0 {
+		inv.Number = lastInvs[0].Number + 1
+	}
+	if err := col.Insert(inv); err != nil {
+		inv.Number = 0
+		return err
+	}
+	return nil
+}
+
+// GetInvoices returns the invoices of a tenant, in the order of their numbers
+func (ms *MongoStorage) GetInvoices(fltr *InvoiceFilter) (invs []*Invoice, err error) {
+	filter := bson.M{"tenant": fltr.Tenant}
+	if fltr.Account != "" {
+		filter["account"] = fltr.Account
+	}
+	if fltr.Number != 0 {
+		filter["number"] = fltr.Number
+	}
+	session, col := ms.conn(utils.TBLInvoices)
+	defer session.Close()
+	q := col.Find(filter).Sort("number")
+	if fltr.Paginator.Limit != nil {
+		q = q.Limit(*fltr.Paginator.Limit)
+	}
+	if fltr.Paginator.Offset != nil {
+		q = q.Skip(*fltr.Paginator.Offset)
+	}
+	if err = q.All(&invs); err != nil {
+		return nil, err
+	}
+	if len(invs) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
 func (ms *MongoStorage) SetCDR(cdr *CDR, allowUpdate bool) (err error) {
 	if cdr.OrderID == 0 {
 		cdr.OrderID = ms.cnter.Next()
@@ -968,33 +1055,33 @@ func (ms *MongoStorage) cleanEmptyFilters(filters bson.M) {
 	}
 }
 
-//  _, err := col(utils.TBLCDRs).UpdateAll(bson.M{CGRIDLow: bson.M{"$in": cgrIds}}, bson.M{"$set": bson.M{"deleted_at": time.Now()}})
-func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
+// cdrsFilter converts the CDRsFilter into a bson query
+func (ms *MongoStorage) cdrsFilter(qryFltr *utils.CDRsFilter) (bson.M, error) {
 	var minPDD, maxPDD, minUsage, maxUsage *time.Duration
 	if len(qryFltr.MinPDD) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MinPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			minPDD = &parsed
 		}
 	}
 	if len(qryFltr.MaxPDD) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MaxPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			maxPDD = &parsed
 		}
 	}
 	if len(qryFltr.MinUsage) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MinUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			minUsage = &parsed
 		}
 	}
 	if len(qryFltr.MaxUsage) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MaxUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			maxUsage = &parsed
 		}
@@ -1099,6 +1186,15 @@ func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	//file.WriteString(fmt.Sprintf("AFTER: %v\n", utils.ToIJSON(filters)))
 	//file.Close()
+	return filters, nil
+}
+
+//  _, err := col(utils.TBLCDRs).UpdateAll(bson.M{CGRIDLow: bson.M{"$in": cgrIds}}, bson.M{"$set": bson.M{"deleted_at": time.Now()}})
+func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
+	filters, err := ms.cdrsFilter(qryFltr)
+	if err != nil {
+		return nil, 0, err
+	}
 	session, col := ms.conn(utils.TBLCDRs)
 	defer session.Close()
 	if remove {
@@ -1109,6 +1205,9 @@ func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 		}
 	}
 	q := col.Find(filters)
+	if qryFltr.OrderByOrderID {
+		q = q.Sort(OrderIDLow)
+	}
 	if qryFltr.Paginator.Limit != nil {
 		q = q.Limit(*qryFltr.Paginator.Limit)
 	}
@@ -1136,6 +1235,73 @@ func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	return cdrs, 0, nil
 }
 
+// GetCDRsAggregates groups the CDRs matching the filter and computes their usage and cost metrics
+func (ms *MongoStorage) GetCDRsAggregates(qryFltr *utils.CDRsFilter, aggr *CDRsAggregation) ([]*CDRsAggregate, error) {
+	if err := aggr.Validate(); err != nil {
+		return nil, err
+	}
+	filters, err := ms.cdrsFilter(qryFltr)
+	if err != nil {
+		return nil, err
+	}
+	grpID := bson.M{}
+	for i, fld := range aggr.GroupBy {
+		grpID[fmt.Sprintf("grp%d", i)] = "$" + mongoCDRField(fld)
+	}
+	if aggr.TimeBucket != "" {
+		grpID["timebucket"] = bson.M{"$dateToString": bson.M{
+			"format": mongoTimeBucketFormat(aggr.TimeBucket),
+			"date":   "$" + strings.ToLower(aggr.TimeField)}}
+	}
+	pipeline := []bson.M{
+		bson.M{"$match": filters},
+		bson.M{"$group": bson.M{
+			"_id":        grpID,
+			"count":      bson.M{"$sum": 1},
+			"totalusage": bson.M{"$sum": "$" + UsageLow},
+			"minusage":   bson.M{"$min": "$" + UsageLow},
+			"maxusage":   bson.M{"$max": "$" + UsageLow},
+			"totalcost":  bson.M{"$sum": "$" + CostLow},
+			"mincost":    bson.M{"$min": "$" + CostLow},
+			"maxcost":    bson.M{"$max": "$" + CostLow}}},
+	}
+	var results []struct {
+		ID         bson.M `bson:"_id"`
+		Count      int64
+		TotalUsage int64
+		MinUsage   int64
+		MaxUsage   int64
+		TotalCost  float64
+		MinCost    float64
+		MaxCost    float64
+	}
+	session, col := ms.conn(utils.TBLCDRs)
+	defer session.Close()
+	if err := col.Pipe(pipeline).All(&results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	aggrs := make([]*CDRsAggregate, len(results))
+	for i, res := range results {
+		ca := &CDRsAggregate{GroupValues: make(map[string]string), Count: res.Count,
+			TotalUsage: time.Duration(res.TotalUsage), MinUsage: time.Duration(res.MinUsage),
+			MaxUsage: time.Duration(res.MaxUsage), TotalCost: res.TotalCost,
+			MinCost: res.MinCost, MaxCost: res.MaxCost}
+		for j, fld := range aggr.GroupBy {
+			ca.GroupValues[fld], _ = res.ID[fmt.Sprintf("grp%d", j)].(string)
+		}
+		bucket, _ := res.ID["timebucket"].(string)
+		if ca.TimeBucket, err = parseTimeBucket(bucket); err != nil {
+			return nil, err
+		}
+		ca.computeAverages()
+		aggrs[i] = ca
+	}
+	return aggrs, nil
+}
+
 func (ms *MongoStorage) GetTPStat(tpid, id string) ([]*utils.TPStats, error) {
 	filter := bson.M{
 		"tpid": tpid,
@@ -1198,6 +1364,68 @@ func (ms *MongoStorage) SetTPThreshold(tpTHs []*utils.TPThreshold) (err error) {
 	return
 }
 
+func (ms *MongoStorage) GetTPExchangeRates(tpid, id string) ([]*utils.TPExchangeRates, error) {
+	filter := bson.M{
+		"tpid": tpid,
+	}
+	if id != "" {
+		filter["id"] = id
+	}
+	var results []*utils.TPExchangeRates
+	session, col := ms.conn(utils.TBLTPExchangeRates)
+	defer session.Close()
+	err := col.Find(filter).All(&results)
+	if len(results) == 0 {
+		return results, utils.ErrNotFound
+	}
+	return results, err
+}
+
+func (ms *MongoStorage) SetTPExchangeRates(tps []*utils.TPExchangeRates) (err error) {
+	if len(tps) == 0 {
+		return
+	}
+	session, col := ms.conn(utils.TBLTPExchangeRates)
+	defer session.Close()
+	tx := col.Bulk()
+	for _, tp := range tps {
+		tx.Upsert(bson.M{"tpid": tp.TPid, "id": tp.ID}, tp)
+	}
+	_, err = tx.Run()
+	return
+}
+
+func (ms *MongoStorage) GetTPTaxRules(tpid, id string) ([]*utils.TPTaxRules, error) {
+	filter := bson.M{
+		"tpid": tpid,
+	}
+	if id != "" {
+		filter["id"] = id
+	}
+	var results []*utils.TPTaxRules
+	session, col := ms.conn(utils.TBLTPTaxRules)
+	defer session.Close()
+	err := col.Find(filter).All(&results)
+	if len(results) == 0 {
+		return results, utils.ErrNotFound
+	}
+	return results, err
+}
+
+func (ms *MongoStorage) SetTPTaxRules(tps []*utils.TPTaxRules) (err error) {
+	if len(tps) == 0 {
+		return
+	}
+	session, col := ms.conn(utils.TBLTPTaxRules)
+	defer session.Close()
+	tx := col.Bulk()
+	for _, tp := range tps {
+		tx.Upsert(bson.M{"tpid": tp.TPid, "tenant": tp.Tenant, "id": tp.ID}, tp)
+	}
+	_, err = tx.Run()
+	return
+}
+
 func (ms *MongoStorage) GetVersions(itm string) (vrs Versions, err error) {
 	return
 }
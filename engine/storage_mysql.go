@@ -84,3 +84,20 @@ func (self *MySQLStorage) notExtraFieldsExistsQry(field string) string {
 func (self *MySQLStorage) notExtraFieldsValueQry(field, value string) string {
 	return fmt.Sprintf(" extra_fields NOT LIKE '%%\"%s\":\"%s\"%%'", field, value)
 }
+
+func (self *MySQLStorage) extraFieldColumn(field string) string {
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(extra_fields, '$.\"%s\"'))", field)
+}
+
+func (self *MySQLStorage) timeBucketColumn(column, bucket string) string {
+	var format string
+	switch bucket {
+	case utils.MetaHourly:
+		format = "%Y-%m-%d %H:00:00"
+	case utils.MetaDaily:
+		format = "%Y-%m-%d 00:00:00"
+	case utils.MetaMonthly:
+		format = "%Y-%m-01 00:00:00"
+	}
+	return fmt.Sprintf("DATE_FORMAT(%s, '%s')", column, format)
+}
@@ -88,3 +88,20 @@ func (self *PostgresStorage) notExtraFieldsExistsQry(field string) string {
 func (self *PostgresStorage) notExtraFieldsValueQry(field, value string) string {
 	return fmt.Sprintf(" NOT (extra_fields ?'%s' AND (extra_fields ->> '%s') = '%s')", field, field, value)
 }
+
+func (self *PostgresStorage) extraFieldColumn(field string) string {
+	return fmt.Sprintf("(extra_fields ->> '%s')", field)
+}
+
+func (self *PostgresStorage) timeBucketColumn(column, bucket string) string {
+	var trunc string
+	switch bucket {
+	case utils.MetaHourly:
+		trunc = "hour"
+	case utils.MetaDaily:
+		trunc = "day"
+	case utils.MetaMonthly:
+		trunc = "month"
+	}
+	return fmt.Sprintf("to_char(date_trunc('%s', %s), 'YYYY-MM-DD HH24:MI:SS')", trunc, column)
+}
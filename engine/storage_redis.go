@@ -751,6 +751,13 @@ func (rs *RedisStorage) SetAccount(ub *Account) (err error) {
 			ac.UnitCounters = ub.UnitCounters
 			ac.AllowNegative = ub.AllowNegative
 			ac.Disabled = ub.Disabled
+			ac.MaxSessionCost = ub.MaxSessionCost
+			ac.SpendingLimit = ub.SpendingLimit
+			ac.CreditLimit = ub.CreditLimit
+			ac.Subscriptions = ub.Subscriptions
+			ac.ParentID = ub.ParentID
+			ac.ParentSubject = ub.ParentSubject
+			ac.SharedGroupUsage = ub.SharedGroupUsage
 			ub = ac
 		}
 	}
@@ -1663,6 +1670,44 @@ func (rs *RedisStorage) RemSQStoredMetrics(sqmID string) (err error) {
 	return
 }
 
+// GetSMGSessionCheckpoints retrieves all the SMG session checkpoints
+func (rs *RedisStorage) GetSMGSessionCheckpoints() (sCps []*SMGSessionCheckpoint, err error) {
+	var keys []string
+	if keys, err = rs.GetKeysForPrefix(utils.SMGSessionCheckpointPrefix); err != nil {
+		return
+	}
+	for _, key := range keys {
+		var values []byte
+		if values, err = rs.Cmd("GET", key).Bytes(); err != nil {
+			if err == redis.ErrRespNil { // removed in the meantime
+				err = nil
+				continue
+			}
+			return nil, err
+		}
+		var sCp *SMGSessionCheckpoint
+		if err = rs.ms.Unmarshal(values, &sCp); err != nil {
+			return nil, err
+		}
+		sCps = append(sCps, sCp)
+	}
+	return
+}
+
+// SetSMGSessionCheckpoint stores the checkpoint of a SMG session
+func (rs *RedisStorage) SetSMGSessionCheckpoint(sCp *SMGSessionCheckpoint) (err error) {
+	var result []byte
+	if result, err = rs.ms.Marshal(sCp); err != nil {
+		return
+	}
+	return rs.Cmd("SET", utils.SMGSessionCheckpointPrefix+sCp.CGRID, result).Err
+}
+
+// RemSMGSessionCheckpoint removes the checkpoint of a SMG session
+func (rs *RedisStorage) RemSMGSessionCheckpoint(cgrID string) (err error) {
+	return rs.Cmd("DEL", utils.SMGSessionCheckpointPrefix+cgrID).Err
+}
+
 // GetThresholdCfg retrieves a ThresholdCfg from dataDB/cache
 func (rs *RedisStorage) GetThresholdCfg(ID string, skipCache bool, transactionID string) (th *ThresholdCfg, err error) {
 	key := utils.ThresholdCfgPrefix + ID
@@ -1711,3 +1756,65 @@ func (rs *RedisStorage) RemThresholdCfg(ID string, transactionID string) (err er
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
+
+// GetExchangeRates retrieves the exchange rates of one currency pair
+func (rs *RedisStorage) GetExchangeRates(fromCurrency, toCurrency string) (ers *ExchangeRates, err error) {
+	var values []byte
+	if values, err = rs.Cmd("GET", utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency)).Bytes(); err != nil {
+		if err == redis.ErrRespNil {
+			err = utils.ErrNotFound
+		}
+		return
+	}
+	err = rs.ms.Unmarshal(values, &ers)
+	return
+}
+
+// SetExchangeRates stores the exchange rates of one currency pair
+func (rs *RedisStorage) SetExchangeRates(ers *ExchangeRates) (err error) {
+	var result []byte
+	if result, err = rs.ms.Marshal(ers); err != nil {
+		return
+	}
+	if err = rs.Cmd("SET", utils.ExchangeRatesPrefix+ers.ID(), result).Err; err != nil {
+		return
+	}
+	cache.RemKey(utils.ExchangeRatesPrefix+ers.ID(), true, utils.NonTransactional)
+	return
+}
+
+// RemExchangeRates removes the exchange rates of one currency pair
+func (rs *RedisStorage) RemExchangeRates(fromCurrency, toCurrency string) (err error) {
+	if err = rs.Cmd("DEL", utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency)).Err; err != nil {
+		return
+	}
+	cache.RemKey(utils.ExchangeRatesPrefix+utils.ConcatenatedKey(fromCurrency, toCurrency), true, utils.NonTransactional)
+	return
+}
+
+// GetTaxRules retrieves the tax rules of one tenant
+func (rs *RedisStorage) GetTaxRules(tenant string) (trs *TaxRules, err error) {
+	var values []byte
+	if values, err = rs.Cmd("GET", utils.TaxRulesPrefix+tenant).Bytes(); err != nil {
+		if err == redis.ErrRespNil {
+			err = utils.ErrNotFound
+		}
+		return
+	}
+	err = rs.ms.Unmarshal(values, &trs)
+	return
+}
+
+// SetTaxRules stores the tax rules of one tenant
+func (rs *RedisStorage) SetTaxRules(trs *TaxRules) (err error) {
+	var result []byte
+	if result, err = rs.ms.Marshal(trs); err != nil {
+		return
+	}
+	return rs.Cmd("SET", utils.TaxRulesPrefix+trs.Tenant, result).Err
+}
+
+// RemTaxRules removes the tax rules of one tenant
+func (rs *RedisStorage) RemTaxRules(tenant string) (err error) {
+	return rs.Cmd("DEL", utils.TaxRulesPrefix+tenant).Err
+}
@@ -36,6 +36,8 @@ type SQLImpl interface {
 	extraFieldsValueQry(string, string) string
 	notExtraFieldsExistsQry(string) string
 	notExtraFieldsValueQry(string, string) string
+	extraFieldColumn(string) string
+	timeBucketColumn(string, string) string
 }
 
 type SQLStorage struct {
@@ -190,7 +192,7 @@ func (self *SQLStorage) RemTpData(table, tpid string, args map[string]string) er
 	if len(table) == 0 { // Remove tpid out of all tables
 		for _, tblName := range []string{utils.TBLTPTimings, utils.TBLTPDestinations, utils.TBLTPRates, utils.TBLTPDestinationRates, utils.TBLTPRatingPlans, utils.TBLTPRateProfiles,
 			utils.TBLTPSharedGroups, utils.TBLTPCdrStats, utils.TBLTPLcrs, utils.TBLTPActions, utils.TBLTPActionPlans, utils.TBLTPActionTriggers, utils.TBLTPAccountActions,
-			utils.TBLTPDerivedChargers, utils.TBLTPAliases, utils.TBLTPUsers, utils.TBLTPResources, utils.TBLTPStats} {
+			utils.TBLTPDerivedChargers, utils.TBLTPAliases, utils.TBLTPUsers, utils.TBLTPResources, utils.TBLTPStats, utils.TBLTPExchangeRates, utils.TBLTPTaxRules} {
 			if err := tx.Table(tblName).Where("tpid = ?", tpid).Delete(nil).Error; err != nil {
 				tx.Rollback()
 				return err
@@ -625,6 +627,56 @@ func (self *SQLStorage) SetTPThreshold(ths []*utils.TPThreshold) error {
 	return nil
 }
 
+func (self *SQLStorage) SetTPExchangeRates(ers []*utils.TPExchangeRates) error {
+	if len(ers) == 0 {
+		return nil
+	}
+	m := make(map[string]bool)
+	tx := self.db.Begin()
+	for _, er := range ers {
+		if found, _ := m[er.ID]; !found {
+			m[er.ID] = true
+			if err := tx.Where(&TpExchangeRate{Tpid: er.TPid, Tag: er.ID}).Delete(TpExchangeRate{}).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		for _, mer := range APItoModelExchangeRates(er) {
+			if err := tx.Save(&mer).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
+func (self *SQLStorage) SetTPTaxRules(trs []*utils.TPTaxRules) error {
+	if len(trs) == 0 {
+		return nil
+	}
+	m := make(map[string]bool)
+	tx := self.db.Begin()
+	for _, tr := range trs {
+		if key := utils.ConcatenatedKey(tr.Tenant, tr.ID); !m[key] {
+			m[key] = true
+			if err := tx.Where(&TpTaxRule{Tpid: tr.TPid, Tenant: tr.Tenant, Tag: tr.ID}).Delete(TpTaxRule{}).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		for _, mtr := range APItoModelTaxRules(tr) {
+			if err := tx.Save(&mtr).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
 func (self *SQLStorage) SetSMCost(smc *SMCost) error {
 	if smc.CostDetails == nil {
 		return nil
@@ -709,6 +761,129 @@ func (self *SQLStorage) GetSMCosts(cgrid, runid, originHost, originIDPrefix stri
 	return smCosts, nil
 }
 
+func (self *SQLStorage) SetBalanceLedgerEntries(entries []*BalanceLedgerEntry) error {
+	tx := self.db.Begin()
+	for _, entry := range entries {
+		if err := tx.Save(&TBLBalanceLedger{
+			AccountID:   entry.AccountID,
+			BalanceUUID: entry.BalanceUUID,
+			BalanceID:   entry.BalanceID,
+			BalanceType: entry.BalanceType,
+			ValueBefore: entry.ValueBefore,
+			ValueAfter:  entry.ValueAfter,
+			Operation:   entry.Operation,
+			Cause:       entry.Cause,
+			CreatedAt:   entry.CreatedAt,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
+// GetBalanceLedger returns the ledger entries of an account, in the order they were created
+func (self *SQLStorage) GetBalanceLedger(fltr *BalanceLedgerFilter) ([]*BalanceLedgerEntry, error) {
+	q := self.db.Where(&TBLBalanceLedger{AccountID: fltr.AccountID})
+	if fltr.TimeStart != nil {
+		q = q.Where("created_at >= ?", *fltr.TimeStart)
+	}
+	if fltr.TimeEnd != nil {
+		q = q.Where("created_at < ?", *fltr.TimeEnd)
+	}
+	q = q.Order("id")
+	if fltr.Paginator.Limit != nil {
+		q = q.Limit(*fltr.Paginator.Limit)
+	}
+	if fltr.Paginator.Offset != nil {
+		q = q.Offset(*fltr.Paginator.Offset)
+	}
+	var results []*TBLBalanceLedger
+	if err := q.Find(&results).Error; err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	entries := make([]*BalanceLedgerEntry, len(results))
+	for i, result := range results {
+		entries[i] = &BalanceLedgerEntry{
+			AccountID:   result.AccountID,
+			BalanceUUID: result.BalanceUUID,
+			BalanceID:   result.BalanceID,
+			BalanceType: result.BalanceType,
+			ValueBefore: result.ValueBefore,
+			ValueAfter:  result.ValueAfter,
+			Operation:   result.Operation,
+			Cause:       result.Cause,
+			CreatedAt:   result.CreatedAt,
+		}
+	}
+	return entries, nil
+}
+
+// SetInvoice stores the invoice under the next number of its tenant, stored invoices are never updated
+func (self *SQLStorage) SetInvoice(inv *Invoice) error {
+	tx := self.db.Begin()
+	var lastNr sql.NullInt64
+	if err := tx.Model(&TBLInvoice{}).Where("tenant = ?", inv.Tenant).Select("MAX(number)").Row().Scan(&lastNr); err != nil {
+		tx.Rollback()
+		return err
+	}
+	inv.Number = lastNr.Int64 + 1
+	content, err := json.Marshal(inv)
+	if err != nil {
+		tx.Rollback()
+		inv.Number = 0
+		return err
+	}
+	if err := tx.Save(&TBLInvoice{
+		Tenant:      inv.Tenant,
+		Number:      inv.Number,
+		Account:     inv.Account,
+		PeriodStart: inv.PeriodStart,
+		PeriodEnd:   inv.PeriodEnd,
+		Total:       inv.Total,
+		Content:     string(content),
+		CreatedAt:   inv.CreatedAt,
+	}).Error; err != nil {
+		tx.Rollback()
+		inv.Number = 0
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// GetInvoices returns the invoices of a tenant, in the order of their numbers
+func (self *SQLStorage) GetInvoices(fltr *InvoiceFilter) ([]*Invoice, error) {
+	q := self.db.Where(&TBLInvoice{Tenant: fltr.Tenant, Account: fltr.Account, Number: fltr.Number})
+	q = q.Order("number")
+	if fltr.Paginator.Limit != nil {
+		q = q.Limit(*fltr.Paginator.Limit)
+	}
+	if fltr.Paginator.Offset != nil {
+		q = q.Offset(*fltr.Paginator.Offset)
+	}
+	var results []*TBLInvoice
+	if err := q.Find(&results).Error; err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	invs := make([]*Invoice, len(results))
+	for i, result := range results {
+		var inv Invoice
+		if err := json.Unmarshal([]byte(result.Content), &inv); err != nil {
+			return nil, err
+		}
+		invs[i] = &inv
+	}
+	return invs, nil
+}
+
 func (self *SQLStorage) LogActionTrigger(ubId, source string, at *ActionTrigger, as Actions) (err error) {
 	return
 }
@@ -793,11 +968,8 @@ func (self *SQLStorage) SetCDR(cdr *CDR, allowUpdate bool) error {
 	return nil
 }
 
-// GetCDRs has ability to remove the selected CDRs, count them or simply return them
-// qryFltr.Unscoped will ignore soft deletes or delete records permanently
-func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
-	var cdrs []*CDR
-	q := self.db.Table(utils.TBLCDRs).Select("*")
+// cdrsQuery applies the CDRsFilter conditions on top of query q
+func (self *SQLStorage) cdrsQuery(q *gorm.DB, qryFltr *utils.CDRsFilter) (*gorm.DB, error) {
 	if qryFltr.Unscoped {
 		q = q.Unscoped()
 	}
@@ -974,7 +1146,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MinUsage) != 0 {
 		if minUsage, err := utils.ParseDurationWithSecs(qryFltr.MinUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			if self.db.Dialect().GetName() == utils.MYSQL { // MySQL needs escaping for usage
 				q = q.Where("`usage` >= ?", minUsage.Seconds())
@@ -985,7 +1157,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MaxUsage) != 0 {
 		if maxUsage, err := utils.ParseDurationWithSecs(qryFltr.MaxUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			if self.db.Dialect().GetName() == utils.MYSQL { // MySQL needs escaping for usage
 				q = q.Where("`usage` < ?", maxUsage.Seconds())
@@ -997,7 +1169,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MinPDD) != 0 {
 		if minPDD, err := utils.ParseDurationWithSecs(qryFltr.MinPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			q = q.Where("pdd >= ?", minPDD.Seconds())
 		}
@@ -1005,7 +1177,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MaxPDD) != 0 {
 		if maxPDD, err := utils.ParseDurationWithSecs(qryFltr.MaxPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			q = q.Where("pdd < ?", maxPDD.Seconds())
 		}
@@ -1027,6 +1199,20 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 			q = q.Where(fmt.Sprintf("( cost IS NULL OR cost < %f )", *qryFltr.MaxCost))
 		}
 	}
+	return q, nil
+}
+
+// GetCDRs has ability to remove the selected CDRs, count them or simply return them
+// qryFltr.Unscoped will ignore soft deletes or delete records permanently
+func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
+	var cdrs []*CDR
+	q, err := self.cdrsQuery(self.db.Table(utils.TBLCDRs).Select("*"), qryFltr)
+	if err != nil {
+		return nil, 0, err
+	}
+	if qryFltr.OrderByOrderID {
+		q = q.Order(utils.TBLCDRs + ".id")
+	}
 	if qryFltr.Paginator.Limit != nil {
 		q = q.Limit(*qryFltr.Paginator.Limit)
 	}
@@ -1107,6 +1293,86 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	return cdrs, 0, nil
 }
 
+// GetCDRsAggregates groups the CDRs matching the filter and computes their usage and cost metrics
+func (self *SQLStorage) GetCDRsAggregates(qryFltr *utils.CDRsFilter, aggr *CDRsAggregation) ([]*CDRsAggregate, error) {
+	if err := aggr.Validate(); err != nil { // field names are not escaped in query
+		return nil, err
+	}
+	usageCol := "usage"
+	if self.db.Dialect().GetName() == utils.MYSQL {
+		usageCol = "`usage`"
+	}
+	var slctCols, grpCols []string
+	for i, fld := range aggr.GroupBy {
+		col, has := sqlCDRColumns[fld]
+		if !has {
+			col = self.SQLImpl.extraFieldColumn(fld)
+		}
+		grpCols = append(grpCols, fmt.Sprintf("grp%d", i))
+		slctCols = append(slctCols, fmt.Sprintf("%s AS grp%d", col, i))
+	}
+	if aggr.TimeBucket != "" {
+		grpCols = append(grpCols, "time_bucket")
+		slctCols = append(slctCols, self.SQLImpl.timeBucketColumn(sqlTimeColumn(aggr.TimeField), aggr.TimeBucket)+" AS time_bucket")
+	}
+	slctCols = append(slctCols, "COUNT(*)",
+		fmt.Sprintf("SUM(%s)", usageCol), fmt.Sprintf("MIN(%s)", usageCol), fmt.Sprintf("MAX(%s)", usageCol),
+		"SUM(cost)", "MIN(cost)", "MAX(cost)")
+	q, err := self.cdrsQuery(self.db.Model(&TBLCDRs{}).Select(strings.Join(slctCols, ", ")), qryFltr)
+	if err != nil {
+		return nil, err
+	}
+	if len(grpCols) != 0 {
+		q = q.Group(strings.Join(grpCols, ", "))
+	}
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var aggrs []*CDRsAggregate
+	for rows.Next() {
+		grpVals := make([]sql.NullString, len(aggr.GroupBy))
+		var timeBucket sql.NullString
+		var cnt int64
+		var usages, costs [3]sql.NullFloat64 // total, min, max
+		dest := make([]interface{}, 0, len(grpVals)+8)
+		for i := range grpVals {
+			dest = append(dest, &grpVals[i])
+		}
+		if aggr.TimeBucket != "" {
+			dest = append(dest, &timeBucket)
+		}
+		dest = append(dest, &cnt, &usages[0], &usages[1], &usages[2], &costs[0], &costs[1], &costs[2])
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if cnt == 0 { // no GROUP BY returns one row even without CDRs
+			continue
+		}
+		ca := &CDRsAggregate{GroupValues: make(map[string]string), Count: cnt,
+			TotalUsage: time.Duration(usages[0].Float64 * utils.NANO_MULTIPLIER),
+			MinUsage:   time.Duration(usages[1].Float64 * utils.NANO_MULTIPLIER),
+			MaxUsage:   time.Duration(usages[2].Float64 * utils.NANO_MULTIPLIER),
+			TotalCost:  costs[0].Float64, MinCost: costs[1].Float64, MaxCost: costs[2].Float64}
+		for i, fld := range aggr.GroupBy {
+			ca.GroupValues[fld] = grpVals[i].String
+		}
+		if ca.TimeBucket, err = parseTimeBucket(timeBucket.String); err != nil {
+			return nil, err
+		}
+		ca.computeAverages()
+		aggrs = append(aggrs, ca)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(aggrs) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return aggrs, nil
+}
+
 func (self *SQLStorage) GetTPDestinations(tpid, id string) (uTPDsts []*utils.TPDestination, err error) {
 	var tpDests TpDestinations
 	q := self.db.Where("tpid = ?", tpid)
@@ -1590,6 +1856,38 @@ func (self *SQLStorage) GetTPThreshold(tpid, id string) ([]*utils.TPThreshold, e
 	return aths, nil
 }
 
+func (self *SQLStorage) GetTPExchangeRates(tpid, id string) ([]*utils.TPExchangeRates, error) {
+	var ers TpExchangeRates
+	q := self.db.Where("tpid = ?", tpid)
+	if len(id) != 0 {
+		q = q.Where("tag = ?", id)
+	}
+	if err := q.Find(&ers).Error; err != nil {
+		return nil, err
+	}
+	aers := ers.AsTPExchangeRates()
+	if len(aers) == 0 {
+		return aers, utils.ErrNotFound
+	}
+	return aers, nil
+}
+
+func (self *SQLStorage) GetTPTaxRules(tpid, id string) ([]*utils.TPTaxRules, error) {
+	var trs TpTaxRules
+	q := self.db.Where("tpid = ?", tpid)
+	if len(id) != 0 {
+		q = q.Where("tag = ?", id)
+	}
+	if err := q.Find(&trs).Error; err != nil {
+		return nil, err
+	}
+	atrs := trs.AsTPTaxRules()
+	if len(atrs) == 0 {
+		return atrs, utils.ErrNotFound
+	}
+	return atrs, nil
+}
+
 // GetVersions returns slice of all versions or a specific version if tag is specified
 func (self *SQLStorage) GetVersions(itm string) (vrs Versions, err error) {
 	q := self.db.Model(&TBLVersion{})
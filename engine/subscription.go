@@ -0,0 +1,286 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// NewSubscription validates the parameters and returns a Subscription not charged yet, defaulting to
+// *monthly periods billed on *anniversary and prorated *daily
+func NewSubscription(id string, fee float64, period, billing, proration string, activationTime time.Time) (*Subscription, error) {
+	if id == "" {
+		return nil, utils.NewErrMandatoryIeMissing("ID")
+	}
+	if fee < 0 {
+		return nil, fmt.Errorf("negative subscription fee: %v", fee)
+	}
+	sub := &Subscription{ID: id, Fee: fee, Period: period, Billing: billing, Proration: proration,
+		ActivationTime: activationTime}
+	if sub.Period == "" {
+		sub.Period = utils.MetaMonthly
+	}
+	if sub.Billing == "" {
+		sub.Billing = utils.MetaAnniversary
+	}
+	if sub.Proration == "" {
+		sub.Proration = utils.MetaDaily
+	}
+	if !utils.IsSliceMember([]string{utils.MetaMonthly, utils.MetaYearly}, sub.Period) {
+		return nil, fmt.Errorf("unsupported subscription period: %s", sub.Period)
+	}
+	if !utils.IsSliceMember([]string{utils.MetaAnniversary, utils.MetaCalendar}, sub.Billing) {
+		return nil, fmt.Errorf("unsupported subscription billing: %s", sub.Billing)
+	}
+	if !utils.IsSliceMember([]string{utils.MetaDaily, utils.META_NONE}, sub.Proration) {
+		return nil, fmt.Errorf("unsupported subscription proration: %s", sub.Proration)
+	}
+	return sub, nil
+}
+
+// Subscription charges the account a fixed fee for every period, in advance
+type Subscription struct {
+	ID             string
+	Fee            float64   // amount charged for a full period
+	Period         string    // *monthly or *yearly
+	Billing        string    // *calendar periods start on the first day of the month or year, *anniversary on the activation day
+	Proration      string    // *daily charges and refunds partial periods per day, *none charges them in full and refunds nothing
+	ActivationTime time.Time // the anniversary day is kept on plan changes
+	PaidUntil      time.Time // end of the last period charged
+}
+
+// months returns the length of the period in months
+func (sub *Subscription) months() int {
+	if sub.Period == utils.MetaYearly {
+		return 12
+	}
+	return 1
+}
+
+// addMonths moves t with n months, keeping the day within the month reached
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// periodBounds returns the period containing t
+func (sub *Subscription) periodBounds(t time.Time) (start, end time.Time) {
+	months := sub.months()
+	if sub.Billing == utils.MetaCalendar {
+		if months == 12 {
+			start = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+		} else {
+			start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		}
+		return start, start.AddDate(0, months, 0)
+	}
+	act := sub.ActivationTime
+	n := ((t.Year()-act.Year())*12 + int(t.Month()) - int(act.Month())) / months
+	if start = addMonths(act, n*months); start.After(t) {
+		n--
+		start = addMonths(act, n*months)
+	}
+	return start, addMonths(act, (n+1)*months)
+}
+
+// proratedFee returns the fee for the interval [from, to) out of the period containing from
+func (sub *Subscription) proratedFee(from, to time.Time) float64 {
+	start, end := sub.periodBounds(from)
+	if sub.Proration == utils.META_NONE || !from.After(start) && !to.Before(end) {
+		return sub.Fee
+	}
+	if to.After(end) {
+		to = end
+	}
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	days := math.Ceil(to.Sub(fromDay).Hours() / 24)
+	periodDays := math.Round(end.Sub(start).Hours() / 24)
+	return utils.Round(sub.Fee*days/periodDays, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+}
+
+// nextDayStart returns at when it is a midnight, otherwise the following one
+func nextDayStart(at time.Time) time.Time {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	if day.Before(at) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// subscribe charges the subscription from at till the end of its current period.
+// Subscribing again with the same ID changes the plan, refunding what was paid in advance on the old one;
+// the day already started stays on the old plan and the new one is charged from the next day on.
+func (acc *Account) subscribe(sub *Subscription, at time.Time) error {
+	if old, has := acc.Subscriptions[sub.ID]; has {
+		sub.ActivationTime = old.ActivationTime
+		acc.refundSubscription(old, at, utils.MetaPlanChange)
+		from := nextDayStart(at)
+		_, end := sub.periodBounds(at)
+		if from.Before(end) {
+			acc.chargeSubscription(sub, sub.proratedFee(from, end), utils.MetaPlanChange, from, end)
+		}
+		sub.PaidUntil = end
+	} else {
+		if sub.ActivationTime.IsZero() {
+			sub.ActivationTime = at
+		}
+		at = sub.ActivationTime
+		_, end := sub.periodBounds(at)
+		acc.chargeSubscription(sub, sub.proratedFee(at, end), utils.MetaActivation, at, end)
+		sub.PaidUntil = end
+	}
+	if acc.Subscriptions == nil {
+		acc.Subscriptions = make(map[string]*Subscription)
+	}
+	acc.Subscriptions[sub.ID] = sub
+	acc.ExecuteActionTriggers(nil)
+	return nil
+}
+
+// unsubscribe terminates the subscription at the given time, refunding the days paid in advance
+func (acc *Account) unsubscribe(id string, at time.Time) error {
+	sub, has := acc.Subscriptions[id]
+	if !has {
+		return utils.ErrNotFound
+	}
+	acc.refundSubscription(sub, at, utils.MetaTermination)
+	delete(acc.Subscriptions, id)
+	if len(acc.Subscriptions) == 0 {
+		acc.Subscriptions = nil
+	}
+	acc.ExecuteActionTriggers(nil)
+	return nil
+}
+
+// chargeSubscriptions charges the full fee of every period started until now and not paid yet
+func (acc *Account) chargeSubscriptions(now time.Time) {
+	ids := make([]string, 0, len(acc.Subscriptions))
+	for id := range acc.Subscriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		sub := acc.Subscriptions[id]
+		for !sub.PaidUntil.After(now) {
+			start, end := sub.periodBounds(sub.PaidUntil)
+			acc.chargeSubscription(sub, sub.Fee, utils.MetaRenewal, start, end)
+			sub.PaidUntil = end
+		}
+	}
+	acc.ExecuteActionTriggers(nil)
+}
+
+// refundSubscription gives back the part of the fee paid for the days following at
+func (acc *Account) refundSubscription(sub *Subscription, at time.Time, chargeType string) {
+	from := nextDayStart(at) // the day already started is not refunded
+	if sub.Proration == utils.META_NONE || !sub.PaidUntil.After(from) {
+		return
+	}
+	if refund := sub.proratedFee(from, sub.PaidUntil); refund != 0 {
+		acc.chargeSubscription(sub, -refund, chargeType, from, sub.PaidUntil)
+	}
+	sub.PaidUntil = from
+}
+
+// chargeSubscription debits the amount out of the default monetary balance, negative for refunds,
+// recording it as *generic CDR stored once the account is saved
+func (acc *Account) chargeSubscription(sub *Subscription, amount float64, chargeType string, from, to time.Time) {
+	if amount != 0 {
+		b := acc.GetDefaultMoneyBalance()
+		b.SubstractValue(amount)
+		b.dirty = true
+	}
+	if cdrStorage == nil {
+		return
+	}
+	var tenant, account string
+	if idSplt := strings.Split(acc.ID, utils.CONCATENATED_KEY_SEP); len(idSplt) == 2 {
+		tenant, account = idSplt[0], idSplt[1]
+	} else {
+		account = acc.ID
+	}
+	cdr := &CDR{RunID: utils.META_DEFAULT, Source: utils.MetaSubscription, OriginID: utils.GenUUID(),
+		ToR: utils.GENERIC, RequestType: utils.META_PREPAID, Direction: utils.OUT,
+		Tenant: tenant, Category: utils.MetaSubscription, Account: account, Subject: account, Destination: sub.ID,
+		SetupTime: from, AnswerTime: from, Usage: to.Sub(from), CostSource: utils.MetaSubscription, Cost: amount,
+		ExtraFields: map[string]string{"SubscriptionID": sub.ID, "ChargeType": chargeType,
+			"PeriodStart": from.Format(time.RFC3339), "PeriodEnd": to.Format(time.RFC3339)},
+		Rated: true}
+	cdr.CGRID = utils.Sha1(cdr.OriginID, cdr.SetupTime.String())
+	acc.subscriptionCDRs = append(acc.subscriptionCDRs, cdr)
+}
+
+// storeSubscriptionCDRs writes the subscription charges collected so far into StorDB
+func (acc *Account) storeSubscriptionCDRs() {
+	cdrs := acc.subscriptionCDRs
+	acc.subscriptionCDRs = nil
+	if cdrStorage == nil {
+		return
+	}
+	for _, cdr := range cdrs {
+		if err := cdrStorage.SetCDR(cdr, true); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<Subscriptions> Could not store charge for subscription: %s, account: %s, error: %s",
+				cdr.ExtraFields["SubscriptionID"], acc.ID, err.Error()))
+		}
+	}
+}
+
+// subscribeAction subscribes the account to the Subscription in the action extra parameters, as JSON
+func subscribeAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+	if acc == nil {
+		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
+	}
+	var params Subscription
+	if err := json.Unmarshal([]byte(a.ExtraParameters), &params); err != nil {
+		return err
+	}
+	sub, err := NewSubscription(params.ID, params.Fee, params.Period, params.Billing, params.Proration, params.ActivationTime)
+	if err != nil {
+		return err
+	}
+	return acc.subscribe(sub, time.Now())
+}
+
+// unsubscribeAction terminates the subscription with the ID in the action extra parameters
+func unsubscribeAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+	if acc == nil {
+		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
+	}
+	return acc.unsubscribe(a.ExtraParameters, time.Now())
+}
+
+// chargeSubscriptionsAction renews the subscriptions whose paid period ended, meant for recurrent action plans
+func chargeSubscriptionsAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+	if acc == nil {
+		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
+	}
+	acc.chargeSubscriptions(time.Now())
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestNewSubscription(t *testing.T) {
+	if _, err := NewSubscription("SUB1", 10, "*weekly", "", "", time.Time{}); err == nil {
+		t.Error("Expecting error for unsupported period")
+	}
+	if _, err := NewSubscription("SUB1", -10, "", "", "", time.Time{}); err == nil {
+		t.Error("Expecting error for negative fee")
+	}
+	if sub, err := NewSubscription("SUB1", 10, "", "", "", time.Time{}); err != nil {
+		t.Error(err)
+	} else if sub.Period != utils.MetaMonthly || sub.Billing != utils.MetaAnniversary || sub.Proration != utils.MetaDaily {
+		t.Errorf("Unexpected defaults: %+v", sub)
+	}
+}
+
+func TestSubscriptionPeriodBounds(t *testing.T) {
+	sub := &Subscription{Period: utils.MetaMonthly, Billing: utils.MetaAnniversary,
+		ActivationTime: time.Date(2017, 1, 31, 10, 0, 0, 0, time.UTC)}
+	start, end := sub.periodBounds(time.Date(2017, 3, 10, 0, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2017, 2, 28, 10, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2017, 3, 31, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period: %v - %v", start, end)
+	}
+	sub.Billing = utils.MetaCalendar
+	start, end = sub.periodBounds(time.Date(2017, 3, 10, 0, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period: %v - %v", start, end)
+	}
+	sub.Period = utils.MetaYearly
+	start, end = sub.periodBounds(time.Date(2017, 3, 10, 0, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period: %v - %v", start, end)
+	}
+}
+
+func TestSubscriptionCalendarLifecycle(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:subscriber", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: utils.GenUUID(), ID: utils.META_DEFAULT, Value: 100}}}}
+	sub, _ := NewSubscription("PREMIUM", 30, utils.MetaMonthly, utils.MetaCalendar, utils.MetaDaily,
+		time.Date(2017, 4, 20, 10, 0, 0, 0, time.UTC))
+	if err := acc.subscribe(sub, time.Date(2017, 4, 20, 10, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	// 11 days out of 30
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != 89 {
+		t.Errorf("Expecting 89 after activation, received: %v", val)
+	}
+	if !sub.PaidUntil.Equal(time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected paid until: %v", sub.PaidUntil)
+	}
+	acc.chargeSubscriptions(time.Date(2017, 5, 1, 0, 0, 1, 0, time.UTC))
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != 59 {
+		t.Errorf("Expecting 59 after renewal, received: %v", val)
+	}
+	acc.chargeSubscriptions(time.Date(2017, 5, 2, 0, 0, 0, 0, time.UTC))
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != 59 {
+		t.Errorf("Expecting no second charge within the period, received: %v", val)
+	}
+	// terminated during the 21st of May, 10 days refunded out of 31
+	if err := acc.unsubscribe("PREMIUM", time.Date(2017, 5, 21, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != utils.Round(59+30.0*10/31, globalRoundingDecimals, utils.ROUNDING_MIDDLE) {
+		t.Errorf("Unexpected value after termination: %v", val)
+	}
+	if acc.Subscriptions != nil {
+		t.Errorf("Subscription not removed: %+v", acc.Subscriptions)
+	}
+	if err := acc.unsubscribe("PREMIUM", time.Now()); err != utils.ErrNotFound {
+		t.Error(err)
+	}
+}
+
+func TestSubscriptionPlanChange(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:subscriber", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: utils.GenUUID(), ID: utils.META_DEFAULT, Value: 100}}}}
+	actTime := time.Date(2017, 6, 10, 0, 0, 0, 0, time.UTC)
+	sub, _ := NewSubscription("BASIC", 30, "", "", "", actTime)
+	if err := acc.subscribe(sub, actTime); err != nil {
+		t.Fatal(err)
+	}
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != 70 {
+		t.Errorf("Expecting full fee on anniversary billing, received: %v", val)
+	}
+	// upgrade on the 25th of June, 15 days left out of 30
+	newSub, _ := NewSubscription("BASIC", 60, "", "", "", time.Time{})
+	if err := acc.subscribe(newSub, time.Date(2017, 6, 25, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != 55 {
+		t.Errorf("Expecting 55 after plan change, received: %v", val)
+	}
+	if !newSub.ActivationTime.Equal(actTime) || !newSub.PaidUntil.Equal(time.Date(2017, 7, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected subscription: %+v", newSub)
+	}
+}
+
+func TestSubscriptionPlanChangeMidDay(t *testing.T) {
+	acc := &Account{ID: "cgrates.org:subscriber", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: utils.GenUUID(), ID: utils.META_DEFAULT, Value: 100}}}}
+	actTime := time.Date(2017, 6, 10, 0, 0, 0, 0, time.UTC)
+	sub, _ := NewSubscription("BASIC", 30, "", "", "", actTime)
+	if err := acc.subscribe(sub, actTime); err != nil {
+		t.Fatal(err)
+	}
+	// the 25th started on the old plan, 14 days refunded and charged on the new one
+	newSub, _ := NewSubscription("BASIC", 60, "", "", "", time.Time{})
+	if err := acc.subscribe(newSub, time.Date(2017, 6, 25, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if val := acc.GetDefaultMoneyBalance().GetValue(); val != 56 {
+		t.Errorf("Expecting 56 after plan change, received: %v", val)
+	}
+}
+
+func TestSubscriptionCDRsStoredOnSave(t *testing.T) {
+	prevCdrStorage := cdrStorage
+	defer func() { cdrStorage = prevCdrStorage }()
+	ts := new(testCdrStorage)
+	cdrStorage = ts
+	acc := &Account{ID: "cgrates.org:subscriber_cdrs", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: utils.GenUUID(), ID: utils.META_DEFAULT, Value: 100}}}}
+	sub, _ := NewSubscription("BASIC", 30, "", "", "", time.Date(2017, 6, 10, 0, 0, 0, 0, time.UTC))
+	if err := acc.subscribe(sub, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts.cdrs) != 0 {
+		t.Errorf("Charges stored before the account: %+v", ts.cdrs)
+	}
+	if err := saveAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts.cdrs) != 1 || ts.cdrs[0].Cost != 30 ||
+		ts.cdrs[0].ExtraFields["ChargeType"] != utils.MetaActivation {
+		t.Errorf("Unexpected charges: %+v", ts.cdrs)
+	}
+	if len(acc.subscriptionCDRs) != 0 {
+		t.Errorf("Charges kept after save: %+v", acc.subscriptionCDRs)
+	}
+}
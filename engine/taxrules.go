@@ -0,0 +1,177 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// TaxRule defines the rate of one tax for the CDRs matching it, starting with ActivationTime
+type TaxRule struct {
+	ID             string          // name of the tax, eg: VAT
+	TaxClass       string          // customer tax class, empty for all
+	Categories     utils.StringMap // empty for all
+	DestinationIDs utils.StringMap // empty for all
+	ActivationTime time.Time
+	Rate           float64 // fraction of the taxed amount, eg: 0.19
+	Compound       bool    // tax the cost together with the taxes applied before
+	Weight         float64 // taxes are applied in descending weight order
+}
+
+// matches checks if the rule applies to an event at t
+func (tr *TaxRule) matches(taxClass, category string, destIDs utils.StringMap, t time.Time) bool {
+	if tr.ActivationTime.After(t) ||
+		(tr.TaxClass != "" && tr.TaxClass != taxClass) ||
+		(len(tr.Categories) != 0 && !tr.Categories[category]) {
+		return false
+	}
+	if len(tr.DestinationIDs) == 0 {
+		return true
+	}
+	for dID := range destIDs {
+		if tr.DestinationIDs[dID] {
+			return true
+		}
+	}
+	return false
+}
+
+// specificity returns the number of filters defined by the rule
+func (tr *TaxRule) specificity() (spec int) {
+	if tr.TaxClass != "" {
+		spec++
+	}
+	if len(tr.Categories) != 0 {
+		spec++
+	}
+	if len(tr.DestinationIDs) != 0 {
+		spec++
+	}
+	return
+}
+
+// TaxRules holds the tax rules of one tenant
+type TaxRules struct {
+	Tenant string
+	Rules  []*TaxRule
+}
+
+// Sort orders the rules on tax name and activation time
+func (trs *TaxRules) Sort() {
+	sort.SliceStable(trs.Rules, func(i, j int) bool {
+		if trs.Rules[i].ID != trs.Rules[j].ID {
+			return trs.Rules[i].ID < trs.Rules[j].ID
+		}
+		return trs.Rules[i].ActivationTime.Before(trs.Rules[j].ActivationTime)
+	})
+}
+
+// TaxCharge is the amount of one tax applied on a cost
+type TaxCharge struct {
+	ID     string
+	Rate   float64
+	Amount float64
+}
+
+// ComputeTaxes selects for each tax the most specific matching rule, the latest activated one on equal specificity,
+// and applies them on cost in descending weight order
+func (trs *TaxRules) ComputeTaxes(cost float64, taxClass, category string, destIDs utils.StringMap, t time.Time,
+	roundingDecimals int) (taxes []*TaxCharge) {
+	active := make(map[string]*TaxRule)
+	for _, tr := range trs.Rules {
+		if !tr.matches(taxClass, category, destIDs, t) {
+			continue
+		}
+		if prev, has := active[tr.ID]; has &&
+			(prev.specificity() > tr.specificity() ||
+				(prev.specificity() == tr.specificity() && prev.ActivationTime.After(tr.ActivationTime))) {
+			continue
+		}
+		active[tr.ID] = tr
+	}
+	rules := make([]*TaxRule, 0, len(active))
+	for _, tr := range active {
+		rules = append(rules, tr)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Weight != rules[j].Weight {
+			return rules[i].Weight > rules[j].Weight
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	var taxTotal float64
+	for _, tr := range rules {
+		taxed := cost
+		if tr.Compound {
+			taxed += taxTotal
+		}
+		amount := utils.Round(taxed*tr.Rate, roundingDecimals, utils.ROUNDING_MIDDLE)
+		taxTotal += amount
+		taxes = append(taxes, &TaxCharge{ID: tr.ID, Rate: tr.Rate, Amount: amount})
+	}
+	return
+}
+
+// applyLocalTaxes computes the taxes of a rated CDR out of the tax rules of its tenant,
+// storing each of them in ExtraFields as Tax_<ID>, together with their TaxTotal
+func applyLocalTaxes(cdr *CDR, taxClassFld *utils.RSRField, roundingDecimals int) error {
+	if cdr.Cost <= 0 { // not rated or nothing to tax
+		return nil
+	}
+	trs, err := dataStorage.GetTaxRules(cdr.Tenant)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	var taxClass string
+	if taxClassFld != nil {
+		taxClass = cdr.FieldAsString(taxClassFld)
+	}
+	destIDs := make(utils.StringMap)
+	for _, p := range utils.SplitPrefix(cdr.Destination, MIN_PREFIX_MATCH) {
+		if dIDs, err := dataStorage.GetReverseDestination(p, false, utils.NonTransactional); err == nil {
+			for _, dID := range dIDs {
+				destIDs[dID] = true
+			}
+		}
+	}
+	t := cdr.AnswerTime
+	if t.IsZero() {
+		t = cdr.SetupTime
+	}
+	taxes := trs.ComputeTaxes(cdr.Cost, taxClass, cdr.Category, destIDs, t, roundingDecimals)
+	if len(taxes) == 0 {
+		return nil
+	}
+	if cdr.ExtraFields == nil {
+		cdr.ExtraFields = make(map[string]string)
+	}
+	var taxTotal float64
+	for _, tax := range taxes {
+		cdr.ExtraFields[utils.TaxFieldPrefix+tax.ID] = strconv.FormatFloat(tax.Amount, 'f', -1, 64)
+		taxTotal += tax.Amount
+	}
+	cdr.ExtraFields[utils.TaxTotal] = strconv.FormatFloat(utils.Round(taxTotal, roundingDecimals, utils.ROUNDING_MIDDLE), 'f', -1, 64)
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestTaxRulesComputeTaxes(t *testing.T) {
+	trs := &TaxRules{Tenant: "cgrates.org", Rules: []*TaxRule{
+		&TaxRule{ID: "VAT", ActivationTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.2, Weight: 20},
+		&TaxRule{ID: "VAT", ActivationTime: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.21, Weight: 20},
+		&TaxRule{ID: "VAT", TaxClass: "*reduced", ActivationTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.05, Weight: 20},
+		&TaxRule{ID: "LEVY", Categories: utils.StringMap{"call": true},
+			ActivationTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.1, Compound: true, Weight: 10},
+		&TaxRule{ID: "INTL", DestinationIDs: utils.StringMap{"INTERNATIONAL": true},
+			ActivationTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.03, Weight: 30},
+	}}
+	eTaxes := []*TaxCharge{
+		&TaxCharge{ID: "VAT", Rate: 0.2, Amount: 2},
+		&TaxCharge{ID: "LEVY", Rate: 0.1, Amount: 1.2},
+	}
+	if taxes := trs.ComputeTaxes(10, "", "call", utils.StringMap{"NATIONAL": true},
+		time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC), 4); !reflect.DeepEqual(eTaxes, taxes) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eTaxes), utils.ToJSON(taxes))
+	}
+	// reduced class, international destination applied first
+	eTaxes = []*TaxCharge{
+		&TaxCharge{ID: "INTL", Rate: 0.03, Amount: 0.3},
+		&TaxCharge{ID: "VAT", Rate: 0.05, Amount: 0.5},
+	}
+	if taxes := trs.ComputeTaxes(10, "*reduced", "sms", utils.StringMap{"INTERNATIONAL": true},
+		time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC), 4); !reflect.DeepEqual(eTaxes, taxes) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eTaxes), utils.ToJSON(taxes))
+	}
+	eTaxes = []*TaxCharge{&TaxCharge{ID: "VAT", Rate: 0.21, Amount: 2.1}}
+	if taxes := trs.ComputeTaxes(10, "", "sms", nil,
+		time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC), 4); !reflect.DeepEqual(eTaxes, taxes) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eTaxes), utils.ToJSON(taxes))
+	}
+	if taxes := trs.ComputeTaxes(10, "", "call", nil,
+		time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC), 4); len(taxes) != 0 {
+		t.Errorf("Expecting no taxes before activation, received: %s", utils.ToJSON(taxes))
+	}
+}
+
+func TestApplyLocalTaxes(t *testing.T) {
+	taxClassFld, _ := utils.NewRSRField(utils.TaxClass)
+	cdr := &CDR{Tenant: "cgrates.org", Category: "call", Destination: "4986517174963",
+		AnswerTime: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), Cost: 10, ExtraFields: map[string]string{}}
+	if err := applyLocalTaxes(cdr, taxClassFld, 4); err != nil {
+		t.Fatal(err)
+	}
+	eExtraFields := map[string]string{"Tax_VAT": "1.9", "Tax_LEVY": "0.119", utils.TaxTotal: "2.019"}
+	if !reflect.DeepEqual(eExtraFields, cdr.ExtraFields) {
+		t.Errorf("Expecting: %+v, received: %+v", eExtraFields, cdr.ExtraFields)
+	}
+	cdr = &CDR{Tenant: "cgrates.org", Category: "call", Destination: "4986517174963",
+		AnswerTime: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), Cost: 10,
+		ExtraFields: map[string]string{utils.TaxClass: "*exempt"}}
+	if err := applyLocalTaxes(cdr, taxClassFld, 4); err != nil {
+		t.Fatal(err)
+	}
+	if cdr.ExtraFields["Tax_VAT"] != "0" || cdr.ExtraFields[utils.TaxTotal] != "0.1" {
+		t.Errorf("Unexpected taxes: %+v", cdr.ExtraFields)
+	}
+	cdr = &CDR{Tenant: "itsyscom.com", Cost: 10}
+	if err := applyLocalTaxes(cdr, taxClassFld, 4); err != nil {
+		t.Error(err)
+	} else if cdr.ExtraFields != nil {
+		t.Errorf("Unexpected taxes: %+v", cdr.ExtraFields)
+	}
+}
@@ -51,18 +51,21 @@ type Increment struct {
 type DebitInfo struct {
 	Unit      *UnitInfo
 	Monetary  *MonetaryInfo
-	AccountID string // used when debited from shared balance
+	AccountID string       // used when debited from shared balance
+	Parents   ParentDebits // debits of the reseller accounts above, refunded together with the increment
 }
 
 func (di *DebitInfo) Equal(other *DebitInfo) bool {
 	return di.Unit.Equal(other.Unit) &&
 		di.Monetary.Equal(other.Monetary) &&
-		di.AccountID == other.AccountID
+		di.AccountID == other.AccountID &&
+		di.Parents.Equal(other.Parents)
 }
 
 func (di *DebitInfo) Clone() *DebitInfo {
 	nDi := &DebitInfo{
 		AccountID: di.AccountID,
+		Parents:   di.Parents.Clone(),
 	}
 	if di.Unit != nil {
 		nDi.Unit = di.Unit.Clone()
@@ -73,11 +76,59 @@ func (di *DebitInfo) Clone() *DebitInfo {
 	return nDi
 }
 
+// ParentDebit is the part of a parent account debit started within one increment of its child
+type ParentDebit struct {
+	AccountID   string
+	BalanceUUID string
+	BalanceType string
+	Value       float64 // taken out of the balance, in the balance currency for monetary ones
+}
+
+// ParentDebits are the debits of all the levels above the account, one per parent balance
+type ParentDebits []*ParentDebit
+
+func (pds ParentDebits) Equal(other ParentDebits) bool {
+	if len(pds) != len(other) {
+		return false
+	}
+	for i, pd := range pds {
+		if *pd != *other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (pds ParentDebits) Clone() ParentDebits {
+	if pds == nil {
+		return nil
+	}
+	cln := make(ParentDebits, len(pds))
+	for i, pd := range pds {
+		pdCln := *pd
+		cln[i] = &pdCln
+	}
+	return cln
+}
+
+// add sums up value on the debit of the same balance, appending it otherwise
+func (pds ParentDebits) add(accountID, balanceUUID, balanceType string, value float64) ParentDebits {
+	for _, pd := range pds {
+		if pd.AccountID == accountID && pd.BalanceUUID == balanceUUID {
+			pd.Value += value
+			return pds
+		}
+	}
+	return append(pds, &ParentDebit{AccountID: accountID, BalanceUUID: balanceUUID, BalanceType: balanceType, Value: value})
+}
+
 type MonetaryInfo struct {
 	UUID         string
 	ID           string
 	Value        float64
 	RateInterval *RateInterval
+	ExchangeRate float64 // converting the cost into the balance currency, 0 if they are the same
+	TierCounter  string  // account counter the usage was added to, when rated on tiers
 }
 
 func (mi *MonetaryInfo) Clone() *MonetaryInfo {
@@ -93,6 +144,8 @@ func (mi *MonetaryInfo) Equal(other *MonetaryInfo) bool {
 		return false
 	}
 	return mi.UUID == other.UUID &&
+		mi.ExchangeRate == other.ExchangeRate &&
+		mi.TierCounter == other.TierCounter &&
 		reflect.DeepEqual(mi.RateInterval, other.RateInterval)
 }
 
@@ -404,7 +457,7 @@ func (ts *TimeSpan) GetDuration() time.Duration {
 	return ts.TimeEnd.Sub(ts.TimeStart)
 }
 
-//Returns the duration of a unitary timespan in a compressed set
+// Returns the duration of a unitary timespan in a compressed set
 func (ts *TimeSpan) GetUnitDuration() time.Duration {
 	return time.Duration(int(ts.TimeEnd.Sub(ts.TimeStart)) / ts.GetCompressFactor())
 }
@@ -441,6 +494,30 @@ func (ts *TimeSpan) CalculateCost() float64 {
 	}
 }
 
+// parentsRounding returns the corrections rounding the monetary parent debits of the timespan the same way as its cost
+func (ts *TimeSpan) parentsRounding() (corrections ParentDebits) {
+	var totals ParentDebits
+	for _, incr := range ts.Increments {
+		if incr.BalanceInfo == nil {
+			continue
+		}
+		for _, pd := range incr.BalanceInfo.Parents {
+			if pd.BalanceType == utils.MONETARY {
+				totals = totals.add(pd.AccountID, pd.BalanceUUID, pd.BalanceType,
+					pd.Value*float64(incr.GetCompressFactor()*ts.GetCompressFactor()))
+			}
+		}
+	}
+	for _, total := range totals {
+		rounded := utils.Round(total.Value, ts.RateInterval.Rating.RoundingDecimals, ts.RateInterval.Rating.RoundingMethod)
+		if correction := rounded - total.Value; correction != 0 {
+			total.Value = correction
+			corrections = append(corrections, total)
+		}
+	}
+	return
+}
+
 func (ts *TimeSpan) setRatingInfo(rp *RatingInfo) {
 	ts.ratingInfo = rp
 	ts.MatchedSubject = rp.MatchedSubject
@@ -504,11 +581,12 @@ func (ts *TimeSpan) SplitByRateInterval(i *RateInterval, data bool) (nts *TimeSp
 	// split by GroupStart
 	if i.Rating != nil {
 		i.Rating.Rates.Sort()
+		groupStart, groupEnd := ts.groupBounds(i)
 		for _, rate := range i.Rating.Rates {
-			if ts.GetGroupStart() < rate.GroupIntervalStart && ts.GetGroupEnd() > rate.GroupIntervalStart {
+			if groupStart < rate.GroupIntervalStart && groupEnd > rate.GroupIntervalStart {
 				//log.Print("Splitting")
 				ts.SetRateInterval(i)
-				splitTime := ts.TimeStart.Add(rate.GroupIntervalStart - ts.GetGroupStart())
+				splitTime := ts.TimeStart.Add(rate.GroupIntervalStart - groupStart)
 				nts = &TimeSpan{
 					TimeStart: splitTime,
 					TimeEnd:   ts.TimeEnd,
@@ -667,15 +745,32 @@ func (ts *TimeSpan) SplitByDay() (newTs *TimeSpan) {
 
 // Returns the starting time of this timespan
 func (ts *TimeSpan) GetGroupStart() time.Duration {
-	s := ts.DurationIndex - ts.GetDuration()
-	if s < 0 {
-		s = 0
-	}
+	s, _ := ts.groupBounds(ts.RateInterval)
 	return s
 }
 
 func (ts *TimeSpan) GetGroupEnd() time.Duration {
-	return ts.DurationIndex
+	_, e := ts.groupBounds(ts.RateInterval)
+	return e
+}
+
+// groupBounds returns the position of the timespan on the rate groups of interval i,
+// moved by the account usage for tiered intervals
+func (ts *TimeSpan) groupBounds(i *RateInterval) (start, end time.Duration) {
+	start = ts.DurationIndex - ts.GetDuration()
+	if start < 0 {
+		start = 0
+	}
+	end = ts.DurationIndex
+	if offset := i.getTierOffset(); offset != 0 {
+		if start += offset; start < 0 {
+			start = 0
+		}
+		if end += offset; end < 0 {
+			end = 0
+		}
+	}
+	return
 }
 
 // sets the DurationIndex attribute to reflect new timespan
@@ -754,7 +849,8 @@ func (ts *TimeSpan) hasBetterRateIntervalThan(interval *RateInterval) bool {
 		return false
 	}
 	ownPrice, _, _ := ts.RateInterval.GetRateParameters(ts.GetGroupStart())
-	otherPrice, _, _ := interval.GetRateParameters(ts.GetGroupStart())
+	otherStart, _ := ts.groupBounds(interval)
+	otherPrice, _, _ := interval.GetRateParameters(otherStart)
 	// if own price is smaller than it's better
 	if ownPrice < otherPrice {
 		return true
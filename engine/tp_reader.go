@@ -52,6 +52,8 @@ type TpReader struct {
 	resProfiles      map[string]*utils.TPResource
 	stats            map[string]*utils.TPStats
 	thresholds       map[string]*utils.TPThreshold
+	exchangeRates    map[string]*ExchangeRates
+	taxRules         map[string]*TaxRules
 
 	revDests,
 	revAliases,
@@ -126,6 +128,8 @@ func (tpr *TpReader) Init() {
 	tpr.resProfiles = make(map[string]*utils.TPResource)
 	tpr.stats = make(map[string]*utils.TPStats)
 	tpr.thresholds = make(map[string]*utils.TPThreshold)
+	tpr.exchangeRates = make(map[string]*ExchangeRates)
+	tpr.taxRules = make(map[string]*TaxRules)
 	tpr.revDests = make(map[string][]string)
 	tpr.revAliases = make(map[string][]string)
 	tpr.acntActionPlans = make(map[string][]string)
@@ -445,9 +449,19 @@ func (tpr *TpReader) LoadSharedGroupsFiltered(tag string, save bool) (err error)
 			}
 		}
 		for _, tpSg := range tpSgs {
+			if !utils.IsSliceMember([]string{"", utils.MetaDaily, utils.MetaMonthly}, tpSg.QuotaPeriod) {
+				return fmt.Errorf("unsupported quota period %s in shared group %s", tpSg.QuotaPeriod, tag)
+			}
+			if tpSg.Quota != 0 && !utils.IsSliceMember([]string{utils.MONETARY, utils.VOICE, utils.DATA, utils.SMS, utils.MMS, utils.GENERIC},
+				tpSg.QuotaBalanceType) {
+				return fmt.Errorf("unsupported quota balance type %s in shared group %s", tpSg.QuotaBalanceType, tag)
+			}
 			sg.AccountParameters[tpSg.Account] = &SharingParameters{
-				Strategy:      tpSg.Strategy,
-				RatingSubject: tpSg.RatingSubject,
+				Strategy:         tpSg.Strategy,
+				RatingSubject:    tpSg.RatingSubject,
+				Quota:            tpSg.Quota,
+				QuotaPeriod:      tpSg.QuotaPeriod,
+				QuotaBalanceType: tpSg.QuotaBalanceType,
 			}
 		}
 		tpr.sharedGroups[tag] = sg
@@ -1639,6 +1653,74 @@ func (tpr *TpReader) LoadThresholds() error {
 	return tpr.LoadThresholdsFiltered("")
 }
 
+// LoadExchangeRates groups the exchange rates on currency pairs, merging the ones defined under different tags
+func (tpr *TpReader) LoadExchangeRates() error {
+	tps, err := tpr.lr.GetTPExchangeRates(tpr.tpid, "")
+	if err != nil {
+		return err
+	}
+	for _, tp := range tps {
+		for _, tpER := range tp.ExchangeRates {
+			if tpER.Rate <= 0 {
+				return fmt.Errorf("invalid exchange rate %v from %s to %s", tpER.Rate, tpER.FromCurrency, tpER.ToCurrency)
+			}
+			at, err := utils.ParseTimeDetectLayout(tpER.ActivationTime, tpr.timezone)
+			if err != nil {
+				return err
+			}
+			pairID := utils.ConcatenatedKey(tpER.FromCurrency, tpER.ToCurrency)
+			ers, exists := tpr.exchangeRates[pairID]
+			if !exists {
+				ers = &ExchangeRates{FromCurrency: tpER.FromCurrency, ToCurrency: tpER.ToCurrency}
+				tpr.exchangeRates[pairID] = ers
+			}
+			ers.Rates = append(ers.Rates, &ExchangeRate{ActivationTime: at, Rate: tpER.Rate})
+		}
+	}
+	for _, ers := range tpr.exchangeRates {
+		ers.Sort()
+	}
+	return nil
+}
+
+// LoadTaxRules groups the tax rules on tenants
+func (tpr *TpReader) LoadTaxRules() error {
+	tps, err := tpr.lr.GetTPTaxRules(tpr.tpid, "")
+	if err != nil {
+		return err
+	}
+	for _, tp := range tps {
+		for _, tpTR := range tp.TaxRules {
+			if tpTR.Rate < 0 {
+				return fmt.Errorf("invalid rate %v for tax %s of tenant %s", tpTR.Rate, tp.ID, tp.Tenant)
+			}
+			at, err := utils.ParseTimeDetectLayout(tpTR.ActivationTime, tpr.timezone)
+			if err != nil {
+				return err
+			}
+			trs, exists := tpr.taxRules[tp.Tenant]
+			if !exists {
+				trs = &TaxRules{Tenant: tp.Tenant}
+				tpr.taxRules[tp.Tenant] = trs
+			}
+			trs.Rules = append(trs.Rules, &TaxRule{
+				ID:             tp.ID,
+				TaxClass:       tpTR.TaxClass,
+				Categories:     utils.ParseStringMap(tpTR.Categories),
+				DestinationIDs: utils.ParseStringMap(tpTR.DestinationIDs),
+				ActivationTime: at,
+				Rate:           tpTR.Rate,
+				Compound:       tpTR.Compound,
+				Weight:         tpTR.Weight,
+			})
+		}
+	}
+	for _, trs := range tpr.taxRules {
+		trs.Sort()
+	}
+	return nil
+}
+
 func (tpr *TpReader) LoadAll() (err error) {
 	if err = tpr.LoadDestinations(); err != nil && err.Error() != utils.NotFoundCaps {
 		return
@@ -1697,6 +1779,12 @@ func (tpr *TpReader) LoadAll() (err error) {
 	if err = tpr.LoadThresholds(); err != nil && err.Error() != utils.NotFoundCaps {
 		return
 	}
+	if err = tpr.LoadExchangeRates(); err != nil && err.Error() != utils.NotFoundCaps {
+		return
+	}
+	if err = tpr.LoadTaxRules(); err != nil && err.Error() != utils.NotFoundCaps {
+		return
+	}
 	return nil
 }
 
@@ -1974,6 +2062,28 @@ func (tpr *TpReader) WriteToDatabase(flush, verbose, disable_reverse bool) (err
 			log.Print("\t", th.ID)
 		}
 	}
+	if verbose {
+		log.Print("ExchangeRates:")
+	}
+	for pairID, ers := range tpr.exchangeRates {
+		if err = tpr.dataStorage.SetExchangeRates(ers); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", pairID)
+		}
+	}
+	if verbose {
+		log.Print("TaxRules:")
+	}
+	for tenant, trs := range tpr.taxRules {
+		if err = tpr.dataStorage.SetTaxRules(trs); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", tenant)
+		}
+	}
 	if verbose {
 		log.Print("Timings:")
 	}
@@ -2141,6 +2251,10 @@ func (tpr *TpReader) ShowStatistics() {
 	log.Print("ResourceProfiles: ", len(tpr.resProfiles))
 	// stats
 	log.Print("Stats: ", len(tpr.stats))
+	// exchange rates
+	log.Print("Exchange rates: ", len(tpr.exchangeRates))
+	// tax rules
+	log.Print("Tax rules: ", len(tpr.taxRules))
 }
 
 // Returns the identities loaded for a specific category, useful for cache reloads
@@ -2290,6 +2404,22 @@ func (tpr *TpReader) GetLoadedIds(categ string) ([]string, error) {
 			i++
 		}
 		return keys, nil
+	case utils.ExchangeRatesPrefix:
+		keys := make([]string, len(tpr.exchangeRates))
+		i := 0
+		for k := range tpr.exchangeRates {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
+	case utils.TaxRulesPrefix:
+		keys := make([]string, len(tpr.taxRules))
+		i := 0
+		for k := range tpr.taxRules {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
 	}
 	return nil, errors.New("Unsupported load category")
 }
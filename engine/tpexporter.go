@@ -143,6 +143,26 @@ func (self *TPExporter) Run() error {
 		}
 	}
 
+	if storData, err := self.storDb.GetTPExchangeRates(self.tpID, ""); err != nil && err != utils.ErrNotFound {
+		return err
+	} else {
+		for _, sd := range storData {
+			for _, mdl := range APItoModelExchangeRates(sd) {
+				toExportMap[utils.ExchangeRatesCsv] = append(toExportMap[utils.ExchangeRatesCsv], mdl)
+			}
+		}
+	}
+
+	if storData, err := self.storDb.GetTPTaxRules(self.tpID, ""); err != nil && err != utils.ErrNotFound {
+		return err
+	} else {
+		for _, sd := range storData {
+			for _, mdl := range APItoModelTaxRules(sd) {
+				toExportMap[utils.TaxRulesCsv] = append(toExportMap[utils.TaxRulesCsv], mdl)
+			}
+		}
+	}
+
 	if storData, err := self.storDb.GetTPActions(self.tpID, ""); err != nil {
 		return err
 	} else {
@@ -59,6 +59,8 @@ var fileHandlers = map[string]func(*TPCSVImporter, string) error{
 	utils.ResourcesCsv:          (*TPCSVImporter).importResources,
 	utils.StatsCsv:              (*TPCSVImporter).importStats,
 	utils.ThresholdsCsv:         (*TPCSVImporter).importThresholds,
+	utils.ExchangeRatesCsv:      (*TPCSVImporter).importExchangeRates,
+	utils.TaxRulesCsv:           (*TPCSVImporter).importTaxRules,
 }
 
 func (self *TPCSVImporter) Run() error {
@@ -82,6 +84,8 @@ func (self *TPCSVImporter) Run() error {
 		path.Join(self.DirPath, utils.ResourcesCsv),
 		path.Join(self.DirPath, utils.StatsCsv),
 		path.Join(self.DirPath, utils.ThresholdsCsv),
+		path.Join(self.DirPath, utils.ExchangeRatesCsv),
+		path.Join(self.DirPath, utils.TaxRulesCsv),
 	)
 	files, _ := ioutil.ReadDir(self.DirPath)
 	for _, f := range files {
@@ -383,3 +387,25 @@ func (self *TPCSVImporter) importThresholds(fn string) error {
 	}
 	return self.StorDb.SetTPThreshold(sts)
 }
+
+func (self *TPCSVImporter) importExchangeRates(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	ers, err := self.csvr.GetTPExchangeRates(self.TPid, "")
+	if err != nil {
+		return err
+	}
+	return self.StorDb.SetTPExchangeRates(ers)
+}
+
+func (self *TPCSVImporter) importTaxRules(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	trs, err := self.csvr.GetTPTaxRules(self.TPid, "")
+	if err != nil {
+		return err
+	}
+	return self.StorDb.SetTPTaxRules(trs)
+}
@@ -0,0 +1,102 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// tpRatingDB serves the rating data of a tariff plan loaded out of StorDB aside the active one.
+// Reads go around the cache so the two sets of rating data do not mix.
+type tpRatingDB struct {
+	DataDB
+	transID string // cache transaction collecting what the reads would cache, rolled back on close
+}
+
+// newTPRatingDB loads the rating data of the tariff plan with tpid into memory
+func newTPRatingDB(lr LoadReader, tpid, timezone string) (db *tpRatingDB, err error) {
+	ms, err := NewMapStorage()
+	if err != nil {
+		return nil, err
+	}
+	db = &tpRatingDB{DataDB: ms, transID: cache.BeginTransaction()}
+	tpr := NewTpReader(ms, lr, tpid, timezone)
+	for _, load := range []func() error{tpr.LoadDestinations, tpr.LoadTimings, tpr.LoadRates,
+		tpr.LoadDestinationRates, tpr.LoadRatingPlans, tpr.LoadRatingProfiles} {
+		if err = load(); err != nil && err.Error() != utils.NotFoundCaps {
+			db.close()
+			return nil, err
+		}
+	}
+	if len(tpr.ratingProfiles) == 0 {
+		db.close()
+		return nil, utils.ErrNotFound
+	}
+	for _, d := range tpr.destinations {
+		if err = ms.SetDestination(d, db.transID); err == nil {
+			err = ms.SetReverseDestination(d, db.transID)
+		}
+		if err != nil {
+			db.close()
+			return nil, err
+		}
+	}
+	for _, rp := range tpr.ratingPlans {
+		if err = ms.SetRatingPlan(rp, db.transID); err != nil {
+			db.close()
+			return nil, err
+		}
+	}
+	for _, rpf := range tpr.ratingProfiles {
+		if err = ms.SetRatingProfile(rpf, db.transID); err != nil {
+			db.close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// close drops what the reads would have cached
+func (db *tpRatingDB) close() {
+	cache.RollbackTransaction(db.transID)
+}
+
+// getRatingProfile returns the rating profile out of the tariff plan the CallDescriptor is rated on
+func (cd *CallDescriptor) getRatingProfile(key string) (*RatingProfile, error) {
+	if cd.ratingDB != nil {
+		return cd.ratingDB.GetRatingProfile(key, true, cd.ratingDB.transID)
+	}
+	return dataStorage.GetRatingProfile(key, false, utils.NonTransactional)
+}
+
+// getRatingPlan returns the rating plan out of the tariff plan the CallDescriptor is rated on
+func (cd *CallDescriptor) getRatingPlan(id string) (*RatingPlan, error) {
+	if cd.ratingDB != nil {
+		return cd.ratingDB.GetRatingPlan(id, true, cd.ratingDB.transID)
+	}
+	return dataStorage.GetRatingPlan(id, false, utils.NonTransactional)
+}
+
+// getReverseDestination returns the destination IDs containing prefix out of the tariff plan the CallDescriptor is rated on
+func (cd *CallDescriptor) getReverseDestination(prefix string) ([]string, error) {
+	if cd.ratingDB != nil {
+		return cd.ratingDB.GetReverseDestination(prefix, true, cd.ratingDB.transID)
+	}
+	return dataStorage.GetReverseDestination(prefix, false, utils.NonTransactional)
+}
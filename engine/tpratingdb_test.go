@@ -0,0 +1,73 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestTPRatingDBGetCost(t *testing.T) {
+	lr := NewStringCSVStorage(',', `
+DST_RERATE,0256
+`, `
+ALWAYS,*any,*any,*any,*any,00:00:00
+`, `
+RT_RERATE,0,0.5,60s,60s,0s
+`, `
+DR_RERATE,DST_RERATE,RT_RERATE,*up,4,0,
+`, `
+RP_RERATE,DR_RERATE,ALWAYS,10
+`, `
+*out,vdf,0,rif,2012-01-01T00:00:00Z,RP_RERATE,,
+`, "", "", "", "", "", "", "", "", "", "", "", "", "", "", "")
+	tpDB, err := newTPRatingDB(lr, "TP_RERATE", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpDB.close()
+	cd := &CallDescriptor{Direction: utils.OUT, Category: "0", Tenant: "vdf", Subject: "rif", Destination: "0256",
+		TimeStart: time.Date(2012, time.February, 2, 17, 30, 0, 0, time.UTC),
+		TimeEnd:   time.Date(2012, time.February, 2, 18, 30, 0, 0, time.UTC)}
+	if cc, err := cd.GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 2701 {
+		t.Errorf("Unexpected cost out of the active tariff plan: %f", cc.Cost)
+	}
+	tpCD := cd.Clone()
+	tpCD.ratingDB = tpDB
+	if cc, err := tpCD.GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 30 {
+		t.Errorf("Unexpected cost out of the tariff plan: %f", cc.Cost)
+	}
+	if cc, err := cd.GetCost(); err != nil { // the tariff plan does not leak into the active one
+		t.Fatal(err)
+	} else if cc.Cost != 2701 {
+		t.Errorf("Unexpected cost out of the active tariff plan: %f", cc.Cost)
+	}
+}
+
+func TestTPRatingDBNotFound(t *testing.T) {
+	lr := NewStringCSVStorage(',', "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "")
+	if _, err := newTPRatingDB(lr, "TP_MISSING", ""); err != utils.ErrNotFound {
+		t.Errorf("Expecting ErrNotFound, received: %v", err)
+	}
+}
@@ -60,6 +60,8 @@ const (
 	AUTH_OK                  = "+AUTH_OK"
 	DISCONNECT               = "+SWITCH DISCONNECT"
 	INSUFFICIENT_FUNDS       = "-INSUFFICIENT_FUNDS"
+	LOW_CREDIT               = "-LOW_CREDIT"
+	SPENDING_CAP_REACHED     = "-SPENDING_CAP_REACHED"
 	UNAUTHORIZED_DESTINATION = "-UNAUTHORIZED_DESTINATION"
 	MISSING_PARAMETER        = "-MISSING_PARAMETER"
 	SYSTEM_ERROR             = "-SYSTEM_ERROR"
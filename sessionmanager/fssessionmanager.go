@@ -250,7 +250,7 @@ func (sm *FSSessionManager) onChannelAnswer(ev engine.Event, connId string) {
 	if ev.MissingParameter(sm.timezone) {
 		sm.DisconnectSession(ev, connId, MISSING_PARAMETER)
 	}
-	s := NewSession(ev, connId, sm)
+	s := NewSession(ev, connId, sm, sm.cfg.MinDurLowBalance)
 	if s != nil {
 		sm.sessions.indexSession(s)
 	}
@@ -397,6 +397,9 @@ func (sm *FSSessionManager) Timezone() string {
 
 // Called when call goes under the minimum duratio threshold, so FreeSWITCH can play an announcement message
 func (sm *FSSessionManager) WarnSessionMinDuration(sessionUuid, connId string) {
+	if sm.cfg.LowBalanceAnnFile == "" { // nothing to play
+		return
+	}
 	if _, err := sm.conns[connId].SendApiCmd(fmt.Sprintf("uuid_broadcast %s %s aleg\n\n",
 		sessionUuid, sm.cfg.LowBalanceAnnFile)); err != nil {
 		utils.Logger.Err(fmt.Sprintf("<SM-FreeSWITCH> Could not send uuid_broadcast to freeswitch, error: %s, connection id: %s",
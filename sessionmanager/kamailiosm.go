@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/cgrates/cgrates/config"
@@ -32,23 +33,24 @@ import (
 )
 
 func NewKamailioSessionManager(smKamCfg *config.SmKamConfig, rater, cdrsrv,
-	rlS rpcclient.RpcClientConnection, timezone string) (ksm *KamailioSessionManager, err error) {
+	rlS rpcclient.RpcClientConnection, timezone, defaultReqType string) (ksm *KamailioSessionManager, err error) {
 	if rlS != nil && reflect.ValueOf(rlS).IsNil() {
 		rlS = nil
 	}
 	ksm = &KamailioSessionManager{cfg: smKamCfg, rater: rater, cdrsrv: cdrsrv, rlS: rlS,
-		timezone: timezone, conns: make(map[string]*kamevapi.KamEvapi), sessions: NewSessions()}
+		timezone: timezone, defaultReqType: defaultReqType, conns: make(map[string]*kamevapi.KamEvapi), sessions: NewSessions()}
 	return
 }
 
 type KamailioSessionManager struct {
-	cfg      *config.SmKamConfig
-	rater    rpcclient.RpcClientConnection
-	cdrsrv   rpcclient.RpcClientConnection
-	rlS      rpcclient.RpcClientConnection
-	timezone string
-	conns    map[string]*kamevapi.KamEvapi
-	sessions *Sessions
+	cfg            *config.SmKamConfig
+	rater          rpcclient.RpcClientConnection
+	cdrsrv         rpcclient.RpcClientConnection
+	rlS            rpcclient.RpcClientConnection
+	timezone       string
+	defaultReqType string
+	conns          map[string]*kamevapi.KamEvapi
+	sessions       *Sessions
 }
 
 func (self *KamailioSessionManager) getSuppliers(kev KamEvent) (string, error) {
@@ -112,7 +114,7 @@ func (self *KamailioSessionManager) onCgrAuth(evData []byte, connId string) {
 	}
 	var supplStr string
 	var errSuppl error
-	if kev.ComputeLcr() {
+	if kev.ComputeLcr() || self.cfg.ComputeLcr {
 		if supplStr, errSuppl = self.getSuppliers(kev); errSuppl != nil {
 			utils.Logger.Err(fmt.Sprintf("<SM-Kamailio> Could not get suppliers, error: %s", errSuppl.Error()))
 		}
@@ -184,7 +186,7 @@ func (self *KamailioSessionManager) onCallStart(evData []byte, connId string) {
 		self.DisconnectSession(kamEv, connId, utils.ErrMandatoryIeMissing.Error())
 		return
 	}
-	s := NewSession(kamEv, connId, self)
+	s := NewSession(kamEv, connId, self, 0)
 	if s != nil {
 		self.sessions.indexSession(s)
 	}
@@ -232,11 +234,12 @@ func (self *KamailioSessionManager) onCallEnd(evData []byte, connId string) {
 func (self *KamailioSessionManager) Connect() error {
 	var err error
 	eventHandlers := map[*regexp.Regexp][]func([]byte, string){
-		regexp.MustCompile(CGR_AUTH_REQUEST): []func([]byte, string){self.onCgrAuth},
-		regexp.MustCompile(CGR_LCR_REQUEST):  []func([]byte, string){self.onCgrLcrReq},
-		regexp.MustCompile(CGR_RL_REQUEST):   []func([]byte, string){self.onCgrRLReq},
-		regexp.MustCompile(CGR_CALL_START):   []func([]byte, string){self.onCallStart},
-		regexp.MustCompile(CGR_CALL_END):     []func([]byte, string){self.onCallEnd},
+		regexp.MustCompile(CGR_AUTH_REQUEST):   []func([]byte, string){self.onCgrAuth},
+		regexp.MustCompile(CGR_LCR_REQUEST):    []func([]byte, string){self.onCgrLcrReq},
+		regexp.MustCompile(CGR_RL_REQUEST):     []func([]byte, string){self.onCgrRLReq},
+		regexp.MustCompile(CGR_CALL_START):     []func([]byte, string){self.onCallStart},
+		regexp.MustCompile(CGR_CALL_END):       []func([]byte, string){self.onCallEnd},
+		regexp.MustCompile(CGR_DLG_LIST_REPLY): []func([]byte, string){self.onDlgListReply},
 	}
 	errChan := make(chan error)
 	for _, connCfg := range self.cfg.EvapiConns {
@@ -250,6 +253,14 @@ func (self *KamailioSessionManager) Connect() error {
 			}
 		}()
 	}
+	if self.cfg.ChannelSyncInterval != 0 { // Schedule running of the sessions sync
+		go func() {
+			for {
+				time.Sleep(self.cfg.ChannelSyncInterval)
+				self.SyncSessions()
+			}
+		}()
+	}
 	err = <-errChan // Will keep the Connect locked until the first error in one of the connections
 	return err
 }
@@ -296,10 +307,78 @@ func (self *KamailioSessionManager) Sessions() []*Session {
 	return self.sessions.getSessions()
 }
 
+// SyncSessions asks Kamailio for the active dialogs, the sessions being reconciled when the replies arrive
 func (self *KamailioSessionManager) SyncSessions() error {
+	dlgListReq := &KamDlgListRequest{Event: CGR_DLG_LIST}
+	for connId, conn := range self.conns {
+		if err := conn.Send(dlgListReq.String()); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SM-Kamailio> Failed sending dialogs list request, error %s, connection id: %s", err.Error(), connId))
+		}
+	}
 	return nil
 }
 
+// onDlgListReply is the handler for CGR_DLG_LIST_REPLY events coming from Kamailio
+func (self *KamailioSessionManager) onDlgListReply(evData []byte, connId string) {
+	dlgRpl, err := NewKamDlgListReply(evData)
+	if err != nil {
+		utils.Logger.Err(fmt.Sprintf("<SM-Kamailio> ERROR unmarshalling dialogs list: %s, error: %s", evData, err.Error()))
+		return
+	}
+	for _, dlg := range self.syncSessions(connId, dlgRpl.JsonrplBody.Result) {
+		utils.Logger.Warning(fmt.Sprintf("<SM-Kamailio> Sync active dialogs, disconnecting prepaid dialog without session, uuid: %s", dlg.GetUUID()))
+		self.DisconnectSession(dlg.AsKamEvent(), connId, utils.ErrNoActiveSession.Error())
+	}
+}
+
+// syncSessions removes the sessions on connId which are not longer among the Kamailio dialogs,
+// returning the prepaid dialogs we do not have a session for
+func (self *KamailioSessionManager) syncSessions(connId string, dlgs []*KamDialog) (untracked []*KamDialog) {
+	activeDlgs := make(map[string]*KamDialog, len(dlgs))
+	for _, dlg := range dlgs {
+		activeDlgs[dlg.GetUUID()] = dlg
+	}
+	trackedUUIDs := make(utils.StringMap)
+	for _, s := range self.sessions.getSessions() {
+		if s.connId != connId { // This session belongs to another connectionId
+			continue
+		}
+		uuid := s.eventStart.GetUUID()
+		if _, stillActive := activeDlgs[uuid]; stillActive {
+			trackedUUIDs[uuid] = true
+			continue
+		}
+		utils.Logger.Warning(fmt.Sprintf("<SM-Kamailio> Sync active dialogs, stale session detected, uuid: %s", uuid))
+		kev := make(KamEvent)
+		for k, v := range s.eventStart.(KamEvent) {
+			kev[k] = v
+		}
+		now := time.Now()
+		aTime, err := kev.GetAnswerTime(utils.META_DEFAULT, self.timezone)
+		if err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SM-Kamailio> Error parsing answer time of stale session with uuid: %s, error: %s", uuid, err.Error()))
+		}
+		if err != nil || aTime.IsZero() { // No usage we can account for, close it as of now
+			aTime = now
+			kev[CGR_ANSWERTIME] = strconv.FormatInt(now.Unix(), 10)
+		}
+		kev[CGR_STOPTIME] = strconv.FormatInt(now.Unix(), 10)
+		kev[CGR_DURATION] = strconv.FormatFloat(now.Sub(aTime).Seconds(), 'f', -1, 64)
+		if err := self.sessions.removeSession(s, kev); err != nil { // Stop loop, refund advanced charges and save the costs deducted so far to database
+			utils.Logger.Err(fmt.Sprintf("<SM-Kamailio> Error on removing stale session with uuid: %s, error: %s", uuid, err.Error()))
+		}
+	}
+	for uuid, dlg := range activeDlgs {
+		if trackedUUIDs[uuid] ||
+			utils.FirstNonEmpty(dlg.GetVariable(KAM_DLG_VAR_REQTYPE), self.defaultReqType) != utils.META_PREPAID ||
+			time.Since(time.Unix(dlg.StartTs, 0)) < self.cfg.DebitInterval { // session might be on the way
+			continue
+		}
+		untracked = append(untracked, dlg)
+	}
+	return
+}
+
 func (self *KamailioSessionManager) Timezone() string {
 	return self.timezone
 }
@@ -18,9 +18,53 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package sessionmanager
 
 import (
+	"reflect"
+	"sort"
 	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
 )
 
 func TestKamSMInterface(t *testing.T) {
 	var _ SessionManager = SessionManager(new(KamailioSessionManager))
 }
+
+func TestKamSMSyncSessions(t *testing.T) {
+	ksm := &KamailioSessionManager{cfg: &config.SmKamConfig{DebitInterval: 10 * time.Second},
+		timezone: "UTC", defaultReqType: utils.META_PREPAID, sessions: NewSessions()}
+	for _, callID := range []string{"call1", "call2"} {
+		ksm.sessions.indexSession(&Session{eventStart: KamEvent{CALLID: callID, FROM_TAG: "tag1",
+			CGR_ANSWERTIME: "1507068000"}, stopDebit: make(chan struct{}), sessionManager: ksm, connId: "conn1"})
+	}
+	ksm.sessions.indexSession(&Session{eventStart: KamEvent{CALLID: "call6", FROM_TAG: "tag1",
+		CGR_ANSWERTIME: "notatime"}, stopDebit: make(chan struct{}), sessionManager: ksm, connId: "conn1"})
+	ksm.sessions.indexSession(&Session{eventStart: KamEvent{CALLID: "call3", FROM_TAG: "tag1"},
+		stopDebit: make(chan struct{}), sessionManager: ksm, connId: "conn2"})
+	dlgRpl, err := NewKamDlgListReply([]byte(`{"event":"CGR_DLG_LIST_REPLY","jsonrpl_body":{"jsonrpc":"2.0","id":1,"result":[
+{"h_entry":1,"h_id":100,"call-id":"call1","start_ts":1507068000,"caller":{"tag":"tag1"},"variables":[{"cgrReqType":"*prepaid"}]},
+{"h_entry":2,"h_id":200,"call-id":"call4","start_ts":1507068000,"caller":{"tag":"tag1"},"variables":[{"cgrReqType":"*prepaid"}]},
+{"h_entry":3,"h_id":300,"call-id":"call5","start_ts":1507068000,"caller":{"tag":"tag1"},"variables":[{"cgrReqType":"*postpaid"}]},
+{"h_entry":4,"h_id":400,"call-id":"call7","start_ts":1507068000,"caller":{"tag":"tag1"},"variables":[]}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	untracked := ksm.syncSessions("conn1", dlgRpl.JsonrplBody.Result)
+	if len(untracked) != 2 {
+		t.Fatalf("Unexpected untracked dialogs: %+v", untracked)
+	}
+	sort.Slice(untracked, func(i, j int) bool { return untracked[i].CallId < untracked[j].CallId })
+	if eKev := (KamEvent{CALLID: "call4", FROM_TAG: "tag1", HASH_ENTRY: "2", HASH_ID: "200"}); !reflect.DeepEqual(eKev, untracked[0].AsKamEvent()) {
+		t.Errorf("Expecting: %+v, received: %+v", eKev, untracked[0].AsKamEvent())
+	} else if eKev := (KamEvent{CALLID: "call7", FROM_TAG: "tag1", HASH_ENTRY: "4", HASH_ID: "400"}); !reflect.DeepEqual(eKev, untracked[1].AsKamEvent()) { // default request type applies
+		t.Errorf("Expecting: %+v, received: %+v", eKev, untracked[1].AsKamEvent())
+	}
+	var uuids []string
+	for _, s := range ksm.sessions.getSessions() {
+		uuids = append(uuids, s.eventStart.GetUUID())
+	}
+	if eUUIDs := []string{"call1;tag1", "call3;tag1"}; !reflect.DeepEqual(eUUIDs, uuids) {
+		t.Errorf("Expecting: %+v, received: %+v", eUUIDs, uuids)
+	}
+}
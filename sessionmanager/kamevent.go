@@ -39,6 +39,8 @@ const (
 	CGR_CALL_END           = "CGR_CALL_END"
 	CGR_RL_REQUEST         = "CGR_RL_REQUEST"
 	CGR_RL_REPLY           = "CGR_RL_REPLY"
+	CGR_DLG_LIST           = "CGR_DLG_LIST"
+	CGR_DLG_LIST_REPLY     = "CGR_DLG_LIST_REPLY"
 	CGR_SETUPTIME          = "cgr_setuptime"
 	CGR_ANSWERTIME         = "cgr_answertime"
 	CGR_STOPTIME           = "cgr_stoptime"
@@ -49,6 +51,8 @@ const (
 	KAM_TR_LABEL = "tr_label"
 	HASH_ENTRY   = "h_entry"
 	HASH_ID      = "h_id"
+
+	KAM_DLG_VAR_REQTYPE = "cgrReqType" // dialog variable holding the request type
 )
 
 var primaryFields = []string{EVENT, CALLID, FROM_TAG, HASH_ENTRY, HASH_ID, CGR_ACCOUNT, CGR_SUBJECT, CGR_DESTINATION,
@@ -94,6 +98,65 @@ func (self *KamSessionDisconnect) String() string {
 	return string(mrsh)
 }
 
+// KamDlgListRequest asks Kamailio for the list of active dialogs
+type KamDlgListRequest struct {
+	Event string
+}
+
+func (self *KamDlgListRequest) String() string {
+	mrsh, _ := json.Marshal(self)
+	return string(mrsh)
+}
+
+// KamDialog is one dialog as listed by the dlg.list JSON-RPC command of Kamailio
+type KamDialog struct {
+	HashEntry json.Number `json:"h_entry"`
+	HashId    json.Number `json:"h_id"`
+	CallId    string      `json:"call-id"`
+	StartTs   int64       `json:"start_ts"`
+	Caller    struct {
+		Tag string `json:"tag"`
+	} `json:"caller"`
+	Variables []map[string]string `json:"variables"`
+}
+
+// GetUUID returns the dialog identifier in the same format as KamEvent.GetUUID
+func (dlg *KamDialog) GetUUID() string {
+	return dlg.CallId + ";" + dlg.Caller.Tag
+}
+
+// GetVariable returns the value of a dialog variable, empty if not set
+func (dlg *KamDialog) GetVariable(name string) string {
+	for _, vars := range dlg.Variables {
+		if val, has := vars[name]; has {
+			return val
+		}
+	}
+	return ""
+}
+
+// AsKamEvent returns a bare KamEvent which can be used to disconnect the dialog
+func (dlg *KamDialog) AsKamEvent() KamEvent {
+	return KamEvent{CALLID: dlg.CallId, FROM_TAG: dlg.Caller.Tag,
+		HASH_ENTRY: dlg.HashEntry.String(), HASH_ID: dlg.HashId.String()}
+}
+
+// KamDlgListReply carries the dlg.list JSON-RPC reply relayed by Kamailio
+type KamDlgListReply struct {
+	Event       string `json:"event"`
+	JsonrplBody struct {
+		Result []*KamDialog `json:"result"`
+	} `json:"jsonrpl_body"`
+}
+
+func NewKamDlgListReply(evData []byte) (*KamDlgListReply, error) {
+	dlgRpl := new(KamDlgListReply)
+	if err := json.Unmarshal(evData, dlgRpl); err != nil {
+		return nil, err
+	}
+	return dlgRpl, nil
+}
+
 func NewKamEvent(kamEvData []byte) (KamEvent, error) {
 	kev := make(map[string]string)
 	if err := json.Unmarshal(kamEvData, &kev); err != nil {
@@ -208,7 +208,12 @@ func (osipsEv *OsipsEvent) GetOriginatorIP(fieldName string) string {
 }
 func (osipsev *OsipsEvent) MissingParameter(timezone string) bool {
 	var nilTime time.Time
-	if osipsev.GetName() == "E_ACC_EVENT" && osipsev.osipsEvent.AttrValues["method"] == "INVITE" {
+	if osipsev.GetName() == OSIPS_AUTHORIZE_EVENT {
+		return len(osipsev.GetUUID()) == 0 ||
+			len(osipsev.GetAccount(utils.META_DEFAULT)) == 0 ||
+			len(osipsev.GetDestination(utils.META_DEFAULT)) == 0 ||
+			len(osipsev.osipsEvent.AttrValues[OSIPS_DIALOG_ID]) == 0
+	} else if osipsev.GetName() == "E_ACC_EVENT" && osipsev.osipsEvent.AttrValues["method"] == "INVITE" {
 		return len(osipsev.GetUUID()) == 0 ||
 			len(osipsev.GetAccount(utils.META_DEFAULT)) == 0 ||
 			len(osipsev.GetDestination(utils.META_DEFAULT)) == 0 ||
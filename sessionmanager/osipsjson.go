@@ -0,0 +1,149 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package sessionmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/osipsdagram"
+)
+
+const (
+	OSIPS_AUTHORIZE_EVENT = "E_CGR_AUTHORIZE" // raised from script once the dialog is created
+	osipsMiJsonTimeout    = 5 * time.Second
+)
+
+/*
+{"jsonrpc":"2.0","method":"E_ACC_EVENT","params":{"method":"INVITE","from_tag":"87d02470","to_tag":"a671a98",
+"callid":"05dac0aaa716c9814f855f0e8fee6936@0:0:0:0:0:0:0:0","sip_code":"200","sip_reason":"OK","time":1430579770,
+"cgr_reqtype":"*prepaid","cgr_account":"1002","cgr_destination":"1002","dialog_id":"3401:1433"}}
+*/
+
+// osipsJsonRPCEvent is the notification sent by OpenSIPS event_jsonrpc, the method being the name of the event subscribed
+type osipsJsonRPCEvent struct {
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// AsOsipsEvent converts the notification into the event handled by the datagram interface
+func (jsnEv *osipsJsonRPCEvent) AsOsipsEvent(remoteAddr net.Addr) *osipsdagram.OsipsEvent {
+	osipsEv := &osipsdagram.OsipsEvent{Name: jsnEv.Method, AttrValues: make(map[string]string, len(jsnEv.Params))}
+	for attr, val := range jsnEv.Params {
+		switch v := val.(type) {
+		case json.Number:
+			osipsEv.AttrValues[attr] = v.String()
+		case nil:
+			osipsEv.AttrValues[attr] = ""
+		default:
+			if strVal, canCast := utils.ConvertIfaceToString(v); canCast {
+				osipsEv.AttrValues[attr] = strVal
+			} else {
+				osipsEv.AttrValues[attr] = fmt.Sprintf("%v", v)
+			}
+		}
+	}
+	if tcpAddr, canCast := remoteAddr.(*net.TCPAddr); canCast {
+		osipsEv.OriginatorAddress = &net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port}
+	}
+	return osipsEv
+}
+
+// jsonRPCEnabled returns true if OpenSIPS is reached over event_jsonrpc and mi_json instead of datagrams
+func (osm *OsipsSessionManager) jsonRPCEnabled() bool {
+	return osm.cfg.ListenJsonRPC != ""
+}
+
+// connectJsonRPC listens for event_jsonrpc notifications, staying connected for the duration of the daemon running
+func (osm *OsipsSessionManager) connectJsonRPC() (err error) {
+	osm.stopServing = make(chan struct{})
+	osm.miHttpClnt = &http.Client{Timeout: osipsMiJsonTimeout}
+	lstnr, err := net.Listen("tcp", osm.cfg.ListenJsonRPC)
+	if err != nil {
+		return fmt.Errorf("Cannot listen for OpenSIPS events at %s, error: %s", osm.cfg.ListenJsonRPC, err.Error())
+	}
+	osm.evSubscribeStop = make(chan struct{})
+	defer func() { osm.evSubscribeStop <- struct{}{} }() // Stop subscribing on disconnect
+	go osm.SubscribeEvents(osm.evSubscribeStop)
+	go func() {
+		<-osm.stopServing
+		lstnr.Close()
+	}()
+	utils.Logger.Info(fmt.Sprintf("<SM-OpenSIPS> Listening for event_jsonrpc events at <%s>", osm.cfg.ListenJsonRPC))
+	for {
+		conn, err := lstnr.Accept()
+		if err != nil {
+			break
+		}
+		go osm.serveJsonRPC(conn)
+	}
+	return errors.New("<SM-OpenSIPS> Stopped reading events")
+}
+
+// serveJsonRPC reads the notifications sent by OpenSIPS over one connection and dispatches them to event handlers
+func (osm *OsipsSessionManager) serveJsonRPC(conn net.Conn) {
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+	dec.UseNumber()
+	for {
+		var jsnEv osipsJsonRPCEvent
+		if err := dec.Decode(&jsnEv); err != nil {
+			if err != io.EOF {
+				utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> Failed decoding event_jsonrpc event from <%s>, error: <%s>", conn.RemoteAddr(), err.Error()))
+			}
+			return
+		}
+		handlers, hasHandlers := osm.eventHandlers[jsnEv.Method]
+		if !hasHandlers {
+			utils.Logger.Warning(fmt.Sprintf("<SM-OpenSIPS> No handler for event: <%s>", jsnEv.Method))
+			continue
+		}
+		osipsEv := jsnEv.AsOsipsEvent(conn.RemoteAddr())
+		for _, handlerFunc := range handlers {
+			go handlerFunc(osipsEv)
+		}
+	}
+}
+
+// miJsonCommand executes the MI command over OpenSIPS mi_json interface
+func (osm *OsipsSessionManager) miJsonCommand(cmd string, params ...string) error {
+	cmdURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(osm.cfg.MiJsonURL, "/"), cmd)
+	if len(params) != 0 {
+		cmdURL += "?params=" + url.QueryEscape(strings.Join(params, ","))
+	}
+	resp, err := osm.miHttpClnt.Get(cmdURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("MI command %s failed with status: %s, reply: %s", cmd, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package sessionmanager
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/osipsdagram"
+)
+
+var osipsJsonAuthorize = `{"jsonrpc":"2.0","method":"E_CGR_AUTHORIZE","params":{"callid":"05dac0aaa716c9814f855f0e8fee6936@0:0:0:0:0:0:0:0","time":1430579770,"cgr_reqtype":"*prepaid","cgr_account":"1002","cgr_destination":"1003","dialog_id":"3401:1433","cgr_supplier":null}}`
+
+func TestOsipsJsonRPCEventAsOsipsEvent(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(osipsJsonAuthorize))
+	dec.UseNumber()
+	var jsnEv osipsJsonRPCEvent
+	if err := dec.Decode(&jsnEv); err != nil {
+		t.Fatal(err)
+	}
+	eOsipsEv := &osipsdagram.OsipsEvent{Name: OSIPS_AUTHORIZE_EVENT,
+		AttrValues: map[string]string{"callid": "05dac0aaa716c9814f855f0e8fee6936@0:0:0:0:0:0:0:0", "time": "1430579770",
+			"cgr_reqtype": "*prepaid", "cgr_account": "1002", "cgr_destination": "1003", "dialog_id": "3401:1433", "cgr_supplier": ""},
+		OriginatorAddress: &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5060}}
+	osipsEv := jsnEv.AsOsipsEvent(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5060})
+	if !reflect.DeepEqual(eOsipsEv, osipsEv) {
+		t.Errorf("Expecting: %+v, received: %+v", eOsipsEv, osipsEv)
+	}
+	ev, _ := NewOsipsEvent(osipsEv)
+	if ev.MissingParameter("") {
+		t.Error("Missing parameter in authorize event")
+	}
+	if sessionIDs := ev.GetSessionIds(); !reflect.DeepEqual([]string{"3401", "1433"}, sessionIDs) {
+		t.Errorf("Received: %+v", sessionIDs)
+	}
+	delete(osipsEv.AttrValues, OSIPS_DIALOG_ID)
+	if !ev.MissingParameter("") {
+		t.Error("Authorize event without dialog passing")
+	}
+}
+
+func TestOsipsMiJsonCommand(t *testing.T) {
+	var rcvPath, rcvParams string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rcvPath = r.URL.Path
+		rcvParams = r.URL.Query().Get("params")
+		if rcvParams == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Too few or too many arguments"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	osm, _ := NewOSipsSessionManager(&config.SmOsipsConfig{ListenJsonRPC: "127.0.0.1:2021", MiJsonURL: srv.URL + "/json/"},
+		0, nil, nil, "UTC")
+	if _, has := osm.eventHandlers[OSIPS_AUTHORIZE_EVENT]; !has {
+		t.Error("No handler for authorize event")
+	}
+	osm.miHttpClnt = srv.Client()
+	if err := osm.miJsonCommand("dlg_end_dlg", "3401", "1433"); err != nil {
+		t.Error(err)
+	} else if rcvPath != "/json/dlg_end_dlg" || rcvParams != "3401,1433" {
+		t.Errorf("Received path: %s, params: %s", rcvPath, rcvParams)
+	}
+	if err := osm.miJsonCommand("dlg_end_dlg"); err == nil {
+		t.Error("Expecting error")
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -89,6 +91,9 @@ func NewOSipsSessionManager(smOsipsCfg *config.SmOsipsConfig, reconnects int, ra
 		"E_ACC_MISSED_EVENT": []func(*osipsdagram.OsipsEvent){osm.onCdr},           // Raised if evi_missed_flag is configured
 		"E_ACC_EVENT":        []func(*osipsdagram.OsipsEvent){osm.onAccEvent},      // Raised if evi_flag is configured and not cdr_flag containing start/stop events
 	}
+	if osm.jsonRPCEnabled() {
+		osm.eventHandlers[OSIPS_AUTHORIZE_EVENT] = []func(*osipsdagram.OsipsEvent){osm.onAuthorize} // Raised from script, authorizing the dialog
+	}
 	return osm, nil
 }
 
@@ -102,6 +107,7 @@ type OsipsSessionManager struct {
 	evSubscribeStop chan struct{}                         // Reference towards the channel controlling subscriptions, keep it as reference so we do not need to copy it
 	stopServing     chan struct{}                         // Stop serving datagrams
 	miConn          *osipsdagram.OsipsMiDatagramConnector // Pool of connections used to various OpenSIPS servers, keep reference towards events received so we can issue commands always to the same remote
+	miHttpClnt      *http.Client                          // Used for MI commands over mi_json when the event_jsonrpc interface is enabled
 	sessions        *Sessions
 	cdrStartEvents  map[string]*OsipsEvent // Used when building CDRs, ToDo: secure access to map
 	cdrSEMux        sync.RWMutex
@@ -109,6 +115,9 @@ type OsipsSessionManager struct {
 
 // Called when firing up the session manager, will stay connected for the duration of the daemon running
 func (osm *OsipsSessionManager) Connect() (err error) {
+	if osm.jsonRPCEnabled() {
+		return osm.connectJsonRPC()
+	}
 	osm.stopServing = make(chan struct{})
 	if osm.miConn, err = osipsdagram.NewOsipsMiDatagramConnector(osm.cfg.MiAddr, osm.reconnects); err != nil {
 		return fmt.Errorf("Cannot connect to OpenSIPS at %s, error: %s", osm.cfg.MiAddr, err.Error())
@@ -165,6 +174,13 @@ func (osm *OsipsSessionManager) DisconnectSession(ev engine.Event, connId, notif
 		utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> " + errMsg))
 		return errors.New(errMsg)
 	}
+	if osm.jsonRPCEnabled() {
+		if err := osm.miJsonCommand("dlg_end_dlg", sessionIds[0], sessionIds[1]); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> Failed disconnecting session for event: %+v, notify: %s, dialogId: %v, error: <%s>", ev, notify, sessionIds, err))
+			return err
+		}
+		return nil
+	}
 	cmd := fmt.Sprintf(":dlg_end_dlg:\n%s\n%s\n\n", sessionIds[0], sessionIds[1])
 	if reply, err := osm.miConn.SendCommand([]byte(cmd)); err != nil {
 		utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> Failed disconnecting session for event: %+v, notify: %s, dialogId: %v, error: <%s>", ev, notify, sessionIds, err))
@@ -197,10 +213,14 @@ func (osm *OsipsSessionManager) SubscribeEvents(evStop chan struct{}) error {
 // One subscribe attempt to OpenSIPS
 func (osm *OsipsSessionManager) subscribeEvents() error {
 	subscribeInterval := osm.cfg.EventsSubscribeInterval + time.Duration(1)*time.Second // Avoid concurrency on expiry
-	listenAddrSplt := strings.Split(osm.cfg.ListenUdp, ":")
+	listenAddr := osm.cfg.ListenUdp
+	if osm.jsonRPCEnabled() {
+		listenAddr = osm.cfg.ListenJsonRPC
+	}
+	listenAddrSplt := strings.Split(listenAddr, ":")
 	portListen := listenAddrSplt[1]
 	addrListen := listenAddrSplt[0]
-	if len(addrListen) == 0 { //Listen on all addresses, try finding out from mi connection
+	if len(addrListen) == 0 && osm.miConn != nil { //Listen on all addresses, try finding out from mi connection
 		if localAddr := osm.miConn.LocallAddr(); localAddr != nil {
 			addrListen = strings.Split(localAddr.String(), ":")[0]
 		}
@@ -209,6 +229,14 @@ func (osm *OsipsSessionManager) subscribeEvents() error {
 		if eventName == "E_OPENSIPS_START" { // Do not subscribe for start since this should be hardcoded
 			continue
 		}
+		if osm.jsonRPCEnabled() {
+			if err := osm.miJsonCommand("event_subscribe", eventName, fmt.Sprintf("jsonrpc:%s:%s/%s", addrListen, portListen, eventName),
+				strconv.Itoa(int(subscribeInterval.Seconds()))); err != nil {
+				utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> Failed subscribing to OpenSIPS at address: <%s>, error: <%s>", osm.cfg.MiJsonURL, err))
+				return err
+			}
+			continue
+		}
 		cmd := fmt.Sprintf(":event_subscribe:\n%s\nudp:%s:%s\n%d\n", eventName, addrListen, portListen, int(subscribeInterval.Seconds()))
 		if reply, err := osm.miConn.SendCommand([]byte(cmd)); err != nil {
 			utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> Failed subscribing to OpenSIPS at address: <%s>, error: <%s>", osm.cfg.MiAddr, err))
@@ -236,6 +264,27 @@ func (osm *OsipsSessionManager) onCdr(cdrDagram *osipsdagram.OsipsEvent) {
 	}
 }
 
+// Triggered by E_CGR_AUTHORIZE, ends the dialogs which are not allowed to start
+func (osm *OsipsSessionManager) onAuthorize(osipsDgram *osipsdagram.OsipsEvent) {
+	osipsEv, _ := NewOsipsEvent(osipsDgram)
+	if osipsEv.GetReqType(utils.META_DEFAULT) == utils.META_NONE { // Do not process this request
+		return
+	}
+	if osipsEv.MissingParameter(osm.timezone) {
+		osm.DisconnectSession(osipsEv, "", utils.ErrMandatoryIeMissing.Error())
+		return
+	}
+	var maxDur float64
+	if err := osm.rater.Call("Responder.GetDerivedMaxSessionTime", osipsEv.AsStoredCdr(osm.timezone), &maxDur); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<SM-OpenSIPS> Could not get max session time for dialog: %s, error: <%s>", osipsEv.DialogId(), err.Error()))
+		osm.DisconnectSession(osipsEv, "", SYSTEM_ERROR)
+		return
+	}
+	if maxDur != -1 && (maxDur == 0 || time.Duration(maxDur) < osm.cfg.MinCallDuration) {
+		osm.DisconnectSession(osipsEv, "", INSUFFICIENT_FUNDS)
+	}
+}
+
 // Triggered by ACC_EVENT
 func (osm *OsipsSessionManager) onAccEvent(osipsDgram *osipsdagram.OsipsEvent) {
 	osipsEv, _ := NewOsipsEvent(osipsDgram)
@@ -267,7 +316,7 @@ func (osm *OsipsSessionManager) callStart(osipsEv *OsipsEvent) error {
 		}
 		return utils.ErrMandatoryIeMissing
 	}
-	s := NewSession(osipsEv, "", osm)
+	s := NewSession(osipsEv, "", osm, 0)
 	if s != nil {
 		osm.sessions.indexSession(s)
 	}
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/cgrates/cgrates/engine"
@@ -33,8 +34,9 @@ type Session struct {
 	eventStart     engine.Event  // Store the original event who started this session so we can use it's info later (eg: disconnect, cgrid)
 	stopDebit      chan struct{} // Channel to communicate with debit loops when closing the session
 	sessionManager SessionManager
-	connId         string // Reference towards connection id on the session manager side.
-	warnMinDur     time.Duration
+	connId         string        // Reference towards connection id on the session manager side.
+	warnMinDur     time.Duration // warn once when the duration left drops below this, 0 to disable
+	warnOnce       sync.Once
 	sessionRuns    []*engine.SessionRun
 }
 
@@ -52,11 +54,12 @@ func (s *Session) SessionRuns() []*engine.SessionRun {
 }
 
 // Creates a new session and in case of prepaid starts the debit loop for each of the session runs individually
-func NewSession(ev engine.Event, connId string, sm SessionManager) *Session {
+func NewSession(ev engine.Event, connId string, sm SessionManager, warnMinDur time.Duration) *Session {
 	s := &Session{eventStart: ev,
 		stopDebit:      make(chan struct{}),
 		sessionManager: sm,
 		connId:         connId,
+		warnMinDur:     warnMinDur,
 	}
 	if err := sm.Rater().Call("Responder.GetSessionRuns", ev.AsStoredCdr(s.sessionManager.Timezone()), &s.sessionRuns); err != nil || len(s.sessionRuns) == 0 {
 		return nil
@@ -100,7 +103,9 @@ func (s *Session) debitLoop(runIdx int) {
 			return
 		}
 		if s.warnMinDur != time.Duration(0) && cc.GetDuration() <= s.warnMinDur {
-			s.sessionManager.WarnSessionMinDuration(s.eventStart.GetUUID(), s.connId)
+			s.warnOnce.Do(func() { // one warning per session, not per run or debit
+				s.sessionManager.WarnSessionMinDuration(s.eventStart.GetUUID(), s.connId)
+			})
 		}
 		s.sessionRuns[runIdx].CallCosts = append(s.sessionRuns[runIdx].CallCosts, cc)
 		nextCd.TimeEnd = cc.GetEndTime() // set debited timeEnd
@@ -44,10 +44,13 @@ func (self *Sessions) indexSession(s *Session) {
 	self.sessionsMux.Unlock()
 }
 
+// getSessions returns a copy of the indexed sessions so callers can remove them while iterating
 func (self *Sessions) getSessions() []*Session {
 	self.sessionsMux.Lock()
 	defer self.sessionsMux.Unlock()
-	return self.sessions
+	ss := make([]*Session, len(self.sessions))
+	copy(ss, self.sessions)
+	return ss
 }
 
 // Searches and return the session with the specifed uuid
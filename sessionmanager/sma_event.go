@@ -18,6 +18,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package sessionmanager
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/cgrates/cgrates/utils"
@@ -150,6 +151,25 @@ func (smaEv *SMAsteriskEvent) Supplier() string {
 	return smaEv.cachedFields[utils.CGR_SUPPLIER]
 }
 
+// ComputeLcr returns true if the suppliers were requested via cgr_computelcr Stasis argument
+func (smaEv *SMAsteriskEvent) ComputeLcr() bool {
+	computeLcr, _ := strconv.ParseBool(smaEv.cachedFields[utils.CGR_COMPUTELCR])
+	return computeLcr
+}
+
+// PlaybackChannelID returns the ID of the channel the media was played on, populated for PlaybackFinished events
+func (smaEv *SMAsteriskEvent) PlaybackChannelID() string {
+	cachedKey := playbackChannelID
+	cachedVal, hasIt := smaEv.cachedFields[cachedKey]
+	if !hasIt {
+		playbackData, _ := smaEv.ariEv["playback"].(map[string]interface{})
+		targetURI, _ := playbackData["target_uri"].(string)
+		cachedVal = strings.TrimPrefix(targetURI, "channel:")
+		smaEv.cachedFields[cachedKey] = cachedVal
+	}
+	return cachedVal
+}
+
 func (smaEv *SMAsteriskEvent) DisconnectCause() string {
 	cachedKey := utils.CGR_DISCONNECT_CAUSE
 	cachedVal, hasIt := smaEv.cachedFields[cachedKey]
@@ -163,7 +183,8 @@ func (smaEv *SMAsteriskEvent) DisconnectCause() string {
 func (smaEv *SMAsteriskEvent) ExtraParameters() (extraParams map[string]string) {
 	extraParams = make(map[string]string)
 	primaryFields := []string{eventType, channelID, timestamp, utils.SETUP_TIME, utils.CGR_ACCOUNT, utils.CGR_DESTINATION, utils.CGR_REQTYPE,
-		utils.CGR_TENANT, utils.CGR_CATEGORY, utils.CGR_SUBJECT, utils.CGR_PDD, utils.CGR_SUPPLIER, utils.CGR_DISCONNECT_CAUSE}
+		utils.CGR_TENANT, utils.CGR_CATEGORY, utils.CGR_SUBJECT, utils.CGR_PDD, utils.CGR_SUPPLIER, utils.CGR_DISCONNECT_CAUSE,
+		utils.CGR_COMPUTELCR, playbackChannelID}
 	for cachedKey, cachedVal := range smaEv.cachedFields {
 		if !utils.IsSliceMember(primaryFields, cachedKey) {
 			extraParams[cachedKey] = cachedVal
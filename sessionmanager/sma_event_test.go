@@ -31,6 +31,7 @@ var (
 	channelAnsweredDestroyed   = `{"type":"ChannelDestroyed","timestamp":"2016-09-12T13:54:27.335+0200","application":"cgrates_auth","cause_txt":"Normal Clearing","channel":{"id":"1473681228.6","state":"Up","name":"PJSIP/1001-00000004","caller":{"name":"1001","number":"1001"},"language":"en","connected":{"name":"","number":"1002"},"accountcode":"","dialplan":{"context":"internal","exten":"1002","priority":3},"creationtime":"2016-09-12T13:53:48.918+0200"},"cause":16}`
 	channelUnansweredDestroyed = `{"type":"ChannelDestroyed","timestamp":"2016-09-12T18:00:18.121+0200","application":"cgrates_auth","cause_txt":"Normal Clearing","channel":{"id":"1473696018.2","state":"Ring","name":"PJSIP/1002-00000002","caller":{"name":"1002","number":"1002"},"language":"en","connected":{"name":"","number":""},"accountcode":"","dialplan":{"context":"internal","exten":"1002","priority":2},"creationtime":"2016-09-12T18:00:18.109+0200"},"cause":16}`
 	channelBusyDestroyed       = `{"type":"ChannelDestroyed","timestamp":"2016-09-13T12:59:48.806+0200","application":"cgrates_auth","cause_txt":"User busy","channel":{"id":"1473764378.3","state":"Ring","name":"PJSIP/1001-00000002","caller":{"name":"1001","number":"1001"},"language":"en","connected":{"name":"","number":"1002"},"accountcode":"","dialplan":{"context":"internal","exten":"1002","priority":4},"creationtime":"2016-09-13T12:59:38.839+0200"},"cause":17}`
+	playbackFinished           = `{"type":"PlaybackFinished","timestamp":"2016-09-13T13:01:12.412+0200","application":"cgrates_auth","playback":{"id":"a7c1b0e6-1c3f-4f0e-8f6d-6a9b3e2f1c0d","media_uri":"sound:no-credit","target_uri":"channel:d2c7b5a4-8e1f-4d6b-9a3c-1f0e2b7c6d5a","language":"en","state":"done"}}`
 )
 
 func TestSMAParseStasisArgs(t *testing.T) {
@@ -328,6 +329,39 @@ func TestSMAEventExtraParameters(t *testing.T) {
 	}
 }
 
+func TestSMAEventComputeLcr(t *testing.T) {
+	var ev map[string]interface{}
+	if err := json.Unmarshal([]byte(stasisStart), &ev); err != nil {
+		t.Error(err)
+	}
+	smaEv := NewSMAsteriskEvent(ev, "127.0.0.1")
+	if smaEv.ComputeLcr() {
+		t.Error("Should not compute LCR")
+	}
+	ev = map[string]interface{}{"args": []interface{}{"cgr_computelcr=true", "extra1=val1"}} // Clear previous data
+	smaEv = NewSMAsteriskEvent(ev, "127.0.0.1")
+	if !smaEv.ComputeLcr() {
+		t.Error("Should compute LCR")
+	}
+	if extraParams := smaEv.ExtraParameters(); !reflect.DeepEqual(map[string]string{"extra1": "val1"}, extraParams) {
+		t.Errorf("Received: %+v", extraParams)
+	}
+}
+
+func TestSMAEventPlaybackChannelID(t *testing.T) {
+	var ev map[string]interface{}
+	if err := json.Unmarshal([]byte(playbackFinished), &ev); err != nil {
+		t.Error(err)
+	}
+	smaEv := NewSMAsteriskEvent(ev, "127.0.0.1")
+	if smaEv.EventType() != ARIPlaybackFinished {
+		t.Error("Received:", smaEv.EventType())
+	}
+	if smaEv.PlaybackChannelID() != "d2c7b5a4-8e1f-4d6b-9a3c-1f0e2b7c6d5a" {
+		t.Error("Received:", smaEv.PlaybackChannelID())
+	}
+}
+
 /*
 func TestSMAEventUpdateFromEvent(t *testing.T) {
 	var ev map[string]interface{}
@@ -18,7 +18,10 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package sessionmanager
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -34,21 +37,25 @@ import (
 const (
 	CGRAuthAPP            = "cgrates_auth"
 	CGRMaxSessionTime     = "CGRMaxSessionTime"
+	CGRSuppliers          = "CGRSuppliers"
 	ARIStasisStart        = "StasisStart"
 	ARIChannelStateChange = "ChannelStateChange"
 	ARIChannelDestroyed   = "ChannelDestroyed"
+	ARIPlaybackFinished   = "PlaybackFinished"
 	eventType             = "eventType"
 	channelID             = "channelID"
 	channelState          = "channelState"
 	channelUp             = "Up"
 	timestamp             = "timestamp"
+	playbackChannelID     = "playbackChannelID"
 	SMAAuthorization      = "SMA_AUTHORIZATION"
 	SMASessionStart       = "SMA_SESSION_START"
 	SMASessionTerminate   = "SMA_SESSION_TERMINATE"
 )
 
 func NewSMAsterisk(cgrCfg *config.CGRConfig, astConnIdx int, smgConn *utils.BiRPCInternalClient) (*SMAsterisk, error) {
-	sma := &SMAsterisk{cgrCfg: cgrCfg, smg: *smgConn, eventsCache: make(map[string]*SMGenericEvent)}
+	sma := &SMAsterisk{cgrCfg: cgrCfg, astConnIdx: astConnIdx, smg: *smgConn,
+		eventsCache: make(map[string]*SMGenericEvent), prompts: make(map[string]*smaPrompt)}
 	sma.smg.SetClientConn(sma) // pass the connection to SMA back into smg so we can receive the disconnects
 	return sma, nil
 }
@@ -62,6 +69,15 @@ type SMAsterisk struct {
 	astErrChan  chan error
 	eventsCache map[string]*SMGenericEvent // used to gather information about events during various phases
 	evCacheMux  sync.RWMutex               // Protect eventsCache
+	prompts     map[string]*smaPrompt      // prompts being played, indexed on the ID of the snoop channel playing them
+	promptsMux  sync.Mutex                 // Protect prompts
+}
+
+// smaPrompt is the media whispered into a channel via a snoop one, since ARI can only play on channels within Stasis
+type smaPrompt struct {
+	channelID string // channel hearing the prompt
+	media     string
+	hangup    bool // disconnect the channel once the prompt was played
 }
 
 func (sma *SMAsterisk) connectAsterisk() (err error) {
@@ -81,6 +97,9 @@ func (sma *SMAsterisk) ListenAndServe() (err error) {
 	if err := sma.connectAsterisk(); err != nil {
 		return err
 	}
+	if err := sma.syncSessions(); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when syncing sessions", err.Error()))
+	}
 	for {
 		select {
 		case err = <-sma.astErrChan:
@@ -94,12 +113,67 @@ func (sma *SMAsterisk) ListenAndServe() (err error) {
 				go sma.handleChannelStateChange(smAsteriskEvent)
 			case ARIChannelDestroyed:
 				go sma.handleChannelDestroyed(smAsteriskEvent)
+			case ARIPlaybackFinished:
+				go sma.finishPrompt(smAsteriskEvent.PlaybackChannelID())
 			}
 		}
 	}
 	panic("<SMAsterisk> ListenAndServe out of select")
 }
 
+// activeChannelIDs returns the IDs of the channels up on Asterisk, queried directly since ARInGO replies only with JSON objects
+func (sma *SMAsterisk) activeChannelIDs() (chanIDs []string, err error) {
+	connCfg := sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx]
+	req, err := http.NewRequest(aringo.HTTP_GET, fmt.Sprintf("http://%s/ari/channels", connCfg.Address), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(connCfg.User, connCfg.Password)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var channels []struct {
+		ID string `json:"id"`
+	}
+	if err = json.Unmarshal(body, &channels); err != nil {
+		return nil, err
+	}
+	chanIDs = make([]string, len(channels))
+	for i, channel := range channels {
+		chanIDs[i] = channel.ID
+	}
+	return
+}
+
+// syncSessions reconciles the sessions SMG restored out of its checkpoints with the channels still up,
+// caching the events of the ones kept so they are terminated once their channels are destroyed
+func (sma *SMAsterisk) syncSessions() (err error) {
+	args := ArgsSyncSessions{OriginHost: strings.Split(sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, ":")[0]}
+	if args.OriginIDs, err = sma.activeChannelIDs(); err != nil {
+		return
+	}
+	var synced []SMGenericEvent
+	if err = sma.smg.Call("SMGenericV1.SyncSessions", args, &synced); err != nil {
+		return
+	}
+	sma.evCacheMux.Lock()
+	for _, smgEv := range synced {
+		ev := smgEv
+		sma.eventsCache[smgEv.GetOriginID(utils.META_DEFAULT)] = &ev
+	}
+	sma.evCacheMux.Unlock()
+	return
+}
+
 // hangupChannel will disconnect from CGRateS side with congestion reason
 func (sma *SMAsterisk) hangupChannel(channelID string) (err error) {
 	_, err = sma.astConn.Call(aringo.HTTP_DELETE, fmt.Sprintf("http://%s/ari/channels/%s",
@@ -108,7 +182,72 @@ func (sma *SMAsterisk) hangupChannel(channelID string) (err error) {
 	return
 }
 
+// setChannelVariable sets a variable on channel so it can be used further in the dialplan
+func (sma *SMAsterisk) setChannelVariable(channelID, varName, varValue string) (err error) {
+	_, err = sma.astConn.Call(aringo.HTTP_POST, fmt.Sprintf("http://%s/ari/channels/%s/variable?variable=%s", // Asterisk having issue with variable terminating empty so harcoding param in url
+		sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, channelID, varName),
+		url.Values{"value": {varValue}})
+	return
+}
+
+// playPrompt whispers the media into the channel out of a snoop channel, the media is played once the snoop channel enters Stasis
+func (sma *SMAsterisk) playPrompt(channelID, media string, hangup bool) (err error) {
+	snoopID := utils.GenUUID()
+	sma.promptsMux.Lock()
+	sma.prompts[snoopID] = &smaPrompt{channelID: channelID, media: media, hangup: hangup}
+	sma.promptsMux.Unlock()
+	if _, err = sma.astConn.Call(aringo.HTTP_POST, fmt.Sprintf("http://%s/ari/channels/%s/snoop",
+		sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, channelID),
+		url.Values{"app": {CGRAuthAPP}, "whisper": {"out"}, "snoopId": {snoopID}}); err != nil {
+		sma.promptsMux.Lock()
+		delete(sma.prompts, snoopID)
+		sma.promptsMux.Unlock()
+	}
+	return
+}
+
+// startPrompt plays the media on the snoop channel which entered Stasis, returns false if the channel is not one of ours
+func (sma *SMAsterisk) startPrompt(snoopID string) bool {
+	sma.promptsMux.Lock()
+	prompt, hasIt := sma.prompts[snoopID]
+	sma.promptsMux.Unlock()
+	if !hasIt {
+		return false
+	}
+	if _, err := sma.astConn.Call(aringo.HTTP_POST, fmt.Sprintf("http://%s/ari/channels/%s/play",
+		sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, snoopID),
+		url.Values{"media": {prompt.media}}); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when playing %s for channelID: %s", err.Error(), prompt.media, prompt.channelID))
+		sma.finishPrompt(snoopID)
+	}
+	return true
+}
+
+// finishPrompt removes the snoop channel, disconnecting the channel which heard the prompt if requested
+func (sma *SMAsterisk) finishPrompt(snoopID string) {
+	sma.promptsMux.Lock()
+	prompt, hasIt := sma.prompts[snoopID]
+	delete(sma.prompts, snoopID)
+	sma.promptsMux.Unlock()
+	if !hasIt { // Finished already or not a prompt of ours
+		return
+	}
+	if _, err := sma.astConn.Call(aringo.HTTP_DELETE, fmt.Sprintf("http://%s/ari/channels/%s",
+		sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, snoopID), nil); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<SMAsterisk> Error: %s when removing snoop channel: %s", err.Error(), snoopID))
+	}
+	if !prompt.hangup {
+		return
+	}
+	if err := sma.hangupChannel(prompt.channelID); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to disconnect channelID: %s", err.Error(), prompt.channelID))
+	}
+}
+
 func (sma *SMAsterisk) handleStasisStart(ev *SMAsteriskEvent) {
+	if sma.startPrompt(ev.ChannelID()) { // Snoop channel created by us to play a prompt
+		return
+	}
 	// Subscribe for channel updates even after we leave Stasis
 	if _, err := sma.astConn.Call(aringo.HTTP_POST, fmt.Sprintf("http://%s/ari/applications/%s/subscription?eventSource=channel:%s",
 		sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, CGRAuthAPP, ev.ChannelID()), nil); err != nil {
@@ -119,7 +258,7 @@ func (sma *SMAsterisk) handleStasisStart(ev *SMAsteriskEvent) {
 		}
 		return
 	}
-	// Query the SMG via RPC for maxUsage
+	// Query the SMG via RPC for maxUsage, SMG authorizes the ResourceS usage too and allocates it once the session starts
 	var maxUsage float64
 	smgEv := ev.AsSMGenericEvent()
 	if err := sma.smg.Call("SMGenericV1.GetMaxUsage", *smgEv, &maxUsage); err != nil {
@@ -136,9 +275,8 @@ func (sma *SMAsterisk) handleStasisStart(ev *SMAsteriskEvent) {
 		return
 	} else if maxUsage != -1 {
 		//  Set absolute timeout for non-postpaid calls
-		if _, err := sma.astConn.Call(aringo.HTTP_POST, fmt.Sprintf("http://%s/ari/channels/%s/variable?variable=%s", // Asterisk having issue with variable terminating empty so harcoding param in url
-			sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, ev.ChannelID(), CGRMaxSessionTime),
-			url.Values{"value": {strconv.FormatFloat(maxUsage*1000, 'f', -1, 64)}}); err != nil { // Asterisk expects value in ms
+		if err := sma.setChannelVariable(ev.ChannelID(), CGRMaxSessionTime,
+			strconv.FormatFloat(maxUsage*1000, 'f', -1, 64)); err != nil { // Asterisk expects value in ms
 			utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when setting %s for channelID: %s", err.Error(), CGRMaxSessionTime, ev.ChannelID()))
 			// Since we got error, disconnect channel
 			if err := sma.hangupChannel(ev.ChannelID()); err != nil {
@@ -147,7 +285,23 @@ func (sma *SMAsterisk) handleStasisStart(ev *SMAsteriskEvent) {
 			return
 		}
 	}
-
+	if ev.ComputeLcr() {
+		var suppliers []string
+		if err := sma.smg.Call("SMGenericV1.GetLCRSuppliers", *smgEv, &suppliers); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when getting suppliers for channelID: %s", err.Error(), ev.ChannelID()))
+			if err := sma.hangupChannel(ev.ChannelID()); err != nil {
+				utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to disconnect channelID: %s", err.Error(), ev.ChannelID()))
+			}
+			return
+		}
+		if err := sma.setChannelVariable(ev.ChannelID(), CGRSuppliers, strings.Join(suppliers, utils.FIELDS_SEP)); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when setting %s for channelID: %s", err.Error(), CGRSuppliers, ev.ChannelID()))
+			if err := sma.hangupChannel(ev.ChannelID()); err != nil {
+				utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to disconnect channelID: %s", err.Error(), ev.ChannelID()))
+			}
+			return
+		}
+	}
 	// Exit channel from stasis
 	if _, err := sma.astConn.Call(aringo.HTTP_POST, fmt.Sprintf("http://%s/ari/channels/%s/continue",
 		sma.cgrCfg.SMAsteriskCfg().AsteriskConns[sma.astConnIdx].Address, ev.ChannelID()), nil); err != nil {
@@ -196,16 +350,15 @@ func (sma *SMAsterisk) handleChannelStateChange(ev *SMAsteriskEvent) {
 
 // Channel disconnect
 func (sma *SMAsterisk) handleChannelDestroyed(ev *SMAsteriskEvent) {
-	sma.evCacheMux.RLock()
+	sma.finishPrompt(ev.ChannelID()) // Snoop channel gone before the prompt finished
+	sma.evCacheMux.Lock()
 	smgEv, hasIt := sma.eventsCache[ev.ChannelID()]
-	sma.evCacheMux.RUnlock()
+	delete(sma.eventsCache, ev.ChannelID())
+	sma.evCacheMux.Unlock()
 	if !hasIt { // Not handled by us
 		return
 	}
-	sma.evCacheMux.Lock()
-	err := ev.UpdateSMGEvent(smgEv) // Updates the event directly in the cache
-	sma.evCacheMux.Unlock()
-	if err != nil {
+	if err := ev.UpdateSMGEvent(smgEv); err != nil { // Not in cache anymore
 		utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to initiate session for channelID: %s", err.Error(), ev.ChannelID()))
 		if err := sma.hangupChannel(ev.ChannelID()); err != nil {
 			utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to disconnect channelID: %s", err.Error(), ev.ChannelID()))
@@ -228,9 +381,17 @@ func (sma *SMAsterisk) ServiceShutdown() error {
 	return nil
 }
 
-// Internal method to disconnect session in asterisk
+// Internal method to disconnect session in asterisk, playing the disconnect prompt first if configured
 func (sma *SMAsterisk) V1DisconnectSession(args utils.AttrDisconnectSession, reply *string) error {
 	channelID := SMGenericEvent(args.EventStart).GetOriginID(utils.META_DEFAULT)
+	if prompt := sma.cgrCfg.SMAsteriskCfg().DisconnectPrompt; prompt != "" {
+		if err := sma.playPrompt(channelID, prompt, true); err == nil {
+			*reply = utils.OK
+			return nil
+		} else {
+			utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to play %s for channelID: %s", err.Error(), prompt, channelID))
+		}
+	}
 	if err := sma.hangupChannel(channelID); err != nil {
 		utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to disconnect channelID: %s", err.Error(), channelID))
 	}
@@ -238,6 +399,18 @@ func (sma *SMAsterisk) V1DisconnectSession(args utils.AttrDisconnectSession, rep
 	return nil
 }
 
+// Internal method to warn the caller of low balance, playing the low balance prompt
+func (sma *SMAsterisk) V1WarnSession(args utils.AttrWarnSession, reply *string) error {
+	if prompt := sma.cgrCfg.SMAsteriskCfg().LowBalancePrompt; prompt != "" {
+		channelID := SMGenericEvent(args.EventStart).GetOriginID(utils.META_DEFAULT)
+		if err := sma.playPrompt(channelID, prompt, false); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMAsterisk> Error: %s when attempting to play %s for channelID: %s", err.Error(), prompt, channelID))
+		}
+	}
+	*reply = utils.OK
+	return nil
+}
+
 // rpcclient.RpcClientConnection interface
 func (sma *SMAsterisk) Call(serviceMethod string, args interface{}, reply interface{}) error {
 	parts := strings.Split(serviceMethod, ".")
@@ -0,0 +1,168 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package sessionmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/rpcclient"
+)
+
+// asRunCheckpoint returns a copy of the session state which can be saved in DataDB
+func (self *SMGSession) asRunCheckpoint() *engine.SMGRunCheckpoint {
+	self.mux.RLock()
+	defer self.mux.RUnlock()
+	rCp := &engine.SMGRunCheckpoint{
+		RunID:         self.RunID,
+		UnitTOR:       self.UnitTOR,
+		Timezone:      self.Timezone,
+		EventStart:    self.EventStart.Clone(),
+		ExtraDuration: self.ExtraDuration,
+		LastUsage:     self.LastUsage,
+		LastDebit:     self.LastDebit,
+		TotalUsage:    self.TotalUsage,
+	}
+	if self.CD != nil {
+		cd := *self.CD
+		rCp.CD = &cd
+	}
+	if self.EventCost != nil {
+		rCp.EventCost = self.EventCost.Clone()
+	}
+	return rCp
+}
+
+// checkpointing returns true if the active sessions are saved in DataDB
+func (smg *SMGeneric) checkpointing() bool {
+	return smg.dataDB != nil && smg.cgrCfg.SmGenericConfig.SessionsCheckpointInterval != 0
+}
+
+// checkpointSessions saves the state of all active sessions into DataDB
+func (smg *SMGeneric) checkpointSessions() (err error) {
+	smg.cpMux.Lock()
+	defer smg.cpMux.Unlock()
+	for cgrID, ss := range smg.getSessions("", false) {
+		sCp := &engine.SMGSessionCheckpoint{CGRID: cgrID, CheckpointTime: time.Now(),
+			Runs: make([]*engine.SMGRunCheckpoint, len(ss))}
+		for i, s := range ss {
+			sCp.Runs[i] = s.asRunCheckpoint()
+		}
+		if err = smg.dataDB.SetSMGSessionCheckpoint(sCp); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// remSessionCheckpoint removes the saved state of a session which is not longer active
+func (smg *SMGeneric) remSessionCheckpoint(cgrID string) {
+	if !smg.checkpointing() {
+		return
+	}
+	smg.cpMux.Lock() // make sure an ongoing checkpoint will not save it back
+	defer smg.cpMux.Unlock()
+	if err := smg.dataDB.RemSMGSessionCheckpoint(cgrID); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Removing checkpoint for session: %s, error: %s", cgrID, err.Error()))
+	}
+}
+
+// checkpointLoop saves the active sessions on each interval, blocking
+func (smg *SMGeneric) checkpointLoop(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		if err := smg.checkpointSessions(); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMGeneric> Checkpointing sessions, error: %s", err.Error()))
+		}
+	}
+}
+
+// restoreSessions activates the sessions saved in DataDB, re-arming their debit loops and terminators
+func (smg *SMGeneric) restoreSessions() (err error) {
+	var sCps []*engine.SMGSessionCheckpoint
+	if sCps, err = smg.dataDB.GetSMGSessionCheckpoints(); err != nil {
+		return
+	}
+	for _, sCp := range sCps {
+		if len(smg.getSessions(sCp.CGRID, false)) != 0 {
+			continue // already active
+		}
+		var stopDebitChan chan struct{}
+		if smg.cgrCfg.SmGenericConfig.DebitInterval != 0 {
+			stopDebitChan = make(chan struct{})
+		}
+		lowCredit := smg.newLowCreditWarning()
+		for _, rCp := range sCp.Runs {
+			s := &SMGSession{CGRID: sCp.CGRID, RunID: rCp.RunID, UnitTOR: rCp.UnitTOR, Timezone: rCp.Timezone,
+				EventStart: SMGenericEvent(rCp.EventStart), CD: rCp.CD, EventCost: rCp.EventCost,
+				ExtraDuration: rCp.ExtraDuration, LastUsage: rCp.LastUsage, LastDebit: rCp.LastDebit,
+				TotalUsage: rCp.TotalUsage, rals: smg.rals, cdrsrv: smg.cdrsrv, restored: true, lowCredit: lowCredit}
+			smg.recordASession(s)
+			if stopDebitChan != nil && s.UnitTOR == "" {
+				s.stopDebit = stopDebitChan
+				go s.debitLoop(smg.cgrCfg.SmGenericConfig.DebitInterval)
+			}
+		}
+		utils.Logger.Info(fmt.Sprintf("<SMGeneric> Restored session: %s, checkpointed at: %v", sCp.CGRID, sCp.CheckpointTime))
+	}
+	return
+}
+
+// ArgsSyncSessions lists the sessions still active on the switch
+type ArgsSyncSessions struct {
+	OriginHost string   // only sync the sessions originated by this host, all when empty
+	OriginIDs  []string // sessions the switch still has
+}
+
+// BiRPCV1SyncSessions reconciles the restored sessions with the ones reported by the switch after reconnect.
+// Sessions still present on the switch are bound to the calling connection, the others are terminated.
+// Replies with the start events of the sessions kept so the agent can follow them further.
+func (smg *SMGeneric) BiRPCV1SyncSessions(clnt rpcclient.RpcClientConnection, args ArgsSyncSessions, reply *[]SMGenericEvent) (err error) {
+	activeIDs := utils.StringMapFromSlice(args.OriginIDs)
+	synced := make([]SMGenericEvent, 0)
+	for _, ss := range smg.getSessions("", false) {
+		s := ss[0]
+		s.mux.RLock()
+		restored := s.restored
+		originHost := s.EventStart.GetOriginatorIP(utils.META_DEFAULT)
+		originID := s.EventStart.GetOriginID(utils.META_DEFAULT)
+		s.mux.RUnlock()
+		if !restored ||
+			(args.OriginHost != "" && args.OriginHost != originHost) {
+			continue
+		}
+		if _, has := activeIDs[originID]; !has {
+			utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Terminating restored session: %s, not longer active on switch", s.CGRID))
+			smg.terminateStaleSession(s)
+			continue
+		}
+		for _, s := range ss {
+			s.mux.Lock()
+			s.clntConn = clnt
+			s.restored = false
+			s.mux.Unlock()
+		}
+		s.mux.RLock()
+		synced = append(synced, s.EventStart.Clone())
+		s.mux.RUnlock()
+	}
+	*reply = synced
+	return
+}
@@ -173,6 +173,61 @@ func (self SMGenericEvent) GetUsage(fieldName string) (time.Duration, error) {
 	return utils.ParseDurationWithSecs(result)
 }
 
+// GetUnits returns the additional units carried by the event next to the main usage, indexed on TOR
+// *voice units are parsed as duration and returned in seconds, the other TORs are plain numbers
+func (self SMGenericEvent) GetUnits() (units map[string]float64, err error) {
+	valIf, hasVal := self[utils.Units]
+	if !hasVal {
+		return nil, utils.ErrNotFound
+	}
+	unitsIf := make(map[string]interface{})
+	switch unitsVal := valIf.(type) {
+	case map[string]interface{}:
+		unitsIf = unitsVal
+	case map[string]string:
+		for tor, val := range unitsVal {
+			unitsIf[tor] = val
+		}
+	default:
+		return nil, utils.ErrNotConvertible
+	}
+	units = make(map[string]float64, len(unitsIf))
+	for tor, val := range unitsIf {
+		if fltVal, canCast := val.(float64); canCast { // JSON numbers, *voice in seconds
+			units[tor] = fltVal
+			continue
+		}
+		result, _ := utils.ConvertIfaceToString(val)
+		if tor == utils.VOICE {
+			var dur time.Duration
+			if dur, err = utils.ParseDurationWithSecs(result); err != nil {
+				return nil, err
+			}
+			units[tor] = dur.Seconds()
+			continue
+		}
+		if units[tor], err = strconv.ParseFloat(result, 64); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// unitsDuration converts units into the duration debited by sessions, one unit being rated as one second
+func unitsDuration(units float64) time.Duration {
+	return time.Duration(units * float64(time.Second))
+}
+
+// GetMaxCost returns the maximum cost the session is allowed to reach, 0 if not limited by the event
+func (self SMGenericEvent) GetMaxCost() (float64, error) {
+	valIf, hasVal := self[utils.MaxCost]
+	if !hasVal {
+		return 0, nil
+	}
+	result, _ := utils.ConvertIfaceToString(valIf)
+	return strconv.ParseFloat(result, 64)
+}
+
 func (self SMGenericEvent) GetLastUsed(fieldName string) (time.Duration, error) {
 	if fieldName == utils.META_DEFAULT {
 		fieldName = utils.LastUsed
@@ -314,7 +369,7 @@ func (self SMGenericEvent) GetCdrSource() string {
 func (self SMGenericEvent) GetExtraFields() map[string]string {
 	extraFields := make(map[string]string)
 	for key, val := range self {
-		primaryFields := append(utils.PrimaryCdrFields, utils.EVENT_NAME)
+		primaryFields := append(utils.PrimaryCdrFields, utils.EVENT_NAME, utils.Units, utils.MaxCost)
 		if utils.IsSliceMember(primaryFields, key) {
 			continue
 		}
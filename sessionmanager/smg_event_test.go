@@ -150,6 +150,24 @@ func TestSMGenericEventGetSessionTTL(t *testing.T) {
 	}
 }
 
+func TestSMGenericEventGetUnits(t *testing.T) {
+	smGev := SMGenericEvent{utils.EVENT_NAME: "TEST_UNITS"}
+	if _, err := smGev.GetUnits(); err != utils.ErrNotFound {
+		t.Errorf("Expecting ErrNotFound, received: %v", err)
+	}
+	smGev[utils.Units] = map[string]interface{}{utils.VOICE: "1m30s", utils.DATA: "1048576", utils.SMS: 2.0, utils.GENERIC: "0.5"}
+	eUnits := map[string]float64{utils.VOICE: 90, utils.DATA: 1048576, utils.SMS: 2, utils.GENERIC: 0.5}
+	if units, err := smGev.GetUnits(); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eUnits, units) {
+		t.Errorf("Expecting: %+v, received: %+v", eUnits, units)
+	}
+	smGev[utils.Units] = map[string]string{utils.SMS: "1s"}
+	if _, err := smGev.GetUnits(); err == nil {
+		t.Error("Expecting error on duration units for *sms")
+	}
+}
+
 func TestSMGenericEventAsStoredCdr(t *testing.T) {
 	smGev := SMGenericEvent{}
 	smGev[utils.EVENT_NAME] = "TEST_EVENT"
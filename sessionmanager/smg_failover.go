@@ -0,0 +1,200 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package sessionmanager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/rpcclient"
+)
+
+// peerSeen records that the replication peer is alive
+func (smg *SMGeneric) peerSeen() {
+	smg.peerMux.Lock()
+	smg.peerLastSeen = time.Now()
+	smg.peerMux.Unlock()
+}
+
+// peerLost returns true if the replication peer was not heard of within timeout
+func (smg *SMGeneric) peerLost(timeout time.Duration) bool {
+	smg.peerMux.RLock()
+	defer smg.peerMux.RUnlock()
+	return time.Since(smg.peerLastSeen) > timeout
+}
+
+// sendHeartbeats signals liveness to the replication peers.
+// With debit loops the active sessions debited since the last heartbeat are replicated as well, since they are not updated by the client.
+// Each peer is served by one goroutine, all of them finishing before the next heartbeat.
+func (smg *SMGeneric) sendHeartbeats() {
+	var cgrIDs []string
+	var ssClns [][]*SMGSession
+	if smg.cgrCfg.SmGenericConfig.DebitInterval != 0 {
+		cgrIDs, ssClns = smg.changedSessions()
+	}
+	var wg sync.WaitGroup
+	for _, rplConn := range smg.smgReplConns {
+		wg.Add(1)
+		go func(conn rpcclient.RpcClientConnection) {
+			defer wg.Done()
+			var reply string
+			for i, cgrID := range cgrIDs {
+				if err := conn.Call("SMGenericV1.SetPassiveSessions",
+					ArgsSetPassiveSessions{CGRID: cgrID, Sessions: ssClns[i]}, &reply); err != nil &&
+					err.Error() == ErrActiveSession.Error() {
+					smg.handBackSessions(cgrID)
+				}
+			}
+			if err := conn.Call("SMGenericV1.ReplicationHeartbeat", "", &reply); err != nil {
+				utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Replication heartbeat failed, error: %s", err.Error()))
+			}
+		}(rplConn.Connection)
+	}
+	wg.Wait()
+}
+
+// changedSessions returns copies of the active sessions which changed their usage since last replicated by heartbeats
+func (smg *SMGeneric) changedSessions() (cgrIDs []string, ssClns [][]*SMGSession) {
+	aSS := smg.getSessions("", false)
+	for cgrID := range smg.hbReplicated {
+		if _, isActive := aSS[cgrID]; !isActive {
+			delete(smg.hbReplicated, cgrID)
+		}
+	}
+	for cgrID, ss := range aSS {
+		var usage time.Duration
+		for _, s := range ss {
+			s.mux.RLock()
+			usage += s.TotalUsage
+			s.mux.RUnlock()
+		}
+		if lastUsage, replicated := smg.hbReplicated[cgrID]; replicated && lastUsage == usage {
+			continue
+		}
+		ssCln, err := smg.cloneSessions(cgrID, false)
+		if err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Could not clone session: %s for replication, error: %s", cgrID, err.Error()))
+			continue
+		}
+		smg.hbReplicated[cgrID] = usage
+		cgrIDs = append(cgrIDs, cgrID)
+		ssClns = append(ssClns, ssCln)
+	}
+	return
+}
+
+// demoteSessions unrecords the active sessions with cgrID, stopping their debit loop
+func (smg *SMGeneric) demoteSessions(cgrID string) (ss map[string][]*SMGSession) {
+	ss = smg.getSessions(cgrID, false)
+	if !smg.unrecordASession(cgrID) { // closed meanwhile
+		return nil
+	}
+	if s := ss[cgrID][0]; s.stopDebit != nil {
+		close(s.stopDebit) // Stop automatic debits
+	}
+	return
+}
+
+// ownsSession returns true if the active session was taken over from the peer and was synced with the switch since,
+// the peer replicating it afterwards has only a stale copy
+func (smg *SMGeneric) ownsSession(cgrID string) bool {
+	aSS := smg.getSessions(cgrID, false)
+	if len(aSS) == 0 {
+		return false
+	}
+	s := aSS[cgrID][0]
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.takenOver && !s.restored
+}
+
+// handBackSessions makes passive the active sessions which the peer reports as its own
+func (smg *SMGeneric) handBackSessions(cgrID string) {
+	guardian.Guardian.Guard(func() (interface{}, error) { // Lock it on CGRID level
+		ss := smg.demoteSessions(cgrID)
+		if len(ss) == 0 {
+			return nil, nil
+		}
+		smg.pSessionsMux.Lock()
+		smg.passiveSessions[cgrID] = ss[cgrID]
+		smg.pSessionsMux.Unlock()
+		for _, s := range ss[cgrID] {
+			smg.indexSession(s, true)
+		}
+		utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Handed back session: %s to the replication peer", cgrID))
+		return nil, nil
+	}, smg.cgrCfg.LockingTimeout, cgrID)
+}
+
+// heartbeatLoop sends heartbeats to the replication peers on interval
+func (smg *SMGeneric) heartbeatLoop(interval time.Duration) {
+	for {
+		smg.sendHeartbeats()
+		time.Sleep(interval)
+	}
+}
+
+// takeoverPassiveSessions activates the passive sessions of a lost peer, restarting their debit loops and terminators
+func (smg *SMGeneric) takeoverPassiveSessions() {
+	for cgrID := range smg.getSessions("", true) {
+		guardian.Guardian.Guard(func() (interface{}, error) { // Lock it on CGRID level
+			ss := smg.passiveToActive(cgrID)
+			if len(ss) == 0 { // activated meanwhile by an update
+				return nil, nil
+			}
+			var stopDebitChan chan struct{}
+			if smg.cgrCfg.SmGenericConfig.DebitInterval != 0 {
+				stopDebitChan = make(chan struct{})
+			}
+			lowCredit := smg.newLowCreditWarning()
+			for _, s := range ss[cgrID] {
+				s.mux.Lock()
+				s.restored = true // client connection will be bound on SyncSessions
+				s.takenOver = true
+				s.lowCredit = lowCredit
+				s.mux.Unlock()
+				if stopDebitChan != nil && s.UnitTOR == "" {
+					s.stopDebit = stopDebitChan
+					go s.debitLoop(smg.cgrCfg.SmGenericConfig.DebitInterval)
+				}
+			}
+			utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Took over session: %s after losing replication peer", cgrID))
+			return nil, nil
+		}, smg.cgrCfg.LockingTimeout, cgrID)
+	}
+}
+
+// takeoverLoop activates the passive sessions once the replication peer is lost for longer than timeout
+func (smg *SMGeneric) takeoverLoop(timeout time.Duration) {
+	for {
+		time.Sleep(timeout)
+		if smg.peerLost(timeout) {
+			smg.takeoverPassiveSessions()
+		}
+	}
+}
+
+// BiRPCV1ReplicationHeartbeat is received from the replication peer to signal it is alive
+func (smg *SMGeneric) BiRPCV1ReplicationHeartbeat(clnt rpcclient.RpcClientConnection, ignr string, reply *string) error {
+	smg.peerSeen()
+	*reply = utils.OK
+	return nil
+}
@@ -0,0 +1,228 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package sessionmanager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/rpcclient"
+)
+
+// smgReservation is the amount debited for an event, waiting for commit or cancel
+type smgReservation struct {
+	runs   []*SMGSession // one for each derived charging run, holding the debit
+	expiry *time.Timer   // cancels the reservation if not committed in time
+}
+
+// cancel refunds all the units debited by the session run
+func (self *SMGSession) cancel() error {
+	self.mux.Lock()
+	defer self.mux.Unlock()
+	return self.refund(0)
+}
+
+// cancel refunds every run of the reservation, returning the errors of all the failed ones
+func (rsrv *smgReservation) cancel() error {
+	var errs []string
+	for _, s := range rsrv.runs {
+		if err := s.cancel(); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not cancel reservation for event: %s, runId: %s, error: %s", s.CGRID, s.RunID, err.Error()))
+			errs = append(errs, utils.ConcatenatedKey(s.RunID, err.Error()))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed cancelling runs: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// recordReservation indexes the reservation and arms its expiry
+func (smg *SMGeneric) recordReservation(cgrID string, rsrv *smgReservation) {
+	if ttl := smg.cgrCfg.SmGenericConfig.ReservationTTL; ttl != 0 {
+		rsrv.expiry = time.AfterFunc(ttl, func() {
+			utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Cancelling expired reservation for event: %s", cgrID))
+			if err := smg.cancelReservation(cgrID); err != nil && err != utils.ErrNotFound {
+				utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not cancel reservation for event: %s, error: %s", cgrID, err.Error()))
+			}
+		})
+	}
+	smg.reservMux.Lock()
+	smg.reservations[cgrID] = rsrv
+	smg.reservMux.Unlock()
+}
+
+// unrecordReservation removes the reservation from index, returning it
+func (smg *SMGeneric) unrecordReservation(cgrID string) *smgReservation {
+	smg.reservMux.Lock()
+	defer smg.reservMux.Unlock()
+	rsrv, has := smg.reservations[cgrID]
+	if !has {
+		return nil
+	}
+	delete(smg.reservations, cgrID)
+	if rsrv.expiry != nil {
+		rsrv.expiry.Stop()
+	}
+	return rsrv
+}
+
+// ReserveEvent debits the usage of an event, keeping the debit on hold until CommitEvent or CancelEvent
+func (smg *SMGeneric) ReserveEvent(gev SMGenericEvent) (maxUsage time.Duration, err error) {
+	cgrID := gev.GetCGRID(utils.META_DEFAULT)
+	cacheKey := "ReserveEvent" + cgrID
+	if item, err := smg.responseCache.Get(cacheKey); err == nil && item != nil {
+		return item.Value.(time.Duration), item.Err
+	}
+	defer func() { smg.responseCache.Cache(cacheKey, &cache.CacheItem{Value: maxUsage, Err: err}) }()
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) { // Lock it on CGRID level
+		smg.reservMux.Lock()
+		_, has := smg.reservations[cgrID]
+		smg.reservMux.Unlock()
+		if has {
+			return nil, utils.ErrExists
+		}
+		usage, err := gev.GetUsage(utils.META_DEFAULT)
+		if err != nil {
+			return nil, err
+		}
+		var sessionRuns []*engine.SessionRun
+		if err := smg.rals.Call("Responder.GetSessionRuns", gev.AsStoredCdr(smg.cgrCfg, smg.Timezone), &sessionRuns); err != nil {
+			return nil, err
+		} else if len(sessionRuns) == 0 {
+			return nil, nil
+		}
+		rsrv := new(smgReservation)
+		var maxDurInit bool // Avoid differences between default 0 and received 0
+		for _, sR := range sessionRuns {
+			s := &SMGSession{CGRID: cgrID, EventStart: gev, RunID: sR.DerivedCharger.RunID, Timezone: smg.Timezone,
+				rals: smg.rals, cdrsrv: smg.cdrsrv, CD: sR.CallDescriptor}
+			var runMaxUsage time.Duration
+			if runMaxUsage, err = s.debit(usage, nil); err == nil && runMaxUsage == 0 {
+				err = utils.ErrInsufficientCredit
+			}
+			if s.EventCost != nil {
+				rsrv.runs = append(rsrv.runs, s)
+			}
+			if err != nil {
+				utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not reserve CD: %+v, RunID: %s, error: %s", sR.CallDescriptor, sR.DerivedCharger.RunID, err.Error()))
+				break
+			}
+			if !maxDurInit || runMaxUsage < maxUsage {
+				maxUsage = runMaxUsage
+				maxDurInit = true
+			}
+		}
+		if err != nil { // Release the ones already reserved
+			maxUsage = 0
+			if errCancel := rsrv.cancel(); errCancel != nil {
+				return nil, errCancel
+			}
+			return nil, err
+		}
+		smg.recordReservation(cgrID, rsrv)
+		return nil, nil
+	}, smg.cgrCfg.LockingTimeout, cgrID)
+	return
+}
+
+// CommitEvent finalises the reservation with the real usage of the event, refunding or debiting the difference
+func (smg *SMGeneric) CommitEvent(gev SMGenericEvent) (err error) {
+	cgrID := gev.GetCGRID(utils.META_DEFAULT)
+	cacheKey := "CommitEvent" + cgrID
+	if item, err := smg.responseCache.Get(cacheKey); err == nil && item != nil {
+		return item.Err
+	}
+	defer func() { smg.responseCache.Cache(cacheKey, &cache.CacheItem{Err: err}) }()
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) { // Lock it on CGRID level
+		rsrv := smg.unrecordReservation(cgrID)
+		if rsrv == nil {
+			return nil, utils.ErrNotFound
+		}
+		usage, errUsage := gev.GetUsage(utils.META_DEFAULT)
+		var withErrors bool
+		for _, s := range rsrv.runs {
+			if errUsage == nil { // no usage in commit keeps the reserved one
+				s.TotalUsage = usage
+			}
+			if err := s.close(s.TotalUsage); err != nil {
+				withErrors = true
+				utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not commit reservation for event: %s, runId: %s, error: %s", cgrID, s.RunID, err.Error()))
+			}
+			if err := s.storeSMCost(); err != nil {
+				withErrors = true
+				utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not save cost for event: %s, runId: %s, error: %s", cgrID, s.RunID, err.Error()))
+			}
+		}
+		if withErrors {
+			return nil, ErrPartiallyExecuted
+		}
+		return nil, nil
+	}, smg.cgrCfg.LockingTimeout, cgrID)
+	return
+}
+
+// CancelEvent releases the amount reserved for an event
+func (smg *SMGeneric) CancelEvent(gev SMGenericEvent) error {
+	return smg.cancelReservation(gev.GetCGRID(utils.META_DEFAULT))
+}
+
+// cancelReservation refunds the reservation of the event with cgrID
+func (smg *SMGeneric) cancelReservation(cgrID string) (err error) {
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) { // Lock it on CGRID level
+		rsrv := smg.unrecordReservation(cgrID)
+		if rsrv == nil {
+			return nil, utils.ErrNotFound
+		}
+		return nil, rsrv.cancel()
+	}, smg.cgrCfg.LockingTimeout, cgrID)
+	return
+}
+
+// BiRPCV1ReserveEvent returns the usage reserved, in seconds for calls
+func (smg *SMGeneric) BiRPCV1ReserveEvent(clnt rpcclient.RpcClientConnection, ev SMGenericEvent, maxUsage *float64) error {
+	if reserved, err := smg.ReserveEvent(ev); err != nil {
+		return utils.NewErrServerError(err)
+	} else {
+		*maxUsage = reserved.Seconds()
+	}
+	return nil
+}
+
+// BiRPCV1CommitEvent charges the event out of the reserved amount
+func (smg *SMGeneric) BiRPCV1CommitEvent(clnt rpcclient.RpcClientConnection, ev SMGenericEvent, reply *string) error {
+	if err := smg.CommitEvent(ev); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+// BiRPCV1CancelEvent releases the amount reserved for the event
+func (smg *SMGeneric) BiRPCV1CancelEvent(clnt rpcclient.RpcClientConnection, ev SMGenericEvent, reply *string) error {
+	if err := smg.CancelEvent(ev); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
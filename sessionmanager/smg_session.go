@@ -37,9 +37,13 @@ type SMGSession struct {
 	clntConn  rpcclient.RpcClientConnection // Reference towards client connection on SMG side so we can disconnect.
 	rals      rpcclient.RpcClientConnection // Connector to rals service
 	cdrsrv    rpcclient.RpcClientConnection // Connector to CDRS service
+	restored  bool                          // restored from checkpoint, not yet synced with the switch
+	takenOver bool                          // activated after losing the replication peer, kept against the peer replicating it again once synced with the switch
+	lowCredit *lowCreditWarning             // shared by the runs of a session, nil if warnings are not configured
 
 	CGRID      string // Unique identifier for this session
 	RunID      string // Keep a reference for the derived run
+	UnitTOR    string // TOR of the additional units debited by this run, empty for the one debiting the main usage
 	Timezone   string
 	EventStart SMGenericEvent         // Event which started the session
 	CD         *engine.CallDescriptor // initial CD used for debits, updated on each debit
@@ -52,6 +56,14 @@ type SMGSession struct {
 
 }
 
+// lowCreditWarning holds the thresholds for warning the client on low credit, the warning being sent only once per session
+type lowCreditWarning struct {
+	sync.Mutex
+	usage   time.Duration // usage left
+	balance float64       // monetary balance left
+	sent    bool
+}
+
 // Called in case of automatic debits
 func (self *SMGSession) debitLoop(debitInterval time.Duration) {
 	loopIndex := 0
@@ -66,6 +78,8 @@ func (self *SMGSession) debitLoop(debitInterval time.Duration) {
 				disconnectReason := SYSTEM_ERROR
 				if err.Error() == utils.ErrUnauthorizedDestination.Error() {
 					disconnectReason = err.Error()
+				} else if err.Error() == utils.ErrSpendingCapReached.Error() {
+					disconnectReason = SPENDING_CAP_REACHED
 				}
 				if err := self.disconnectSession(disconnectReason); err != nil {
 					utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not disconnect session: %s, error: %s", self.CGRID, err.Error()))
@@ -78,6 +92,7 @@ func (self *SMGSession) debitLoop(debitInterval time.Duration) {
 				}
 				return
 			}
+			self.warnLowCredit()
 			sleepDur = debitInterval
 			loopIndex++
 		}
@@ -120,6 +135,16 @@ func (self *SMGSession) debit(dur time.Duration, lastUsed *time.Duration) (time.
 		self.LastDebit = 0
 		return 0, err
 	}
+	if err := checkSpendingCaps(self.EventStart, self.CD.MaxCostSoFar+cc.Cost, cc.AccountSummary); err != nil {
+		if errRefund := self.refundCallCost(cc); errRefund != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not refund debit over spending cap on session: %s, error: %s", self.CGRID, errRefund.Error()))
+		}
+		self.CD.TimeEnd = self.CD.TimeStart
+		self.CD.DurationIndex -= dur
+		self.LastUsage = 0
+		self.LastDebit = 0
+		return 0, err
+	}
 	// cd corrections
 	self.CD.TimeEnd = cc.GetEndTime() // set debited timeEnd
 	// update call duration with real debited duration
@@ -150,6 +175,96 @@ func (self *SMGSession) debit(dur time.Duration, lastUsed *time.Duration) (time.
 	return requestedDuration, nil
 }
 
+// warnLowCredit sends the low credit warning to the client if the credit left is under one of the thresholds
+func (self *SMGSession) warnLowCredit() {
+	if self.lowCredit == nil {
+		return
+	}
+	self.lowCredit.Lock()
+	sent := self.lowCredit.sent
+	self.lowCredit.Unlock()
+	if sent {
+		return
+	}
+	self.mux.RLock()
+	if self.CD == nil {
+		self.mux.RUnlock()
+		return
+	}
+	var lowCredit bool
+	if self.lowCredit.balance != 0 && self.EventCost != nil && self.EventCost.AccountSummary != nil {
+		var balance float64
+		for _, bs := range self.EventCost.AccountSummary.BalanceSummaries {
+			if bs.Type == utils.MONETARY && !bs.Disabled {
+				balance += bs.Value
+			}
+		}
+		lowCredit = balance < self.lowCredit.balance
+	}
+	cd := *self.CD // copy so we can query what is left after the last debit
+	extraDur := self.ExtraDuration
+	self.mux.RUnlock()
+	if !lowCredit && self.lowCredit.usage != 0 {
+		cd.TimeStart = cd.TimeEnd
+		cd.TimeEnd = cd.TimeStart.Add(self.lowCredit.usage)
+		cd.DurationIndex += self.lowCredit.usage
+		var maxDur float64
+		if err := self.rals.Call("Responder.GetMaxSessionTime", &cd, &maxDur); err != nil {
+			utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not query usage left for session: %s, error: %s", self.CGRID, err.Error()))
+			return
+		}
+		lowCredit = time.Duration(maxDur)+extraDur < self.lowCredit.usage
+	}
+	if !lowCredit {
+		return
+	}
+	self.lowCredit.Lock()
+	if self.lowCredit.sent { // another run was faster
+		self.lowCredit.Unlock()
+		return
+	}
+	self.lowCredit.sent = true
+	self.lowCredit.Unlock()
+	if err := self.warnSession(LOW_CREDIT); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<SMGeneric> Could not warn session: %s, error: %s", self.CGRID, err.Error()))
+	}
+}
+
+// checkSpendingCaps returns ErrSpendingCapReached if the session cost is over the maximum requested by the event
+// or configured on the account, or the account spent more than its limit within the current period
+func checkSpendingCaps(ev SMGenericEvent, sessionCost float64, acntSummary *engine.AccountSummary) error {
+	maxCost, err := ev.GetMaxCost()
+	if err != nil {
+		return err
+	}
+	if acntSummary != nil && acntSummary.MaxSessionCost > 0 &&
+		(maxCost == 0 || acntSummary.MaxSessionCost < maxCost) {
+		maxCost = acntSummary.MaxSessionCost
+	}
+	if maxCost > 0 && sessionCost > maxCost {
+		return utils.ErrSpendingCapReached
+	}
+	if acntSummary != nil && acntSummary.SpendingLimit != nil &&
+		acntSummary.SpendingLimit.Exceeded(time.Now()) {
+		return utils.ErrSpendingCapReached
+	}
+	return nil
+}
+
+// Send warning to remote connection
+func (self *SMGSession) warnSession(reason string) error {
+	if self.clntConn == nil || reflect.ValueOf(self.clntConn).IsNil() {
+		return errors.New("Calling SMGClientV1.WarnSession requires bidirectional JSON connection")
+	}
+	var reply string
+	if err := self.clntConn.Call("SMGClientV1.WarnSession", utils.AttrWarnSession{EventStart: self.EventStart, Reason: reason}, &reply); err != nil {
+		return err
+	} else if reply != utils.OK {
+		return fmt.Errorf("Unexpected warning reply: %s", reply)
+	}
+	return nil
+}
+
 // Send disconnect order to remote connection
 func (self *SMGSession) disconnectSession(reason string) error {
 	self.EventStart[utils.USAGE] = strconv.FormatFloat(self.TotalUsage.Seconds(), 'f', -1, 64) // Set the usage to total one debitted
@@ -203,8 +318,11 @@ func (self *SMGSession) refund(usage time.Duration) (err error) {
 	if srplsEC == nil {
 		return
 	}
+	return self.refundCallCost(srplsEC.AsCallCost())
+}
 
-	cc := srplsEC.AsCallCost()
+// refundCallCost gives back to the account the increments debited in cc
+func (self *SMGSession) refundCallCost(cc *engine.CallCost) (err error) {
 	var incrmts engine.Increments
 	for _, tmspn := range cc.Timespans {
 		for _, incr := range tmspn.Increments {
@@ -62,13 +62,22 @@ type SMGReplicationConn struct {
 	Synchronous bool
 }
 
-func NewSMGeneric(cgrCfg *config.CGRConfig, rals rpcclient.RpcClientConnection, cdrsrv rpcclient.RpcClientConnection,
+func NewSMGeneric(cgrCfg *config.CGRConfig, rals, cdrsrv, rls, stats rpcclient.RpcClientConnection, dataDB engine.DataDB,
 	smgReplConns []*SMGReplicationConn, timezone string) *SMGeneric {
 	ssIdxCfg := cgrCfg.SmGenericConfig.SessionIndexes
 	ssIdxCfg[utils.ACCID] = true // Make sure we have indexing for OriginID since it is a requirement on prefix searching
+	if rls != nil && reflect.ValueOf(rls).IsNil() {
+		rls = nil
+	}
+	if stats != nil && reflect.ValueOf(stats).IsNil() {
+		stats = nil
+	}
 	return &SMGeneric{cgrCfg: cgrCfg,
 		rals:               rals,
 		cdrsrv:             cdrsrv,
+		rls:                rls,
+		stats:              stats,
+		dataDB:             dataDB,
 		smgReplConns:       smgReplConns,
 		Timezone:           timezone,
 		activeSessions:     make(map[string][]*SMGSession),
@@ -79,6 +88,9 @@ func NewSMGeneric(cgrCfg *config.CGRConfig, rals rpcclient.RpcClientConnection,
 		pSessionsIndex:     make(map[string]map[string]map[string]utils.StringMap),
 		pSessionsRIndex:    make(map[string][]*riFieldNameVal),
 		sessionTerminators: make(map[string]*smgSessionTerminator),
+		reservations:       make(map[string]*smgReservation),
+		peerLastSeen:       time.Now(),
+		hbReplicated:       make(map[string]time.Duration),
 		responseCache:      cache.NewResponseCache(cgrCfg.ResponseCacheTTL)}
 }
 
@@ -86,7 +98,11 @@ type SMGeneric struct {
 	cgrCfg             *config.CGRConfig // Separate from smCfg since there can be multiple
 	rals               rpcclient.RpcClientConnection
 	cdrsrv             rpcclient.RpcClientConnection
-	smgReplConns       []*SMGReplicationConn // list of connections where we will replicate our session data
+	rls                rpcclient.RpcClientConnection // ResourceS, nil when not used
+	stats              rpcclient.RpcClientConnection // StatS, nil when not used
+	dataDB             engine.DataDB                 // sessions are checkpointed here
+	cpMux              sync.Mutex                    // protects the sessions checkpoints
+	smgReplConns       []*SMGReplicationConn         // list of connections where we will replicate our session data
 	Timezone           string
 	activeSessions     map[string][]*SMGSession // group sessions per sessionId, multiple runs based on derived charging
 	aSessionsMux       sync.RWMutex
@@ -101,6 +117,11 @@ type SMGeneric struct {
 	pSIMux             sync.RWMutex                                     // protects pSessionsIndex
 	sessionTerminators map[string]*smgSessionTerminator                 // terminate and cleanup the session if timer expires
 	sTsMux             sync.RWMutex                                     // protects sessionTerminators
+	reservations       map[string]*smgReservation                       // event charges reserved and waiting for commit, indexed on CGRID
+	reservMux          sync.Mutex                                       // protects reservations
+	peerLastSeen       time.Time                                        // last replication or heartbeat received from the peer
+	peerMux            sync.RWMutex                                     // protects peerLastSeen
+	hbReplicated       map[string]time.Duration                         // usage of the active sessions last replicated by heartbeats, accessed only by the heartbeat loop
 	responseCache      *cache.ResponseCache                             // cache replies here
 }
 
@@ -181,13 +202,23 @@ func (smg *SMGeneric) ttlTerminate(s *SMGSession, tmtr *smgSessionTerminator) {
 		return
 	}
 	for _, s := range aSessions[s.CGRID] {
+		if s.UnitTOR != "" {
+			continue
+		}
 		s.debit(debitUsage, tmtr.ttlLastUsed)
 	}
+	smg.terminateStaleSession(s)
+}
+
+// terminateStaleSession ends a session with the usage debited so far and generates its CDR
+func (smg *SMGeneric) terminateStaleSession(s *SMGSession) {
 	smg.sessionEnd(s.CGRID, s.TotalUsage)
+	smg.releaseResources(s.EventStart)
 	cdr := s.EventStart.AsStoredCdr(smg.cgrCfg, smg.Timezone)
 	cdr.Usage = s.TotalUsage
 	var reply string
 	smg.cdrsrv.Call("CdrsV1.ProcessCDR", cdr, &reply)
+	smg.processStatsEvent(cdr)
 	smg.replicateSessionsWithID(s.CGRID, false, smg.smgReplConns)
 }
 
@@ -202,8 +233,8 @@ func (smg *SMGeneric) recordASession(s *SMGSession) {
 // Remove session from session list, removes all related in case of multiple runs, true if item was found
 func (smg *SMGeneric) unrecordASession(cgrID string) bool {
 	smg.aSessionsMux.Lock()
-	defer smg.aSessionsMux.Unlock()
 	if _, found := smg.activeSessions[cgrID]; !found {
+		smg.aSessionsMux.Unlock()
 		return false
 	}
 	delete(smg.activeSessions, cgrID)
@@ -213,6 +244,8 @@ func (smg *SMGeneric) unrecordASession(cgrID string) bool {
 	}
 	smg.sTsMux.RUnlock()
 	smg.unindexSession(cgrID, false)
+	smg.aSessionsMux.Unlock()
+	smg.remSessionCheckpoint(cgrID) // outside aSessionsMux since checkpointing is reading the sessions
 	return true
 }
 
@@ -373,21 +406,39 @@ func (smg *SMGeneric) sessionStart(evStart SMGenericEvent, clntConn rpcclient.Rp
 			return nil, nil
 		}
 		stopDebitChan := make(chan struct{})
+		lowCredit := smg.newLowCreditWarning()
+		units, _ := evStart.GetUnits() // additional units, debited on their own TOR
 		for _, sessionRun := range sessionRuns {
 			s := &SMGSession{CGRID: cgrID, EventStart: evStart, RunID: sessionRun.DerivedCharger.RunID, Timezone: smg.Timezone,
-				rals: smg.rals, cdrsrv: smg.cdrsrv, CD: sessionRun.CallDescriptor, clntConn: clntConn}
+				rals: smg.rals, cdrsrv: smg.cdrsrv, CD: sessionRun.CallDescriptor, clntConn: clntConn, lowCredit: lowCredit}
 			smg.recordASession(s)
 			//utils.Logger.Info(fmt.Sprintf("<SMGeneric> Starting session: %s, runId: %s", sessionId, s.runId))
 			if smg.cgrCfg.SmGenericConfig.DebitInterval != 0 {
 				s.stopDebit = stopDebitChan
 				go s.debitLoop(smg.cgrCfg.SmGenericConfig.DebitInterval)
 			}
+			for tor := range units {
+				cd := *sessionRun.CallDescriptor
+				cd.TOR = tor
+				smg.recordASession(&SMGSession{CGRID: cgrID, EventStart: evStart,
+					RunID:   utils.ConcatenatedKey(sessionRun.DerivedCharger.RunID, tor), // distinct SMCost for each unit, CDRS rates it into its own CDR
+					UnitTOR: tor, Timezone: smg.Timezone, rals: smg.rals, cdrsrv: smg.cdrsrv, CD: &cd, clntConn: clntConn})
+			}
 		}
 		return nil, nil
 	}, smg.cgrCfg.LockingTimeout, cgrID)
 	return
 }
 
+// newLowCreditWarning returns the low credit warning settings for a new session, nil when not configured
+func (smg *SMGeneric) newLowCreditWarning() *lowCreditWarning {
+	if smg.cgrCfg.SmGenericConfig.LowCreditUsage == 0 && smg.cgrCfg.SmGenericConfig.LowCreditBalance == 0 {
+		return nil
+	}
+	return &lowCreditWarning{usage: smg.cgrCfg.SmGenericConfig.LowCreditUsage,
+		balance: smg.cgrCfg.SmGenericConfig.LowCreditBalance}
+}
+
 // sessionEnd will end a session from outside
 func (smg *SMGeneric) sessionEnd(cgrID string, usage time.Duration) error {
 	_, err := guardian.Guardian.Guard(func() (interface{}, error) { // Lock it on UUID level
@@ -401,7 +452,11 @@ func (smg *SMGeneric) sessionEnd(cgrID string, usage time.Duration) error {
 			return nil, nil // Did not find the session so no need to close it anymore
 		}
 		for idx, s := range ss[cgrID] {
-			s.TotalUsage = usage // save final usage as totalUsage
+			sUsage := usage
+			if s.UnitTOR != "" { // additional units are not following the main usage
+				sUsage = s.TotalUsage
+			}
+			s.TotalUsage = sUsage // save final usage as totalUsage
 			if idx == 0 && s.stopDebit != nil {
 				close(s.stopDebit) // Stop automatic debits
 			}
@@ -411,7 +466,7 @@ func (smg *SMGeneric) sessionEnd(cgrID string, usage time.Duration) error {
 					cgrID, s.RunID, aTime, err))
 				continue // Unanswered session
 			}
-			if err := s.close(usage); err != nil {
+			if err := s.close(sUsage); err != nil {
 				utils.Logger.Err(fmt.Sprintf("<SMGeneric> Could not close session: %s, runId: %s, error: %s", cgrID, s.RunID, err.Error()))
 			}
 			if err := s.storeSMCost(); err != nil {
@@ -460,26 +515,11 @@ func (smg *SMGeneric) sessionRelocate(initialID, cgrID, newOriginID string) erro
 // replicateSessions will replicate session based on configuration
 func (smg *SMGeneric) replicateSessionsWithID(cgrID string, passiveSessions bool, smgReplConns []*SMGReplicationConn) (err error) {
 	if len(smgReplConns) == 0 ||
-		(smg.cgrCfg.SmGenericConfig.DebitInterval != 0 && !passiveSessions) { // Replicating active not supported
+		(smg.cgrCfg.SmGenericConfig.DebitInterval != 0 && !passiveSessions &&
+			smg.cgrCfg.SmGenericConfig.ReplicationHeartbeat == 0) { // Replicating active not supported unless refreshed by heartbeats
 		return
 	}
-	ssMux := &smg.aSessionsMux
-	ssMp := smg.activeSessions // reference it so we don't overwrite the new map without protection
-	if passiveSessions {
-		ssMux = &smg.pSessionsMux
-		ssMp = smg.passiveSessions
-	}
-	ssMux.RLock()
-	ss := ssMp[cgrID]
-	if len(ss) != 0 {
-		ss[0].mux.RLock() // lock session so we can clone it after releasing the map lock
-	}
-	ssMux.RUnlock()
-	var ssCln []*SMGSession
-	err = utils.Clone(ss, &ssCln)
-	if len(ss) != 0 {
-		ss[0].mux.RUnlock()
-	}
+	ssCln, err := smg.cloneSessions(cgrID, passiveSessions)
 	if err != nil {
 		return
 	}
@@ -501,6 +541,27 @@ func (smg *SMGeneric) replicateSessionsWithID(cgrID string, passiveSessions bool
 	return
 }
 
+// cloneSessions returns a copy of the active or passive sessions with cgrID, to be sent over replication
+func (smg *SMGeneric) cloneSessions(cgrID string, passiveSessions bool) (ssCln []*SMGSession, err error) {
+	ssMux := &smg.aSessionsMux
+	ssMp := smg.activeSessions // reference it so we don't overwrite the new map without protection
+	if passiveSessions {
+		ssMux = &smg.pSessionsMux
+		ssMp = smg.passiveSessions
+	}
+	ssMux.RLock()
+	ss := ssMp[cgrID]
+	if len(ss) != 0 {
+		ss[0].mux.RLock() // lock session so we can clone it after releasing the map lock
+	}
+	ssMux.RUnlock()
+	err = utils.Clone(ss, &ssCln)
+	if len(ss) != 0 {
+		ss[0].mux.RUnlock()
+	}
+	return
+}
+
 // getSessions is used to return in a thread-safe manner active or passive sessions
 func (smg *SMGeneric) getSessions(cgrID string, passiveSessions bool) (aSS map[string][]*SMGSession) {
 	ssMux := &smg.aSessionsMux
@@ -535,7 +596,12 @@ func (smg *SMGeneric) setPassiveSessions(cgrID string, ss []*SMGSession) (err er
 			return ErrActiveSession
 		}
 	}
-	smg.unrecordASession(cgrID)
+	smg.peerSeen()
+	if smg.ownsSession(cgrID) {
+		smg.deletePassiveSessions(cgrID)
+		return ErrActiveSession // the peer will hand it back
+	}
+	smg.demoteSessions(cgrID)
 	smg.pSessionsMux.Lock()
 	smg.passiveSessions[cgrID] = ss
 	smg.pSessionsMux.Unlock()
@@ -548,6 +614,7 @@ func (smg *SMGeneric) setPassiveSessions(cgrID string, ss []*SMGSession) (err er
 // remPassiveSession is called when a session is removed via RPC from passive sessions table
 // ToDo: test
 func (smg *SMGeneric) removePassiveSessions(cgrID string) (err error) {
+	smg.peerSeen()
 	for _, cacheKey := range []string{"InitiateSession" + cgrID, "UpdateSession" + cgrID, "TerminateSession" + cgrID} {
 		if _, err := smg.responseCache.Get(cacheKey); err == nil { // Stop processing passive when there has been an update over active RPC
 			smg.deletePassiveSessions(cgrID)
@@ -579,6 +646,7 @@ func (smg *SMGeneric) passiveToActive(cgrID string) (pSessions map[string][]*SMG
 		smg.recordASession(s)
 		s.rals = smg.rals
 		s.cdrsrv = smg.cdrsrv
+		s.takenOver = false
 	}
 	smg.deletePassiveSessions(cgrID)
 	return
@@ -644,6 +712,66 @@ func (smg *SMGeneric) asActiveSessions(fltrs map[string]string, count, passiveSe
 	return
 }
 
+// asResourceUsage builds the ResourceS arguments out of event, one unit per session
+func (smg *SMGeneric) asResourceUsage(gev SMGenericEvent) utils.AttrRLsResourceUsage {
+	return utils.AttrRLsResourceUsage{UsageID: gev.GetCGRID(utils.META_DEFAULT),
+		Event: map[string]interface{}(gev), Units: 1}
+}
+
+// resourceSError restores ErrResourceUnavailable out of remote errors so agents can match it
+func resourceSError(err error) error {
+	if err != nil && err.Error() == utils.ErrResourceUnavailable.Error() {
+		return utils.ErrResourceUnavailable
+	}
+	return err
+}
+
+// authorizeResources queries ResourceS if the session is allowed to start
+func (smg *SMGeneric) authorizeResources(gev SMGenericEvent) (err error) {
+	if smg.rls == nil {
+		return
+	}
+	var allow bool
+	if err = smg.rls.Call("ResourceSV1.AllowUsage", smg.asResourceUsage(gev), &allow); err != nil {
+		return resourceSError(err)
+	}
+	if !allow {
+		return utils.ErrResourceUnavailable
+	}
+	return
+}
+
+// allocateResources reserves the resources for the session in ResourceS
+func (smg *SMGeneric) allocateResources(gev SMGenericEvent) (err error) {
+	if smg.rls == nil {
+		return
+	}
+	var reply string
+	return resourceSError(smg.rls.Call("ResourceSV1.AllocateResource", smg.asResourceUsage(gev), &reply))
+}
+
+// releaseResources frees the resources allocated for the session, errors are only logged
+func (smg *SMGeneric) releaseResources(gev SMGenericEvent) {
+	if smg.rls == nil {
+		return
+	}
+	var reply string
+	if err := smg.rls.Call("ResourceSV1.ReleaseResource", smg.asResourceUsage(gev), &reply); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<SMGeneric> RLs API error: %s", err.Error()))
+	}
+}
+
+// processStatsEvent sends the CDR to StatS
+func (smg *SMGeneric) processStatsEvent(cdr *engine.CDR) {
+	if smg.stats == nil {
+		return
+	}
+	cdrIf, _ := cdr.AsMapStringIface()
+	cdrIf[utils.ID] = cdr.CGRID
+	var reply string
+	go smg.stats.Call("StatSV1.ProcessEvent", cdrIf, &reply)
+}
+
 // Methods to apply on sessions, mostly exported through RPC/Bi-RPC
 
 // MaxUsage calculates maximum usage allowed for given gevent
@@ -654,6 +782,9 @@ func (smg *SMGeneric) GetMaxUsage(gev SMGenericEvent) (maxUsage time.Duration, e
 	}
 	defer smg.responseCache.Cache(cacheKey, &cache.CacheItem{Value: maxUsage, Err: err})
 	gev[utils.EVENT_NAME] = utils.CGR_AUTHORIZATION
+	if err = smg.authorizeResources(gev); err != nil {
+		return
+	}
 	storedCdr := gev.AsStoredCdr(config.CgrConfig(), smg.Timezone)
 	var maxDur float64
 	if err = smg.rals.Call("Responder.GetDerivedMaxSessionTime", storedCdr, &maxDur); err != nil {
@@ -702,17 +833,23 @@ func (smg *SMGeneric) InitiateSession(gev SMGenericEvent, clnt rpcclient.RpcClie
 	}
 	defer smg.responseCache.Cache(cacheKey, &cache.CacheItem{Value: maxUsage, Err: err}) // schedule response caching
 	smg.deletePassiveSessions(cgrID)
+	if err = smg.allocateResources(gev); err != nil {
+		return
+	}
 	if err = smg.sessionStart(gev, clnt); err != nil {
 		smg.sessionEnd(cgrID, 0)
+		smg.releaseResources(gev)
 		return
 	}
 	if smg.cgrCfg.SmGenericConfig.DebitInterval != 0 { // Session handled by debit loop
+		smg.replicateSessionsWithID(cgrID, false, smg.smgReplConns)
 		maxUsage = time.Duration(-1 * time.Second)
 		return
 	}
 	maxUsage, err = smg.UpdateSession(gev, clnt)
 	if err != nil || maxUsage == 0 {
 		smg.sessionEnd(cgrID, 0)
+		smg.releaseResources(gev)
 	}
 	return
 }
@@ -766,12 +903,16 @@ func (smg *SMGeneric) UpdateSession(gev SMGenericEvent, clnt rpcclient.RpcClient
 	}
 	defer smg.replicateSessionsWithID(gev.GetCGRID(utils.META_DEFAULT), false, smg.smgReplConns)
 	for _, s := range aSessions[cgrID] {
+		if s.UnitTOR != "" { // debited out of Units
+			continue
+		}
 		var maxDur time.Duration
 		if maxDur, err = s.debit(maxUsage, lastUsed); err != nil {
 			return
 		} else if maxDur < maxUsage {
 			maxUsage = maxDur
 		}
+		go s.warnLowCredit() // do not block the reply while querying and calling back the client
 	}
 	return
 }
@@ -836,9 +977,13 @@ func (smg *SMGeneric) TerminateSession(gev SMGenericEvent, clnt rpcclient.RpcCli
 		if errUsage != nil {
 			usage = s.TotalUsage - s.LastUsage + lastUsed
 		}
+		if units, errUnits := gev.GetUnits(); errUnits == nil {
+			setUnitsUsage(aSessions[sessionID], units)
+		}
 		if errSEnd := smg.sessionEnd(sessionID, usage); errSEnd != nil {
 			err = errSEnd // Last error will be the one returned as API result
 		}
+		smg.releaseResources(s.EventStart)
 	}
 	if !hasActiveSession {
 		err = rpcclient.ErrSessionNotFound
@@ -847,6 +992,92 @@ func (smg *SMGeneric) TerminateSession(gev SMGenericEvent, clnt rpcclient.RpcCli
 	return
 }
 
+// setUnitsUsage records the final usage of the additional units before closing the session
+func setUnitsUsage(ss []*SMGSession, units map[string]float64) {
+	for _, s := range ss {
+		if usage, has := units[s.UnitTOR]; has && s.UnitTOR != "" {
+			s.mux.Lock()
+			s.TotalUsage = unitsDuration(usage)
+			s.mux.Unlock()
+		}
+	}
+}
+
+// debitUnits debits the additional units requested by the event, returning the units granted for each TOR
+func (smg *SMGeneric) debitUnits(cgrID string, gev SMGenericEvent) (grantedUnits map[string]float64, err error) {
+	var units map[string]float64
+	if units, err = gev.GetUnits(); err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	grantedUnits = make(map[string]float64, len(units))
+	for tor := range units {
+		grantedUnits[tor] = 0 // units not announced on session start will not be granted
+	}
+	granted := make(utils.StringMap)
+	for _, s := range smg.getSessions(cgrID, false)[cgrID] {
+		reqUnits, has := units[s.UnitTOR]
+		if s.UnitTOR == "" || !has {
+			continue
+		}
+		var maxUnits time.Duration
+		if maxUnits, err = s.debit(unitsDuration(reqUnits), nil); err != nil {
+			return
+		}
+		if !granted[s.UnitTOR] || maxUnits.Seconds() < grantedUnits[s.UnitTOR] { // minimum out of all runs
+			grantedUnits[s.UnitTOR] = maxUnits.Seconds()
+			granted[s.UnitTOR] = true
+		}
+	}
+	return
+}
+
+// InitiateSessionWithUnits starts a session debiting next to the main usage the additional Units, returns granted units per TOR
+func (smg *SMGeneric) InitiateSessionWithUnits(gev SMGenericEvent, clnt rpcclient.RpcClientConnection) (maxUnits map[string]float64, err error) {
+	cgrID := gev.GetCGRID(utils.META_DEFAULT)
+	cacheKey := "InitiateSessionWithUnits" + cgrID
+	if item, err := smg.responseCache.Get(cacheKey); err == nil && item != nil {
+		return item.Value.(map[string]float64), item.Err
+	}
+	defer func() { smg.responseCache.Cache(cacheKey, &cache.CacheItem{Value: maxUnits, Err: err}) }()
+	var maxUsage time.Duration
+	if maxUsage, err = smg.InitiateSession(gev, clnt); err != nil {
+		return
+	}
+	if maxUnits, err = smg.debitUnits(cgrID, gev); err != nil {
+		return
+	}
+	if maxUnits == nil {
+		maxUnits = make(map[string]float64)
+	}
+	maxUnits[gev.GetTOR(utils.META_DEFAULT)] = maxUsage.Seconds()
+	return
+}
+
+// UpdateSessionWithUnits debits next to the main usage the additional Units, returns granted units per TOR
+func (smg *SMGeneric) UpdateSessionWithUnits(gev SMGenericEvent, clnt rpcclient.RpcClientConnection) (maxUnits map[string]float64, err error) {
+	cgrID := gev.GetCGRID(utils.META_DEFAULT)
+	cacheKey := "UpdateSessionWithUnits" + cgrID
+	if item, err := smg.responseCache.Get(cacheKey); err == nil && item != nil {
+		return item.Value.(map[string]float64), item.Err
+	}
+	defer func() { smg.responseCache.Cache(cacheKey, &cache.CacheItem{Value: maxUnits, Err: err}) }()
+	var maxUsage time.Duration
+	if maxUsage, err = smg.UpdateSession(gev, clnt); err != nil {
+		return
+	}
+	if maxUnits, err = smg.debitUnits(cgrID, gev); err != nil {
+		return
+	}
+	if maxUnits == nil {
+		maxUnits = make(map[string]float64)
+	}
+	maxUnits[gev.GetTOR(utils.META_DEFAULT)] = maxUsage.Seconds()
+	return
+}
+
 // Processes one time events (eg: SMS)
 func (smg *SMGeneric) ChargeEvent(gev SMGenericEvent) (maxUsage time.Duration, err error) {
 	cgrID := gev.GetCGRID(utils.META_DEFAULT)
@@ -872,6 +1103,8 @@ func (smg *SMGeneric) ChargeEvent(gev SMGenericEvent) (maxUsage time.Duration, e
 		if ccDur := cc.GetDuration(); ccDur == 0 {
 			err = utils.ErrInsufficientCredit
 			break
+		} else if err = checkSpendingCaps(gev, cc.Cost, cc.AccountSummary); err != nil {
+			break
 		} else if !maxDurInit || ccDur < maxUsage {
 			maxUsage = ccDur
 		}
@@ -960,18 +1193,36 @@ func (smg *SMGeneric) ProcessCDR(gev SMGenericEvent) (err error) {
 	}
 	defer smg.responseCache.Cache(cacheKey, &cache.CacheItem{Err: err})
 	var reply string
-	if err = smg.cdrsrv.Call("CdrsV1.ProcessCDR", gev.AsStoredCdr(smg.cgrCfg, smg.Timezone), &reply); err != nil {
+	cdr := gev.AsStoredCdr(smg.cgrCfg, smg.Timezone)
+	if err = smg.cdrsrv.Call("CdrsV1.ProcessCDR", cdr, &reply); err != nil {
 		return
 	}
+	smg.processStatsEvent(cdr)
 	return
 }
 
 func (smg *SMGeneric) Connect() error {
+	if interval := smg.cgrCfg.SmGenericConfig.ReplicationHeartbeat; interval != 0 && len(smg.smgReplConns) != 0 {
+		go smg.heartbeatLoop(interval)
+	}
+	if timeout := smg.cgrCfg.SmGenericConfig.PassiveTakeover; timeout != 0 {
+		go smg.takeoverLoop(timeout)
+	}
+	if !smg.checkpointing() {
+		return nil
+	}
+	if err := smg.restoreSessions(); err != nil {
+		return err
+	}
+	go smg.checkpointLoop(smg.cgrCfg.SmGenericConfig.SessionsCheckpointInterval)
 	return nil
 }
 
 // System shutdown
 func (smg *SMGeneric) Shutdown() error {
+	if smg.checkpointing() { // keep the sessions so we can restore them on next start
+		return smg.checkpointSessions()
+	}
 	for ssId := range smg.getSessions("", false) { // Force sessions shutdown
 		smg.sessionEnd(ssId, time.Duration(smg.cgrCfg.MaxCallDuration))
 	}
@@ -1088,6 +1339,32 @@ func (smg *SMGeneric) BiRPCV1UpdateSession(clnt rpcclient.RpcClientConnection, e
 	return
 }
 
+// BiRPCV1InitiateSessionWithUnits initiates a session charging several units at once, returns the units granted per TOR
+func (smg *SMGeneric) BiRPCV1InitiateSessionWithUnits(clnt rpcclient.RpcClientConnection, ev SMGenericEvent, maxUnits *map[string]float64) (err error) {
+	var grantedUnits map[string]float64
+	if grantedUnits, err = smg.InitiateSessionWithUnits(ev, clnt); err != nil {
+		if err != rpcclient.ErrSessionNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return
+	}
+	*maxUnits = grantedUnits
+	return
+}
+
+// BiRPCV1UpdateSessionWithUnits updates a session charging several units at once, returns the units granted per TOR
+func (smg *SMGeneric) BiRPCV1UpdateSessionWithUnits(clnt rpcclient.RpcClientConnection, ev SMGenericEvent, maxUnits *map[string]float64) (err error) {
+	var grantedUnits map[string]float64
+	if grantedUnits, err = smg.UpdateSessionWithUnits(ev, clnt); err != nil {
+		if err != rpcclient.ErrSessionNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return
+	}
+	*maxUnits = grantedUnits
+	return
+}
+
 // BiRPCV1UpdateSession updates an existing session, returning the duration which the session can still last
 func (smg *SMGeneric) BiRPCV2UpdateSession(clnt rpcclient.RpcClientConnection, ev SMGenericEvent, maxUsage *time.Duration) (err error) {
 	var minMaxUsage time.Duration
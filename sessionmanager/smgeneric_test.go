@@ -18,10 +18,14 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package sessionmanager
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/engine"
 	"github.com/cgrates/cgrates/utils"
 )
 
@@ -34,7 +38,7 @@ func init() {
 }
 
 func TestSMGSessionIndexing(t *testing.T) {
-	smg := NewSMGeneric(smgCfg, nil, nil, nil, "UTC")
+	smg := NewSMGeneric(smgCfg, nil, nil, nil, nil, nil, nil, "UTC")
 	smGev := SMGenericEvent{
 		utils.EVENT_NAME:       "TEST_EVENT",
 		utils.TOR:              "*voice",
@@ -389,7 +393,7 @@ func TestSMGSessionIndexing(t *testing.T) {
 }
 
 func TestSMGActiveSessions(t *testing.T) {
-	smg := NewSMGeneric(smgCfg, nil, nil, nil, "UTC")
+	smg := NewSMGeneric(smgCfg, nil, nil, nil, nil, nil, nil, "UTC")
 	smGev1 := SMGenericEvent{
 		utils.EVENT_NAME:       "TEST_EVENT",
 		utils.TOR:              "*voice",
@@ -463,7 +467,7 @@ func TestSMGActiveSessions(t *testing.T) {
 }
 
 func TestGetPassiveSessions(t *testing.T) {
-	smg := NewSMGeneric(smgCfg, nil, nil, nil, "UTC")
+	smg := NewSMGeneric(smgCfg, nil, nil, nil, nil, nil, nil, "UTC")
 	if pSS := smg.getSessions("", true); len(pSS) != 0 {
 		t.Errorf("PassiveSessions: %+v", pSS)
 	}
@@ -532,3 +536,606 @@ func TestGetPassiveSessions(t *testing.T) {
 		t.Errorf("PassiveSessions: %+v", pSS)
 	}
 }
+
+// mockRLsConn simulates ResourceS with no resources available
+type mockRLsConn struct {
+	calls []string
+}
+
+func (rls *mockRLsConn) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	rls.calls = append(rls.calls, serviceMethod)
+	switch serviceMethod {
+	case "ResourceSV1.AllowUsage":
+		*reply.(*bool) = false
+		return nil
+	case "ResourceSV1.AllocateResource":
+		return errors.New(utils.ErrResourceUnavailable.Error()) // errors are received as strings over RPC
+	}
+	return utils.ErrNotImplemented
+}
+
+func TestSMGResourceUnavailable(t *testing.T) {
+	rls := new(mockRLsConn)
+	smg := NewSMGeneric(smgCfg, nil, nil, rls, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.VOICE,
+		utils.ACCID:       "12346",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+	}
+	if _, err := smg.GetMaxUsage(smGev); err != utils.ErrResourceUnavailable {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrResourceUnavailable, err)
+	}
+	if _, err := smg.InitiateSession(smGev, nil); err != utils.ErrResourceUnavailable {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrResourceUnavailable, err)
+	}
+	if eCalls := []string{"ResourceSV1.AllowUsage", "ResourceSV1.AllocateResource"}; !reflect.DeepEqual(eCalls, rls.calls) {
+		t.Errorf("Expecting: %+v, received: %+v", eCalls, rls.calls)
+	}
+	if len(smg.getSessions(smGev.GetCGRID(utils.META_DEFAULT), false)) != 0 {
+		t.Error("Session should not be started")
+	}
+}
+
+func TestSMGSessionsCheckpoint(t *testing.T) {
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.SmGenericConfig.SessionsCheckpointInterval = time.Hour
+	dataDB, _ := engine.NewMapStorage()
+	smg := NewSMGeneric(cfg, nil, nil, nil, nil, dataDB, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.VOICE,
+		utils.ACCID:       "12347",
+		utils.CDRHOST:     "127.0.0.1",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+	}
+	cgrID := smGev.GetCGRID(utils.META_DEFAULT)
+	tStart := time.Date(2017, 10, 1, 10, 0, 0, 0, time.UTC)
+	smg.recordASession(&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, Timezone: "UTC", EventStart: smGev,
+		CD: &engine.CallDescriptor{CgrID: cgrID, RunID: utils.META_DEFAULT, Tenant: "cgrates.org", Account: "1001",
+			TimeStart: tStart, TimeEnd: tStart.Add(time.Minute), LoopIndex: 1, DurationIndex: time.Minute},
+		ExtraDuration: 10 * time.Second, LastUsage: 50 * time.Second, LastDebit: time.Minute, TotalUsage: 50 * time.Second})
+	if err := smg.checkpointSessions(); err != nil {
+		t.Fatal(err)
+	}
+	smgRestored := NewSMGeneric(cfg, nil, nil, nil, nil, dataDB, nil, "UTC")
+	if err := smgRestored.restoreSessions(); err != nil {
+		t.Fatal(err)
+	}
+	aSessions := smgRestored.getSessions(cgrID, false)
+	if len(aSessions[cgrID]) != 1 {
+		t.Fatalf("Unexpected restored sessions: %+v", aSessions)
+	}
+	s := aSessions[cgrID][0]
+	if !s.restored || s.RunID != utils.META_DEFAULT ||
+		s.EventStart.GetOriginID(utils.META_DEFAULT) != "12347" ||
+		s.ExtraDuration != 10*time.Second || s.LastUsage != 50*time.Second ||
+		s.LastDebit != time.Minute || s.TotalUsage != 50*time.Second {
+		t.Errorf("Unexpected restored session: %+v", s)
+	}
+	if s.CD == nil || !s.CD.TimeEnd.Equal(tStart.Add(time.Minute)) || s.CD.LoopIndex != 1 {
+		t.Errorf("Unexpected restored CD: %+v", s.CD)
+	}
+	if aSs, _, _ := smgRestored.asActiveSessions(map[string]string{"Account": "1001"}, false, false); len(aSs) != 1 {
+		t.Errorf("Restored session not indexed: %+v", aSs)
+	}
+	var synced []SMGenericEvent
+	if err := smgRestored.BiRPCV1SyncSessions(nil, ArgsSyncSessions{OriginHost: "127.0.0.1", OriginIDs: []string{"12347"}}, &synced); err != nil {
+		t.Error(err)
+	} else if s.restored {
+		t.Error("Session should be synced")
+	} else if len(synced) != 1 || synced[0].GetOriginID(utils.META_DEFAULT) != "12347" {
+		t.Errorf("Unexpected synced sessions: %+v", synced)
+	}
+	smgRestored.unrecordASession(cgrID)
+	if sCps, err := dataDB.GetSMGSessionCheckpoints(); err != nil {
+		t.Error(err)
+	} else if len(sCps) != 0 {
+		t.Errorf("Checkpoint not removed: %+v", sCps)
+	}
+}
+
+func TestSMGSessionsCheckpointDisabled(t *testing.T) {
+	cfg, _ := config.NewDefaultCGRConfig()
+	dataDB, _ := engine.NewMapStorage()
+	sCp := &engine.SMGSessionCheckpoint{CGRID: "CHECKPOINTED", CheckpointTime: time.Now()}
+	if err := dataDB.SetSMGSessionCheckpoint(sCp); err != nil {
+		t.Fatal(err)
+	}
+	smg := NewSMGeneric(cfg, nil, nil, nil, nil, dataDB, nil, "UTC")
+	smg.recordASession(&SMGSession{CGRID: "CHECKPOINTED", RunID: utils.META_DEFAULT, EventStart: SMGenericEvent{}})
+	smg.unrecordASession("CHECKPOINTED")
+	if sCps, err := dataDB.GetSMGSessionCheckpoints(); err != nil {
+		t.Error(err)
+	} else if len(sCps) != 1 {
+		t.Errorf("Checkpoint removed while checkpointing is disabled: %+v", sCps)
+	}
+}
+
+type mockSMGClientConn struct {
+	warnings []utils.AttrWarnSession
+}
+
+func (clnt *mockSMGClientConn) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	if serviceMethod != "SMGClientV1.WarnSession" {
+		return utils.ErrNotImplemented
+	}
+	clnt.warnings = append(clnt.warnings, args.(utils.AttrWarnSession))
+	*reply.(*string) = utils.OK
+	return nil
+}
+
+type mockMaxSessionTimeConn struct {
+	maxSessionTime time.Duration
+}
+
+func (rals *mockMaxSessionTimeConn) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	if serviceMethod != "Responder.GetMaxSessionTime" {
+		return utils.ErrNotImplemented
+	}
+	*reply.(*float64) = float64(rals.maxSessionTime)
+	return nil
+}
+
+func TestSMGSessionWarnLowCredit(t *testing.T) {
+	clnt := new(mockSMGClientConn)
+	rals := &mockMaxSessionTimeConn{maxSessionTime: 2 * time.Minute}
+	lowCredit := &lowCreditWarning{usage: time.Minute}
+	tStart := time.Date(2017, 10, 1, 10, 0, 0, 0, time.UTC)
+	ss := []*SMGSession{
+		&SMGSession{CGRID: "warnCGRID", RunID: utils.META_DEFAULT, EventStart: SMGenericEvent{utils.ACCID: "12348"},
+			CD:   &engine.CallDescriptor{TimeStart: tStart, TimeEnd: tStart.Add(time.Minute)},
+			rals: rals, clntConn: clnt, lowCredit: lowCredit},
+		&SMGSession{CGRID: "warnCGRID", RunID: "run2", EventStart: SMGenericEvent{utils.ACCID: "12348"},
+			CD:   &engine.CallDescriptor{TimeStart: tStart, TimeEnd: tStart.Add(time.Minute)},
+			rals: rals, clntConn: clnt, lowCredit: lowCredit},
+	}
+	ss[0].warnLowCredit()
+	if len(clnt.warnings) != 0 {
+		t.Errorf("Unexpected warnings: %+v", clnt.warnings)
+	}
+	rals.maxSessionTime = 30 * time.Second
+	for _, s := range ss {
+		s.warnLowCredit()
+		s.warnLowCredit()
+	}
+	if len(clnt.warnings) != 1 {
+		t.Fatalf("Expecting one warning, received: %+v", clnt.warnings)
+	} else if clnt.warnings[0].Reason != LOW_CREDIT {
+		t.Errorf("Unexpected warning: %+v", clnt.warnings[0])
+	}
+	balanceWarn := &SMGSession{CGRID: "warnCGRID2", EventStart: SMGenericEvent{utils.ACCID: "12349"}, CD: &engine.CallDescriptor{},
+		EventCost: &engine.EventCost{AccountSummary: &engine.AccountSummary{BalanceSummaries: []*engine.BalanceSummary{
+			&engine.BalanceSummary{Type: utils.MONETARY, Value: 0.5},
+			&engine.BalanceSummary{Type: utils.VOICE, Value: 3600}}}},
+		clntConn: clnt, lowCredit: &lowCreditWarning{balance: 1}}
+	balanceWarn.warnLowCredit()
+	if len(clnt.warnings) != 2 {
+		t.Errorf("Expecting balance warning, received: %+v", clnt.warnings)
+	}
+}
+
+type mockMultiUnitRALs struct {
+	maxDataUnits time.Duration
+	debits       map[string]time.Duration // final debits per TOR
+}
+
+func (rals *mockMultiUnitRALs) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	switch serviceMethod {
+	case "Responder.GetSessionRuns":
+		cdr := args.(*engine.CDR)
+		*reply.(*[]*engine.SessionRun) = []*engine.SessionRun{
+			&engine.SessionRun{DerivedCharger: &utils.DerivedCharger{RunID: utils.META_DEFAULT},
+				CallDescriptor: &engine.CallDescriptor{CgrID: cdr.CGRID, RunID: utils.META_DEFAULT, TOR: cdr.ToR,
+					Tenant: cdr.Tenant, Account: cdr.Account, Destination: cdr.Destination, TimeStart: cdr.AnswerTime}}}
+	case "Responder.MaxDebit":
+		cd := args.(*engine.CallDescriptor)
+		tEnd := cd.TimeEnd
+		if cd.TOR == utils.DATA && tEnd.Sub(cd.TimeStart) > rals.maxDataUnits {
+			tEnd = cd.TimeStart.Add(rals.maxDataUnits)
+		}
+		*reply.(*engine.CallCost) = engine.CallCost{TOR: cd.TOR,
+			Timespans: engine.TimeSpans{&engine.TimeSpan{TimeStart: cd.TimeStart, TimeEnd: tEnd}}}
+	case "Responder.Debit":
+		cd := args.(*engine.CallDescriptor)
+		rals.debits[cd.TOR] = cd.TimeEnd.Sub(cd.TimeStart)
+	case "CdrsV2.StoreSMCost":
+		*reply.(*string) = utils.OK
+	default:
+		return utils.ErrNotImplemented
+	}
+	return nil
+}
+
+func TestSMGMultiUnitSession(t *testing.T) {
+	rals := &mockMultiUnitRALs{maxDataUnits: 500 * time.Second, debits: make(map[string]time.Duration)}
+	smg := NewSMGeneric(smgCfg, rals, rals, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.VOICE,
+		utils.ACCID:       "12350",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+		utils.USAGE:       "60s",
+		utils.Units:       map[string]interface{}{utils.DATA: "1024"},
+	}
+	eUnits := map[string]float64{utils.VOICE: 60, utils.DATA: 500}
+	if maxUnits, err := smg.InitiateSessionWithUnits(smGev, nil); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(eUnits, maxUnits) {
+		t.Errorf("Expecting: %+v, received: %+v", eUnits, maxUnits)
+	}
+	cgrID := smGev.GetCGRID(utils.META_DEFAULT)
+	aSessions := smg.getSessions(cgrID, false)[cgrID]
+	if len(aSessions) != 2 {
+		t.Fatalf("Unexpected sessions: %+v", aSessions)
+	} else if aSessions[1].UnitTOR != utils.DATA || aSessions[1].CD.TOR != utils.DATA ||
+		aSessions[1].RunID != utils.ConcatenatedKey(utils.META_DEFAULT, utils.DATA) {
+		t.Errorf("Unexpected units session: %+v", aSessions[1])
+	}
+	if aSessions[0].TotalUsage != time.Minute || aSessions[1].TotalUsage != 500*time.Second {
+		t.Errorf("Unexpected usage, voice: %v, data: %v", aSessions[0].TotalUsage, aSessions[1].TotalUsage)
+	}
+	smGev[utils.USAGE] = "70s"
+	smGev[utils.Units] = map[string]interface{}{utils.DATA: "800"}
+	if err := smg.TerminateSession(smGev, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rals.debits[utils.DATA] != 800*time.Second || rals.debits[utils.VOICE] != 70*time.Second {
+		t.Errorf("Unexpected final debits: %+v", rals.debits)
+	}
+}
+
+type mockSpendingCapRALs struct {
+	costPerDebit  float64
+	spendingLimit *engine.SpendingLimit
+	refunds       int
+}
+
+func (rals *mockSpendingCapRALs) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	switch serviceMethod {
+	case "Responder.GetSessionRuns":
+		cdr := args.(*engine.CDR)
+		*reply.(*[]*engine.SessionRun) = []*engine.SessionRun{
+			&engine.SessionRun{DerivedCharger: &utils.DerivedCharger{RunID: utils.META_DEFAULT},
+				CallDescriptor: &engine.CallDescriptor{CgrID: cdr.CGRID, RunID: utils.META_DEFAULT, TOR: cdr.ToR,
+					Tenant: cdr.Tenant, Account: cdr.Account, Destination: cdr.Destination, TimeStart: cdr.AnswerTime,
+					TimeEnd: cdr.AnswerTime.Add(cdr.Usage)}}}
+	case "Responder.MaxDebit":
+		cd := args.(*engine.CallDescriptor)
+		acntSmry := &engine.AccountSummary{Tenant: cd.Tenant, ID: cd.Account}
+		if rals.spendingLimit != nil {
+			rals.spendingLimit.AddSpent(rals.costPerDebit, time.Now())
+			acntSmry.SpendingLimit = rals.spendingLimit.Clone()
+		}
+		*reply.(*engine.CallCost) = engine.CallCost{TOR: cd.TOR, Cost: rals.costPerDebit,
+			Timespans: engine.TimeSpans{&engine.TimeSpan{TimeStart: cd.TimeStart, TimeEnd: cd.TimeEnd, CompressFactor: 1,
+				RateInterval: &engine.RateInterval{Rating: &engine.RIRate{RoundingDecimals: 4,
+					RoundingMethod: utils.ROUNDING_MIDDLE}},
+				Increments: engine.Increments{&engine.Increment{Duration: cd.TimeEnd.Sub(cd.TimeStart),
+					Cost: rals.costPerDebit, CompressFactor: 1,
+					BalanceInfo: &engine.DebitInfo{AccountID: utils.ConcatenatedKey(cd.Tenant, cd.Account),
+						Monetary: &engine.MonetaryInfo{UUID: "MONETARY_BALANCE"}}}}}},
+			AccountSummary: acntSmry}
+	case "Responder.RefundIncrements":
+		rals.refunds++
+		if rals.spendingLimit != nil {
+			rals.spendingLimit.AddSpent(-rals.costPerDebit, time.Now())
+		}
+	case "CdrsV1.StoreSMCost", "CdrsV2.StoreSMCost":
+		*reply.(*string) = utils.OK
+	default:
+		return utils.ErrNotImplemented
+	}
+	return nil
+}
+
+func TestSMGSessionSpendingCap(t *testing.T) {
+	rals := &mockSpendingCapRALs{costPerDebit: 1}
+	smg := NewSMGeneric(smgCfg, rals, rals, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.VOICE,
+		utils.ACCID:       "12351",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+		utils.USAGE:       "60s",
+		utils.MaxCost:     "1.5",
+	}
+	if maxUsage, err := smg.InitiateSession(smGev, nil); err != nil {
+		t.Fatal(err)
+	} else if maxUsage != time.Minute {
+		t.Errorf("Unexpected maxUsage: %v", maxUsage)
+	}
+	smGev[utils.LastUsed] = "60s"
+	if _, err := smg.UpdateSession(smGev, nil); err != utils.ErrSpendingCapReached {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrSpendingCapReached, err)
+	}
+	if rals.refunds != 1 {
+		t.Errorf("Expecting the debit over the cap to be refunded, refunds: %d", rals.refunds)
+	}
+	cgrID := smGev.GetCGRID(utils.META_DEFAULT)
+	if aSessions := smg.getSessions(cgrID, false)[cgrID]; len(aSessions) != 1 {
+		t.Fatalf("Unexpected sessions: %+v", aSessions)
+	} else if aSessions[0].CD.MaxCostSoFar != 1 || aSessions[0].TotalUsage != time.Minute {
+		t.Errorf("Unexpected session: %+v", aSessions[0])
+	}
+}
+
+func TestSMGChargeEventSpendingLimit(t *testing.T) {
+	sl, _ := engine.NewSpendingLimit(2.5, utils.MetaDaily)
+	rals := &mockSpendingCapRALs{costPerDebit: 1, spendingLimit: sl}
+	smg := NewSMGeneric(smgCfg, rals, rals, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.SMS,
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+		utils.USAGE:       "1",
+	}
+	for i := 0; i < 2; i++ {
+		smGev[utils.ACCID] = fmt.Sprintf("12352_%d", i)
+		if _, err := smg.ChargeEvent(smGev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	smGev[utils.ACCID] = "12352_2"
+	if _, err := smg.ChargeEvent(smGev); err != utils.ErrSpendingCapReached {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrSpendingCapReached, err)
+	}
+	if rals.refunds != 1 {
+		t.Errorf("Expecting the charge over the limit to be refunded, refunds: %d", rals.refunds)
+	}
+	if spent := sl.SpentAt(time.Now()); spent != 2 {
+		t.Errorf("Unexpected spent: %v", spent)
+	}
+}
+
+func TestSMGEventReservation(t *testing.T) {
+	rals := &mockSpendingCapRALs{costPerDebit: 1}
+	smg := NewSMGeneric(smgCfg, rals, rals, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.SMS,
+		utils.ACCID:       "12353",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+		utils.USAGE:       "1",
+	}
+	if maxUsage, err := smg.ReserveEvent(smGev); err != nil {
+		t.Fatal(err)
+	} else if maxUsage != time.Second {
+		t.Errorf("Unexpected maxUsage: %v", maxUsage)
+	}
+	cgrID := smGev.GetCGRID(utils.META_DEFAULT)
+	if rsrv, has := smg.reservations[cgrID]; !has || len(rsrv.runs) != 1 {
+		t.Fatalf("Unexpected reservations: %+v", smg.reservations)
+	}
+	if err := smg.CommitEvent(smGev); err != nil {
+		t.Error(err)
+	}
+	if len(smg.reservations) != 0 || rals.refunds != 0 {
+		t.Errorf("Unexpected reservations: %+v, refunds: %d", smg.reservations, rals.refunds)
+	}
+	if err := smg.CancelEvent(smGev); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	smGev[utils.ACCID] = "12354"
+	if _, err := smg.ReserveEvent(smGev); err != nil {
+		t.Fatal(err)
+	}
+	if err := smg.CancelEvent(smGev); err != nil {
+		t.Error(err)
+	}
+	if rals.refunds != 1 || len(smg.reservations) != 0 {
+		t.Errorf("Unexpected reservations: %+v, refunds: %d", smg.reservations, rals.refunds)
+	}
+}
+
+// mockReservationRALs debits two runs for each event, failing the refunds of the first one
+type mockReservationRALs struct {
+	mockSpendingCapRALs
+	refundRunIDs []string
+}
+
+func (rals *mockReservationRALs) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	switch serviceMethod {
+	case "Responder.GetSessionRuns":
+		cdr := args.(*engine.CDR)
+		for _, runID := range []string{"RUN1", "RUN2"} {
+			*reply.(*[]*engine.SessionRun) = append(*reply.(*[]*engine.SessionRun),
+				&engine.SessionRun{DerivedCharger: &utils.DerivedCharger{RunID: runID},
+					CallDescriptor: &engine.CallDescriptor{CgrID: cdr.CGRID, RunID: runID, TOR: cdr.ToR,
+						Tenant: cdr.Tenant, Account: cdr.Account, Destination: cdr.Destination, TimeStart: cdr.AnswerTime,
+						TimeEnd: cdr.AnswerTime.Add(cdr.Usage)}})
+		}
+		return nil
+	case "Responder.RefundIncrements":
+		cd := args.(*engine.CallDescriptor)
+		rals.refundRunIDs = append(rals.refundRunIDs, cd.RunID)
+		if cd.RunID == "RUN1" {
+			return errors.New("REFUND_FAILED")
+		}
+	}
+	return rals.mockSpendingCapRALs.Call(serviceMethod, args, reply)
+}
+
+func TestSMGCancelReservationAllRuns(t *testing.T) {
+	rals := &mockReservationRALs{mockSpendingCapRALs: mockSpendingCapRALs{costPerDebit: 1}}
+	smg := NewSMGeneric(smgCfg, rals, rals, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.SMS,
+		utils.ACCID:       "12360",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+		utils.USAGE:       "1",
+	}
+	if _, err := smg.ReserveEvent(smGev); err != nil {
+		t.Fatal(err)
+	}
+	if err := smg.CancelEvent(smGev); err == nil || err.Error() != "failed cancelling runs: RUN1:REFUND_FAILED" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if eRunIDs := []string{"RUN1", "RUN2"}; !reflect.DeepEqual(eRunIDs, rals.refundRunIDs) {
+		t.Errorf("Expecting refunds for: %+v, received: %+v", eRunIDs, rals.refundRunIDs)
+	}
+	if len(smg.reservations) != 0 {
+		t.Errorf("Unexpected reservations: %+v", smg.reservations)
+	}
+}
+
+func TestSMGPassiveTakeover(t *testing.T) {
+	smg := NewSMGeneric(smgCfg, nil, nil, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.VOICE,
+		utils.ACCID:       "12355",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+	}
+	cgrID := smGev.GetCGRID(utils.META_DEFAULT)
+	var reply string
+	if err := smg.BiRPCV1SetPassiveSessions(nil, ArgsSetPassiveSessions{CGRID: cgrID,
+		Sessions: []*SMGSession{&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, EventStart: smGev}}}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if smg.peerLost(time.Second) {
+		t.Error("Peer lost right after replication")
+	}
+	smg.peerLastSeen = time.Now().Add(-2 * time.Second)
+	if !smg.peerLost(time.Second) {
+		t.Error("Peer not lost")
+	}
+	if err := smg.BiRPCV1ReplicationHeartbeat(nil, "", &reply); err != nil {
+		t.Error(err)
+	} else if smg.peerLost(time.Second) {
+		t.Error("Peer lost after heartbeat")
+	}
+	smg.takeoverPassiveSessions()
+	if pSS := smg.getSessions(cgrID, true); len(pSS) != 0 {
+		t.Errorf("Passive sessions left: %+v", pSS)
+	}
+	if aSS := smg.getSessions(cgrID, false); len(aSS[cgrID]) != 1 {
+		t.Errorf("Active sessions: %+v", aSS)
+	} else if !aSS[cgrID][0].restored {
+		t.Error("Session not waiting for sync")
+	}
+}
+
+func TestSMGPassiveHandBack(t *testing.T) {
+	smg := NewSMGeneric(smgCfg, nil, nil, nil, nil, nil, nil, "UTC")
+	smGev := SMGenericEvent{
+		utils.EVENT_NAME:  "TEST_EVENT",
+		utils.TOR:         utils.VOICE,
+		utils.ACCID:       "12356",
+		utils.TENANT:      "cgrates.org",
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "1002",
+		utils.ANSWER_TIME: "2017-10-01T10:00:00Z",
+	}
+	cgrID := smGev.GetCGRID(utils.META_DEFAULT)
+	var reply string
+	if err := smg.BiRPCV1SetPassiveSessions(nil, ArgsSetPassiveSessions{CGRID: cgrID,
+		Sessions: []*SMGSession{&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, EventStart: smGev}}}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	smg.takeoverPassiveSessions()
+	stopDebit := make(chan struct{})
+	smg.getSessions(cgrID, false)[cgrID][0].stopDebit = stopDebit
+	// the peer is back and replicates the session it owns
+	if err := smg.BiRPCV1SetPassiveSessions(nil, ArgsSetPassiveSessions{CGRID: cgrID,
+		Sessions: []*SMGSession{&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, EventStart: smGev, TotalUsage: time.Minute}}}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopDebit:
+	default:
+		t.Error("Debit loop not stopped")
+	}
+	if aSS := smg.getSessions(cgrID, false); len(aSS) != 0 {
+		t.Errorf("Active sessions left: %+v", aSS)
+	}
+	if pSS := smg.getSessions(cgrID, true); len(pSS[cgrID]) != 1 || pSS[cgrID][0].TotalUsage != time.Minute {
+		t.Errorf("Passive sessions: %+v", pSS)
+	}
+	// taken over again, the peer rejects our replication since it still owns the session
+	smg.takeoverPassiveSessions()
+	smg.handBackSessions(cgrID)
+	if aSS := smg.getSessions(cgrID, false); len(aSS) != 0 {
+		t.Errorf("Active sessions left: %+v", aSS)
+	}
+	if pSS := smg.getSessions(cgrID, true); len(pSS[cgrID]) != 1 {
+		t.Errorf("Passive sessions: %+v", pSS)
+	}
+	// taken over and synced with the switch, kept active against the stale copy of the peer
+	smg.takeoverPassiveSessions()
+	var synced []SMGenericEvent
+	if err := smg.BiRPCV1SyncSessions(nil, ArgsSyncSessions{OriginIDs: []string{"12356"}}, &synced); err != nil {
+		t.Fatal(err)
+	}
+	if err := smg.BiRPCV1SetPassiveSessions(nil, ArgsSetPassiveSessions{CGRID: cgrID,
+		Sessions: []*SMGSession{&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, EventStart: smGev}}}, &reply); err != ErrActiveSession {
+		t.Errorf("Expecting: %v, received: %v", ErrActiveSession, err)
+	}
+	if aSS := smg.getSessions(cgrID, false); len(aSS[cgrID]) != 1 {
+		t.Errorf("Active sessions: %+v", aSS)
+	}
+	if pSS := smg.getSessions(cgrID, true); len(pSS) != 0 {
+		t.Errorf("Passive sessions left: %+v", pSS)
+	}
+	// a session started here is demoted once the peer replicates it
+	smg.demoteSessions(cgrID)
+	smg.recordASession(&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, EventStart: smGev})
+	if err := smg.BiRPCV1SetPassiveSessions(nil, ArgsSetPassiveSessions{CGRID: cgrID,
+		Sessions: []*SMGSession{&SMGSession{CGRID: cgrID, RunID: utils.META_DEFAULT, EventStart: smGev}}}, &reply); err != nil {
+		t.Error(err)
+	}
+	if aSS := smg.getSessions(cgrID, false); len(aSS) != 0 {
+		t.Errorf("Active sessions left: %+v", aSS)
+	}
+	if pSS := smg.getSessions(cgrID, true); len(pSS[cgrID]) != 1 {
+		t.Errorf("Passive sessions: %+v", pSS)
+	}
+}
+
+func TestSMGChangedSessions(t *testing.T) {
+	smg := NewSMGeneric(smgCfg, nil, nil, nil, nil, nil, nil, "UTC")
+	s := &SMGSession{CGRID: "CGRID_CHANGED", RunID: utils.META_DEFAULT,
+		EventStart: SMGenericEvent{utils.ACCID: "12357"}, TotalUsage: time.Minute}
+	smg.recordASession(s)
+	if cgrIDs, ssClns := smg.changedSessions(); !reflect.DeepEqual([]string{"CGRID_CHANGED"}, cgrIDs) {
+		t.Errorf("Unexpected changed sessions: %+v", cgrIDs)
+	} else if len(ssClns[0]) != 1 || ssClns[0][0].TotalUsage != time.Minute {
+		t.Errorf("Unexpected sessions: %+v", ssClns)
+	}
+	if cgrIDs, _ := smg.changedSessions(); len(cgrIDs) != 0 {
+		t.Errorf("Unchanged sessions replicated: %+v", cgrIDs)
+	}
+	s.TotalUsage = 2 * time.Minute
+	if cgrIDs, _ := smg.changedSessions(); !reflect.DeepEqual([]string{"CGRID_CHANGED"}, cgrIDs) {
+		t.Errorf("Unexpected changed sessions: %+v", cgrIDs)
+	}
+	smg.unrecordASession("CGRID_CHANGED")
+	if cgrIDs, _ := smg.changedSessions(); len(cgrIDs) != 0 {
+		t.Errorf("Unexpected changed sessions: %+v", cgrIDs)
+	} else if len(smg.hbReplicated) != 0 {
+		t.Errorf("Replication marks left: %+v", smg.hbReplicated)
+	}
+}
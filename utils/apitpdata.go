@@ -150,6 +150,8 @@ type DestinationRate struct {
 	RoundingDecimals int
 	MaxCost          float64
 	MaxCostStrategy  string
+	Currency         string // currency of the rates, empty for the default one
+	TierCounter      string // account counter selecting the rate groups, empty to select them on call duration
 }
 
 type ApierTPTiming struct {
@@ -331,9 +333,42 @@ type TPSharedGroups struct {
 }
 
 type TPSharedGroup struct {
-	Account       string
-	Strategy      string
-	RatingSubject string
+	Account          string
+	Strategy         string
+	RatingSubject    string
+	Quota            float64 // maximum value the member can consume out of the shared balances within QuotaPeriod, 0 for no quota
+	QuotaPeriod      string  // *daily, *monthly or empty for a quota which is never reset
+	QuotaBalanceType string  // type of the shared balances the quota applies to, mandatory with a quota
+}
+
+type TPExchangeRates struct {
+	TPid          string
+	ID            string
+	ExchangeRates []*TPExchangeRate
+}
+
+type TPExchangeRate struct {
+	FromCurrency   string
+	ToCurrency     string
+	ActivationTime string // Time when the rate becomes active
+	Rate           float64
+}
+
+type TPTaxRules struct {
+	TPid     string
+	Tenant   string
+	ID       string // name of the tax, eg: VAT
+	TaxRules []*TPTaxRule
+}
+
+type TPTaxRule struct {
+	TaxClass       string // customer tax class, empty for all
+	Categories     string // semicolon separated list, empty for all
+	DestinationIDs string // semicolon separated list, empty for all
+	ActivationTime string // Time when the rule becomes active
+	Rate           float64
+	Compound       bool // tax the amount including the taxes applied before
+	Weight         float64
 }
 
 type TPLcrRules struct {
@@ -1044,6 +1079,7 @@ type CDRsFilter struct {
 	MaxCost                *float64          // End of the usage interval (<)
 	Unscoped               bool              // Include soft-deleted records in results
 	Count                  bool              // If true count the items instead of returning data
+	OrderByOrderID         bool              // Sort ascending on OrderID, used in cursor based pagination
 	Paginator
 }
 
@@ -1217,13 +1253,17 @@ type AttrExecuteAction struct {
 }
 
 type AttrSetAccount struct {
-	Tenant           string
-	Account          string
-	ActionPlanId     string
-	ActionTriggersId string
-	AllowNegative    *bool
-	Disabled         *bool
-	ReloadScheduler  bool
+	Tenant              string
+	Account             string
+	ActionPlanId        string
+	ActionTriggersId    string
+	AllowNegative       *bool
+	Disabled            *bool
+	MaxSessionCost      *float64
+	SpendingLimit       *float64 // 0 removes the limit
+	SpendingLimitPeriod *string  // *daily, *monthly or empty for no reset
+	CreditLimit         *float64 // amount the account can go negative, ignored with AllowNegative
+	ReloadScheduler     bool
 }
 
 type AttrRemoveAccount struct {
@@ -1258,6 +1298,7 @@ type AttrSetBalance struct {
 	Value          *float64
 	ExpiryTime     *string
 	RatingSubject  *string
+	Currency       *string
 	Categories     *string
 	DestinationIds *string
 	TimingIds      *string
@@ -1331,6 +1372,12 @@ type AttrDisconnectSession struct {
 	Reason     string
 }
 
+// Attributes to send on SessionWarning by SMG
+type AttrWarnSession struct {
+	EventStart map[string]interface{}
+	Reason     string
+}
+
 // TPStats is used in APIs to manage remotely offline Stats config
 type TPStats struct {
 	TPid               string
@@ -57,6 +57,7 @@ var (
 		CacheResourceProfiles:    ResourceProfilesPrefix,
 		CacheResources:           ResourcesPrefix,
 		CacheTimings:             TimingsPrefix,
+		CacheExchangeRates:       ExchangeRatesPrefix,
 		CacheStatSQueues:         META_NONE,
 		CacheStatSEventQueues:    META_NONE,
 	}
@@ -105,7 +106,11 @@ const (
 	TBLTPResources                = "tp_resources"
 	TBLTPStats                    = "tp_stats"
 	TBLTPThresholds               = "tp_thresholds"
+	TBLTPExchangeRates            = "tp_exchange_rates"
+	TBLTPTaxRules                 = "tp_tax_rules"
 	TBLSMCosts                    = "sm_costs"
+	TBLBalanceLedger              = "balance_ledger"
+	TBLInvoices                   = "invoices"
 	TBLCDRs                       = "cdrs"
 	TBLVersions                   = "versions"
 	TIMINGS_CSV                   = "Timings.csv"
@@ -127,6 +132,8 @@ const (
 	ResourcesCsv                  = "Resources.csv"
 	StatsCsv                      = "Stats.csv"
 	ThresholdsCsv                 = "Thresholds.csv"
+	ExchangeRatesCsv              = "ExchangeRates.csv"
+	TaxRulesCsv                   = "TaxRules.csv"
 	ROUNDING_UP                   = "*up"
 	ROUNDING_MIDDLE               = "*middle"
 	ROUNDING_DOWN                 = "*down"
@@ -168,6 +175,8 @@ const (
 	ANSWER_TIME                   = "AnswerTime"
 	USAGE                         = "Usage"
 	LastUsed                      = "LastUsed"
+	Units                         = "Units"
+	MaxCost                       = "MaxCost"
 	PDD                           = "PDD"
 	SUPPLIER                      = "Supplier"
 	MEDI_RUNID                    = "RunID"
@@ -255,8 +264,11 @@ const (
 	LOG_CDR                       = "cdr_"
 	LOG_MEDIATED_CDR              = "mcd_"
 	SQStoredMetricsPrefix         = "ssm_"
+	SMGSessionCheckpointPrefix    = "smc_"
 	StatsConfigPrefix             = "scf_"
 	ThresholdCfgPrefix            = "thc_"
+	ExchangeRatesPrefix           = "exr_"
+	TaxRulesPrefix                = "txr_"
 	LOADINST_KEY                  = "load_history"
 	SESSION_MANAGER_SOURCE        = "SMR"
 	MEDIATOR_SOURCE               = "MED"
@@ -431,12 +443,39 @@ const (
 	CacheResources               = "resources"
 	CacheResourceProfiles        = "resource_profiles"
 	CacheTimings                 = "timings"
+	CacheExchangeRates           = "exchange_rates"
 	StatS                        = "stats"
 	CostSource                   = "CostSource"
 	ExtraInfo                    = "ExtraInfo"
 	MetaPrefix                   = "*"
 	CacheStatSQueues             = "stats_queues"
 	CacheStatSEventQueues        = "stats_event_queues"
+	MetaDrop                     = "*drop"
+	MetaKeepNewest               = "*keep_newest"
+	MetaMergeExtraFields         = "*merge_extra_fields"
+	RALService                   = "RALs"
+	OldCost                      = "OldCost"
+	CostDelta                    = "CostDelta"
+	MetaDaily                    = "*daily"
+	MetaMonthly                  = "*monthly"
+	MetaRefund                   = "*refund"
+	MetaExpired                  = "*expired"
+	MetaYearly                   = "*yearly"
+	MetaAnniversary              = "*anniversary"
+	MetaCalendar                 = "*calendar"
+	MetaSubscription             = "*subscription"
+	MetaActivation               = "*activation"
+	MetaRenewal                  = "*renewal"
+	MetaPlanChange               = "*plan_change"
+	MetaTermination              = "*termination"
+	MetaReseller                 = "*reseller"
+	MetaJSON                     = "*json"
+	MetaCSV                      = "*csv"
+	MetaHTML                     = "*html"
+	Tax                          = "Tax"
+	TaxClass                     = "TaxClass"
+	TaxTotal                     = "TaxTotal"
+	TaxFieldPrefix               = "Tax_"
 )
 
 func buildCacheInstRevPrefixes() {
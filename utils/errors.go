@@ -44,6 +44,7 @@ var (
 	ErrNotConvertible          = errors.New("NOT_CONVERTIBLE")
 	ErrResourceUnavailable     = errors.New("RESOURCE_UNAVAILABLE")
 	ErrNoActiveSession         = errors.New("NO_ACTIVE_SESSION")
+	ErrSpendingCapReached      = errors.New("SPENDING_CAP_REACHED")
 )
 
 // NewCGRError initialises a new CGRError
@@ -90,6 +91,10 @@ func NewErrServerError(err error) error {
 	return fmt.Errorf("SERVER_ERROR: %s", err)
 }
 
+func NewErrNotConnected(serv string) error {
+	return fmt.Errorf("NOT_CONNECTED: %s", serv)
+}
+
 // Centralized returns for APIs
 func APIErrorHandler(err error) error {
 	cgrErr, ok := err.(*CGRError)